@@ -10,12 +10,18 @@ import (
 type MutablePropertySources struct {
 	lock               sync.Mutex // 读写锁保护并发访问
 	propertySourceList *ag_ext.CopyOnWriteSlice[IPropertySource]
+
+	// 属性源变更监听
+	listenerLock sync.Mutex
+	listeners    map[int]func()
+	listenerSeq  int
 }
 
 func NewMutablePropertySources() *MutablePropertySources {
 	return &MutablePropertySources{
 		lock:               sync.Mutex{},
 		propertySourceList: ag_ext.NewCopyOnWriteSlice[IPropertySource](),
+		listeners:          make(map[int]func()),
 	}
 }
 
@@ -69,16 +75,18 @@ func (m *MutablePropertySources) RangePropertySourceHandler(handler func(ps IPro
 /* ========= 自实现方法 ======== */
 func (m *MutablePropertySources) AddFirst(ps IPropertySource) {
 	m.lock.Lock()
-	defer m.lock.Unlock()
 	m.removeIfPresent(ps.GetName())
 	m.propertySourceList.AddIndex(0, ps)
+	m.lock.Unlock()
+	m.fireChanged()
 }
 
 func (m *MutablePropertySources) AddLast(ps IPropertySource) {
 	m.lock.Lock()
-	defer m.lock.Unlock()
 	m.removeIfPresent(ps.GetName())
 	m.propertySourceList.Add(ps)
+	m.lock.Unlock()
+	m.fireChanged()
 }
 
 func (m *MutablePropertySources) AddBefore(name string, ps IPropertySource) error {
@@ -87,11 +95,11 @@ func (m *MutablePropertySources) AddBefore(name string, ps IPropertySource) erro
 		return err
 	}
 	m.lock.Lock()
-	defer m.lock.Unlock()
-
 	m.removeIfPresent(ps.GetName())
 	index := m.indexOfName(name)
 	m.propertySourceList.AddIndex(index, ps)
+	m.lock.Unlock()
+	m.fireChanged()
 	return nil
 }
 
@@ -101,25 +109,58 @@ func (m *MutablePropertySources) AddAfter(name string, ps IPropertySource) error
 		return err
 	}
 	m.lock.Lock()
-	defer m.lock.Unlock()
 	m.removeIfPresent(ps.GetName())
 	index := m.indexOfName(name)
 	m.propertySourceList.AddIndex(index+1, ps)
+	m.lock.Unlock()
+	m.fireChanged()
 	return nil
 }
 
 func (m *MutablePropertySources) Remove(name string) {
 	m.lock.Lock()
-	defer m.lock.Unlock()
 	index := m.indexOfName(name)
 	m.propertySourceList.DeleteIndex(index)
+	m.lock.Unlock()
+	m.fireChanged()
 }
 
 func (m *MutablePropertySources) Replace(name string, ps IPropertySource) {
 	m.lock.Lock()
-	defer m.lock.Unlock()
 	index := m.indexOfName(name)
 	m.propertySourceList.Set(index, ps)
+	m.lock.Unlock()
+	m.fireChanged()
+}
+
+// AddChangeListener 注册属性源变更监听，属性源增删或替换后回调，返回取消监听函数
+func (m *MutablePropertySources) AddChangeListener(fn func()) (cancel func()) {
+	m.listenerLock.Lock()
+	defer m.listenerLock.Unlock()
+	if m.listeners == nil {
+		m.listeners = make(map[int]func())
+	}
+	m.listenerSeq++
+	id := m.listenerSeq
+	m.listeners[id] = fn
+	return func() {
+		m.listenerLock.Lock()
+		defer m.listenerLock.Unlock()
+		delete(m.listeners, id)
+	}
+}
+
+// fireChanged 通知变更监听，在锁外回调以便监听方读取属性源
+func (m *MutablePropertySources) fireChanged() {
+	m.listenerLock.Lock()
+	fns := make([]func(), 0, len(m.listeners))
+	for _, fn := range m.listeners {
+		fns = append(fns, fn)
+	}
+	m.listenerLock.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
 }
 
 func (m *MutablePropertySources) removeIfPresent(toDelName string) {
@@ -2,39 +2,50 @@ package ag_conf
 
 import (
 	"context"
+	"log/slog"
+	"reflect"
 	"sync"
 )
 
-// TODO !!!!!!!!!!!!
-var onece sync.Once
-
+// Watcher 配置监听器，属性源变更(如nacos配置刷新)时重新绑定已注册的配置，配置有变化时回调
 type Watcher struct {
-	bind        IBinder
-	refreshChan chan IPropertySource
-	stops       []func() error
+	bind IBinder
+
+	lock     sync.Mutex
+	refreshs []func()
+	stops    []func() error
 }
 
 func NewConfigWatcher(bind IBinder) *Watcher {
 
 	watcher := &Watcher{
-		bind:  bind,
-		stops: make([]func() error, 0),
+		bind:     bind,
+		refreshs: make([]func(), 0),
+		stops:    make([]func() error, 0),
 	}
 
 	return watcher
 }
 
+// Start 订阅属性源变更，并刷新一次以处理注册后、启动前的配置变更
 func (w *Watcher) Start(context.Context) error {
-	onece.Do(func() {
-		env := w.bind.GetEnv()
-		pslen := len(env.GetPropertySources().GetPropertySources())
-		w.refreshChan = make(chan IPropertySource, pslen)
+	cancel := w.bind.GetEnv().GetPropertySources().AddChangeListener(w.Refresh)
+	w.lock.Lock()
+	w.stops = append(w.stops, func() error {
+		cancel()
+		return nil
 	})
+	w.lock.Unlock()
+	w.Refresh()
 	return nil
 }
 
 func (w *Watcher) Stop(context.Context) error {
-	for _, stop := range w.stops {
+	w.lock.Lock()
+	stops := w.stops
+	w.stops = make([]func() error, 0)
+	w.lock.Unlock()
+	for _, stop := range stops {
 		err := stop()
 		if err != nil {
 			return err
@@ -42,3 +53,77 @@ func (w *Watcher) Stop(context.Context) error {
 	}
 	return nil
 }
+
+// Refresh 重新绑定所有已注册的配置
+func (w *Watcher) Refresh() {
+	w.lock.Lock()
+	refreshs := append([]func(){}, w.refreshs...)
+	w.lock.Unlock()
+	for _, refresh := range refreshs {
+		refresh()
+	}
+}
+
+// Watch 绑定prefix下的配置并注册监听，返回当前配置；
+// 配置变更且重新绑定结果不同时以新配置回调onChange，绑定失败时记录日志并保留原配置
+func Watch[T any](w *Watcher, prefix string, onChange func(*T)) (*T, error) {
+	current := new(T)
+	if err := w.bind.Bind(current, prefix); err != nil {
+		return nil, err
+	}
+
+	var lock sync.Mutex
+	refresh := func() {
+		lock.Lock()
+		defer lock.Unlock()
+		next := new(T)
+		if err := w.bind.Bind(next, prefix); err != nil {
+			slog.Warn("config watcher rebind failed, keep previous", "prefix", prefix, "error", err)
+			return
+		}
+		if reflect.DeepEqual(current, next) {
+			return
+		}
+		slog.Info("config watcher refreshed", "prefix", prefix)
+		current = next
+		onChange(next)
+	}
+
+	w.lock.Lock()
+	w.refreshs = append(w.refreshs, refresh)
+	w.lock.Unlock()
+	return current, nil
+}
+
+// BindRefreshable 绑定prefix下的配置并以build创建对象；w非nil时配置变更后以update刷新该对象，
+// 刷新失败时记录日志并保留原配置
+func BindRefreshable[T, R any](bind IBinder, w *Watcher, prefix string, build func(T) (R, error), update func(R, T) error) (R, error) {
+	if w == nil {
+		var conf T
+		if err := bind.Bind(&conf, prefix); err != nil {
+			var zero R
+			return zero, err
+		}
+		return build(conf)
+	}
+
+	// 监听器在应用启动后才刷新配置，此时对象已创建
+	var (
+		target R
+		built  bool
+	)
+	conf, err := Watch(w, prefix, func(conf *T) {
+		if !built {
+			return
+		}
+		if err := update(target, *conf); err != nil {
+			slog.Error("config refresh failed, keep previous", "prefix", prefix, "error", err)
+		}
+	})
+	if err != nil {
+		return target, err
+	}
+	target, err = build(*conf)
+	built = err == nil
+	return target, err
+}
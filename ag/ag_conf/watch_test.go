@@ -0,0 +1,108 @@
+package ag_conf_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/frochyzhang/ag-core/ag/ag_conf"
+)
+
+func watchSource(name string, source map[string]any) *ag_conf.MapPropertySource {
+	ps := &ag_conf.MapPropertySource{Source: source}
+	ps.Name = name
+	return ps
+}
+
+func TestWatcherRefresh(t *testing.T) {
+	env := ag_conf.NewStandardEnvironment()
+	env.GetPropertySources().AddFirst(watchSource("remote", map[string]any{"hzw.name": "a"}))
+	watcher := ag_conf.NewConfigWatcher(ag_conf.NewConfigurationPropertiesBinder(env))
+
+	changes := make([]Hzw, 0)
+	current, err := ag_conf.Watch(watcher, "hzw", func(h *Hzw) {
+		changes = append(changes, *h)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Name != "a" || current.Age != 22 {
+		t.Fatalf("initial = %+v", *current)
+	}
+	if err := watcher.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 配置未变化时不回调
+	env.GetPropertySources().AddLast(watchSource("other", map[string]any{"x": "1"}))
+	if len(changes) != 0 {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+
+	// 替换属性源触发刷新
+	env.GetPropertySources().Replace("remote", watchSource("remote", map[string]any{"hzw.name": "b", "hzw.age": "30"}))
+	if len(changes) != 1 || changes[0].Name != "b" || changes[0].Age != 30 {
+		t.Fatalf("changes = %+v", changes)
+	}
+
+	// 绑定失败时保留原配置
+	env.GetPropertySources().Replace("remote", watchSource("remote", map[string]any{"hzw.name": "b", "hzw.age": "x"}))
+	if len(changes) != 1 {
+		t.Fatalf("changes after bad config = %+v", changes)
+	}
+
+	// 停止后不再刷新
+	if err := watcher.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	env.GetPropertySources().Replace("remote", watchSource("remote", map[string]any{"hzw.name": "c"}))
+	if len(changes) != 1 {
+		t.Fatalf("changes after stop = %+v", changes)
+	}
+}
+
+func TestBindRefreshable(t *testing.T) {
+	env := ag_conf.NewStandardEnvironment()
+	env.GetPropertySources().AddFirst(watchSource("remote", map[string]any{"hzw.name": "a"}))
+	binder := ag_conf.NewConfigurationPropertiesBinder(env)
+	build := func(h Hzw) (*Hzw, error) {
+		if h.Name == "" {
+			return nil, errors.New("empty name")
+		}
+		return &h, nil
+	}
+	update := func(target *Hzw, h Hzw) error {
+		if h.Name == "bad" {
+			return errors.New("bad name")
+		}
+		*target = h
+		return nil
+	}
+
+	// 无监听器时仅创建
+	got, err := ag_conf.BindRefreshable(binder, nil, "hzw", build, update)
+	if err != nil || got.Name != "a" {
+		t.Fatalf("BindRefreshable() without watcher = %+v, %v", got, err)
+	}
+
+	watcher := ag_conf.NewConfigWatcher(binder)
+	got, err = ag_conf.BindRefreshable(binder, watcher, "hzw", build, update)
+	if err != nil || got.Name != "a" {
+		t.Fatalf("BindRefreshable() = %+v, %v", got, err)
+	}
+	if err := watcher.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Stop(context.Background())
+
+	env.GetPropertySources().Replace("remote", watchSource("remote", map[string]any{"hzw.name": "b"}))
+	if got.Name != "b" {
+		t.Fatalf("after refresh = %+v", got)
+	}
+
+	// 刷新失败时保留原配置
+	env.GetPropertySources().Replace("remote", watchSource("remote", map[string]any{"hzw.name": "bad"}))
+	if got.Name != "b" {
+		t.Fatalf("after bad refresh = %+v", got)
+	}
+}
@@ -0,0 +1,35 @@
+package ag_crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+)
+
+const (
+	MacHmacSHA256 = "hmac-sha256"
+	MacHmacSM3    = "hmac-sm3"
+)
+
+// NewMac 按算法名创建消息认证码，支持hmac-sha256与hmac-sm3
+func NewMac(algorithm string, key []byte) (hash.Hash, error) {
+	switch algorithm {
+	case MacHmacSHA256:
+		return hmac.New(sha256.New, key), nil
+	case MacHmacSM3:
+		return hmac.New(NewSM3, key), nil
+	default:
+		return nil, fmt.Errorf("ag_crypto: unsupported mac %q", algorithm)
+	}
+}
+
+// Mac 计算消息认证码
+func Mac(algorithm string, key, data []byte) ([]byte, error) {
+	h, err := NewMac(algorithm, key)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(data)
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,87 @@
+package ag_crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/tjfoc/gmsm/sm3"
+	"github.com/tjfoc/gmsm/sm4"
+)
+
+// ErrInvalidPadding 解密后的PKCS7填充非法，通常为密钥错误或数据被篡改
+var ErrInvalidPadding = errors.New("ag_crypto: invalid padding")
+
+const (
+	CipherSM4CBC = "sm4-cbc"
+	CipherAESCBC = "aes-cbc"
+)
+
+// NewSM3 创建SM3摘要
+func NewSM3() hash.Hash {
+	return sm3.New()
+}
+
+// SM3Sum 计算SM3摘要
+func SM3Sum(data []byte) []byte {
+	return sm3.Sm3Sum(data)
+}
+
+// NewBlockCipher 按算法名创建分组密码，支持sm4-cbc与aes-cbc
+func NewBlockCipher(algorithm string, key []byte) (cipher.Block, error) {
+	switch algorithm {
+	case CipherSM4CBC:
+		return sm4.NewCipher(key)
+	case CipherAESCBC:
+		return aes.NewCipher(key)
+	default:
+		return nil, fmt.Errorf("ag_crypto: unsupported cipher %q", algorithm)
+	}
+}
+
+// EncryptCBC CBC模式加密，PKCS7填充，随机IV置于密文头部
+func EncryptCBC(algorithm string, key, plaintext []byte) ([]byte, error) {
+	block, err := NewBlockCipher(algorithm, key)
+	if err != nil {
+		return nil, err
+	}
+	size := block.BlockSize()
+	padding := size - len(plaintext)%size
+	data := append(append(make([]byte, 0, len(plaintext)+padding), plaintext...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	out := make([]byte, size+len(data))
+	if _, err := rand.Read(out[:size]); err != nil {
+		return nil, err
+	}
+	cipher.NewCBCEncrypter(block, out[:size]).CryptBlocks(out[size:], data)
+	return out, nil
+}
+
+// DecryptCBC 解密 EncryptCBC 的密文
+func DecryptCBC(algorithm string, key, ciphertext []byte) ([]byte, error) {
+	block, err := NewBlockCipher(algorithm, key)
+	if err != nil {
+		return nil, err
+	}
+	size := block.BlockSize()
+	if len(ciphertext) < 2*size || len(ciphertext)%size != 0 {
+		return nil, fmt.Errorf("ag_crypto: ciphertext length %d", len(ciphertext))
+	}
+	out := make([]byte, len(ciphertext)-size)
+	cipher.NewCBCDecrypter(block, ciphertext[:size]).CryptBlocks(out, ciphertext[size:])
+
+	padding := int(out[len(out)-1])
+	if padding == 0 || padding > size {
+		return nil, ErrInvalidPadding
+	}
+	for _, b := range out[len(out)-padding:] {
+		if int(b) != padding {
+			return nil, ErrInvalidPadding
+		}
+	}
+	return out[:len(out)-padding], nil
+}
@@ -0,0 +1,49 @@
+package ag_crypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestSM3AndMac(t *testing.T) {
+	if got := hex.EncodeToString(SM3Sum([]byte("abc"))); got != "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0" {
+		t.Errorf("SM3Sum() = %s", got)
+	}
+	// RFC 4231 测试用例2
+	mac, err := Mac(MacHmacSHA256, []byte("Jefe"), []byte("what do ya want for nothing?"))
+	if err != nil || hex.EncodeToString(mac) != "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843" {
+		t.Errorf("Mac(hmac-sha256) = %x, %v", mac, err)
+	}
+	if mac, err := Mac(MacHmacSM3, []byte("key"), []byte("data")); err != nil || len(mac) != 32 {
+		t.Errorf("Mac(hmac-sm3) = %x, %v", mac, err)
+	}
+	if _, err := NewMac("md5", nil); err == nil {
+		t.Error("NewMac() accepted unsupported algorithm")
+	}
+}
+
+func TestSM4(t *testing.T) {
+	// GB/T 32907 示例1
+	key, _ := hex.DecodeString("0123456789abcdeffedcba9876543210")
+	block, err := NewBlockCipher(CipherSM4CBC, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]byte, 16)
+	block.Encrypt(out, key)
+	if hex.EncodeToString(out) != "681edf34d206965e86b3e94f536e4246" {
+		t.Errorf("SM4 block = %x", out)
+	}
+
+	for _, plain := range [][]byte{nil, []byte("16 bytes exactly"), []byte("转账报文")} {
+		ciphertext, err := EncryptCBC(CipherSM4CBC, key, plain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := DecryptCBC(CipherSM4CBC, key, ciphertext)
+		if err != nil || !bytes.Equal(got, plain) {
+			t.Errorf("DecryptCBC() = %q, %v", got, err)
+		}
+	}
+}
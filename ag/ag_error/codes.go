@@ -0,0 +1,11 @@
+package ag_error
+
+// 框架保留的业务码，与HTTP状态码语义对齐，业务自定义码应避开该区间
+const (
+	// CodeLimited 服务端限流拒绝(QPS或并发超限)
+	CodeLimited int32 = 429
+	// CodeCircuitOpen 客户端熔断拒绝，下游服务熔断期间不发起调用
+	CodeCircuitOpen int32 = 503
+	// CodeBulkheadFull 客户端隔离拒绝，对下游服务的并发调用数已满
+	CodeBulkheadFull int32 = 509
+)
@@ -0,0 +1,66 @@
+package ag_error
+
+import (
+	"strconv"
+
+	"github.com/cloudwego/kitex/pkg/remote/trans/nphttp2/codes"
+	"github.com/cloudwego/kitex/pkg/remote/trans/nphttp2/status"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+const (
+	// BizErrorDomain gRPC传输时业务异常 google.rpc.ErrorInfo 的domain
+	BizErrorDomain = "ag.biz"
+	// BizGRPCCode gRPC传输时业务异常的状态码，对端按ErrorInfo识别业务异常，不依赖状态码
+	BizGRPCCode = codes.Unknown
+)
+
+// ToGRPCStatus 将业务异常编码为 google.rpc.Status：message为业务消息，
+// ErrorInfo详情的reason为业务码、metadata为extra，与spring等gRPC服务互通
+func ToGRPCStatus(e BizStatusErrorIface) *status.Status {
+	st := status.New(BizGRPCCode, e.BizMessage())
+	info := &errdetails.ErrorInfo{
+		Reason:   strconv.FormatInt(int64(e.BizCode()), 10),
+		Domain:   BizErrorDomain,
+		Metadata: e.BizExtra(),
+	}
+	if withInfo, err := st.WithDetails(info); err == nil {
+		return withInfo
+	}
+	return st
+}
+
+// FromGRPCStatus 从 google.rpc.Status 解码业务异常，
+// 需包含reason为业务码(整数)的ErrorInfo详情，不限定domain以兼容对端自定义的domain
+func FromGRPCStatus(st *status.Status) (BizStatusErrorIface, bool) {
+	if st == nil || st.Code() == codes.OK {
+		return nil, false
+	}
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok {
+			continue
+		}
+		code, err := strconv.ParseInt(info.GetReason(), 10, 32)
+		if err != nil {
+			continue
+		}
+		if len(info.GetMetadata()) > 0 {
+			return NewBizStatusError(int32(code), st.Message(), info.GetMetadata()), true
+		}
+		return NewBizStatusError(int32(code), st.Message()), true
+	}
+	return nil, false
+}
+
+// FromGRPCError 从gRPC调用返回的错误中解码业务异常
+func FromGRPCError(err error) (BizStatusErrorIface, bool) {
+	if err == nil {
+		return nil, false
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil, false
+	}
+	return FromGRPCStatus(st)
+}
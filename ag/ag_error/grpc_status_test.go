@@ -0,0 +1,31 @@
+package ag_error
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/kitex/pkg/remote/trans/nphttp2/codes"
+	"github.com/cloudwego/kitex/pkg/remote/trans/nphttp2/status"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+func TestGRPCStatus(t *testing.T) {
+	extra := map[string]string{"orderId": "42"}
+	st := ToGRPCStatus(NewBizStatusError(1001, "balance not enough", extra))
+	if st.Code() != BizGRPCCode || st.Message() != "balance not enough" {
+		t.Fatalf("status = %v", st.Proto())
+	}
+
+	be, ok := FromGRPCError(st.Err())
+	if !ok || be.BizCode() != 1001 || be.BizMessage() != "balance not enough" || !reflect.DeepEqual(be.BizExtra(), extra) {
+		t.Fatalf("decoded = %v, %v", be, ok)
+	}
+
+	// 非业务码的ErrorInfo及无详情的状态不视为业务异常
+	other, _ := status.New(codes.ResourceExhausted, "quota").WithDetails(&errdetails.ErrorInfo{Reason: "RATE_LIMIT_EXCEEDED"})
+	for _, err := range []error{other.Err(), status.Err(codes.Internal, "boom")} {
+		if be, ok := FromGRPCError(err); ok {
+			t.Fatalf("decoded %v from %v", be, err)
+		}
+	}
+}
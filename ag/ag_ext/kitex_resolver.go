@@ -1,6 +1,7 @@
 /*
 	对 github.com/kitex-contrib/registry-nacos/resolver.go 的增强
 	添加对 spring-grpc 服务注册的元数据解析支持，spring-grpc将grpc的端口放在了metadata中
+	改为订阅推送维护实例快照，并持久化到本地文件用于nacos不可用时兜底
 */
 
 package ag_ext
@@ -8,6 +9,8 @@ package ag_ext
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 
 	"github.com/cloudwego/kitex/pkg/discovery"
@@ -15,12 +18,14 @@ import (
 
 	"github.com/nacos-group/nacos-sdk-go/clients/naming_client"
 	"github.com/nacos-group/nacos-sdk-go/model"
-	"github.com/nacos-group/nacos-sdk-go/vo"
 )
 
 type options struct {
-	cluster string
-	group   string
+	cluster  string
+	group    string
+	cacheDir string
+	// registeredPort 使用实例注册的端口，不替换为spring-grpc元数据中的gRPC端口
+	registeredPort bool
 }
 
 // Option is nacos option.
@@ -36,9 +41,16 @@ func WithGroup(group string) Option {
 	return func(o *options) { o.group = group }
 }
 
+// WithCacheDir 实例列表本地缓存目录，为空时不持久化；默认为当前用户缓存目录(os.UserCacheDir)下的ag_nacos_cache，
+// 无用户缓存目录时不持久化。目录以0700创建，目录及缓存文件须为当前用户所有且其他用户不可写，否则不读写缓存
+func WithCacheDir(dir string) Option {
+	return func(o *options) { o.cacheDir = dir }
+}
+
 type AgNacosResolver struct {
 	cli  naming_client.INamingClient
 	opts options
+	sub  *nacosSubscriber
 }
 
 // NewDefaultNacosResolver create a default service resolver using nacos.
@@ -52,14 +64,45 @@ type AgNacosResolver struct {
 
 // NewNacosResolver create a service resolver using nacos.
 func NewNacosResolver(cli naming_client.INamingClient, opts ...Option) discovery.Resolver {
+	return newNacosResolver(cli, false, opts...)
+}
+
+// NewNacosHTTPResolver HTTP客户端使用的resolver，实例地址使用注册的端口；
+// spring-cloud服务注册的是HTTP端口，gRPC端口仅在元数据中，HTTP调用不应替换
+func NewNacosHTTPResolver(cli naming_client.INamingClient, opts ...Option) discovery.Resolver {
+	return newNacosResolver(cli, true, opts...)
+}
+
+func newNacosResolver(cli naming_client.INamingClient, registeredPort bool, opts ...Option) discovery.Resolver {
 	op := options{
-		cluster: "DEFAULT",
-		group:   "DEFAULT_GROUP",
+		cluster:        "DEFAULT",
+		group:          "DEFAULT_GROUP",
+		cacheDir:       defaultCacheDir(),
+		registeredPort: registeredPort,
 	}
 	for _, option := range opts {
 		option(&op)
 	}
-	return &AgNacosResolver{cli: cli, opts: op}
+	return &AgNacosResolver{
+		cli:  cli,
+		opts: op,
+		sub: getSubscriber(subscriberKey{
+			cli:            cli,
+			cluster:        op.cluster,
+			group:          op.group,
+			cacheDir:       op.cacheDir,
+			registeredPort: op.registeredPort,
+		}),
+	}
+}
+
+// defaultCacheDir 当前用户私有的缓存目录，不使用所有用户共享可写的临时目录
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ag_nacos_cache")
 }
 
 // Target return a description for the given target that is suitable for being a key for cache.
@@ -68,34 +111,14 @@ func (n *AgNacosResolver) Target(_ context.Context, target rpcinfo.EndpointInfo)
 }
 
 // Resolve a service info by desc.
+// 首次解析时查询并订阅服务，之后返回由推送更新的内存快照
 func (n *AgNacosResolver) Resolve(_ context.Context, desc string) (discovery.Result, error) {
-	res, err := n.cli.SelectInstances(vo.SelectInstancesParam{
-		ServiceName: desc,
-		HealthyOnly: true,
-		GroupName:   n.opts.group,
-		Clusters:    []string{n.opts.cluster},
-	})
-	if err != nil {
-		return discovery.Result{}, err
-	}
-	if len(res) == 0 {
-		return discovery.Result{}, fmt.Errorf("no instance remains for %v", desc)
-	}
-	instances := make([]discovery.Instance, 0, len(res))
-	for _, in := range res {
-		inst, ok := resolverInstance(in)
-		if ok {
-			instances = append(instances, inst)
-		}
-	}
-	if len(instances) == 0 {
-		return discovery.Result{}, fmt.Errorf("no instance remains for %v", desc)
-	}
-	return discovery.Result{
-		Cacheable: true,
-		CacheKey:  desc,
-		Instances: instances,
-	}, nil
+	return n.sub.resolve(desc)
+}
+
+// Watch 注册实例变化回调，nacos推送实例变化时触发
+func (n *AgNacosResolver) Watch(fn func(discovery.Change)) (cancel func()) {
+	return n.sub.watch(fn)
 }
 
 // Diff computes the difference between two results.
@@ -104,13 +127,22 @@ func (n *AgNacosResolver) Diff(cacheKey string, prev, next discovery.Result) (di
 }
 
 // Name returns the name of the resolver.
+// 实例地址不同的两种resolver名称不同，kitex按名称缓存负载均衡器时不会混用
 func (n *AgNacosResolver) Name() string {
-	return "nacos" + ":" + n.opts.cluster + ":" + n.opts.group
+	name := "nacos"
+	if n.opts.registeredPort {
+		name = "nacos-http"
+	}
+	return name + ":" + n.opts.cluster + ":" + n.opts.group
 }
 
-var _ discovery.Resolver = (*AgNacosResolver)(nil)
+var (
+	_ discovery.Resolver = (*AgNacosResolver)(nil)
+	_ ChangeNotifier     = (*AgNacosResolver)(nil)
+)
 
-func resolverInstance(in model.Instance) (discovery.Instance, bool) {
+// resolverInstance registeredPort为false时，spring-grpc服务使用元数据中的gRPC端口
+func resolverInstance(in model.Instance, registeredPort bool) (discovery.Instance, bool) {
 	if !in.Enable {
 		return nil, false
 	}
@@ -120,7 +152,7 @@ func resolverInstance(in model.Instance) (discovery.Instance, bool) {
 	// spring-grpc将grpc的端口放在了metadata中
 	metadate := in.Metadata
 	prs, ok := metadate["preserved.register.source"]
-	if ok {
+	if ok && !registeredPort {
 		if prs == "SPRING_CLOUD" {
 			grpcPort, ok := metadate["gRPC_port"]
 			if ok {
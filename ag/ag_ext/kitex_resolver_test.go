@@ -0,0 +1,190 @@
+package ag_ext
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/nacos-group/nacos-sdk-go/clients/naming_client"
+	"github.com/nacos-group/nacos-sdk-go/model"
+	"github.com/nacos-group/nacos-sdk-go/vo"
+)
+
+// fakeNamingClient 仅实现查询及订阅的nacos客户端
+type fakeNamingClient struct {
+	naming_client.INamingClient
+
+	mu        sync.Mutex
+	instances []model.Instance
+	err       error
+	selects   int
+	callback  func([]model.SubscribeService, error)
+	// syncPush 订阅时同步推送当前实例，与nacos客户端已有服务缓存时的行为一致
+	syncPush bool
+}
+
+func (c *fakeNamingClient) SelectInstances(vo.SelectInstancesParam) ([]model.Instance, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.selects++
+	return c.instances, c.err
+}
+
+func (c *fakeNamingClient) Subscribe(param *vo.SubscribeParam) error {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.callback = param.SubscribeCallback
+	syncPush := c.syncPush
+	c.mu.Unlock()
+	if syncPush {
+		c.push("10.0.0.9")
+	}
+	return nil
+}
+
+func (c *fakeNamingClient) push(ips ...string) {
+	services := make([]model.SubscribeService, 0, len(ips))
+	for _, ip := range ips {
+		services = append(services, model.SubscribeService{Ip: ip, Port: 8000, Weight: 10, Enable: true, Healthy: true})
+	}
+	c.callback(services, nil)
+}
+
+func testInstances(ips ...string) []model.Instance {
+	ins := make([]model.Instance, 0, len(ips))
+	for _, ip := range ips {
+		ins = append(ins, model.Instance{Ip: ip, Port: 8000, Weight: 10, Enable: true, Healthy: true})
+	}
+	return ins
+}
+
+func TestNacosResolverSubscribe(t *testing.T) {
+	dir := t.TempDir()
+	cli := &fakeNamingClient{instances: testInstances("10.0.0.1", "10.0.0.2")}
+	r := NewNacosResolver(cli, WithCacheDir(dir))
+
+	var changes []discovery.Change
+	cancel := r.(ChangeNotifier).Watch(func(change discovery.Change) {
+		changes = append(changes, change)
+	})
+	defer cancel()
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		res, err := r.Resolve(ctx, "svc")
+		if err != nil || len(res.Instances) != 2 {
+			t.Fatalf("resolve = %v, %v", res.Instances, err)
+		}
+	}
+	if cli.selects != 1 || cli.callback == nil {
+		t.Fatalf("selects = %d, subscribed = %v", cli.selects, cli.callback != nil)
+	}
+
+	// 推送实例变化，更新快照并通知
+	cli.push("10.0.0.1")
+	if len(changes) != 1 || len(changes[0].Removed) != 1 || changes[0].Result.CacheKey != "svc" {
+		t.Fatalf("changes = %+v", changes)
+	}
+	if res, _ := r.Resolve(ctx, "svc"); len(res.Instances) != 1 {
+		t.Fatalf("instances after push = %d", len(res.Instances))
+	}
+	// 相同列表及空推送不更新
+	cli.push("10.0.0.1")
+	cli.push()
+	if res, _ := r.Resolve(ctx, "svc"); len(changes) != 1 || len(res.Instances) != 1 {
+		t.Fatalf("changes = %d, instances = %d", len(changes), len(res.Instances))
+	}
+
+	// nacos不可用时使用本地文件中的实例列表
+	down := &fakeNamingClient{err: errors.New("nacos unavailable")}
+	res, err := NewNacosResolver(down, WithCacheDir(dir)).Resolve(ctx, "svc")
+	if err != nil || len(res.Instances) != 1 || res.Instances[0].Address().String() != "10.0.0.1:8000" {
+		t.Fatalf("local cache resolve = %v, %v", res.Instances, err)
+	}
+	// 订阅失败时再次解析重试
+	down.mu.Lock()
+	down.err = nil
+	down.instances = testInstances("10.0.0.3")
+	down.mu.Unlock()
+	res, _ = NewNacosResolver(down, WithCacheDir(dir)).Resolve(ctx, "svc")
+	if down.callback == nil || res.Instances[0].Address().String() != "10.0.0.3:8000" {
+		t.Fatalf("retry resolve = %v, subscribed = %v", res.Instances, down.callback != nil)
+	}
+
+	if _, err := NewNacosResolver(&fakeNamingClient{err: errors.New("down")}, WithCacheDir("")).Resolve(ctx, "svc"); err == nil {
+		t.Fatal("expect error without local cache")
+	}
+}
+
+func TestNacosResolverCacheDirNotPrivate(t *testing.T) {
+	dir := t.TempDir()
+	cli := &fakeNamingClient{instances: testInstances("10.0.0.1")}
+	if _, err := NewNacosResolver(cli, WithCacheDir(dir), WithGroup("private")).Resolve(context.Background(), "svc"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 其他用户可写的目录中的缓存文件可能被篡改，不再读取
+	if err := os.Chmod(dir, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	down := &fakeNamingClient{err: errors.New("nacos unavailable")}
+	if _, err := NewNacosResolver(down, WithCacheDir(dir), WithGroup("private")).Resolve(context.Background(), "svc"); err == nil {
+		t.Fatal("resolved from a world-writable cache dir")
+	}
+}
+
+func TestNacosHTTPResolverPort(t *testing.T) {
+	spring := model.Instance{Ip: "10.0.0.1", Port: 8080, Weight: 10, Enable: true, Healthy: true, Metadata: map[string]string{
+		"preserved.register.source": "SPRING_CLOUD",
+		"gRPC_port":                 "9090",
+	}}
+	ctx := context.Background()
+
+	// kitex客户端使用spring-grpc元数据中的gRPC端口，HTTP客户端使用注册的端口
+	for _, tt := range []struct {
+		resolver func(naming_client.INamingClient, ...Option) discovery.Resolver
+		want     string
+	}{
+		{NewNacosResolver, "10.0.0.1:9090"},
+		{NewNacosHTTPResolver, "10.0.0.1:8080"},
+	} {
+		cli := &fakeNamingClient{instances: []model.Instance{spring}}
+		r := tt.resolver(cli, WithCacheDir(""))
+		res, err := r.Resolve(ctx, "spring-svc")
+		if err != nil || len(res.Instances) != 1 || res.Instances[0].Address().String() != tt.want {
+			t.Fatalf("%s resolve = %v, %v, want %s", r.Name(), res.Instances, err, tt.want)
+		}
+	}
+	if NewNacosResolver(nil).Name() == NewNacosHTTPResolver(nil).Name() {
+		t.Error("http resolver shares the grpc resolver name")
+	}
+}
+
+func TestNacosResolverSyncPush(t *testing.T) {
+	cli := &fakeNamingClient{instances: testInstances("10.0.0.1"), syncPush: true}
+	r := NewNacosResolver(cli, WithCacheDir(""), WithGroup("sync"))
+
+	// 订阅回调同步执行时不死锁，解析结果为推送的实例
+	done := make(chan struct{})
+	var res discovery.Result
+	var err error
+	go func() {
+		defer close(done)
+		res, err = r.Resolve(context.Background(), "svc")
+	}()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("resolve deadlocked on synchronous subscribe callback")
+	}
+	if err != nil || len(res.Instances) != 1 || res.Instances[0].Address().String() != "10.0.0.9:8000" {
+		t.Fatalf("resolve = %v, %v", res.Instances, err)
+	}
+}
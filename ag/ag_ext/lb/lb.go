@@ -0,0 +1,175 @@
+// Package lb 服务发现客户端的负载均衡策略，基于kitex loadbalance接口，kitex客户端与hertz客户端共用
+package lb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/loadbalance"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+)
+
+const (
+	StrategyWeightedRoundRobin            = "weighted-round-robin"
+	StrategyInterleavedWeightedRoundRobin = "interleaved-weighted-round-robin"
+	StrategyWeightedRandom                = "weighted-random"
+	StrategyP2C                           = "p2c"
+	StrategyConsistentHash                = "consistent-hash"
+)
+
+// LoadBalanceProperties 负载均衡配置
+type LoadBalanceProperties struct {
+	// Strategy 策略：weighted-round-robin|interleaved-weighted-round-robin|weighted-random|p2c|consistent-hash
+	Strategy string `value:"${strategy:weighted-round-robin}"`
+	// HashKey 一致性哈希键的透传(metainfo)键名，WithHashKey设置的键优先；无键时按权重随机
+	HashKey string `value:"${hash-key:}"`
+	// VirtualFactor 一致性哈希每个实例的虚拟节点数(按权重折算)
+	VirtualFactor int `value:"${virtual-factor:100}"`
+
+	// 区域亲和：优先选择实例元数据zone-key与local-zone相同的实例，local-zone为空时不启用
+	ZoneKey   string `value:"${zone-key:zone}"`
+	LocalZone string `value:"${local-zone:}"`
+	// ZoneMinInstances 本区域实例数少于该值时回退到全部实例
+	ZoneMinInstances int `value:"${zone-min-instances:1}"`
+}
+
+// ActiveTracker 依赖实例活跃请求数的负载均衡器实现该接口，由客户端在请求实例前后计数
+type ActiveTracker interface {
+	Begin(addr string) (end func())
+}
+
+// New 按配置创建负载均衡器
+func New(conf LoadBalanceProperties) (loadbalance.Loadbalancer, error) {
+	var balancer loadbalance.Loadbalancer
+	switch strings.ToLower(strings.TrimSpace(conf.Strategy)) {
+	case "", StrategyWeightedRoundRobin:
+		balancer = loadbalance.NewWeightedRoundRobinBalancer()
+	case StrategyInterleavedWeightedRoundRobin:
+		balancer = loadbalance.NewInterleavedWeightedRoundRobinBalancer()
+	case StrategyWeightedRandom:
+		balancer = loadbalance.NewWeightedRandomBalancer()
+	case StrategyP2C:
+		balancer = NewP2CBalancer()
+	case StrategyConsistentHash:
+		if conf.VirtualFactor <= 0 {
+			return nil, fmt.Errorf("lb virtual-factor invalid: %d", conf.VirtualFactor)
+		}
+		balancer = newHashBalancer(conf.HashKey, uint32(conf.VirtualFactor))
+	default:
+		return nil, fmt.Errorf("lb strategy invalid: %s", conf.Strategy)
+	}
+
+	if conf.LocalZone != "" {
+		if conf.ZoneKey == "" || conf.ZoneMinInstances < 1 {
+			return nil, fmt.Errorf("lb zone invalid: zone-key=%s zone-min-instances=%d", conf.ZoneKey, conf.ZoneMinInstances)
+		}
+		balancer = NewZoneBalancer(balancer, conf.ZoneKey, conf.LocalZone, conf.ZoneMinInstances)
+	}
+	return balancer, nil
+}
+
+type hashKeyCtxKey struct{}
+
+// WithHashKey 设置一致性哈希键，相同键的请求路由到同一实例
+func WithHashKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, hashKeyCtxKey{}, key)
+}
+
+// hashBalancer 一致性哈希，请求无哈希键时按权重随机
+type hashBalancer struct {
+	metaKey       string
+	virtualFactor uint32
+	consist       loadbalance.Loadbalancer
+	fallback      loadbalance.Loadbalancer
+}
+
+func newHashBalancer(metaKey string, virtualFactor uint32) *hashBalancer {
+	b := &hashBalancer{metaKey: metaKey, virtualFactor: virtualFactor, fallback: loadbalance.NewWeightedRandomBalancer()}
+	opt := loadbalance.NewConsistentHashOption(func(ctx context.Context, _ interface{}) string {
+		return b.key(ctx)
+	})
+	opt.VirtualFactor = virtualFactor
+	b.consist = loadbalance.NewConsistBalancer(opt)
+	return b
+}
+
+func (b *hashBalancer) key(ctx context.Context) string {
+	if key, ok := ctx.Value(hashKeyCtxKey{}).(string); ok && key != "" {
+		return key
+	}
+	if b.metaKey == "" {
+		return ""
+	}
+	if v, ok := metainfo.GetPersistentValue(ctx, b.metaKey); ok {
+		return v
+	}
+	v, _ := metainfo.GetValue(ctx, b.metaKey)
+	return v
+}
+
+func (b *hashBalancer) GetPicker(res discovery.Result) loadbalance.Picker {
+	return &hashPicker{
+		b:        b,
+		consist:  b.consist.GetPicker(res),
+		fallback: b.fallback.GetPicker(res),
+	}
+}
+
+// Name 含哈希键配置，kitex lbcache按名称共享均衡器
+func (b *hashBalancer) Name() string {
+	return fmt.Sprintf("%s:%s:%d", StrategyConsistentHash, b.metaKey, b.virtualFactor)
+}
+
+func (b *hashBalancer) Rebalance(change discovery.Change) {
+	forwardRebalance(b.consist, change)
+	forwardRebalance(b.fallback, change)
+}
+
+func (b *hashBalancer) Delete(change discovery.Change) {
+	forwardDelete(b.consist, change)
+	forwardDelete(b.fallback, change)
+}
+
+type hashPicker struct {
+	b        *hashBalancer
+	consist  loadbalance.Picker
+	fallback loadbalance.Picker
+}
+
+func (p *hashPicker) Next(ctx context.Context, request interface{}) discovery.Instance {
+	if p.b.key(ctx) == "" {
+		return p.fallback.Next(ctx, request)
+	}
+	return p.consist.Next(ctx, request)
+}
+
+// KitexActiveMW kitex实例级中间件，为 ActiveTracker 统计实例活跃请求数，通过 client.WithInstanceMW 使用
+func KitexActiveMW(tracker ActiveTracker) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, req, resp interface{}) error {
+			ri := rpcinfo.GetRPCInfo(ctx)
+			if ri == nil || ri.To().Address() == nil {
+				return next(ctx, req, resp)
+			}
+			end := tracker.Begin(ri.To().Address().String())
+			defer end()
+			return next(ctx, req, resp)
+		}
+	}
+}
+
+func forwardRebalance(lb loadbalance.Loadbalancer, change discovery.Change) {
+	if r, ok := lb.(loadbalance.Rebalancer); ok {
+		r.Rebalance(change)
+	}
+}
+
+func forwardDelete(lb loadbalance.Loadbalancer, change discovery.Change) {
+	if r, ok := lb.(loadbalance.Rebalancer); ok {
+		r.Delete(change)
+	}
+}
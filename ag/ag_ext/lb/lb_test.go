@@ -0,0 +1,107 @@
+package lb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+)
+
+func testResult(zones ...string) discovery.Result {
+	instances := make([]discovery.Instance, 0, len(zones))
+	for i, zone := range zones {
+		instances = append(instances, discovery.NewInstance("tcp", fmt.Sprintf("10.0.0.%d:8000", i+1), 10, map[string]string{"zone": zone}))
+	}
+	return discovery.Result{Cacheable: true, CacheKey: "svc", Instances: instances}
+}
+
+func TestNewInvalid(t *testing.T) {
+	for name, conf := range map[string]LoadBalanceProperties{
+		"strategy":       {Strategy: "fastest"},
+		"virtual factor": {Strategy: StrategyConsistentHash},
+		"zone":           {LocalZone: "a", ZoneKey: "zone"},
+	} {
+		if _, err := New(conf); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+	}
+}
+
+func TestP2C(t *testing.T) {
+	b := NewP2CBalancer()
+	res := testResult("a", "b")
+	picker := b.GetPicker(res)
+
+	// 第一个实例活跃请求多，两实例时总选择另一个
+	busy := res.Instances[0].Address().String()
+	ends := []func(){b.Begin(busy), b.Begin(busy)}
+	for i := 0; i < 20; i++ {
+		if ins := picker.Next(context.Background(), nil); ins.Address().String() == busy {
+			t.Fatalf("picked busy instance %s", busy)
+		}
+	}
+	for _, end := range ends {
+		end()
+		end()
+	}
+	if n := b.Active(busy); n != 0 {
+		t.Fatalf("active = %d after end", n)
+	}
+}
+
+func TestConsistentHash(t *testing.T) {
+	b, err := New(LoadBalanceProperties{Strategy: StrategyConsistentHash, VirtualFactor: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	picker := b.GetPicker(testResult("a", "b", "c", "d"))
+
+	for _, key := range []string{"user-1", "user-2", "user-3"} {
+		ctx := WithHashKey(context.Background(), key)
+		first := picker.Next(ctx, nil).Address().String()
+		for i := 0; i < 10; i++ {
+			if addr := picker.Next(ctx, nil).Address().String(); addr != first {
+				t.Fatalf("key %s picked %s and %s", key, first, addr)
+			}
+		}
+	}
+	// 无哈希键时按权重随机
+	if ins := picker.Next(context.Background(), nil); ins == nil {
+		t.Fatal("no instance picked without hash key")
+	}
+}
+
+func TestZoneAffinity(t *testing.T) {
+	b, err := New(LoadBalanceProperties{ZoneKey: "zone", LocalZone: "b", ZoneMinInstances: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := testResult("a", "b", "b", "c")
+	picker := b.GetPicker(res)
+	for i := 0; i < 10; i++ {
+		if zone, _ := picker.Next(context.Background(), nil).Tag("zone"); zone != "b" {
+			t.Fatalf("picked zone %s", zone)
+		}
+	}
+
+	// 本区域实例不足时回退到全部实例
+	b, _ = New(LoadBalanceProperties{ZoneKey: "zone", LocalZone: "b", ZoneMinInstances: 3})
+	picker = b.GetPicker(res)
+	zones := make(map[string]bool)
+	for i := 0; i < 8; i++ {
+		zone, _ := picker.Next(context.Background(), nil).Tag("zone")
+		zones[zone] = true
+	}
+	if len(zones) != 3 {
+		t.Fatalf("fallback picked zones %v", zones)
+	}
+
+	// 区域亲和转发活跃请求统计
+	b, _ = New(LoadBalanceProperties{Strategy: StrategyP2C, ZoneKey: "zone", LocalZone: "b", ZoneMinInstances: 1})
+	end := b.(ActiveTracker).Begin("10.0.0.9:8000")
+	if n := NewP2CBalancer().Active("10.0.0.9:8000"); n != 1 {
+		t.Fatalf("active through zone balancer = %d", n)
+	}
+	end()
+}
@@ -0,0 +1,100 @@
+package lb
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/loadbalance"
+)
+
+// activeCounters 实例活跃请求数，按实例地址在进程内共享，
+// 同名负载均衡器在kitex lbcache中可能被多个客户端共用，计数与具体的均衡器实例无关
+var activeCounters sync.Map // addr -> *atomic.Int64
+
+// P2CBalancer 最少活跃请求(power of two choices)：随机选取两个实例，选择按权重折算后活跃请求数较少者；
+// 活跃请求数由客户端通过 ActiveTracker 统计
+type P2CBalancer struct{}
+
+func NewP2CBalancer() *P2CBalancer {
+	return &P2CBalancer{}
+}
+
+// Begin 实例请求开始，返回请求结束时的回调
+func (b *P2CBalancer) Begin(addr string) func() {
+	counter := activeCounter(addr)
+	counter.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() { counter.Add(-1) })
+	}
+}
+
+// Active 实例当前活跃请求数
+func (b *P2CBalancer) Active(addr string) int64 {
+	if v, ok := activeCounters.Load(addr); ok {
+		return v.(*atomic.Int64).Load()
+	}
+	return 0
+}
+
+func activeCounter(addr string) *atomic.Int64 {
+	if v, ok := activeCounters.Load(addr); ok {
+		return v.(*atomic.Int64)
+	}
+	v, _ := activeCounters.LoadOrStore(addr, new(atomic.Int64))
+	return v.(*atomic.Int64)
+}
+
+func (b *P2CBalancer) GetPicker(res discovery.Result) loadbalance.Picker {
+	instances := make([]discovery.Instance, 0, len(res.Instances))
+	for _, ins := range res.Instances {
+		if ins.Weight() > 0 {
+			instances = append(instances, ins)
+		}
+	}
+	return &p2cPicker{b: b, instances: instances}
+}
+
+func (b *P2CBalancer) Name() string {
+	return StrategyP2C
+}
+
+// Delete 实例下线时清理计数，进行中的请求仍持有原计数器
+func (b *P2CBalancer) Delete(change discovery.Change) {
+	for _, ins := range change.Removed {
+		activeCounters.Delete(ins.Address().String())
+	}
+}
+
+func (b *P2CBalancer) Rebalance(change discovery.Change) {
+	b.Delete(change)
+}
+
+type p2cPicker struct {
+	b         *P2CBalancer
+	instances []discovery.Instance
+}
+
+func (p *p2cPicker) Next(_ context.Context, _ interface{}) discovery.Instance {
+	switch n := len(p.instances); n {
+	case 0:
+		return nil
+	case 1:
+		return p.instances[0]
+	default:
+		i := rand.IntN(n)
+		j := rand.IntN(n - 1)
+		if j >= i {
+			j++
+		}
+		a, b := p.instances[i], p.instances[j]
+		// 比较 active/weight，交叉相乘避免浮点运算
+		if (p.b.Active(a.Address().String())+1)*int64(b.Weight()) <= (p.b.Active(b.Address().String())+1)*int64(a.Weight()) {
+			return a
+		}
+		return b
+	}
+}
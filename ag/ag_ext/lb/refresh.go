@@ -0,0 +1,38 @@
+package lb
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/frochyzhang/ag-core/ag/ag_ext"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/loadbalance/lbcache"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+)
+
+// RefreshOnChange resolver推送实例变化时立即刷新lbcache中对应服务的均衡器，
+// 刷新时经Diff触发Rebalance及kitex的discovery_change事件；services为空时刷新所有服务。
+// resolver未实现 ag_ext.ChangeNotifier 时不做处理，仍按lbcache的定时刷新
+func RefreshOnChange(resolver discovery.Resolver, factory *lbcache.BalancerFactory, services ...string) (cancel func()) {
+	notifier, ok := resolver.(ag_ext.ChangeNotifier)
+	if !ok {
+		return func() {}
+	}
+	filter := make(map[string]bool, len(services))
+	for _, s := range services {
+		filter[s] = true
+	}
+	return notifier.Watch(func(change discovery.Change) {
+		service := change.Result.CacheKey
+		if len(filter) > 0 && !filter[service] {
+			return
+		}
+		b, err := factory.Get(context.Background(), rpcinfo.NewEndpointInfo(service, "", nil, nil))
+		if err != nil {
+			slog.Warn("load balancer refresh failed", "service", service, "error", err)
+			return
+		}
+		b.Refresh()
+	})
+}
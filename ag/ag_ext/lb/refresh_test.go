@@ -0,0 +1,53 @@
+package lb
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/loadbalance/lbcache"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+)
+
+// notifyResolver 支持变化通知的resolver
+type notifyResolver struct {
+	discovery.SynthesizedResolver
+	res   atomic.Value
+	watch func(discovery.Change)
+}
+
+func (r *notifyResolver) Watch(fn func(discovery.Change)) func() {
+	r.watch = fn
+	return func() { r.watch = nil }
+}
+
+func TestRefreshOnChange(t *testing.T) {
+	r := &notifyResolver{}
+	r.res.Store(testResult("a", "b"))
+	r.TargetFunc = func(_ context.Context, target rpcinfo.EndpointInfo) string { return target.ServiceName() }
+	r.ResolveFunc = func(context.Context, string) (discovery.Result, error) { return r.res.Load().(discovery.Result), nil }
+	r.NameFunc = func() string { return "notify" }
+
+	factory := lbcache.NewBalancerFactory(r, NewP2CBalancer(), lbcache.Options{Cacheable: false})
+	cancel := RefreshOnChange(r, factory, "svc")
+	b, err := factory.Get(context.Background(), rpcinfo.NewEndpointInfo("svc", "", nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 其他服务的变化不刷新
+	r.res.Store(testResult("a"))
+	r.watch(discovery.Change{Result: discovery.Result{CacheKey: "other"}})
+	if res, _ := b.GetResult(); len(res.Instances) != 2 {
+		t.Fatalf("instances = %d after other change", len(res.Instances))
+	}
+	r.watch(discovery.Change{Result: discovery.Result{CacheKey: "svc"}})
+	if res, _ := b.GetResult(); len(res.Instances) != 1 {
+		t.Fatalf("instances = %d after change", len(res.Instances))
+	}
+	cancel()
+	if r.watch != nil {
+		t.Fatal("watch not canceled")
+	}
+}
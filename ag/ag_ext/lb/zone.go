@@ -0,0 +1,94 @@
+package lb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/loadbalance"
+)
+
+// ZoneBalancer 区域亲和：优先在实例元数据key与本地区域相同的实例中选择，
+// 本区域实例不足minInstances或本区域无可选实例时回退到全部实例
+type ZoneBalancer struct {
+	inner        loadbalance.Loadbalancer
+	key          string
+	zone         string
+	minInstances int
+}
+
+func NewZoneBalancer(inner loadbalance.Loadbalancer, key, zone string, minInstances int) *ZoneBalancer {
+	return &ZoneBalancer{inner: inner, key: key, zone: zone, minInstances: minInstances}
+}
+
+func (b *ZoneBalancer) GetPicker(res discovery.Result) loadbalance.Picker {
+	all := b.inner.GetPicker(res)
+	local := b.localResult(res)
+	if len(local.Instances) < b.minInstances {
+		return all
+	}
+	return &zonePicker{local: b.inner.GetPicker(local), all: all}
+}
+
+func (b *ZoneBalancer) Name() string {
+	return fmt.Sprintf("%s:zone:%s=%s:%d", b.inner.Name(), b.key, b.zone, b.minInstances)
+}
+
+// Begin 转发活跃请求统计
+func (b *ZoneBalancer) Begin(addr string) func() {
+	if tracker, ok := b.inner.(ActiveTracker); ok {
+		return tracker.Begin(addr)
+	}
+	return func() {}
+}
+
+func (b *ZoneBalancer) Rebalance(change discovery.Change) {
+	forwardRebalance(b.inner, change)
+	forwardRebalance(b.inner, b.localChange(change))
+}
+
+func (b *ZoneBalancer) Delete(change discovery.Change) {
+	forwardDelete(b.inner, change)
+	forwardDelete(b.inner, b.localChange(change))
+}
+
+// localResult 本区域实例，使用独立的CacheKey以免与全部实例的picker缓存冲突
+func (b *ZoneBalancer) localResult(res discovery.Result) discovery.Result {
+	local := discovery.Result{
+		Cacheable: res.Cacheable,
+		CacheKey:  res.CacheKey + "#zone=" + b.zone,
+		Instances: b.filter(res.Instances),
+	}
+	return local
+}
+
+func (b *ZoneBalancer) localChange(change discovery.Change) discovery.Change {
+	return discovery.Change{
+		Result:  b.localResult(change.Result),
+		Added:   b.filter(change.Added),
+		Updated: b.filter(change.Updated),
+		Removed: b.filter(change.Removed),
+	}
+}
+
+func (b *ZoneBalancer) filter(instances []discovery.Instance) []discovery.Instance {
+	local := make([]discovery.Instance, 0, len(instances))
+	for _, ins := range instances {
+		if zone, ok := ins.Tag(b.key); ok && zone == b.zone {
+			local = append(local, ins)
+		}
+	}
+	return local
+}
+
+type zonePicker struct {
+	local loadbalance.Picker
+	all   loadbalance.Picker
+}
+
+func (p *zonePicker) Next(ctx context.Context, request interface{}) discovery.Instance {
+	if ins := p.local.Next(ctx, request); ins != nil {
+		return ins
+	}
+	return p.all.Next(ctx, request)
+}
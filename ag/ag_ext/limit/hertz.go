@@ -0,0 +1,73 @@
+package limit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/frochyzhang/ag-core/ag/ag_error"
+)
+
+// NewHertzMiddleware 创建hertz服务端限流中间件，service为当前服务名；
+// 方法为路由路径(未匹配路由时为请求路径)，调用方取自 caller-header 请求头，
+// 客户端IP为对端地址，仅对端为 trusted-proxies 中的代理时取自 X-Forwarded-For，拒绝时返回HTTP 429及业务码
+func NewHertzMiddleware(limiter *Limiter, service string) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		method := c.FullPath()
+		if method == "" {
+			method = string(c.Path())
+		}
+		release, err := limiter.Acquire(Request{
+			Service: service,
+			Method:  method,
+			Caller:  string(c.GetHeader(limiter.CallerHeader())),
+			IP:      clientIP(c, limiter.rules.Load().trustedProxies),
+		})
+		if err != nil {
+			abe := err.(ag_error.BizStatusErrorIface)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, map[string]interface{}{
+				"code":  abe.BizCode(),
+				"msg":   abe.BizMessage(),
+				"extra": abe.BizExtra(),
+			})
+			return
+		}
+		defer release()
+		c.Next(ctx)
+	}
+}
+
+// clientIP 对端为可信代理时取 X-Forwarded-For 中自右向左第一个非可信代理的地址，
+// 不使用 RequestContext.ClientIP：hertz默认信任全部代理，请求头可被客户端伪造
+func clientIP(c *app.RequestContext, proxies []*net.IPNet) string {
+	ip := hostOf(c.RemoteAddr().String())
+	if !trusted(ip, proxies) {
+		return ip
+	}
+	hops := strings.Split(string(c.GetHeader("X-Forwarded-For")), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		if ip = hop; !trusted(hop, proxies) {
+			break
+		}
+	}
+	return ip
+}
+
+func trusted(ip string, proxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range proxies {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package limit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/network"
+)
+
+// addrConn 仅提供对端地址的连接
+type addrConn struct {
+	network.Conn
+	addr net.Addr
+}
+
+func (c addrConn) RemoteAddr() net.Addr { return c.addr }
+
+func TestHertzClientIP(t *testing.T) {
+	l, _ := testLimiter(t, LimitProperties{Enabled: true, TrustedProxies: "10.0.0.0/8", Rules: map[string]LimitRule{
+		"ip": {QPS: 1, Key: "ip"},
+	}})
+	mw := NewHertzMiddleware(l, "order")
+	serve := func(remote, xff string) int {
+		c := app.NewContext(0)
+		c.SetConn(addrConn{addr: &net.TCPAddr{IP: net.ParseIP(remote), Port: 5000}})
+		if xff != "" {
+			c.Request.Header.Set("X-Forwarded-For", xff)
+		}
+		mw(context.Background(), c)
+		return c.Response.StatusCode()
+	}
+
+	// 非可信代理伪造的 X-Forwarded-For 不生效，按对端地址限流
+	if code := serve("192.168.1.1", "1.1.1.1"); code != http.StatusOK {
+		t.Fatalf("first status = %d", code)
+	}
+	if code := serve("192.168.1.1", "2.2.2.2"); code != http.StatusTooManyRequests {
+		t.Fatalf("spoofed header status = %d, want 429", code)
+	}
+
+	// 可信代理转发时取自右向左第一个非可信代理的地址
+	if code := serve("10.0.0.1", "3.3.3.3, 192.168.1.1, 10.0.0.2"); code != http.StatusTooManyRequests {
+		t.Fatalf("forwarded status = %d, want 429", code)
+	}
+	if code := serve("10.0.0.1", "192.168.1.2"); code != http.StatusOK {
+		t.Fatalf("forwarded new client status = %d", code)
+	}
+}
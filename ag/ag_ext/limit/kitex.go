@@ -0,0 +1,77 @@
+package limit
+
+import (
+	"context"
+	"net"
+
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+)
+
+// KitexMiddlewareOrder kitex服务端限流中间件的顺序，先于其他中间件执行以尽早拒绝
+const KitexMiddlewareOrder = -100
+
+// KitexMiddleware kitex服务端限流中间件，实现 IAgKitexServerMiddleware 及 IAgKitexServerReleaser；
+// 流式方法按整个流计数，拒绝时返回的业务异常由服务端业务异常中间件传递给调用方
+type KitexMiddleware struct {
+	limiter *Limiter
+}
+
+// releaseKey ctx中归还并发数的函数
+type releaseKey struct{}
+
+// NewKitexMiddleware 创建kitex服务端限流中间件
+func NewKitexMiddleware(limiter *Limiter) *KitexMiddleware {
+	return &KitexMiddleware{limiter: limiter}
+}
+
+func (m *KitexMiddleware) Order() int {
+	return KitexMiddlewareOrder
+}
+
+func (m *KitexMiddleware) Before(ctx context.Context, req, resp interface{}) (context.Context, error) {
+	release, err := m.limiter.Acquire(kitexRequest(ctx))
+	if err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, releaseKey{}, release), nil
+}
+
+func (m *KitexMiddleware) After(ctx context.Context, req, resp interface{}, e error) error {
+	return nil
+}
+
+// Release 归还Before占用的并发数
+func (m *KitexMiddleware) Release(ctx context.Context, err error) {
+	if release, ok := ctx.Value(releaseKey{}).(func()); ok {
+		release()
+	}
+}
+
+func kitexRequest(ctx context.Context) Request {
+	var req Request
+	ri := rpcinfo.GetRPCInfo(ctx)
+	if ri == nil {
+		return req
+	}
+	if to := ri.To(); to != nil {
+		req.Service = to.ServiceName()
+		req.Method = to.Method()
+	}
+	if req.Method == "" && ri.Invocation() != nil {
+		req.Method = ri.Invocation().MethodName()
+	}
+	if from := ri.From(); from != nil {
+		req.Caller = from.ServiceName()
+		if addr := from.Address(); addr != nil {
+			req.IP = hostOf(addr.String())
+		}
+	}
+	return req
+}
+
+func hostOf(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,43 @@
+package limit
+
+import (
+	"context"
+	"testing"
+)
+
+func TestKitexMiddlewareRelease(t *testing.T) {
+	l, _ := testLimiter(t, LimitProperties{Enabled: true, Rules: map[string]LimitRule{
+		"conc": {MaxConcurrency: 2},
+	}})
+	m := NewKitexMiddleware(l)
+
+	// 并发数随各自的ctx归还，与请求参数无关
+	ctx1, err := m.Before(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx2, err := m.Before(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = m.Before(context.Background(), nil, nil)
+	assertLimited(t, err, "concurrency")
+
+	m.Release(ctx1, nil)
+	ctx3, err := m.Before(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("Before() after release = %v", err)
+	}
+	m.Release(ctx2, nil)
+	m.Release(ctx3, nil)
+
+	// 未经Before的ctx不归还
+	m.Release(context.Background(), nil)
+	for i := 0; i < 2; i++ {
+		if _, err := m.Before(context.Background(), nil, nil); err != nil {
+			t.Fatalf("Before() #%d = %v", i, err)
+		}
+	}
+	_, err = m.Before(context.Background(), nil, nil)
+	assertLimited(t, err, "concurrency")
+}
@@ -0,0 +1,339 @@
+// Package limit 服务端限流：按规则对请求做令牌桶QPS限制及最大并发限制，
+// 规则可按服务、方法、调用方服务、客户端IP匹配及分维度计数，kitex服务端与hertz服务端共用
+package limit
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/frochyzhang/ag-core/ag/ag_error"
+)
+
+const (
+	LimitPropertiesPrefix = "limit"
+
+	DimService = "service"
+	DimMethod  = "method"
+	DimCaller  = "caller"
+	DimIP      = "ip"
+
+	// matchAny 匹配条件为空或*时匹配全部
+	matchAny = "*"
+)
+
+// LimitProperties 限流配置 limit.*
+type LimitProperties struct {
+	Enabled bool `value:"${enabled:true}"`
+	// CallerHeader hertz请求中标识调用方服务的请求头
+	CallerHeader string `value:"${caller-header:x-ag-caller}"`
+	// TrustedProxies 可信代理，逗号分隔的IP或CIDR；hertz请求的对端地址为可信代理时，
+	// 客户端IP取 X-Forwarded-For 中自右向左第一个非可信代理的地址，为空时始终取对端地址
+	TrustedProxies string `value:"${trusted-proxies:}"`
+	// MaxKeys 每条规则按key分维度计数时保留的最大key数，超出时淘汰最久未使用且无进行中请求的key，0为不限制
+	MaxKeys int `value:"${max-keys:10000}"`
+	// KeyIdleTimeout key无请求超过该时长(毫秒)后淘汰，0为不淘汰；应不小于令牌桶补满的时间 burst/qps
+	KeyIdleTimeout int `value:"${key-idle-timeout:60000}"`
+	// Rules 限流规则，如 limit.rules.order-create.method=CreateOrder、limit.rules.order-create.qps=100；
+	// 请求需通过全部匹配的规则
+	Rules map[string]LimitRule `value:"${rules:}"`
+}
+
+// LimitRule 限流规则，匹配条件为空或*时匹配全部；qps与max-concurrency至少配置其一，为0的一项不限制
+type LimitRule struct {
+	Service string `value:"${service:}"`
+	Method  string `value:"${method:}"`
+	Caller  string `value:"${caller:}"`
+	IP      string `value:"${ip:}"`
+	// Key 计数维度，逗号分隔的service、method、caller、ip，如 key=caller 时每个调用方独立限流；
+	// 为空时匹配规则的请求共用同一计数
+	Key string `value:"${key:}"`
+	// QPS 每秒令牌数
+	QPS float64 `value:"${qps:0}"`
+	// Burst 令牌桶容量，为0时取ceil(qps)
+	Burst          int `value:"${burst:0}"`
+	MaxConcurrency int `value:"${max-concurrency:0}"`
+}
+
+// Request 限流判断使用的请求信息
+type Request struct {
+	Service string
+	Method  string
+	Caller  string
+	IP      string
+}
+
+func (r Request) dim(name string) string {
+	switch name {
+	case DimService:
+		return r.Service
+	case DimMethod:
+		return r.Method
+	case DimCaller:
+		return r.Caller
+	default:
+		return r.IP
+	}
+}
+
+// rule 编译后的规则，计数状态随规则更新重建
+type rule struct {
+	name                        string
+	service, method, caller, ip string
+	dims                        []string
+	qps                         float64
+	burst                       float64
+	maxConcurrency              int64
+	maxKeys                     int
+	idle                        time.Duration
+
+	mu sync.Mutex
+	// states key -> *list.Element(*keyState)，lru按最近使用排序，队首最新
+	states map[string]*list.Element
+	lru    list.List
+}
+
+// keyState 单个key的令牌桶及并发数，last为令牌补充时间，seen为最近请求时间
+type keyState struct {
+	key      string
+	tokens   float64
+	last     time.Time
+	seen     time.Time
+	inflight int64
+}
+
+type rules struct {
+	enabled        bool
+	callerHeader   string
+	trustedProxies []*net.IPNet
+	list           []*rule
+}
+
+func compile(conf LimitProperties) (*rules, error) {
+	names := make([]string, 0, len(conf.Rules))
+	for name := range conf.Rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if conf.MaxKeys < 0 || conf.KeyIdleTimeout < 0 {
+		return nil, fmt.Errorf("limit max-keys and key-idle-timeout must not be negative")
+	}
+	proxies, err := parseProxies(conf.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	r := &rules{enabled: conf.Enabled, callerHeader: conf.CallerHeader, trustedProxies: proxies, list: make([]*rule, 0, len(names))}
+	for _, name := range names {
+		c := conf.Rules[name]
+		if c.QPS < 0 || c.Burst < 0 || c.MaxConcurrency < 0 {
+			return nil, fmt.Errorf("limit rule %s: qps, burst and max-concurrency must not be negative", name)
+		}
+		if c.QPS == 0 && c.MaxConcurrency == 0 {
+			return nil, fmt.Errorf("limit rule %s: qps or max-concurrency is required", name)
+		}
+		dims, err := parseDims(c.Key)
+		if err != nil {
+			return nil, fmt.Errorf("limit rule %s: %w", name, err)
+		}
+		burst := float64(c.Burst)
+		if burst == 0 {
+			burst = math.Ceil(c.QPS)
+		}
+		r.list = append(r.list, &rule{
+			name:           name,
+			service:        c.Service,
+			method:         c.Method,
+			caller:         c.Caller,
+			ip:             c.IP,
+			dims:           dims,
+			qps:            c.QPS,
+			burst:          burst,
+			maxConcurrency: int64(c.MaxConcurrency),
+			maxKeys:        conf.MaxKeys,
+			idle:           time.Duration(conf.KeyIdleTimeout) * time.Millisecond,
+			states:         make(map[string]*list.Element),
+		})
+	}
+	return r, nil
+}
+
+func parseDims(key string) ([]string, error) {
+	var dims []string
+	for _, d := range strings.Split(key, ",") {
+		switch d = strings.TrimSpace(d); d {
+		case "":
+		case DimService, DimMethod, DimCaller, DimIP:
+			dims = append(dims, d)
+		default:
+			return nil, fmt.Errorf("key dimension invalid: %s", d)
+		}
+	}
+	return dims, nil
+}
+
+func parseProxies(s string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("limit trusted-proxies invalid: %s", p)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func matches(cond, v string) bool {
+	return cond == "" || cond == matchAny || cond == v
+}
+
+func (r *rule) match(req Request) bool {
+	return matches(r.service, req.Service) && matches(r.method, req.Method) &&
+		matches(r.caller, req.Caller) && matches(r.ip, req.IP)
+}
+
+func (r *rule) key(req Request) string {
+	if len(r.dims) == 0 {
+		return ""
+	}
+	parts := make([]string, len(r.dims))
+	for i, d := range r.dims {
+		parts[i] = req.dim(d)
+	}
+	return strings.Join(parts, "|")
+}
+
+// acquire 占用并发数并获取令牌，失败时已占用的并发数归还
+func (r *rule) acquire(key string, now time.Time) (release func(), reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := r.state(key, now)
+	if r.maxConcurrency > 0 && st.inflight >= r.maxConcurrency {
+		return nil, "concurrency"
+	}
+	if r.qps > 0 {
+		if elapsed := now.Sub(st.last); elapsed > 0 {
+			st.tokens = math.Min(r.burst, st.tokens+elapsed.Seconds()*r.qps)
+			st.last = now
+		}
+		if st.tokens < 1 {
+			return nil, "qps"
+		}
+		st.tokens--
+	}
+	if r.maxConcurrency == 0 {
+		return func() {}, ""
+	}
+	st.inflight++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			st.inflight--
+			r.mu.Unlock()
+		})
+	}, ""
+}
+
+// state 取key的计数状态并标记为最近使用，不存在时创建；
+// 创建前淘汰空闲超时的key，key数达到上限时淘汰最久未使用的key，有进行中请求的key不淘汰
+func (r *rule) state(key string, now time.Time) *keyState {
+	if e, ok := r.states[key]; ok {
+		r.lru.MoveToFront(e)
+		st := e.Value.(*keyState)
+		st.seen = now
+		return st
+	}
+	for e := r.lru.Back(); e != nil; {
+		st, prev := e.Value.(*keyState), e.Prev()
+		full := r.maxKeys > 0 && r.lru.Len() >= r.maxKeys
+		if !full && (r.idle == 0 || now.Sub(st.seen) < r.idle) {
+			break
+		}
+		if st.inflight == 0 {
+			r.lru.Remove(e)
+			delete(r.states, st.key)
+		}
+		e = prev
+	}
+	st := &keyState{key: key, tokens: r.burst, last: now, seen: now}
+	r.states[key] = r.lru.PushFront(st)
+	return st
+}
+
+// Limiter 限流器，规则支持运行时更新，更新后计数状态重新开始
+type Limiter struct {
+	rules atomic.Pointer[rules]
+	now   func() time.Time
+}
+
+// NewLimiter 按配置创建限流器
+func NewLimiter(conf LimitProperties) (*Limiter, error) {
+	l := &Limiter{now: time.Now}
+	if err := l.Update(conf); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Update 更新限流规则，配置无效时返回错误并保留原规则
+func (l *Limiter) Update(conf LimitProperties) error {
+	compiled, err := compile(conf)
+	if err != nil {
+		return err
+	}
+	l.rules.Store(compiled)
+	return nil
+}
+
+// CallerHeader hertz请求中标识调用方服务的请求头
+func (l *Limiter) CallerHeader() string {
+	return l.rules.Load().callerHeader
+}
+
+// Acquire 按匹配的规则判断请求是否放行，放行时返回的release需在请求结束后调用以归还并发数；
+// 拒绝时返回业务码为 ag_error.CodeLimited 的业务异常
+func (l *Limiter) Acquire(req Request) (release func(), err error) {
+	rs := l.rules.Load()
+	if !rs.enabled {
+		return func() {}, nil
+	}
+	now := l.now()
+	var releases []func()
+	for _, r := range rs.list {
+		if !r.match(req) {
+			continue
+		}
+		rel, reason := r.acquire(r.key(req), now)
+		if rel == nil {
+			for _, rel := range releases {
+				rel()
+			}
+			return nil, ag_error.NewBizStatusError(ag_error.CodeLimited, "request limited",
+				map[string]string{"rule": r.name, "reason": reason})
+		}
+		releases = append(releases, rel)
+	}
+	return func() {
+		for _, rel := range releases {
+			rel()
+		}
+	}, nil
+}
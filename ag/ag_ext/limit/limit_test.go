@@ -0,0 +1,159 @@
+package limit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/frochyzhang/ag-core/ag/ag_error"
+)
+
+// testLimiter 使用可控时钟的限流器
+func testLimiter(t *testing.T, conf LimitProperties) (*Limiter, *time.Time) {
+	t.Helper()
+	l, err := NewLimiter(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1000, 0)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func assertLimited(t *testing.T, err error, reason string) {
+	t.Helper()
+	var abe ag_error.BizStatusErrorIface
+	if !errors.As(err, &abe) || abe.BizCode() != ag_error.CodeLimited || abe.BizExtra()["reason"] != reason {
+		t.Fatalf("err = %v, want limited by %s", err, reason)
+	}
+}
+
+func TestLimiterQPS(t *testing.T) {
+	l, now := testLimiter(t, LimitProperties{Enabled: true, Rules: map[string]LimitRule{
+		"create": {Method: "Create", QPS: 2, Key: "caller"},
+	}})
+	a := Request{Service: "order", Method: "Create", Caller: "a"}
+	for i := 0; i < 2; i++ {
+		if _, err := l.Acquire(a); err != nil {
+			t.Fatalf("acquire %d: %v", i, err)
+		}
+	}
+	_, err := l.Acquire(a)
+	assertLimited(t, err, "qps")
+
+	// 按调用方独立计数，未匹配方法不限流
+	if _, err := l.Acquire(Request{Method: "Create", Caller: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := l.Acquire(Request{Method: "Query", Caller: "a"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if _, err := l.Acquire(a); err != nil {
+		t.Fatalf("after refill: %v", err)
+	}
+	_, err = l.Acquire(a)
+	assertLimited(t, err, "qps")
+}
+
+func TestLimiterConcurrency(t *testing.T) {
+	l, _ := testLimiter(t, LimitProperties{Enabled: true, Rules: map[string]LimitRule{
+		"conc": {MaxConcurrency: 1},
+		"qps":  {IP: "10.0.0.1", QPS: 1},
+	}})
+	req := Request{IP: "10.0.0.2"}
+	release, err := l.Acquire(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = l.Acquire(req)
+	assertLimited(t, err, "concurrency")
+	release()
+	release()
+	if release, err = l.Acquire(req); err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	// 后续规则拒绝时归还已占用的并发数
+	ip1 := Request{IP: "10.0.0.1"}
+	release, _ = l.Acquire(ip1)
+	release()
+	_, err = l.Acquire(ip1)
+	assertLimited(t, err, "qps")
+	if _, err := l.Acquire(req); err != nil {
+		t.Fatalf("concurrency leaked: %v", err)
+	}
+}
+
+func TestLimiterUpdate(t *testing.T) {
+	l, _ := testLimiter(t, LimitProperties{Enabled: true, Rules: map[string]LimitRule{
+		"all": {QPS: 1},
+	}})
+	l.Acquire(Request{})
+	_, err := l.Acquire(Request{})
+	assertLimited(t, err, "qps")
+
+	for _, conf := range []LimitProperties{
+		{Enabled: true, Rules: map[string]LimitRule{"bad": {QPS: 1, Key: "user"}}},
+		{Enabled: true, Rules: map[string]LimitRule{"empty": {Method: "Create"}}},
+	} {
+		if err := l.Update(conf); err == nil {
+			t.Fatalf("update %v succeeded", conf)
+		}
+	}
+	_, err = l.Acquire(Request{})
+	assertLimited(t, err, "qps")
+
+	if err := l.Update(LimitProperties{Enabled: false, Rules: map[string]LimitRule{"all": {QPS: 1}}}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := l.Acquire(Request{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLimiterKeyEviction(t *testing.T) {
+	l, now := testLimiter(t, LimitProperties{Enabled: true, MaxKeys: 2, KeyIdleTimeout: 1000, Rules: map[string]LimitRule{
+		"ip": {QPS: 1, MaxConcurrency: 1, Key: "ip"},
+	}})
+	r := l.rules.Load().list[0]
+	keys := func() int {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return len(r.states)
+	}
+
+	// 有进行中请求的key不淘汰
+	hold, err := l.Acquire(Request{IP: "10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ip := range []string{"10.0.0.2", "10.0.0.3", "10.0.0.4"} {
+		release, err := l.Acquire(Request{IP: ip})
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	if n := keys(); n != 2 {
+		t.Fatalf("keys = %d, want 2", n)
+	}
+	_, err = l.Acquire(Request{IP: "10.0.0.1"})
+	assertLimited(t, err, "concurrency")
+
+	// 空闲超时的key淘汰
+	hold()
+	*now = now.Add(2 * time.Second)
+	if _, err := l.Acquire(Request{IP: "10.0.0.5"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := keys(); n != 1 {
+		t.Fatalf("keys after idle = %d, want 1", n)
+	}
+}
@@ -0,0 +1,302 @@
+package ag_ext
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+
+	"github.com/nacos-group/nacos-sdk-go/clients/naming_client"
+	"github.com/nacos-group/nacos-sdk-go/model"
+	"github.com/nacos-group/nacos-sdk-go/vo"
+)
+
+// ChangeNotifier 实例变化通知，由推送式resolver实现；
+// 客户端据此立即刷新负载均衡器，无需等待定时Resolve
+type ChangeNotifier interface {
+	// Watch 注册实例变化回调，Change.Result.CacheKey为服务名，返回取消函数
+	Watch(fn func(discovery.Change)) (cancel func())
+}
+
+type subscriberKey struct {
+	cli            naming_client.INamingClient
+	cluster        string
+	group          string
+	cacheDir       string
+	registeredPort bool
+}
+
+// subscribers 同一nacos客户端、集群、分组的resolver共用订阅及实例快照，避免多个客户端重复订阅
+var subscribers sync.Map // subscriberKey -> *nacosSubscriber
+
+// nacosSubscriber 订阅服务实例变化并维护内存快照，快照同时持久化到本地文件，
+// nacos不可用时以最近一次成功的实例列表兜底
+type nacosSubscriber struct {
+	subscriberKey
+
+	lock     sync.Mutex
+	services map[string]*serviceSnapshot
+
+	listenerLock sync.RWMutex
+	listeners    map[int]func(discovery.Change)
+	listenerSeq  int
+}
+
+type serviceSnapshot struct {
+	lock       sync.RWMutex
+	result     discovery.Result
+	ok         bool
+	subscribed bool
+	// subscribing 订阅进行中，避免并发解析重复订阅
+	subscribing bool
+}
+
+func getSubscriber(key subscriberKey) *nacosSubscriber {
+	val, _ := subscribers.LoadOrStore(key, &nacosSubscriber{
+		subscriberKey: key,
+		services:      make(map[string]*serviceSnapshot),
+		listeners:     make(map[int]func(discovery.Change)),
+	})
+	return val.(*nacosSubscriber)
+}
+
+// resolve 返回服务实例快照；未订阅或订阅失败时查询并(重新)订阅，查询失败时回退到本地文件
+func (s *nacosSubscriber) resolve(service string) (discovery.Result, error) {
+	snap := s.snapshot(service)
+	snap.lock.RLock()
+	res, ok, subscribed := snap.result, snap.ok, snap.subscribed
+	snap.lock.RUnlock()
+	if ok && subscribed {
+		return res, nil
+	}
+
+	snap.lock.Lock()
+	if snap.ok && snap.subscribed {
+		res = snap.result
+		snap.lock.Unlock()
+		return res, nil
+	}
+	ins, err := s.cli.SelectInstances(vo.SelectInstancesParam{
+		ServiceName: service,
+		HealthyOnly: true,
+		GroupName:   s.group,
+		Clusters:    []string{s.cluster},
+	})
+	if err == nil {
+		if next, ok := s.buildResult(service, ins); ok {
+			snap.result, snap.ok = next, true
+			s.save(service, ins)
+		} else {
+			err = fmt.Errorf("no instance remains for %v", service)
+		}
+	}
+	if err != nil && !snap.ok {
+		// nacos不可用时使用本地文件中最近一次成功的实例列表
+		if cached, lerr := s.load(service); lerr == nil {
+			if next, ok := s.buildResult(service, cached); ok {
+				slog.Warn("nacos resolve failed, use local cache", "service", service, "error", err)
+				snap.result, snap.ok = next, true
+			}
+		}
+	}
+	subscribe := !snap.subscribed && !snap.subscribing
+	snap.subscribing = subscribe
+	snap.lock.Unlock()
+
+	// 订阅时nacos客户端可能同步回调推送，推送需获取快照锁，因此在锁外订阅
+	if subscribe {
+		serr := s.cli.Subscribe(&vo.SubscribeParam{
+			ServiceName: service,
+			GroupName:   s.group,
+			Clusters:    []string{s.cluster},
+			SubscribeCallback: func(services []model.SubscribeService, err error) {
+				s.onPush(service, services, err)
+			},
+		})
+		if serr != nil {
+			slog.Warn("nacos subscribe failed", "service", service, "error", serr)
+		}
+		snap.lock.Lock()
+		snap.subscribing = false
+		snap.subscribed = serr == nil
+		snap.lock.Unlock()
+	}
+
+	snap.lock.RLock()
+	defer snap.lock.RUnlock()
+	if !snap.ok {
+		return discovery.Result{}, err
+	}
+	return snap.result, nil
+}
+
+func (s *nacosSubscriber) snapshot(service string) *serviceSnapshot {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	snap, ok := s.services[service]
+	if !ok {
+		snap = &serviceSnapshot{}
+		s.services[service] = snap
+	}
+	return snap
+}
+
+// onPush 处理nacos推送，推送为空时保留原快照，避免注册中心异常导致实例被清空
+func (s *nacosSubscriber) onPush(service string, services []model.SubscribeService, err error) {
+	if err != nil {
+		slog.Warn("nacos subscribe callback failed", "service", service, "error", err)
+		return
+	}
+	ins := make([]model.Instance, 0, len(services))
+	for _, in := range services {
+		if !in.Healthy {
+			continue
+		}
+		ins = append(ins, model.Instance{
+			InstanceId:  in.InstanceId,
+			Ip:          in.Ip,
+			Port:        in.Port,
+			Weight:      in.Weight,
+			Healthy:     in.Healthy,
+			Enable:      in.Enable,
+			Metadata:    in.Metadata,
+			ClusterName: in.ClusterName,
+			ServiceName: in.ServiceName,
+		})
+	}
+	next, ok := s.buildResult(service, ins)
+	if !ok {
+		slog.Warn("nacos push empty instances, keep last snapshot", "service", service)
+		return
+	}
+
+	snap := s.snapshot(service)
+	snap.lock.Lock()
+	change, changed := discovery.DefaultDiff(service, snap.result, next)
+	snap.result, snap.ok = next, true
+	snap.lock.Unlock()
+	if !changed {
+		return
+	}
+	s.save(service, ins)
+	slog.Info("nacos instances changed",
+		"service", service,
+		"instances", len(next.Instances),
+		"added", len(change.Added),
+		"removed", len(change.Removed))
+
+	s.listenerLock.RLock()
+	listeners := make([]func(discovery.Change), 0, len(s.listeners))
+	for _, fn := range s.listeners {
+		listeners = append(listeners, fn)
+	}
+	s.listenerLock.RUnlock()
+	for _, fn := range listeners {
+		fn(change)
+	}
+}
+
+func (s *nacosSubscriber) watch(fn func(discovery.Change)) (cancel func()) {
+	s.listenerLock.Lock()
+	defer s.listenerLock.Unlock()
+	s.listenerSeq++
+	id := s.listenerSeq
+	s.listeners[id] = fn
+	return func() {
+		s.listenerLock.Lock()
+		defer s.listenerLock.Unlock()
+		delete(s.listeners, id)
+	}
+}
+
+func (s *nacosSubscriber) cacheFile(service string) string {
+	return filepath.Join(s.cacheDir, url.PathEscape(s.group+"@@"+service+"@@"+s.cluster)+".json")
+}
+
+// save 持久化实例列表，先写临时文件再重命名，避免进程中断留下不完整的文件
+func (s *nacosSubscriber) save(service string, ins []model.Instance) {
+	if s.cacheDir == "" {
+		return
+	}
+	data, err := json.Marshal(ins)
+	if err == nil {
+		err = os.MkdirAll(s.cacheDir, 0o700)
+	}
+	if err == nil {
+		err = checkPrivate(s.cacheDir, true)
+	}
+	if err == nil {
+		file := s.cacheFile(service)
+		tmp := file + ".tmp"
+		if err = os.WriteFile(tmp, data, 0o600); err == nil {
+			err = os.Rename(tmp, file)
+		}
+	}
+	if err != nil {
+		slog.Warn("nacos save local cache failed", "service", service, "error", err)
+	}
+}
+
+func (s *nacosSubscriber) load(service string) ([]model.Instance, error) {
+	if s.cacheDir == "" {
+		return nil, os.ErrNotExist
+	}
+	// 缓存文件决定nacos不可用时的路由目标，仅读取当前用户私有的目录及文件
+	file := s.cacheFile(service)
+	if err := checkPrivate(s.cacheDir, true); err != nil {
+		return nil, err
+	}
+	if err := checkPrivate(file, false); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var ins []model.Instance
+	if err := json.Unmarshal(data, &ins); err != nil {
+		return nil, err
+	}
+	return ins, nil
+}
+
+// checkPrivate 校验缓存目录或文件为当前用户所有、不是符号链接且其他用户不可写
+func checkPrivate(path string, dir bool) error {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	mode := fi.Mode()
+	switch {
+	case dir && !mode.IsDir(), !dir && !mode.IsRegular():
+		return fmt.Errorf("nacos cache %s: unexpected file type %s", path, mode.Type())
+	case mode.Perm()&0o022 != 0:
+		return fmt.Errorf("nacos cache %s: permission %s too open", path, mode.Perm())
+	case !ownedByCurrentUser(fi):
+		return fmt.Errorf("nacos cache %s: not owned by current user", path)
+	}
+	return nil
+}
+
+func (s *nacosSubscriber) buildResult(service string, res []model.Instance) (discovery.Result, bool) {
+	instances := make([]discovery.Instance, 0, len(res))
+	for _, in := range res {
+		inst, ok := resolverInstance(in, s.registeredPort)
+		if ok {
+			instances = append(instances, inst)
+		}
+	}
+	if len(instances) == 0 {
+		return discovery.Result{}, false
+	}
+	return discovery.Result{
+		Cacheable: true,
+		CacheKey:  service,
+		Instances: instances,
+	}, true
+}
@@ -0,0 +1,302 @@
+// Package route 灰度及标签路由：按请求上下文中的路由标签过滤实例元数据，
+// 作为负载均衡器的外层包装，kitex客户端与hertz客户端共用
+package route
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/frochyzhang/ag-core/ag/ag_ext/lb"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/loadbalance"
+)
+
+const (
+	RoutePropertiesPrefix = "route"
+
+	// TagKey 路由标签的透传键，经metainfo持久化值在调用链中逐跳传递
+	TagKey = "AG_ROUTE_TAG"
+
+	FallbackBase = "base"
+	FallbackAll  = "all"
+	FallbackNone = "none"
+)
+
+// RouteProperties 路由配置 route.*
+type RouteProperties struct {
+	// LabelKey 实例元数据中的标签键，路由标签未配置规则时按 元数据[label-key]==路由标签 匹配；
+	// 未设置该元数据的实例为基线实例
+	LabelKey string `value:"${label-key:tag}"`
+	// Isolate 无路由标签的请求只选择基线实例，基线实例为空时选择全部实例
+	Isolate bool `value:"${isolate:true}"`
+	// Fallback 带标签的请求无匹配实例时的处理：base回退到基线实例，all回退到全部实例，none不回退
+	Fallback string `value:"${fallback:base}"`
+	// Rules 按路由标签配置实例元数据匹配条件，如 route.rules.gray.labels.version=v2
+	Rules map[string]RouteRule `value:"${rules:}"`
+}
+
+// RouteRule 路由标签的匹配规则，实例元数据包含全部labels时匹配
+type RouteRule struct {
+	Labels map[string]string `value:"${labels:}"`
+	// Fallback 为空时使用全局配置
+	Fallback string `value:"${fallback:}"`
+}
+
+// WithTag 设置路由标签，随调用链透传到下游
+func WithTag(ctx context.Context, tag string) context.Context {
+	return metainfo.WithPersistentValue(ctx, TagKey, tag)
+}
+
+// Tag 获取请求的路由标签
+func Tag(ctx context.Context) string {
+	if v, ok := metainfo.GetPersistentValue(ctx, TagKey); ok {
+		return v
+	}
+	v, _ := metainfo.GetValue(ctx, TagKey)
+	return v
+}
+
+// selector 实例选择条件，base为基线实例(未设置label-key元数据)
+type selector struct {
+	key    string
+	labels map[string]string
+	base   string
+}
+
+func newSelector(labels map[string]string) *selector {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+labels[k])
+	}
+	return &selector{key: strings.Join(pairs, ","), labels: labels}
+}
+
+func baseSelector(labelKey string) *selector {
+	return &selector{key: "!" + labelKey, base: labelKey}
+}
+
+func (s *selector) match(ins discovery.Instance) bool {
+	if s.base != "" {
+		v, ok := ins.Tag(s.base)
+		return !ok || v == ""
+	}
+	for k, want := range s.labels {
+		if v, ok := ins.Tag(k); !ok || v != want {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *selector) filter(instances []discovery.Instance) []discovery.Instance {
+	matched := make([]discovery.Instance, 0, len(instances))
+	for _, ins := range instances {
+		if s.match(ins) {
+			matched = append(matched, ins)
+		}
+	}
+	return matched
+}
+
+// rules 编译后的路由规则，tag为空对应无标签请求；选择列表依次尝试，fallbackAll为真时最后回退到全部实例
+type rules struct {
+	labelKey string
+	isolate  bool
+	fallback string
+	tags     map[string]target
+	base     *selector
+}
+
+type target struct {
+	selectors   []*selector
+	fallbackAll bool
+}
+
+func compile(conf RouteProperties) (*rules, error) {
+	if conf.LabelKey == "" {
+		return nil, fmt.Errorf("route label-key is empty")
+	}
+	fallback, err := parseFallback(conf.Fallback)
+	if err != nil {
+		return nil, err
+	}
+	r := &rules{
+		labelKey: conf.LabelKey,
+		isolate:  conf.Isolate,
+		fallback: fallback,
+		tags:     make(map[string]target, len(conf.Rules)),
+		base:     baseSelector(conf.LabelKey),
+	}
+	for tag, rule := range conf.Rules {
+		if len(rule.Labels) == 0 {
+			return nil, fmt.Errorf("route rule %s labels is empty", tag)
+		}
+		fb := fallback
+		if rule.Fallback != "" {
+			if fb, err = parseFallback(rule.Fallback); err != nil {
+				return nil, fmt.Errorf("route rule %s: %w", tag, err)
+			}
+		}
+		r.tags[tag] = r.build(newSelector(rule.Labels), fb)
+	}
+	return r, nil
+}
+
+func parseFallback(s string) (string, error) {
+	switch s {
+	case FallbackBase, FallbackAll, FallbackNone:
+		return s, nil
+	default:
+		return "", fmt.Errorf("route fallback invalid: %s", s)
+	}
+}
+
+func (r *rules) build(sel *selector, fallback string) target {
+	switch fallback {
+	case FallbackBase:
+		// 基线实例为空时仍回退到全部实例，避免灰度规则导致服务不可用
+		return target{selectors: []*selector{sel, r.base}, fallbackAll: true}
+	case FallbackAll:
+		return target{selectors: []*selector{sel}, fallbackAll: true}
+	default:
+		return target{selectors: []*selector{sel}}
+	}
+}
+
+func (r *rules) route(tag string) target {
+	if tag == "" {
+		if r.isolate {
+			return target{selectors: []*selector{r.base}, fallbackAll: true}
+		}
+		return target{fallbackAll: true}
+	}
+	if rt, ok := r.tags[tag]; ok {
+		return rt
+	}
+	return r.build(newSelector(map[string]string{r.labelKey: tag}), r.fallback)
+}
+
+// Router 路由规则，支持运行时更新，通过 Balancer 包装负载均衡器
+type Router struct {
+	rules atomic.Pointer[rules]
+	// selectors 曾使用过的选择条件，实例变化时转发到各选择条件的子结果
+	selectors sync.Map // key -> *selector
+}
+
+// NewRouter 按配置创建路由
+func NewRouter(conf RouteProperties) (*Router, error) {
+	r := &Router{}
+	if err := r.Update(conf); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Update 更新路由规则，配置无效时返回错误并保留原规则
+func (r *Router) Update(conf RouteProperties) error {
+	compiled, err := compile(conf)
+	if err != nil {
+		return err
+	}
+	r.rules.Store(compiled)
+	return nil
+}
+
+// Balancer 以路由规则包装负载均衡器
+func (r *Router) Balancer(inner loadbalance.Loadbalancer) loadbalance.Loadbalancer {
+	return &routeBalancer{router: r, inner: inner}
+}
+
+type routeBalancer struct {
+	router *Router
+	inner  loadbalance.Loadbalancer
+}
+
+func (b *routeBalancer) GetPicker(res discovery.Result) loadbalance.Picker {
+	return &routePicker{b: b, res: res}
+}
+
+// Name 规则可运行时更新，以路由实例区分，避免lbcache在不同路由间共享均衡器
+func (b *routeBalancer) Name() string {
+	return fmt.Sprintf("%s:route:%p", b.inner.Name(), b.router)
+}
+
+// Begin 转发活跃请求统计
+func (b *routeBalancer) Begin(addr string) func() {
+	if tracker, ok := b.inner.(lb.ActiveTracker); ok {
+		return tracker.Begin(addr)
+	}
+	return func() {}
+}
+
+func (b *routeBalancer) Rebalance(change discovery.Change) {
+	if r, ok := b.inner.(loadbalance.Rebalancer); ok {
+		r.Rebalance(change)
+		b.router.selectors.Range(func(_, v any) bool {
+			r.Rebalance(subChange(change, v.(*selector)))
+			return true
+		})
+	}
+}
+
+func (b *routeBalancer) Delete(change discovery.Change) {
+	if r, ok := b.inner.(loadbalance.Rebalancer); ok {
+		r.Delete(change)
+		b.router.selectors.Range(func(_, v any) bool {
+			r.Delete(subChange(change, v.(*selector)))
+			return true
+		})
+	}
+}
+
+// subResult 选择条件过滤后的实例，使用独立的CacheKey以免与全部实例的picker缓存冲突
+func subResult(res discovery.Result, sel *selector) discovery.Result {
+	return discovery.Result{
+		Cacheable: res.Cacheable,
+		CacheKey:  res.CacheKey + "#route=" + sel.key,
+		Instances: sel.filter(res.Instances),
+	}
+}
+
+func subChange(change discovery.Change, sel *selector) discovery.Change {
+	return discovery.Change{
+		Result:  subResult(change.Result, sel),
+		Added:   sel.filter(change.Added),
+		Updated: sel.filter(change.Updated),
+		Removed: sel.filter(change.Removed),
+	}
+}
+
+type routePicker struct {
+	b   *routeBalancer
+	res discovery.Result
+}
+
+func (p *routePicker) Next(ctx context.Context, request interface{}) discovery.Instance {
+	rt := p.b.router.rules.Load().route(Tag(ctx))
+	for _, sel := range rt.selectors {
+		sub := subResult(p.res, sel)
+		if len(sub.Instances) == 0 {
+			continue
+		}
+		p.b.router.selectors.LoadOrStore(sel.key, sel)
+		if ins := p.b.inner.GetPicker(sub).Next(ctx, request); ins != nil {
+			return ins
+		}
+	}
+	if !rt.fallbackAll {
+		return nil
+	}
+	return p.b.inner.GetPicker(p.res).Next(ctx, request)
+}
@@ -0,0 +1,93 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/loadbalance"
+)
+
+func testResult(tags ...map[string]string) discovery.Result {
+	instances := make([]discovery.Instance, 0, len(tags))
+	for i, tag := range tags {
+		instances = append(instances, discovery.NewInstance("tcp", fmt.Sprintf("10.0.0.%d:8000", i+1), 10, tag))
+	}
+	return discovery.Result{Cacheable: true, CacheKey: "svc", Instances: instances}
+}
+
+// picked 多次选择并返回选中的实例地址集合
+func picked(t *testing.T, picker loadbalance.Picker, ctx context.Context) map[string]bool {
+	t.Helper()
+	addrs := make(map[string]bool)
+	for i := 0; i < 12; i++ {
+		ins := picker.Next(ctx, nil)
+		if ins == nil {
+			return addrs
+		}
+		addrs[ins.Address().String()] = true
+	}
+	return addrs
+}
+
+func TestRouter(t *testing.T) {
+	conf := RouteProperties{
+		LabelKey: "tag",
+		Isolate:  true,
+		Fallback: FallbackBase,
+		Rules: map[string]RouteRule{
+			"v2": {Labels: map[string]string{"version": "2.0"}},
+		},
+	}
+	router, err := NewRouter(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := router.Balancer(loadbalance.NewWeightedRoundRobinBalancer())
+	picker := b.GetPicker(testResult(
+		nil,
+		map[string]string{"tag": "gray"},
+		map[string]string{"version": "2.0"},
+	))
+	bg := context.Background()
+
+	for name, c := range map[string]struct {
+		ctx  context.Context
+		want []string
+	}{
+		"untagged isolate": {bg, []string{"10.0.0.1:8000", "10.0.0.3:8000"}},
+		"tag label":        {WithTag(bg, "gray"), []string{"10.0.0.2:8000"}},
+		"tag rule":         {WithTag(bg, "v2"), []string{"10.0.0.3:8000"}},
+		"fallback base":    {WithTag(bg, "blue"), []string{"10.0.0.1:8000", "10.0.0.3:8000"}},
+	} {
+		got := picked(t, picker, c.ctx)
+		want := make(map[string]bool, len(c.want))
+		for _, addr := range c.want {
+			want[addr] = true
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: picked %v, want %v", name, got, c.want)
+		}
+	}
+
+	// 刷新规则：不回退时无匹配实例返回空
+	conf.Fallback = FallbackNone
+	if err := router.Update(conf); err != nil {
+		t.Fatal(err)
+	}
+	if ins := picker.Next(WithTag(bg, "blue"), nil); ins != nil {
+		t.Fatalf("picked %s without fallback", ins.Address())
+	}
+	// 无效规则保留原规则
+	if err := router.Update(RouteProperties{LabelKey: "tag", Fallback: "nowhere"}); err == nil {
+		t.Fatal("expect invalid fallback error")
+	}
+	if err := router.Update(RouteProperties{LabelKey: "tag", Fallback: FallbackAll, Rules: map[string]RouteRule{"x": {}}}); err == nil {
+		t.Fatal("expect empty labels error")
+	}
+	if ins := picker.Next(WithTag(bg, "blue"), nil); ins != nil {
+		t.Fatal("rules changed by invalid update")
+	}
+}
@@ -64,6 +64,27 @@ func RegisterHandler(methodName string, prioritizedMws []PrioritizedMiddleware,
 	return wrappedHandler
 }
 
+// StreamCall 流式方法经过中间件链时的请求：Req为服务端流的首个请求，客户端流及双向流为nil；
+// Stream为生成代码中的流对象(如 pb.Xxx_MethodServer)，中间件可据此识别流式调用
+type StreamCall struct {
+	Req    interface{}
+	Stream interface{}
+}
+
+type StreamHandlerFunc func(ctx context.Context, call *StreamCall) error
+
+// RegisterStreamHandler 注册流式方法的处理链，与非流式方法共用中间件；
+// 中间件在整个流的生命周期内执行一次，收到的req为 *StreamCall，响应恒为nil
+func RegisterStreamHandler(methodName string, prioritizedMws []PrioritizedMiddleware, handler StreamHandlerFunc) StreamHandlerFunc {
+	wrapped := RegisterHandler(methodName, prioritizedMws, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, handler(ctx, req.(*StreamCall))
+	})
+	return func(ctx context.Context, call *StreamCall) error {
+		_, err := wrapped(ctx, call)
+		return err
+	}
+}
+
 // LoggingMiddleware 示例：日志中间件
 type LoggingMiddleware struct{}
 
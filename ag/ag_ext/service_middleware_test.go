@@ -0,0 +1,48 @@
+package ag_ext
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type ctxKey struct{}
+
+type testMiddleware struct {
+	order int
+	trace *[]string
+}
+
+func (m testMiddleware) GetOrder() int { return m.order }
+
+func (m testMiddleware) GetMiddleware() Middleware {
+	return func(method string, ctx context.Context, req interface{}, next func(context.Context, interface{}) (interface{}, error)) (interface{}, error) {
+		call, ok := req.(*StreamCall)
+		if !ok {
+			return nil, errors.New("not a stream call")
+		}
+		*m.trace = append(*m.trace, method+":"+call.Stream.(string))
+		return next(context.WithValue(ctx, ctxKey{}, m.order), req)
+	}
+}
+
+func TestRegisterStreamHandler(t *testing.T) {
+	var trace []string
+	mws := []PrioritizedMiddleware{
+		testMiddleware{order: MiddlewarePriorityLow, trace: &trace},
+		testMiddleware{order: MiddlewarePriorityHigh, trace: &trace},
+	}
+	wantErr := errors.New("stream closed")
+	handler := RegisterStreamHandler("Chat", mws, func(ctx context.Context, call *StreamCall) error {
+		if ctx.Value(ctxKey{}) != MiddlewarePriorityLow || call.Req != "first" {
+			t.Errorf("ctx value = %v, req = %v", ctx.Value(ctxKey{}), call.Req)
+		}
+		return wantErr
+	})
+	if err := handler(context.Background(), &StreamCall{Req: "first", Stream: "conn"}); err != wantErr {
+		t.Fatalf("err = %v", err)
+	}
+	if len(trace) != 2 || trace[0] != "Chat:conn" {
+		t.Fatalf("trace = %v", trace)
+	}
+}
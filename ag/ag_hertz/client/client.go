@@ -3,13 +3,25 @@ package client
 import (
 	"context"
 	"errors"
+	hertzclient "github.com/cloudwego/hertz/pkg/app/client"
 	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/kitex/pkg/loadbalance"
+	"github.com/frochyzhang/ag-core/ag/ag_ext/lb"
+	"github.com/frochyzhang/ag-core/ag/ag_ext/route"
+	"github.com/frochyzhang/ag-core/ag/ag_resilience"
 	"github.com/nacos-group/nacos-sdk-go/clients/naming_client"
 )
 
 type Client struct {
-	nc      naming_client.INamingClient
-	hostUrl string
+	nc       naming_client.INamingClient
+	balancer loadbalance.Loadbalancer
+	lbErr    error
+	router   *route.Router
+	mws      []hertzclient.Middleware
+	// resilience 不为nil时Invoke按下游服务name的重试策略调用，熔断及隔离舱由mws中的中间件完成
+	resilience *ag_resilience.Manager
+	name       string
+	hostUrl    string
 	*cli
 	reqOpt []config.RequestOption
 }
@@ -21,6 +33,29 @@ func WithNamingClient(nc naming_client.INamingClient) ClientOption {
 	}
 }
 
+// WithLoadBalance 服务发现的负载均衡策略，与kitex客户端 load-balance 配置一致
+func WithLoadBalance(conf lb.LoadBalanceProperties) ClientOption {
+	return func(c *Client) {
+		c.balancer, c.lbErr = lb.New(conf)
+	}
+}
+
+// WithRouter 灰度及标签路由，按请求上下文中的路由标签过滤服务发现的实例
+func WithRouter(router *route.Router) ClientOption {
+	return func(c *Client) {
+		c.router = router
+	}
+}
+
+// WithResilience 以name作为下游服务名启用熔断、隔离舱及重试，
+// 重试策略的幂等方法按"HTTP方法 路径"匹配，如 GET /users/{id}
+func WithResilience(m *ag_resilience.Manager, name string) ClientOption {
+	return func(c *Client) {
+		c.resilience, c.name = m, name
+		c.mws = append(c.mws, ag_resilience.HertzMiddleware(m, name))
+	}
+}
+
 func WithHostUrl(hostUrl string) ClientOption {
 	return func(c *Client) {
 		c.hostUrl = hostUrl
@@ -34,8 +69,22 @@ func NewClient(opts []ClientOption) *Client {
 	}
 
 	options := make([]Option, 0)
+	if c.lbErr != nil {
+		panic(c.lbErr)
+	}
 	if c.nc != nil {
 		options = append(options, withNamingClient(c.nc))
+		if c.router != nil {
+			if c.balancer == nil {
+				if c.balancer, c.lbErr = lb.New(lb.LoadBalanceProperties{}); c.lbErr != nil {
+					panic(c.lbErr)
+				}
+			}
+			c.balancer = c.router.Balancer(c.balancer)
+		}
+		if c.balancer != nil {
+			options = append(options, withLoadbalancer(c.balancer))
+		}
 		c.reqOpt = append(c.reqOpt, config.WithSD(true))
 	}
 
@@ -44,6 +93,9 @@ func NewClient(opts []ClientOption) *Client {
 	}
 
 	options = append(options, withHostUrl(c.hostUrl))
+	if len(c.mws) > 0 {
+		options = append(options, WithHertzClientMiddleware(c.mws...))
+	}
 
 	client, err := newClient(getOptions(options...))
 	if err != nil {
@@ -55,6 +107,15 @@ func NewClient(opts []ClientOption) *Client {
 }
 
 func (c *Client) Invoke(ctx context.Context, method, path string, pathVars map[string]string, args any, reply any, opts ...config.RequestOption) error {
+	if c.resilience != nil {
+		return c.resilience.Retry(ctx, c.name, method+" "+path, func(ctx context.Context) error {
+			return c.invoke(ctx, method, path, pathVars, args, reply, opts...)
+		})
+	}
+	return c.invoke(ctx, method, path, pathVars, args, reply, opts...)
+}
+
+func (c *Client) invoke(ctx context.Context, method, path string, pathVars map[string]string, args any, reply any, opts ...config.RequestOption) error {
 	opts = append(c.reqOpt, opts...)
 	_, err := c.cli.r().
 		setContext(ctx).
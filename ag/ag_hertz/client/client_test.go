@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/frochyzhang/ag-core/ag/ag_error"
+	"github.com/frochyzhang/ag-core/ag/ag_resilience"
+)
+
+func newResilienceClient(t *testing.T, url string, conf ag_resilience.PolicyProperties) (*Client, *ag_resilience.Manager) {
+	m, err := ag_resilience.NewManager(ag_resilience.ResilienceProperties{Default: conf}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewClient([]ClientOption{WithHostUrl(url), WithResilience(m, "user-service")}), m
+}
+
+func bizCode(err error) int32 {
+	var be ag_error.BizStatusErrorIface
+	if errors.As(err, &be) {
+		return be.BizCode()
+	}
+	return 0
+}
+
+func TestResilienceBulkhead(t *testing.T) {
+	var inflight, peak atomic.Int32
+	release := make(chan struct{})
+	var once sync.Once
+	unblock := func() { once.Do(func() { close(release) }) }
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		<-release
+	}))
+	defer srv.Close()
+	defer unblock()
+
+	c, _ := newResilienceClient(t, srv.URL, ag_resilience.PolicyProperties{
+		Bulkhead: ag_resilience.BulkheadProperties{MaxConcurrency: 2},
+	})
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- c.Invoke(context.Background(), http.MethodGet, "/ping", nil, nil, nil)
+		}()
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for inflight.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	// 每次调用只占用一个隔离舱许可，max-concurrency个调用可同时进行
+	if n := inflight.Load(); n != 2 {
+		t.Fatalf("inflight = %d, want 2", n)
+	}
+	if err := c.Invoke(context.Background(), http.MethodGet, "/ping", nil, nil, nil); bizCode(err) != ag_error.CodeBulkheadFull {
+		t.Fatalf("third call err = %v", err)
+	}
+	unblock()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p := peak.Load(); p != 2 {
+		t.Fatalf("peak = %d", p)
+	}
+}
+
+func TestResilienceHalfOpen(t *testing.T) {
+	var status atomic.Int32
+	var hits atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer srv.Close()
+
+	c, m := newResilienceClient(t, srv.URL, ag_resilience.PolicyProperties{
+		CircuitBreaker: ag_resilience.BreakerProperties{
+			Enable: true, Window: 10000, Buckets: 10, MinCalls: 2, ErrorRate: 0.5,
+			OpenDuration: 50, HalfOpenCalls: 1,
+		},
+	})
+	invoke := func() error {
+		return c.Invoke(context.Background(), http.MethodGet, "/ping", nil, nil, nil)
+	}
+	for i := 0; i < 2; i++ {
+		_ = invoke()
+	}
+	if s := m.State("user-service"); s != ag_resilience.StateOpen {
+		t.Fatalf("state = %s, want open", s)
+	}
+	if err := invoke(); bizCode(err) != ag_error.CodeCircuitOpen {
+		t.Fatalf("open err = %v", err)
+	}
+
+	// 半开探测须真正到达下游，下游仍失败时重新熔断
+	time.Sleep(60 * time.Millisecond)
+	before := hits.Load()
+	if err := invoke(); err == nil {
+		t.Fatal("probe to failing downstream succeeded")
+	}
+	if hits.Load() != before+1 {
+		t.Fatalf("probe did not reach downstream")
+	}
+	if s := m.State("user-service"); s != ag_resilience.StateOpen {
+		t.Fatalf("state after failed probe = %s, want open", s)
+	}
+
+	status.Store(http.StatusOK)
+	time.Sleep(60 * time.Millisecond)
+	if err := invoke(); err != nil {
+		t.Fatal(err)
+	}
+	if s := m.State("user-service"); s != ag_resilience.StateClosed {
+		t.Fatalf("state after probe = %s, want closed", s)
+	}
+}
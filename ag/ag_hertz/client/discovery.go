@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/frochyzhang/ag-core/ag/ag_ext/lb"
+
+	hertzclient "github.com/cloudwego/hertz/pkg/app/client"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/loadbalance"
+	"github.com/cloudwego/kitex/pkg/loadbalance/lbcache"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+)
+
+// discoveryMiddleware 服务发现中间件，以请求Host为服务名解析实例并按负载均衡策略选择实例；
+// 与kitex客户端共用resolver及负载均衡策略，替代hertz的sd.Discovery以便按请求上下文选择实例
+func discoveryMiddleware(resolver discovery.Resolver, balancer loadbalance.Loadbalancer) hertzclient.Middleware {
+	// 不在客户端间共享缓存，避免同名均衡器的配置被其他客户端覆盖
+	factory := lbcache.NewBalancerFactory(resolver, balancer, lbcache.Options{Cacheable: false})
+	// 注册中心推送实例变化时立即刷新
+	lb.RefreshOnChange(resolver, factory)
+	tracker, _ := balancer.(lb.ActiveTracker)
+
+	return func(next hertzclient.Endpoint) hertzclient.Endpoint {
+		return func(ctx context.Context, req *protocol.Request, resp *protocol.Response) error {
+			if req.Options() == nil || !req.Options().IsSD() {
+				return next(ctx, req, resp)
+			}
+			service := string(req.Host())
+			b, err := factory.Get(ctx, rpcinfo.NewEndpointInfo(service, "", nil, nil))
+			if err != nil {
+				return err
+			}
+			ins := b.GetPicker().Next(ctx, req)
+			if ins == nil {
+				return fmt.Errorf("no instance available for %s", service)
+			}
+			addr := ins.Address().String()
+			req.SetHost(addr)
+			if tracker != nil {
+				end := tracker.Begin(addr)
+				defer end()
+			}
+			return next(ctx, req, resp)
+		}
+	}
+}
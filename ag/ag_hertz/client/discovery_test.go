@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/frochyzhang/ag-core/ag/ag_ext/lb"
+	"github.com/frochyzhang/ag-core/ag/ag_ext/route"
+
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+)
+
+func TestDiscoveryMiddleware(t *testing.T) {
+	resolver := &discovery.SynthesizedResolver{
+		TargetFunc: func(_ context.Context, target rpcinfo.EndpointInfo) string {
+			return target.ServiceName()
+		},
+		ResolveFunc: func(_ context.Context, key string) (discovery.Result, error) {
+			return discovery.Result{Cacheable: true, CacheKey: key, Instances: []discovery.Instance{
+				discovery.NewInstance("tcp", "10.0.0.1:8000", 10, nil),
+				discovery.NewInstance("tcp", "10.0.0.2:8000", 10, nil),
+			}}, nil
+		},
+		NameFunc: func() string { return "test" },
+	}
+	balancer, err := lb.New(lb.LoadBalanceProperties{Strategy: lb.StrategyConsistentHash, VirtualFactor: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var host string
+	endpoint := discoveryMiddleware(resolver, balancer)(func(_ context.Context, req *protocol.Request, _ *protocol.Response) error {
+		host = string(req.Host())
+		return nil
+	})
+	call := func(ctx context.Context, sd bool) string {
+		req := protocol.NewRequest("GET", "http://user-service/ping", nil)
+		req.SetOptions(config.WithSD(sd))
+		if err := endpoint(ctx, req, &protocol.Response{}); err != nil {
+			t.Fatal(err)
+		}
+		return host
+	}
+
+	if h := call(context.Background(), false); h != "user-service" {
+		t.Fatalf("host without sd = %s", h)
+	}
+	// 相同哈希键路由到同一实例
+	ctx := lb.WithHashKey(context.Background(), "user-1")
+	first := call(ctx, true)
+	if first != "10.0.0.1:8000" && first != "10.0.0.2:8000" {
+		t.Fatalf("host = %s", first)
+	}
+	for i := 0; i < 5; i++ {
+		if h := call(ctx, true); h != first {
+			t.Fatalf("hash key routed to %s and %s", first, h)
+		}
+	}
+}
+
+func TestMetainfoMiddleware(t *testing.T) {
+	var header string
+	endpoint := metainfoMiddleware(func(_ context.Context, req *protocol.Request, _ *protocol.Response) error {
+		header = req.Header.Get("rpc-persist-ag-route-tag")
+		return nil
+	})
+	req := protocol.NewRequest("GET", "http://user-service/ping", nil)
+	if err := endpoint(route.WithTag(context.Background(), "gray"), req, &protocol.Response{}); err != nil {
+		t.Fatal(err)
+	}
+	if header != "gray" {
+		t.Fatalf("route tag header = %q", header)
+	}
+}
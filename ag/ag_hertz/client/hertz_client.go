@@ -5,8 +5,9 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
-	"github.com/cloudwego/hertz/pkg/app/middlewares/client/sd"
-	"github.com/hertz-contrib/registry/nacos"
+	"github.com/cloudwego/kitex/pkg/loadbalance"
+	"github.com/frochyzhang/ag-core/ag/ag_ext"
+	"github.com/frochyzhang/ag-core/ag/ag_ext/lb"
 	"github.com/nacos-group/nacos-sdk-go/clients/naming_client"
 	"io"
 	"net/http"
@@ -55,6 +56,7 @@ type Option struct {
 type Options struct {
 	hostUrl               string
 	namingClient          naming_client.INamingClient
+	balancer              loadbalance.Loadbalancer
 	doer                  client.Doer
 	header                http.Header
 	requestBodyBind       bindRequestBodyFunc
@@ -113,8 +115,14 @@ func withHostUrl(HostUrl string) Option {
 }
 func withNamingClient(namingClient naming_client.INamingClient) Option {
 	return Option{func(op *Options) {
-		r := nacos.NewNacosResolver(namingClient)
-		op.middlewares = append(op.middlewares, sd.Discovery(r))
+		op.namingClient = namingClient
+	}}
+}
+
+// withLoadbalancer 服务发现的负载均衡器，未设置时使用 lb 包的默认策略
+func withLoadbalancer(balancer loadbalance.Loadbalancer) Option {
+	return Option{func(op *Options) {
+		op.balancer = balancer
 	}}
 }
 
@@ -146,6 +154,18 @@ func newClient(opts *Options) (*cli, error) {
 	if opts.responseResultDecider == nil {
 		opts.responseResultDecider = defaultResponseResultDecider
 	}
+	opts.middlewares = append([]hertzclient.Middleware{metainfoMiddleware}, opts.middlewares...)
+	if opts.namingClient != nil {
+		if opts.balancer == nil {
+			balancer, err := lb.New(lb.LoadBalanceProperties{})
+			if err != nil {
+				return nil, err
+			}
+			opts.balancer = balancer
+		}
+		r := ag_ext.NewNacosHTTPResolver(opts.namingClient)
+		opts.middlewares = append(opts.middlewares, discoveryMiddleware(r, opts.balancer))
+	}
 	if opts.doer == nil {
 		cli, err := hertzclient.NewClient(opts.clientOption...)
 		if err != nil {
@@ -602,7 +622,7 @@ func parseResponseBody(c *cli, res *response) (err error) {
 			if jsonErr != nil {
 				return jsonErr
 			}
-			err = fmt.Errorf(string(jsonByte))
+			err = &StatusError{StatusCode: res.rawResponse.StatusCode(), msg: string(jsonByte)}
 		}
 	} else if res.request.result != nil {
 		if isJSONType(ct) || isXMLType(ct) {
@@ -613,6 +633,26 @@ func parseResponseBody(c *cli, res *response) (err error) {
 	return
 }
 
+// StatusError 应答状态码判定为错误且未设置错误结构时返回的错误，错误信息为状态码及应答体的JSON
+type StatusError struct {
+	StatusCode int
+	msg        string
+}
+
+func (e *StatusError) Error() string {
+	return e.msg
+}
+
+// Retryable 限流及网关类错误(429、502、503、504)可重试
+func (e *StatusError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
 // unmarshalContent content into object from JSON or XML
 func unmarshalContent(ct string, b []byte, d interface{}) (err error) {
 	if isJSONType(ct) {
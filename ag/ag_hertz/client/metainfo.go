@@ -0,0 +1,18 @@
+package client
+
+import (
+	"context"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	hertzclient "github.com/cloudwego/hertz/pkg/app/client"
+	"github.com/cloudwego/hertz/pkg/protocol"
+)
+
+// metainfoMiddleware 将metainfo透传值写入请求头(rpc-persist-*、rpc-transit-*)，
+// 与kitex的元信息透传一致，路由标签等随调用链传递到下游
+func metainfoMiddleware(next hertzclient.Endpoint) hertzclient.Endpoint {
+	return func(ctx context.Context, req *protocol.Request, resp *protocol.Response) error {
+		metainfo.ToHTTPHeader(ctx, &req.Header)
+		return next(ctx, req, resp)
+	}
+}
@@ -1,7 +1,7 @@
 package server
 
 const (
-	hertzServerPropertiesPrefix = "hertz.server"
+	HertzServerPropertiesPrefix = "hertz.server"
 	DefaultHertzOriginPort      = 7000
 )
 
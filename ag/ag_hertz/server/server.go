@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"fmt"
+	"github.com/bytedance/gopkg/cloud/metainfo"
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/route"
 	"github.com/frochyzhang/ag-core/ag/ag_conf"
@@ -36,6 +37,14 @@ func WithRoute(r *Route) Option {
 		s.root.Handle(r.HttpMethod, r.RelativePath, r.Handlers...)
 	}
 }
+
+// WithMiddleware 在根路由组注册全局中间件，需在注册路由之前应用
+func WithMiddleware(mws ...app.HandlerFunc) Option {
+	return func(s *Server) {
+		s.root.Use(mws...)
+	}
+}
+
 func NewServer(hertz *server.Hertz, logger *slog.Logger, opts ...Option) *Server {
 	s := &Server{
 		Hertz:  hertz,
@@ -50,7 +59,22 @@ func NewServer(hertz *server.Hertz, logger *slog.Logger, opts ...Option) *Server
 }
 
 func rootMw() []app.HandlerFunc {
-	return nil
+	return []app.HandlerFunc{metainfoMw}
+}
+
+// metainfoMw 从请求头(rpc-persist-*、rpc-transit-*)读取上游透传的metainfo，路由标签等继续传递到下游
+func metainfoMw(ctx context.Context, c *app.RequestContext) {
+	c.Next(metainfo.FromHTTPHeader(ctx, requestHeader{c}))
+}
+
+type requestHeader struct {
+	c *app.RequestContext
+}
+
+func (h requestHeader) Visit(v func(k, v string)) {
+	h.c.Request.Header.VisitAll(func(k, val []byte) {
+		v(string(k), string(val))
+	})
 }
 
 // NewHertzServerWithSuit 创建一个Hertz服务实例，使用配置套件，并且注册服务
@@ -65,7 +89,7 @@ func NewHertzServerWithSuit(
 		server.Default(
 			suite.Options()...),
 		logger,
-		suite.Routers()...,
+		append([]Option{WithMiddleware(suite.Middlewares()...)}, suite.Routers()...)...,
 	)
 }
 
@@ -92,8 +116,9 @@ func (s *Server) Stop(ctx context.Context) error {
 
 // HertzOptionSuite 定义了Hertz服务的配置套件
 type HertzOptionSuite struct {
-	opts    []config.Option
-	routers []Option
+	opts        []config.Option
+	middlewares []app.HandlerFunc
+	routers     []Option
 }
 
 // Options 返回配置项
@@ -104,24 +129,31 @@ func (s *HertzOptionSuite) Routers() []Option {
 	return s.routers
 }
 
+// Middlewares 返回全局中间件，先于路由注册
+func (s *HertzOptionSuite) Middlewares() []app.HandlerFunc {
+	return s.middlewares
+}
+
 // HertzSuiteBuilder 定义了Hertz服务配置套件的构建器
 type HertzSuiteBuilder struct {
 	Env           ag_conf.IConfigurableEnvironment
 	Binder        ag_conf.IBinder
 	CustOptions   []config.Option
 	RouterOptions []Option
+	Middlewares   []app.HandlerFunc
 	NamingClient  naming_client.INamingClient
 }
 
 // BuildSuite 构建Hertz服务配置套件
 func (builder *HertzSuiteBuilder) BuildSuite() (*HertzOptionSuite, error) {
 	suite := &HertzOptionSuite{
-		opts:    builder.CustOptions,
-		routers: builder.RouterOptions,
+		opts:        builder.CustOptions,
+		middlewares: builder.Middlewares,
+		routers:     builder.RouterOptions,
 	}
 
 	var hconf HertzServerProperties
-	err := builder.Binder.Bind(&hconf, hertzServerPropertiesPrefix)
+	err := builder.Binder.Bind(&hconf, HertzServerPropertiesPrefix)
 	if err != nil {
 		slog.Error("hertz server config error", "error", err)
 		return nil, err
@@ -2,6 +2,8 @@ package client
 
 import (
 	"github.com/frochyzhang/ag-core/ag/ag_ext"
+	"github.com/frochyzhang/ag-core/ag/ag_ext/route"
+	"github.com/frochyzhang/ag-core/ag/ag_resilience"
 
 	"github.com/cloudwego/kitex/client"
 	"github.com/cloudwego/kitex/transport"
@@ -20,9 +22,18 @@ type KitexSuiteBuilder struct {
 	CustOptions []client.Option
 
 	NamingClient naming_client.INamingClient
+
+	// Router 灰度及标签路由，为nil时不按路由标签过滤实例
+	Router *route.Router
+
+	// Resilience 下游服务的熔断、隔离舱及重试，为nil时不启用
+	Resilience *ag_resilience.Manager
 }
 
-// func (builder *KitexSuiteBuilder) BuildSuite() (client.Suite, error) {
+// BuildSuite 构建所有下游服务共用的grpc客户端套件
+//
+// Deprecated: 共用套件不区分下游服务的传输协议、超时及负载均衡配置，
+// 使用 KitexServiceSuites.Suite 或生成代码的 New{Service}GRPCClientWithSuites
 func (builder *KitexSuiteBuilder) BuildSuite() (*KitexClientSuite, error) {
 	opts := make([]client.Option, 0)
 
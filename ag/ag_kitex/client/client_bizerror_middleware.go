@@ -13,11 +13,15 @@ func clientErrMidleware(next endpoint.Endpoint) endpoint.Endpoint {
 	return func(ctx context.Context, req, resp interface{}) (err error) {
 		err = next(ctx, req, resp)
 		if err != nil {
+			// spring等gRPC对端以 google.rpc.Status 的ErrorInfo详情返回业务异常
+			if abe, ok := ag_error.FromGRPCError(err); ok {
+				return abe
+			}
 			return err
 		}
 		// 提取rpcinfo
 		ri := rpcinfo.GetRPCInfo(ctx)
-		be := ri.Invocation().BizStatusErr() // BizStatusErr是通过协议层头传递的。TODO 要测试该方式是否能和spring-grpc端兼容
+		be := ri.Invocation().BizStatusErr() // kitex对端的BizStatusErr通过协议层头(TTHeader或gRPC的biz-status)传递
 		if be != nil {
 			// 如果是业务异常则转换成业务系统内部业务异常
 			err = ag_error.NewBizStatusError(be.BizStatusCode(), be.BizMessage(), be.BizExtra())
@@ -0,0 +1,29 @@
+package client
+
+import "github.com/frochyzhang/ag-core/ag/ag_ext/lb"
+
+const (
+	KitexClientPropertiesPrefix = "kitex.client"
+)
+
+// KitexClientProperties 客户端配置，按下游服务名配置 kitex.client.services.<name>
+type KitexClientProperties struct {
+	Services map[string]KitexServiceProperties `value:"${services:}"`
+}
+
+// KitexServiceProperties 单个下游服务的客户端配置，时间单位为毫秒，0为kitex默认值；
+// 超时支持运行时刷新，transport、host-ports及load-balance变更需重建客户端；
+// 熔断、隔离舱及重试按同一服务名配置在 resilience.services.<name>
+type KitexServiceProperties struct {
+	// Transport 传输协议：grpc|ttheader|ttheader-framed|framed|buffered
+	Transport string `value:"${transport:grpc}"`
+	// HostPorts 直连地址，逗号分隔，配置后不再通过注册中心解析
+	HostPorts string `value:"${host-ports:}"`
+
+	RPCTimeout       int `value:"${rpc-timeout:0}"`
+	ConnectTimeout   int `value:"${connect-timeout:0}"`
+	ReadWriteTimeout int `value:"${read-write-timeout:0}"`
+
+	// LoadBalance 负载均衡策略，见 lb.LoadBalanceProperties
+	LoadBalance lb.LoadBalanceProperties `value:"${load-balance}"`
+}
@@ -0,0 +1,284 @@
+package client
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/frochyzhang/ag-core/ag/ag_conf"
+	"github.com/frochyzhang/ag-core/ag/ag_ext"
+	"github.com/frochyzhang/ag-core/ag/ag_ext/lb"
+	"github.com/frochyzhang/ag-core/ag/ag_resilience"
+
+	"github.com/cloudwego/kitex/client"
+	"github.com/cloudwego/kitex/pkg/loadbalance/lbcache"
+	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/pkg/transmeta"
+	"github.com/cloudwego/kitex/transport"
+)
+
+// KitexServiceSuites 按 kitex.client.services.<name> 配置为每个下游服务构建客户端套件，
+// 存在配置监听器时，配置刷新后动态更新已构建套件的超时；熔断、隔离舱及重试由 ag_resilience 按下游服务名提供
+type KitexServiceSuites struct {
+	builder *KitexSuiteBuilder
+	binder  ag_conf.IBinder
+
+	lock     sync.Mutex
+	props    *KitexClientProperties
+	policies map[string][]*servicePolicy
+}
+
+// NewKitexServiceSuites 绑定客户端配置，watcher为nil时不刷新
+func NewKitexServiceSuites(builder *KitexSuiteBuilder, binder ag_conf.IBinder, watcher *ag_conf.Watcher) (*KitexServiceSuites, error) {
+	s := &KitexServiceSuites{
+		builder:  builder,
+		binder:   binder,
+		policies: make(map[string][]*servicePolicy),
+	}
+
+	var props *KitexClientProperties
+	var err error
+	if watcher != nil {
+		props, err = ag_conf.Watch(watcher, KitexClientPropertiesPrefix, s.refresh)
+	} else {
+		props = &KitexClientProperties{}
+		err = binder.Bind(props, KitexClientPropertiesPrefix)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for name, conf := range props.Services {
+		if _, err := newServicePolicy(name, conf); err != nil {
+			return nil, err
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.props == nil {
+		s.props = props
+	}
+	return s, nil
+}
+
+// Suite 构建指定下游服务的客户端套件，name与创建客户端时的服务名一致，未配置时使用默认配置(grpc传输)
+func (s *KitexServiceSuites) Suite(name string) (*KitexClientSuite, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	conf, err := s.serviceConf(name)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := newServicePolicy(name, conf)
+	if err != nil {
+		return nil, err
+	}
+	suite, err := s.builder.buildServiceSuite(conf, policy)
+	if err != nil {
+		return nil, err
+	}
+	s.policies[name] = append(s.policies[name], policy)
+	return suite, nil
+}
+
+// serviceConf 获取下游服务配置，未配置时绑定默认值
+func (s *KitexServiceSuites) serviceConf(name string) (KitexServiceProperties, error) {
+	if conf, ok := s.props.Services[name]; ok {
+		return conf, nil
+	}
+	var conf KitexServiceProperties
+	err := s.binder.Bind(&conf, KitexClientPropertiesPrefix+".services."+name)
+	return conf, err
+}
+
+// refresh 配置刷新时更新已构建套件的策略，更新失败时保留原策略
+func (s *KitexServiceSuites) refresh(props *KitexClientProperties) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.props = props
+	for name, policies := range s.policies {
+		conf, err := s.serviceConf(name)
+		if err != nil {
+			slog.Error("kitex client config refresh failed", "service", name, "error", err)
+			continue
+		}
+		for _, policy := range policies {
+			if err := policy.update(conf); err != nil {
+				slog.Error("kitex client config refresh failed, keep previous", "service", name, "error", err)
+			}
+		}
+	}
+}
+
+// buildServiceSuite 构建下游服务的客户端套件
+func (builder *KitexSuiteBuilder) buildServiceSuite(conf KitexServiceProperties, policy *servicePolicy) (*KitexClientSuite, error) {
+	protocol, err := parseTransport(conf.Transport)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := make([]client.Option, 0)
+	opts = append(opts, builder.CustOptions...)
+	opts = append(opts, client.WithTransportProtocol(protocol))
+	// 元信息透传，路由标签等metainfo持久化值随请求传递到下游
+	if h := metaHandler(protocol); h != nil {
+		opts = append(opts, client.WithMetaHandler(h))
+	}
+
+	// 负载均衡，最少活跃请求策略需统计实例活跃请求数
+	balancer, err := lb.New(conf.LoadBalance)
+	if err != nil {
+		return nil, fmt.Errorf("kitex client load-balance invalid: %w", err)
+	}
+	if builder.Router != nil {
+		balancer = builder.Router.Balancer(balancer)
+	}
+
+	// 直连地址优先于注册中心
+	if hostPorts := splitHostPorts(conf.HostPorts); len(hostPorts) > 0 {
+		opts = append(opts, client.WithHostPorts(hostPorts...))
+		opts = append(opts, client.WithLoadBalancer(balancer))
+	} else if builder.NamingClient != nil {
+		resolver := ag_ext.NewNacosResolver(builder.NamingClient)
+		// 均衡器名称包含其配置，可在lbcache中共享；以相同参数取得kitex客户端使用的均衡器工厂，
+		// nacos推送实例变化时立即刷新，订阅随进程存在，不随客户端关闭取消
+		cacheOpts := lbcache.Options{Cacheable: true}
+		opts = append(opts, client.WithResolver(resolver))
+		opts = append(opts, client.WithLoadBalancer(balancer, &cacheOpts))
+		lb.RefreshOnChange(resolver, lbcache.NewBalancerFactory(resolver, balancer, cacheOpts), policy.name)
+	} else {
+		opts = append(opts, client.WithLoadBalancer(balancer))
+	}
+	if tracker, ok := balancer.(lb.ActiveTracker); ok {
+		opts = append(opts, client.WithInstanceMW(lb.KitexActiveMW(tracker)))
+	}
+
+	// 可刷新的超时，熔断、隔离舱及重试统一由ag_resilience完成
+	opts = append(opts, client.WithTimeoutProvider(policy))
+	if builder.Resilience != nil {
+		opts = append(opts, client.WithMiddleware(ag_resilience.KitexMiddleware(builder.Resilience, policy.name)))
+	}
+
+	return &KitexClientSuite{opts: opts}, nil
+}
+
+// metaHandler 按传输协议选择元信息透传方式，framed|buffered不支持透传
+func metaHandler(protocol transport.Protocol) remote.MetaHandler {
+	switch {
+	case protocol&transport.GRPC != 0:
+		return transmeta.ClientHTTP2Handler
+	case protocol&transport.TTHeader != 0:
+		return transmeta.ClientTTHeaderHandler
+	default:
+		return nil
+	}
+}
+
+// servicePolicy 下游服务的可刷新策略
+type servicePolicy struct {
+	name string
+
+	conf     atomic.Pointer[KitexServiceProperties]
+	timeouts atomic.Pointer[serviceTimeouts]
+}
+
+func newServicePolicy(name string, conf KitexServiceProperties) (*servicePolicy, error) {
+	if _, err := parseTransport(conf.Transport); err != nil {
+		return nil, err
+	}
+	if _, err := lb.New(conf.LoadBalance); err != nil {
+		return nil, fmt.Errorf("kitex client load-balance invalid: %w", err)
+	}
+	p := &servicePolicy{name: name}
+	if err := p.update(conf); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// update 校验并应用配置，校验失败时不做任何变更
+func (p *servicePolicy) update(conf KitexServiceProperties) error {
+	timeouts, err := buildTimeouts(conf)
+	if err != nil {
+		return err
+	}
+
+	prev := p.conf.Load()
+	if prev != nil && (prev.Transport != conf.Transport || prev.HostPorts != conf.HostPorts || prev.LoadBalance != conf.LoadBalance) {
+		slog.Warn("kitex client transport, host-ports and load-balance take effect after client rebuild", "service", p.name)
+	}
+
+	p.timeouts.Store(timeouts)
+	p.conf.Store(&conf)
+	slog.Info("kitex client service policy applied", "service", p.name, "rpcTimeout", conf.RPCTimeout,
+		"connectTimeout", conf.ConnectTimeout, "readWriteTimeout", conf.ReadWriteTimeout)
+	return nil
+}
+
+// Timeouts 实现 rpcinfo.TimeoutProvider，未配置的超时保持客户端原有配置
+func (p *servicePolicy) Timeouts(ri rpcinfo.RPCInfo) rpcinfo.Timeouts {
+	t := *p.timeouts.Load()
+	cfg := ri.Config()
+	if t.rpc == 0 {
+		t.rpc = cfg.RPCTimeout()
+	}
+	if t.connect == 0 {
+		t.connect = cfg.ConnectTimeout()
+	}
+	if t.readWrite == 0 {
+		t.readWrite = cfg.ReadWriteTimeout()
+	}
+	return &t
+}
+
+type serviceTimeouts struct {
+	rpc, connect, readWrite time.Duration
+}
+
+func (t *serviceTimeouts) RPCTimeout() time.Duration       { return t.rpc }
+func (t *serviceTimeouts) ConnectTimeout() time.Duration   { return t.connect }
+func (t *serviceTimeouts) ReadWriteTimeout() time.Duration { return t.readWrite }
+
+func buildTimeouts(conf KitexServiceProperties) (*serviceTimeouts, error) {
+	if conf.RPCTimeout < 0 || conf.ConnectTimeout < 0 || conf.ReadWriteTimeout < 0 {
+		return nil, fmt.Errorf("kitex client timeout invalid: rpc-timeout=%d connect-timeout=%d read-write-timeout=%d",
+			conf.RPCTimeout, conf.ConnectTimeout, conf.ReadWriteTimeout)
+	}
+	return &serviceTimeouts{
+		rpc:       time.Duration(conf.RPCTimeout) * time.Millisecond,
+		connect:   time.Duration(conf.ConnectTimeout) * time.Millisecond,
+		readWrite: time.Duration(conf.ReadWriteTimeout) * time.Millisecond,
+	}, nil
+}
+
+func parseTransport(s string) (transport.Protocol, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "grpc":
+		return transport.GRPC, nil
+	case "ttheader":
+		return transport.TTHeader, nil
+	case "ttheader-framed":
+		return transport.TTHeaderFramed, nil
+	case "framed":
+		return transport.Framed, nil
+	case "buffered":
+		return transport.PurePayload, nil
+	default:
+		return transport.PurePayload, fmt.Errorf("kitex client transport invalid: %s", s)
+	}
+}
+
+func splitHostPorts(s string) []string {
+	hostPorts := make([]string, 0)
+	for _, hp := range strings.Split(s, ",") {
+		if hp = strings.TrimSpace(hp); hp != "" {
+			hostPorts = append(hostPorts, hp)
+		}
+	}
+	return hostPorts
+}
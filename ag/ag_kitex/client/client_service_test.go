@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frochyzhang/ag-core/ag/ag_conf"
+
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+)
+
+func serviceSource(props map[string]any) *ag_conf.MapPropertySource {
+	source := &ag_conf.MapPropertySource{Source: map[string]any{}}
+	source.Name = "kitex-client"
+	for k, v := range props {
+		source.Source[KitexClientPropertiesPrefix+".services."+k] = fmt.Sprint(v)
+	}
+	return source
+}
+
+func newTestSuites(t *testing.T, props map[string]any) (*KitexServiceSuites, ag_conf.IConfigurableEnvironment, error) {
+	t.Helper()
+	env := ag_conf.NewStandardEnvironment()
+	env.GetPropertySources().AddFirst(serviceSource(props))
+	binder := ag_conf.NewConfigurationPropertiesBinder(env)
+	watcher := ag_conf.NewConfigWatcher(binder)
+	suites, err := NewKitexServiceSuites(&KitexSuiteBuilder{}, binder, watcher)
+	if err != nil {
+		return nil, env, err
+	}
+	if err := watcher.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = watcher.Stop(context.Background()) })
+	return suites, env, nil
+}
+
+func policyTimeouts(p *servicePolicy) rpcinfo.Timeouts {
+	return p.Timeouts(rpcinfo.NewRPCInfo(nil, nil, nil, rpcinfo.NewRPCConfig(), nil))
+}
+
+func TestServiceSuiteRefresh(t *testing.T) {
+	suites, env, err := newTestSuites(t, map[string]any{
+		"pay.transport":   "ttheader",
+		"pay.rpc-timeout": 300,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	suite, err := suites.Suite("pay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 传输协议、元信息透传、负载均衡、超时
+	if n := len(suite.Options()); n != 4 {
+		t.Errorf("options = %d, want 4", n)
+	}
+	policy := suites.policies["pay"][0]
+	timeouts := policyTimeouts(policy)
+	defaults := rpcinfo.NewRPCConfig()
+	if timeouts.RPCTimeout() != 300*time.Millisecond || timeouts.ConnectTimeout() != defaults.ConnectTimeout() {
+		t.Errorf("timeouts rpc=%v connect=%v", timeouts.RPCTimeout(), timeouts.ConnectTimeout())
+	}
+
+	// 刷新超时
+	env.GetPropertySources().Replace("kitex-client", serviceSource(map[string]any{
+		"pay.transport":       "ttheader",
+		"pay.rpc-timeout":     800,
+		"pay.connect-timeout": 50,
+	}))
+	timeouts = policyTimeouts(policy)
+	if timeouts.RPCTimeout() != 800*time.Millisecond || timeouts.ConnectTimeout() != 50*time.Millisecond {
+		t.Errorf("refreshed timeouts rpc=%v connect=%v", timeouts.RPCTimeout(), timeouts.ConnectTimeout())
+	}
+
+	// 非法配置保留原策略
+	env.GetPropertySources().Replace("kitex-client", serviceSource(map[string]any{
+		"pay.rpc-timeout":     100,
+		"pay.connect-timeout": -1,
+	}))
+	if d := policyTimeouts(policy).RPCTimeout(); d != 800*time.Millisecond {
+		t.Errorf("rpc timeout after invalid refresh = %v", d)
+	}
+
+	// 未配置的服务使用默认配置
+	if _, err := suites.Suite("order"); err != nil {
+		t.Fatal(err)
+	}
+	if d := policyTimeouts(suites.policies["order"][0]).RPCTimeout(); d != defaults.RPCTimeout() {
+		t.Errorf("default rpc timeout = %v", d)
+	}
+}
+
+func TestServiceSuiteInvalid(t *testing.T) {
+	for name, props := range map[string]map[string]any{
+		"transport":    {"pay.transport": "http3"},
+		"timeout":      {"pay.connect-timeout": -1},
+		"load balance": {"pay.load-balance.strategy": "fastest"},
+	} {
+		if _, _, err := newTestSuites(t, props); err == nil || !strings.Contains(err.Error(), "kitex") {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
@@ -13,10 +13,56 @@ type KitexServerProperties struct {
 	ServiceName   string
 	EnableIPRange string `value:"${:}"`
 
-	Grpc Grpc
+	// ExitWaitTime 优雅停机等待时间(秒)，0为kitex默认值
+	ExitWaitTime int `value:"${:0}"`
+	// MaxConnIdleTime 连接最大空闲时间(秒)，0为kitex默认值
+	MaxConnIdleTime int `value:"${:0}"`
+	// ReadWriteTimeout 读写超时(毫秒)，0为kitex默认值
+	ReadWriteTimeout int `value:"${:0}"`
+
+	// 限流：最大连接数及最大QPS，0为不限制
+	MaxConnections int `value:"${:0}"`
+	MaxQPS         int `value:"${:0}"`
+
+	// MuxTransport 启用连接多路复用，仅支持TTHeader协议，不能与grpc同时启用
+	MuxTransport bool `value:"${:false}"`
+	// StatsLevel 埋点级别：disabled|base|detailed，为空时使用kitex默认值
+	StatsLevel string `value:"${:}"`
+	// MetaHandlers 元信息透传协议，逗号分隔：ttheader|http2；为空且启用grpc时使用http2
+	MetaHandlers string `value:"${:}"`
+
+	Codec Codec
+	Grpc  Grpc
+}
+
+// Codec 负载编解码配置，仅适用于thrift服务：配置后非gRPC请求均使用该编解码，gRPC请求不受影响
+type Codec struct {
+	// Thrift thrift编解码：basic|fast|frugal，为空时使用kitex默认值(fast)
+	Thrift string `value:"${:}"`
+	// SkipDecoder 启用SkipDecoder，用于thrift buffered等非Framed协议
+	SkipDecoder bool `value:"${:false}"`
 }
 
+// Grpc gRPC传输配置，时间单位为秒，0为kitex默认值
 type Grpc struct {
-	Enable            bool `value:"${:false}"`
-	MaxConnectionIdle int  `value:"${:0}"`
+	Enable                bool `value:"${:false}"`
+	MaxConnectionIdle     int  `value:"${:0}"`
+	MaxConnectionAge      int  `value:"${:0}"`
+	MaxConnectionAgeGrace int  `value:"${:0}"`
+	// KeepaliveTime 连接无活动后发送ping的间隔，KeepaliveTimeout 等待ping应答的超时
+	KeepaliveTime    int `value:"${:0}"`
+	KeepaliveTimeout int `value:"${:0}"`
+
+	// 客户端keepalive约束：ping最小间隔及无活跃流时是否允许ping，违反时关闭连接
+	KeepaliveMinTime    int  `value:"${:0}"`
+	PermitWithoutStream bool `value:"${:false}"`
+
+	// 流控窗口(字节)，不小于64K
+	InitialWindowSize     int `value:"${:0}"`
+	InitialConnWindowSize int `value:"${:0}"`
+
+	MaxConcurrentStreams int `value:"${:0}"`
+	MaxHeaderListSize    int `value:"${:0}"`
+	ReadBufferSize       int `value:"${:0}"`
+	WriteBufferSize      int `value:"${:0}"`
 }
@@ -18,6 +18,18 @@ type IAgKitexServerMiddleware interface {
 	After(ctx context.Context, req, resp interface{}, e error) (err error)
 }
 
+// IAgKitexServerReleaser 中间件可选实现，Before成功后请求结束时必定回调Release，
+// 包括后续中间件Before出错的情况，ctx为该中间件Before返回的ctx，用于释放Before占用的资源(如限流并发数)
+type IAgKitexServerReleaser interface {
+	Release(ctx context.Context, err error)
+}
+
+// releasing 已执行Before的 IAgKitexServerReleaser 及其Before返回的ctx
+type releasing struct {
+	releaser IAgKitexServerReleaser
+	ctx      context.Context
+}
+
 type AgKitexServerMiddleware struct {
 	Middlewares []IAgKitexServerMiddleware
 }
@@ -54,12 +66,22 @@ func AgRegistKitexServerMiddlewareOption(mids *AgKitexServerMiddleware) *server.
 				// treq := req
 				// tresp := resp
 
+				var releasings []releasing
+				defer func() {
+					for i := len(releasings) - 1; i >= 0; i-- {
+						releasings[i].releaser.Release(releasings[i].ctx, rerr)
+					}
+				}()
+
 				for _, mid := range middlewares {
 					var err error
 					if tctx, err = mid.Before(tctx, req, resp); err != nil {
 						// Before 方法出错，直接返回
 						return err
 					}
+					if r, ok := mid.(IAgKitexServerReleaser); ok {
+						releasings = append(releasings, releasing{releaser: r, ctx: tctx})
+					}
 				}
 
 				// Call next middleware/handler
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"sort"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/endpoint/sep"
+	"github.com/cloudwego/kitex/pkg/streaming"
+	"github.com/cloudwego/kitex/server"
+)
+
+// IAgKitexServerStreamMiddleware 流式方法的逐消息中间件；
+// 整个流仍经过 IAgKitexServerMiddleware 一次，此时req为kitex传入的 *streaming.Args、resp为nil；
+// 生成代码中经 ag_ext.RegisterStreamHandler 注册的业务中间件收到的req则为 *ag_ext.StreamCall
+type IAgKitexServerStreamMiddleware interface {
+	// OnRecv 收到消息后调用，err为接收错误(对端发送结束时为io.EOF)，返回值作为接收结果的错误
+	OnRecv(ctx context.Context, msg interface{}, err error) error
+	// OnSend 发送消息前调用，返回错误时不发送
+	OnSend(ctx context.Context, msg interface{}) error
+}
+
+type AgKitexServerStreamMiddleware struct {
+	Middlewares []IAgKitexServerStreamMiddleware
+}
+
+// AgRegistKitexServerStreamMiddlewareOptions 创建有序的流消息中间件链，
+// 同时注册到StreamX接口及旧版gRPC流接口(streaming.Stream)的收发链上，两者对同一消息只会经过其一；
+// 与Before/After一致，OnSend按Order顺序调用，OnRecv按逆序调用
+func AgRegistKitexServerStreamMiddlewareOptions(mids *AgKitexServerStreamMiddleware) []*server.Option {
+	if mids == nil || len(mids.Middlewares) == 0 {
+		return nil
+	}
+	middlewares := make([]IAgKitexServerStreamMiddleware, len(mids.Middlewares))
+	copy(middlewares, mids.Middlewares)
+	sort.SliceStable(middlewares, func(i, j int) bool {
+		return orderOf(middlewares[i]) < orderOf(middlewares[j])
+	})
+
+	recv := func(ctx context.Context, msg interface{}, err error) error {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			err = middlewares[i].OnRecv(ctx, msg, err)
+		}
+		return err
+	}
+	send := func(ctx context.Context, msg interface{}) error {
+		for _, mid := range middlewares {
+			if err := mid.OnSend(ctx, msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	streamOpt := server.WithStreamOptions(
+		server.WithStreamRecvMiddleware(func(next sep.StreamRecvEndpoint) sep.StreamRecvEndpoint {
+			return func(ctx context.Context, st streaming.ServerStream, msg interface{}) error {
+				return recv(ctx, msg, next(ctx, st, msg))
+			}
+		}),
+		server.WithStreamSendMiddleware(func(next sep.StreamSendEndpoint) sep.StreamSendEndpoint {
+			return func(ctx context.Context, st streaming.ServerStream, msg interface{}) error {
+				if err := send(ctx, msg); err != nil {
+					return err
+				}
+				return next(ctx, st, msg)
+			}
+		}),
+	)
+	// 旧版gRPC流接口仍使用已废弃的收发中间件
+	recvOpt := server.WithRecvMiddleware(func(next endpoint.RecvEndpoint) endpoint.RecvEndpoint {
+		return func(st streaming.Stream, msg interface{}) error {
+			return recv(st.Context(), msg, next(st, msg))
+		}
+	})
+	sendOpt := server.WithSendMiddleware(func(next endpoint.SendEndpoint) endpoint.SendEndpoint {
+		return func(st streaming.Stream, msg interface{}) error {
+			if err := send(st.Context(), msg); err != nil {
+				return err
+			}
+			return next(st, msg)
+		}
+	})
+	return []*server.Option{&streamOpt, &recvOpt, &sendOpt}
+}
+
+func orderOf(mid interface{}) int {
+	if o, ok := mid.(order); ok {
+		return o.Order()
+	}
+	return 0
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/limit"
+	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/cloudwego/kitex/pkg/remote/codec/thrift"
+	"github.com/cloudwego/kitex/pkg/remote/trans/nphttp2/grpc"
+	"github.com/cloudwego/kitex/pkg/stats"
+	"github.com/cloudwego/kitex/pkg/transmeta"
+	"github.com/cloudwego/kitex/server"
+)
+
+// grpcMinWindowSize gRPC流控窗口下限，小于该值时kitex忽略配置
+const grpcMinWindowSize = 64 * 1024
+
+// buildTuningOptions 根据配置构建超时、限流、传输、埋点、元信息透传、编解码及gRPC相关配置项
+func buildTuningOptions(kconf KitexServerProperties) ([]server.Option, error) {
+	opts := make([]server.Option, 0)
+
+	// 超时配置
+	if kconf.ExitWaitTime < 0 || kconf.MaxConnIdleTime < 0 || kconf.ReadWriteTimeout < 0 {
+		return nil, fmt.Errorf("kitex timeout invalid: ExitWaitTime=%d MaxConnIdleTime=%d ReadWriteTimeout=%d",
+			kconf.ExitWaitTime, kconf.MaxConnIdleTime, kconf.ReadWriteTimeout)
+	}
+	if kconf.ExitWaitTime > 0 {
+		opts = append(opts, server.WithExitWaitTime(time.Duration(kconf.ExitWaitTime)*time.Second))
+	}
+	if kconf.MaxConnIdleTime > 0 {
+		opts = append(opts, server.WithMaxConnIdleTime(time.Duration(kconf.MaxConnIdleTime)*time.Second))
+	}
+	if kconf.ReadWriteTimeout > 0 {
+		opts = append(opts, server.WithReadWriteTimeout(time.Duration(kconf.ReadWriteTimeout)*time.Millisecond))
+	}
+
+	// 限流配置
+	if kconf.MaxConnections < 0 || kconf.MaxQPS < 0 {
+		return nil, fmt.Errorf("kitex limit invalid: MaxConnections=%d MaxQPS=%d", kconf.MaxConnections, kconf.MaxQPS)
+	}
+	if kconf.MaxConnections > 0 || kconf.MaxQPS > 0 {
+		slog.Info("kitex server enable limit", "maxConnections", kconf.MaxConnections, "maxQPS", kconf.MaxQPS)
+		opts = append(opts, server.WithLimit(&limit.Option{MaxConnections: kconf.MaxConnections, MaxQPS: kconf.MaxQPS}))
+	}
+
+	// 多路复用
+	if kconf.MuxTransport {
+		if kconf.Grpc.Enable {
+			return nil, errors.New("kitex MuxTransport is not compatible with grpc")
+		}
+		slog.Info("kitex server enable mux transport")
+		opts = append(opts, server.WithMuxTransport())
+	}
+
+	// 埋点级别
+	if kconf.StatsLevel != "" {
+		level, err := parseStatsLevel(kconf.StatsLevel)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, server.WithStatsLevel(level))
+	}
+
+	// 元信息透传
+	handlers, err := parseMetaHandlers(kconf.MetaHandlers, kconf.Grpc.Enable)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range handlers {
+		opts = append(opts, server.WithMetaHandler(h))
+	}
+
+	// 负载编解码
+	codecOpt, err := buildCodecOption(kconf.Codec)
+	if err != nil {
+		return nil, err
+	}
+	if codecOpt != nil {
+		opts = append(opts, *codecOpt)
+	}
+
+	// Grpc配置
+	if kconf.Grpc.Enable {
+		grpcOpts, err := buildGrpcOptions(kconf.Grpc)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpcOpts...)
+	}
+
+	return opts, nil
+}
+
+func parseStatsLevel(s string) (stats.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "disabled":
+		return stats.LevelDisabled, nil
+	case "base":
+		return stats.LevelBase, nil
+	case "detailed":
+		return stats.LevelDetailed, nil
+	default:
+		return stats.LevelDisabled, fmt.Errorf("kitex StatsLevel invalid: %s", s)
+	}
+}
+
+// parseMetaHandlers 解析元信息透传协议，未配置时启用grpc则使用http2
+func parseMetaHandlers(s string, grpcEnable bool) ([]remote.MetaHandler, error) {
+	if strings.TrimSpace(s) == "" {
+		if grpcEnable {
+			return []remote.MetaHandler{transmeta.ServerHTTP2Handler}, nil
+		}
+		return nil, nil
+	}
+	handlers := make([]remote.MetaHandler, 0)
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		switch name {
+		case "ttheader":
+			handlers = append(handlers, transmeta.ServerTTHeaderHandler)
+		case "http2":
+			handlers = append(handlers, transmeta.ServerHTTP2Handler)
+		default:
+			return nil, fmt.Errorf("kitex MetaHandlers invalid: %s", name)
+		}
+	}
+	return handlers, nil
+}
+
+func buildCodecOption(conf Codec) (*server.Option, error) {
+	var codec thrift.CodecType
+	switch strings.ToLower(strings.TrimSpace(conf.Thrift)) {
+	case "":
+		if !conf.SkipDecoder {
+			return nil, nil
+		}
+		codec = thrift.FastReadWrite
+	case "basic":
+		codec = thrift.Basic
+	case "fast":
+		codec = thrift.FastReadWrite
+	case "frugal":
+		codec = thrift.FrugalReadWrite
+	default:
+		return nil, fmt.Errorf("kitex Codec.Thrift invalid: %s", conf.Thrift)
+	}
+	if conf.SkipDecoder {
+		codec |= thrift.EnableSkipDecoder
+	}
+	slog.Info("kitex server thrift codec", "thrift", conf.Thrift, "skipDecoder", conf.SkipDecoder)
+	opt := server.WithPayloadCodec(thrift.NewThriftCodecWithConfig(codec))
+	return &opt, nil
+}
+
+func buildGrpcOptions(conf Grpc) ([]server.Option, error) {
+	durations := map[string]int{
+		"MaxConnectionIdle":     conf.MaxConnectionIdle,
+		"MaxConnectionAge":      conf.MaxConnectionAge,
+		"MaxConnectionAgeGrace": conf.MaxConnectionAgeGrace,
+		"KeepaliveTime":         conf.KeepaliveTime,
+		"KeepaliveTimeout":      conf.KeepaliveTimeout,
+		"KeepaliveMinTime":      conf.KeepaliveMinTime,
+	}
+	for name, v := range durations {
+		if v < 0 {
+			return nil, fmt.Errorf("kitex Grpc.%s invalid: %d", name, v)
+		}
+	}
+
+	// keepalive配置，0保持kitex默认值
+	gskeep := grpc.ServerKeepalive{
+		MaxConnectionIdle:     time.Second * time.Duration(conf.MaxConnectionIdle),
+		MaxConnectionAge:      time.Second * time.Duration(conf.MaxConnectionAge),
+		MaxConnectionAgeGrace: time.Second * time.Duration(conf.MaxConnectionAgeGrace),
+		Time:                  time.Second * time.Duration(conf.KeepaliveTime),
+		Timeout:               time.Second * time.Duration(conf.KeepaliveTimeout),
+	}
+	opts := []server.Option{server.WithGRPCKeepaliveParams(gskeep)}
+
+	if conf.KeepaliveMinTime > 0 || conf.PermitWithoutStream {
+		opts = append(opts, server.WithGRPCKeepaliveEnforcementPolicy(grpc.EnforcementPolicy{
+			MinTime:             time.Second * time.Duration(conf.KeepaliveMinTime),
+			PermitWithoutStream: conf.PermitWithoutStream,
+		}))
+	}
+
+	// 流控窗口
+	for name, v := range map[string]int{
+		"InitialWindowSize":     conf.InitialWindowSize,
+		"InitialConnWindowSize": conf.InitialConnWindowSize,
+	} {
+		if v != 0 && (v < grpcMinWindowSize || v > math.MaxInt32) {
+			return nil, fmt.Errorf("kitex Grpc.%s invalid: %d, must be in [%d, %d]", name, v, grpcMinWindowSize, math.MaxInt32)
+		}
+	}
+	if conf.InitialWindowSize > 0 {
+		opts = append(opts, server.WithGRPCInitialWindowSize(uint32(conf.InitialWindowSize)))
+	}
+	if conf.InitialConnWindowSize > 0 {
+		opts = append(opts, server.WithGRPCInitialConnWindowSize(uint32(conf.InitialConnWindowSize)))
+	}
+
+	// 并发流、头部及读写缓冲
+	for name, v := range map[string]int{
+		"MaxConcurrentStreams": conf.MaxConcurrentStreams,
+		"MaxHeaderListSize":    conf.MaxHeaderListSize,
+		"ReadBufferSize":       conf.ReadBufferSize,
+		"WriteBufferSize":      conf.WriteBufferSize,
+	} {
+		if v < 0 || int64(v) > math.MaxUint32 {
+			return nil, fmt.Errorf("kitex Grpc.%s invalid: %d", name, v)
+		}
+	}
+	if conf.MaxConcurrentStreams > 0 {
+		opts = append(opts, server.WithGRPCMaxConcurrentStreams(uint32(conf.MaxConcurrentStreams)))
+	}
+	if conf.MaxHeaderListSize > 0 {
+		opts = append(opts, server.WithGRPCMaxHeaderListSize(uint32(conf.MaxHeaderListSize)))
+	}
+	if conf.ReadBufferSize > 0 {
+		opts = append(opts, server.WithGRPCReadBufferSize(uint32(conf.ReadBufferSize)))
+	}
+	if conf.WriteBufferSize > 0 {
+		opts = append(opts, server.WithGRPCWriteBufferSize(uint32(conf.WriteBufferSize)))
+	}
+
+	return opts, nil
+}
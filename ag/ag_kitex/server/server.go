@@ -252,17 +252,12 @@ func (builder *KitexSuiteBuilder) BuildSuite() (server.Suite, error) {
 	}
 	suite.Opts = append(suite.Opts, server.WithRegistryInfo(regInfo))
 
-	// Grpc配置
-	if kconf.Grpc.Enable {
-		gskeep := grpc.ServerKeepalive{}
-		if kconf.Grpc.MaxConnectionIdle > 0 {
-			// 最大空闲连接时间
-			gskeep.MaxConnectionIdle = time.Second * time.Duration(kconf.Grpc.MaxConnectionIdle)
-		}
-		suite.Opts = append(suite.Opts, server.WithGRPCKeepaliveParams(gskeep))
-
-		server.WithMetaHandler(transmeta.ClientHTTP2Handler)
+	// 超时、限流、传输、编解码及Grpc配置
+	tuningOpts, err := buildTuningOptions(kconf)
+	if err != nil {
+		return nil, err
 	}
+	suite.Opts = append(suite.Opts, tuningOpts...)
 
 	// options := []server.Option{
 	// 	server.WithServiceAddr(addr),
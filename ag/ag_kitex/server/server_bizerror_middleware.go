@@ -21,7 +21,9 @@ func serverErrMidleware(next endpoint.Endpoint) endpoint.Endpoint {
 				// 提取rpcinfo
 				ri := rpcinfo.GetRPCInfo(ctx)
 				if setter, ok := ri.Invocation().(rpcinfo.InvocationSetter); ok {
-					kbe := kerrors.NewBizStatusErrorWithExtra(abe.BizCode(), abe.BizMessage(), abe.BizExtra())
+					// TTHeader经biz-status等头传递；gRPC同时写入google.rpc.Status详情，供spring等gRPC对端解析
+					kbe := kerrors.NewGRPCBizStatusErrorWithExtra(abe.BizCode(), abe.BizMessage(), abe.BizExtra())
+					kbe.(kerrors.GRPCStatusIface).SetGRPCStatus(ag_error.ToGRPCStatus(abe))
 					setter.SetBizStatusErr(kbe)
 					return nil
 				}
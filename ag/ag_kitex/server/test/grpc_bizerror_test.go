@@ -0,0 +1,190 @@
+package kitex_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/frochyzhang/ag-core/ag/ag_error"
+	kitexclient "github.com/frochyzhang/ag-core/ag/ag_kitex/client"
+	kitex "github.com/frochyzhang/ag-core/ag/ag_kitex/server"
+
+	"github.com/cloudwego/kitex/client"
+	"github.com/cloudwego/kitex/pkg/serviceinfo"
+	"github.com/cloudwego/kitex/pkg/streaming"
+	"github.com/cloudwego/kitex/server"
+	"github.com/cloudwego/kitex/transport"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	gcodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	gstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// echoArgs/echoResult 等价于protoc-gen-go-grpc生成的参数及结果结构
+type echoArgs struct{ Req *wrapperspb.StringValue }
+
+func (p *echoArgs) Marshal(out []byte) ([]byte, error) { return proto.Marshal(p.Req) }
+func (p *echoArgs) Unmarshal(in []byte) error {
+	p.Req = new(wrapperspb.StringValue)
+	return proto.Unmarshal(in, p.Req)
+}
+func (p *echoArgs) GetFirstArgument() interface{} { return p.Req }
+
+type echoResult struct{ Success *wrapperspb.StringValue }
+
+func (p *echoResult) Marshal(out []byte) ([]byte, error) { return proto.Marshal(p.Success) }
+func (p *echoResult) Unmarshal(in []byte) error {
+	p.Success = new(wrapperspb.StringValue)
+	return proto.Unmarshal(in, p.Success)
+}
+func (p *echoResult) GetSuccess() *wrapperspb.StringValue { return p.Success }
+func (p *echoResult) SetSuccess(x interface{})            { p.Success = x.(*wrapperspb.StringValue) }
+func (p *echoResult) IsSetSuccess() bool                  { return p.Success != nil }
+func (p *echoResult) GetResult() interface{}              { return p.Success }
+
+type echoHandler func(ctx context.Context, req *wrapperspb.StringValue) (*wrapperspb.StringValue, error)
+
+func echoServiceInfo() *serviceinfo.ServiceInfo {
+	handler := func(ctx context.Context, h interface{}, arg, result interface{}) error {
+		switch s := arg.(type) {
+		case *streaming.Args:
+			req := new(wrapperspb.StringValue)
+			if err := s.Stream.RecvMsg(req); err != nil {
+				return err
+			}
+			resp, err := h.(echoHandler)(ctx, req)
+			if err != nil {
+				return err
+			}
+			return s.Stream.SendMsg(resp)
+		case *echoArgs:
+			resp, err := h.(echoHandler)(ctx, s.Req)
+			if err != nil {
+				return err
+			}
+			result.(*echoResult).Success = resp
+			return nil
+		default:
+			return errors.New("invalid message type")
+		}
+	}
+	return &serviceinfo.ServiceInfo{
+		ServiceName: "Echo",
+		HandlerType: (*echoHandler)(nil),
+		Methods: map[string]serviceinfo.MethodInfo{
+			"Echo": serviceinfo.NewMethodInfo(handler,
+				func() interface{} { return &echoArgs{} },
+				func() interface{} { return &echoResult{} },
+				false, serviceinfo.WithStreamingMode(serviceinfo.StreamingUnary)),
+		},
+		PayloadCodec: serviceinfo.Protobuf,
+		Extra:        map[string]interface{}{"PackageName": "test"},
+	}
+}
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func echoCall(t *testing.T, addr string) error {
+	t.Helper()
+	cli, err := client.NewClient(echoServiceInfo(),
+		client.WithDestService("echo"),
+		client.WithHostPorts(addr),
+		client.WithTransportProtocol(transport.GRPC),
+		*kitexclient.NewAgBizErrorMiddlewareOption(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return cli.Call(ctx, "Echo", &echoArgs{Req: wrapperspb.String("hi")}, &echoResult{})
+}
+
+func assertBizError(t *testing.T, err error, code int32, msg string, extra map[string]string) {
+	t.Helper()
+	var abe ag_error.BizStatusErrorIface
+	if !errors.As(err, &abe) {
+		t.Fatalf("err = %v, want biz error", err)
+	}
+	if abe.BizCode() != code || abe.BizMessage() != msg || !reflect.DeepEqual(abe.BizExtra(), extra) {
+		t.Fatalf("biz error = %d %q %v", abe.BizCode(), abe.BizMessage(), abe.BizExtra())
+	}
+}
+
+// TestGRPCBizErrorFromPeer 以grpc-go服务模拟spring-grpc对端，按 google.rpc.Status 返回业务异常
+func TestGRPCBizErrorFromPeer(t *testing.T) {
+	addr := freeAddr(t)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer := grpc.NewServer(grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+		method, _ := grpc.MethodFromServerStream(stream)
+		if method != "/test.Echo/Echo" {
+			return gstatus.Error(gcodes.Unimplemented, method)
+		}
+		if err := stream.RecvMsg(new(wrapperspb.StringValue)); err != nil {
+			return err
+		}
+		st, _ := gstatus.New(gcodes.FailedPrecondition, "balance not enough").WithDetails(&errdetails.ErrorInfo{
+			Reason:   "1001",
+			Domain:   "com.example.account",
+			Metadata: map[string]string{"orderId": "42"},
+		})
+		return st.Err()
+	}))
+	go peer.Serve(ln)
+	defer peer.Stop()
+
+	assertBizError(t, echoCall(t, addr), 1001, "balance not enough", map[string]string{"orderId": "42"})
+}
+
+// TestGRPCBizErrorToPeer kitex服务返回的业务异常可由grpc-go客户端(模拟spring-grpc对端)从状态详情中解析，
+// kitex客户端仍经biz-status头解析
+func TestGRPCBizErrorToPeer(t *testing.T) {
+	addr := freeAddr(t)
+	tcpAddr, _ := net.ResolveTCPAddr("tcp", addr)
+	svr := server.NewServer(server.WithServiceAddr(tcpAddr), *kitex.NewAgBizErrorMiddlewareOption())
+	extra := map[string]string{"orderId": "42"}
+	if err := svr.RegisterService(echoServiceInfo(), echoHandler(func(context.Context, *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+		return nil, ag_error.NewBizStatusError(2001, "order closed", extra)
+	})); err != nil {
+		t.Fatal(err)
+	}
+	go svr.Run()
+	defer svr.Stop()
+	time.Sleep(200 * time.Millisecond)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	err = conn.Invoke(ctx, "/test.Echo/Echo", wrapperspb.String("hi"), new(wrapperspb.StringValue))
+	st := gstatus.Convert(err)
+	if st.Code() != gcodes.Unknown || st.Message() != "order closed" || len(st.Details()) != 1 {
+		t.Fatalf("status = %v, details = %v", st, st.Details())
+	}
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	if !ok || info.Reason != "2001" || info.Domain != ag_error.BizErrorDomain || !reflect.DeepEqual(info.Metadata, extra) {
+		t.Fatalf("error info = %v", st.Details()[0])
+	}
+
+	assertBizError(t, echoCall(t, addr), 2001, "order closed", extra)
+}
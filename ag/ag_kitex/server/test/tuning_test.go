@@ -0,0 +1,93 @@
+package kitex_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/frochyzhang/ag-core/ag/ag_conf"
+	kitex "github.com/frochyzhang/ag-core/ag/ag_kitex/server"
+)
+
+func buildSuite(props map[string]any) (int, error) {
+	source := &ag_conf.MapPropertySource{Source: map[string]any{
+		"kitex.server.Host":        "0.0.0.0",
+		"kitex.server.Port":        "8080",
+		"kitex.server.ServiceName": "tuning",
+	}}
+	source.Name = "tuning"
+	for k, v := range props {
+		source.Source["kitex.server."+k] = fmt.Sprint(v)
+	}
+	env := ag_conf.NewStandardEnvironment()
+	env.GetPropertySources().AddFirst(source)
+
+	builder := &kitex.KitexSuiteBuilder{Env: env, Binder: ag_conf.NewConfigurationPropertiesBinder(env)}
+	suite, err := builder.BuildSuite()
+	if err != nil {
+		return 0, err
+	}
+	return len(suite.Options()), nil
+}
+
+func TestBuildSuiteTuning(t *testing.T) {
+	base, err := buildSuite(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := buildSuite(map[string]any{
+		"ExitWaitTime":               10,
+		"MaxConnIdleTime":            60,
+		"ReadWriteTimeout":           500,
+		"MaxConnections":             1000,
+		"MaxQPS":                     5000,
+		"StatsLevel":                 "base",
+		"MetaHandlers":               "ttheader, http2",
+		"Codec.Thrift":               "frugal",
+		"Grpc.Enable":                true,
+		"Grpc.MaxConnectionIdle":     50,
+		"Grpc.KeepaliveMinTime":      10,
+		"Grpc.PermitWithoutStream":   true,
+		"Grpc.InitialWindowSize":     1 << 20,
+		"Grpc.InitialConnWindowSize": 1 << 21,
+		"Grpc.MaxConcurrentStreams":  100,
+		"Grpc.MaxHeaderListSize":     8192,
+		"Grpc.ReadBufferSize":        32768,
+		"Grpc.WriteBufferSize":       32768,
+		"Grpc.KeepaliveTime":         7200,
+		"Grpc.KeepaliveTimeout":      20,
+		"Grpc.MaxConnectionAge":      3600,
+		"Grpc.MaxConnectionAgeGrace": 30,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 超时3项、限流、埋点、透传2项、编解码、keepalive、enforcement、窗口2项、流及缓冲4项
+	if want := base + 16; n != want {
+		t.Errorf("options = %d, want %d", n, want)
+	}
+
+	// 启用grpc且未配置透传协议时使用http2
+	n, err = buildSuite(map[string]any{"Grpc.Enable": true})
+	if err != nil || n != base+2 {
+		t.Errorf("grpc default options = %d, %v, want %d", n, err, base+2)
+	}
+}
+
+func TestBuildSuiteTuningInvalid(t *testing.T) {
+	for name, props := range map[string]map[string]any{
+		"negative timeout": {"ReadWriteTimeout": -1},
+		"negative limit":   {"MaxQPS": -1},
+		"mux with grpc":    {"MuxTransport": true, "Grpc.Enable": true},
+		"stats level":      {"StatsLevel": "verbose"},
+		"meta handler":     {"MetaHandlers": "ttheader,json"},
+		"thrift codec":     {"Codec.Thrift": "slow"},
+		"window size":      {"Grpc.Enable": true, "Grpc.InitialWindowSize": 1024},
+		"keepalive":        {"Grpc.Enable": true, "Grpc.KeepaliveTime": -5},
+	} {
+		if _, err := buildSuite(props); err == nil || !strings.Contains(err.Error(), "kitex") {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package ag_metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Kind 指标类型
+type Kind string
+
+const (
+	KindCounter   Kind = "counter"
+	KindGauge     Kind = "gauge"
+	KindHistogram Kind = "histogram"
+)
+
+// DefaultBuckets 默认耗时分桶(秒)
+var DefaultBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// labelSep 标签值拼接分隔符
+const labelSep = "\xff"
+
+// family 同名指标及其全部时间序列
+type family struct {
+	name    string
+	help    string
+	kind    Kind
+	labels  []string
+	buckets []float64
+
+	mu     sync.RWMutex
+	series map[string]*series
+}
+
+// series 一组标签值对应的时间序列
+type series struct {
+	values []string
+
+	value atomic.Uint64 // float64位模式，counter及gauge使用
+	fn    atomic.Pointer[func() float64]
+
+	// histogram使用
+	mu     sync.Mutex
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (f *family) with(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("ag_metrics %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, labelSep)
+	f.mu.RLock()
+	s, ok := f.series[key]
+	f.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok = f.series[key]; ok {
+		return s
+	}
+	s = &series{values: append([]string(nil), values...)}
+	if f.kind == KindHistogram {
+		s.counts = make([]uint64, len(f.buckets))
+	}
+	f.series[key] = s
+	return s
+}
+
+func (f *family) delete(values []string) {
+	f.mu.Lock()
+	delete(f.series, strings.Join(values, labelSep))
+	f.mu.Unlock()
+}
+
+// snapshot 按标签值排序返回全部时间序列
+func (f *family) snapshot() []*series {
+	f.mu.RLock()
+	list := make([]*series, 0, len(f.series))
+	for _, s := range f.series {
+		list = append(list, s)
+	}
+	f.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		return strings.Join(list[i].values, labelSep) < strings.Join(list[j].values, labelSep)
+	})
+	return list
+}
+
+func (s *series) add(v float64) {
+	for {
+		old := s.value.Load()
+		if s.value.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (s *series) load() float64 {
+	if fn := s.fn.Load(); fn != nil {
+		return (*fn)()
+	}
+	return math.Float64frombits(s.value.Load())
+}
+
+// CounterVec 只增计数器
+type CounterVec struct{ f *family }
+
+// Counter 一组标签值对应的计数器
+type Counter struct{ s *series }
+
+// With 获取标签值对应的计数器，标签值个数须与注册时一致
+func (v *CounterVec) With(values ...string) *Counter {
+	return &Counter{s: v.f.with(values)}
+}
+
+// Inc 加1
+func (c *Counter) Inc() { c.s.add(1) }
+
+// Add 增加v，v须非负
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		panic("ag_metrics counter cannot decrease")
+	}
+	c.s.add(v)
+}
+
+// Value 当前值
+func (c *Counter) Value() float64 { return c.s.load() }
+
+// GaugeVec 可增可减的仪表
+type GaugeVec struct{ f *family }
+
+// Gauge 一组标签值对应的仪表
+type Gauge struct{ s *series }
+
+// With 获取标签值对应的仪表
+func (v *GaugeVec) With(values ...string) *Gauge {
+	return &Gauge{s: v.f.with(values)}
+}
+
+// SetFunc 标签值对应的仪表在采集时取fn的返回值，用于队列长度等由外部维护的值
+func (v *GaugeVec) SetFunc(fn func() float64, values ...string) {
+	v.f.with(values).fn.Store(&fn)
+}
+
+// Delete 删除标签值对应的时间序列
+func (v *GaugeVec) Delete(values ...string) {
+	v.f.delete(values)
+}
+
+// Set 设置为v
+func (g *Gauge) Set(v float64) { g.s.value.Store(math.Float64bits(v)) }
+
+// Add 增加v，v可为负数
+func (g *Gauge) Add(v float64) { g.s.add(v) }
+
+// Inc 加1
+func (g *Gauge) Inc() { g.s.add(1) }
+
+// Dec 减1
+func (g *Gauge) Dec() { g.s.add(-1) }
+
+// Value 当前值
+func (g *Gauge) Value() float64 { return g.s.load() }
+
+// HistogramVec 分桶直方图
+type HistogramVec struct{ f *family }
+
+// Histogram 一组标签值对应的直方图
+type Histogram struct {
+	s       *series
+	buckets []float64
+}
+
+// With 获取标签值对应的直方图
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return &Histogram{s: v.f.with(values), buckets: v.f.buckets}
+}
+
+// Observe 记录一次观测值
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+	h.s.mu.Lock()
+	if i < len(h.s.counts) {
+		h.s.counts[i]++
+	}
+	h.s.sum += v
+	h.s.count++
+	h.s.mu.Unlock()
+}
+
+// Count 观测次数
+func (h *Histogram) Count() uint64 {
+	h.s.mu.Lock()
+	defer h.s.mu.Unlock()
+	return h.s.count
+}
@@ -0,0 +1,68 @@
+package ag_metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.Counter("requests_total", "请求数", "method")
+	requests.With("get").Add(2)
+	requests.With("post").Inc()
+	depth := r.Gauge("queue_depth", "队列长度", "queue")
+	depth.With("a\"b").Set(3)
+	depth.SetFunc(func() float64 { return 7 }, "fn")
+	latency := r.Histogram("latency_seconds", "耗时", []float64{0.1, 1})
+	latency.With().Observe(0.05)
+	latency.With().Observe(0.5)
+	latency.With().Observe(5)
+
+	var buf strings.Builder
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP latency_seconds 耗时
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1
+latency_seconds_bucket{le="1"} 2
+latency_seconds_bucket{le="+Inf"} 3
+latency_seconds_sum 5.55
+latency_seconds_count 3
+# HELP queue_depth 队列长度
+# TYPE queue_depth gauge
+queue_depth{queue="a\"b"} 3
+queue_depth{queue="fn"} 7
+# HELP requests_total 请求数
+# TYPE requests_total counter
+requests_total{method="get"} 2
+requests_total{method="post"} 1
+`
+	if buf.String() != want {
+		t.Fatalf("WriteText =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	depth.Delete("fn")
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if strings.Contains(rec.Body.String(), `queue="fn"`) {
+		t.Fatalf("deleted series exported:\n%s", rec.Body.String())
+	}
+}
+
+func TestRegisterConflict(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("hits_total", "命中数", "cache")
+	c.With("a").Inc()
+	// 同名同类型重复注册共享时间序列
+	if v := r.Counter("hits_total", "命中数", "cache").With("a").Value(); v != 1 {
+		t.Fatalf("shared counter = %v", v)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("conflicting registration did not panic")
+		}
+	}()
+	r.Gauge("hits_total", "命中数", "cache")
+}
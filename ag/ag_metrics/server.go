@@ -0,0 +1,71 @@
+package ag_metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// MetricsPropertiesPrefix 指标导出配置前缀
+const MetricsPropertiesPrefix = "metrics"
+
+// MetricsProperties 指标导出配置，启用时在独立端口以Prometheus文本格式导出
+type MetricsProperties struct {
+	Enable bool   `value:"${enable:false}"`
+	Host   string `value:"${host:0.0.0.0}"`
+	Port   int    `value:"${port:9091}"`
+	Path   string `value:"${path:/metrics}"`
+}
+
+// Server 指标导出服务
+type Server struct {
+	registry *Registry
+	conf     MetricsProperties
+	httpSrv  *http.Server
+	logger   *slog.Logger
+}
+
+// NewServer 创建指标导出服务，未启用时启停均为空操作
+func NewServer(registry *Registry, conf MetricsProperties, logger *slog.Logger) *Server {
+	return &Server{registry: registry, conf: conf, logger: logger}
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	if !s.conf.Enable {
+		return nil
+	}
+	path := s.conf.Path
+	if path == "" {
+		path = "/metrics"
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, s.registry.Handler())
+
+	addr := fmt.Sprintf("%s:%d", s.conf.Host, s.conf.Port)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.httpSrv = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	s.logger.Info("metrics server start", "addr", fmt.Sprintf("http://%s%s", addr, path))
+	go func() {
+		if err := s.httpSrv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("metrics server stopped", "error", err)
+		}
+	}()
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	if s.httpSrv == nil {
+		return nil
+	}
+	s.logger.Info("metrics server shutdown")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.httpSrv.Shutdown(ctx)
+}
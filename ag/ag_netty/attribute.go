@@ -0,0 +1,50 @@
+package ag_netty
+
+import "sync"
+
+// AttributeMap 通道属性存储，供同一通道上的处理器共享会话状态，并发安全
+type AttributeMap struct {
+	m sync.Map
+}
+
+// Get 获取属性
+func (a *AttributeMap) Get(key string) (any, bool) {
+	return a.m.Load(key)
+}
+
+// Set 设置属性
+func (a *AttributeMap) Set(key string, value any) {
+	a.m.Store(key, value)
+}
+
+// SetIfAbsent 属性不存在时设置，返回当前值及属性是否已存在
+func (a *AttributeMap) SetIfAbsent(key string, value any) (actual any, loaded bool) {
+	return a.m.LoadOrStore(key, value)
+}
+
+// CompareAndSwap 属性当前值等于old时替换为new
+func (a *AttributeMap) CompareAndSwap(key string, old, new any) bool {
+	return a.m.CompareAndSwap(key, old, new)
+}
+
+// Delete 删除属性并返回原值
+func (a *AttributeMap) Delete(key string) (any, bool) {
+	return a.m.LoadAndDelete(key)
+}
+
+// Range 遍历属性，f返回false时停止
+func (a *AttributeMap) Range(f func(key string, value any) bool) {
+	a.m.Range(func(k, v any) bool {
+		return f(k.(string), v)
+	})
+}
+
+// AttrOf 按类型获取属性，属性不存在或类型不匹配时ok为false
+func AttrOf[T any](a *AttributeMap, key string) (value T, ok bool) {
+	v, exists := a.Get(key)
+	if !exists {
+		return value, false
+	}
+	value, ok = v.(T)
+	return value, ok
+}
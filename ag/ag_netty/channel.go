@@ -1,67 +1,351 @@
 package ag_netty
 
 import (
+	"crypto/tls"
+	"errors"
 	"github.com/cloudwego/netpoll"
 	"io"
 	"net"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// transport 通道底层连接，运行时为netpoll连接，EmbeddedChannel中为内存连接
+type transport interface {
+	Write(b []byte) (n int, err error)
+	Close() error
+	RemoteAddr() net.Addr
+	LocalAddr() net.Addr
+}
+
+// channelIDSeq 通道ID序列，进程内唯一
+var channelIDSeq atomic.Uint64
+
 // Channel 网络通道
 type Channel struct {
-	conn      netpoll.Connection
+	id        uint64
+	conn      transport
 	looper    EventLooper // 使用接口类型
 	Pipeline  *Pipeline
-	active    bool
+	active    atomic.Bool
 	closeOnce sync.Once
+	closed    chan struct{}
 	future    *Future
+	tlsConn   *tls.Conn // 启用TLS时所有读写经由该连接
+	tlsRaw    *tlsTransport
+	tlsReadMu sync.Mutex // 串行解密，保证读事件按记录顺序投递
+	attrs     AttributeMap
+	metrics   *Metrics // 运行指标，未启用时为nil
+
+	closeMu        sync.Mutex
+	closeListeners []*closeListener
+
+	// 写缓冲水位线：待写出字节数超过高水位时通道不可写，回落到低水位以下时恢复可写
+	pendingBytes  atomic.Int64
+	highWatermark int64
+	lowWatermark  int64
+	unwritable    atomic.Bool
+	writableMu    sync.Mutex
+	polling       atomic.Bool // 不可写期间是否已调度可写性检查
+
+	// 暂停读取：不可写期间读到的数据暂存于此，恢复可写后按序投递
+	suspendRead  bool
+	readMu       sync.Mutex
+	heldReads    [][]byte
+	readDispatch func(task func())
+	draining     bool
 }
 
 // NewChannel 创建新通道
 func NewChannel(conn netpoll.Connection, looper EventLooper) *Channel {
+	return newChannel(conn, looper)
+}
+
+func newChannel(conn transport, looper EventLooper) *Channel {
 	ch := &Channel{
+		id:     channelIDSeq.Add(1),
 		conn:   conn,
 		looper: looper,
-		active: true,
+		closed: make(chan struct{}),
 	}
+	ch.active.Store(true)
 	ch.Pipeline = NewPipeline(ch)
 	return ch
 }
 
+// TLSConnectionState 获取TLS连接状态(对端证书、协商的协议版本等)，未启用TLS时ok为false
+func (c *Channel) TLSConnectionState() (state tls.ConnectionState, ok bool) {
+	if c.tlsConn == nil {
+		return state, false
+	}
+	return c.tlsConn.ConnectionState(), true
+}
+
+// SetWriteBufferWatermark 设置写缓冲高低水位线(字节)，high<=0时不启用
+func (c *Channel) SetWriteBufferWatermark(low, high int) {
+	if low > high {
+		low = high
+	}
+	c.lowWatermark = int64(low)
+	c.highWatermark = int64(high)
+}
+
+// IsWritable 待写出字节数未超过高水位线时可写，处理器可据此决定是否继续写入
+func (c *Channel) IsWritable() bool {
+	return !c.unwritable.Load()
+}
+
+// PendingWriteBytes 已提交但尚未写入套接字的字节数
+func (c *Channel) PendingWriteBytes() int64 {
+	return c.pendingBytes.Load()
+}
+
+// WriteBacklog 待写出字节数：已提交未写入套接字的字节数与套接字发送队列中对端尚未确认的字节数之和
+// 套接字发送队列仅在Linux上统计
+func (c *Channel) WriteBacklog() int64 {
+	return c.pendingBytes.Load() + int64(socketSendQueue(c.conn))
+}
+
+// writabilityPollInterval 不可写期间重新检查写缓冲的间隔，套接字发送队列回落时没有事件通知
+const writabilityPollInterval = 10 * time.Millisecond
+
+// incPending 增加待写字节数，超过高水位时切换为不可写
+func (c *Channel) incPending(n int) {
+	c.pendingBytes.Add(int64(n))
+	c.updateWritability()
+}
+
+// decPending 减少待写字节数，回落到低水位时恢复可写
+func (c *Channel) decPending(n int) {
+	c.pendingBytes.Add(-int64(n))
+	c.updateWritability()
+}
+
+// updateWritability 按当前写缓冲切换可写状态并触发可写性变化事件，不可写期间定时重新检查
+func (c *Channel) updateWritability() {
+	if c.highWatermark <= 0 {
+		return
+	}
+	backlog := c.WriteBacklog()
+	c.writableMu.Lock()
+	var changed bool
+	switch unwritable := c.unwritable.Load(); {
+	case !unwritable && backlog > c.highWatermark:
+		c.unwritable.Store(true)
+		changed = true
+	case unwritable && backlog <= c.lowWatermark:
+		c.unwritable.Store(false)
+		changed = true
+	}
+	writable := !c.unwritable.Load()
+	c.writableMu.Unlock()
+
+	if !writable && c.IsActive() && c.polling.CompareAndSwap(false, true) {
+		c.looper.Schedule(writabilityPollInterval, func() {
+			c.polling.Store(false)
+			c.updateWritability()
+		})
+	}
+	if !changed {
+		return
+	}
+	c.Pipeline.FireWritabilityChanged(writable)
+	if writable {
+		c.resumeReads()
+	}
+}
+
+// deliverRead 通过dispatch投递读事件，启用暂停读取且通道不可写时暂存数据，恢复可写后按序投递
+// netpoll要求读回调读走全部数据，因此暂停读取期间数据仍被读出，只是不再进入流水线
+func (c *Channel) deliverRead(data []byte, dispatch func(task func())) {
+	if c.suspendRead {
+		c.readMu.Lock()
+		if len(c.heldReads) > 0 || c.draining || !c.IsWritable() {
+			c.heldReads = append(c.heldReads, data)
+			c.readDispatch = dispatch
+			c.readMu.Unlock()
+			return
+		}
+		c.readMu.Unlock()
+	}
+	dispatch(func() {
+		c.Pipeline.FireRead(data)
+	})
+}
+
+// resumeReads 按序投递暂停读取期间暂存的数据，期间再次不可写时停止
+func (c *Channel) resumeReads() {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	if c.draining {
+		return
+	}
+	c.draining = true
+	for len(c.heldReads) > 0 && c.IsWritable() && c.IsActive() {
+		data, dispatch := c.heldReads[0], c.readDispatch
+		c.heldReads[0] = nil
+		c.heldReads = c.heldReads[1:]
+		c.readMu.Unlock()
+		dispatch(func() {
+			c.Pipeline.FireRead(data)
+		})
+		c.readMu.Lock()
+	}
+	if !c.IsActive() {
+		c.heldReads = nil
+	}
+	c.draining = false
+}
+
+// ID 通道ID，进程内唯一
+func (c *Channel) ID() uint64 {
+	return c.id
+}
+
+// Attrs 通道属性
+func (c *Channel) Attrs() *AttributeMap {
+	return &c.attrs
+}
+
+// EventLoop 通道所属的事件循环，处理器可通过其Schedule执行定时任务
+func (c *Channel) EventLoop() EventLooper {
+	return c.looper
+}
+
 func (c *Channel) Future() *Future {
 	return c.future
 }
 
 // Write 写数据
 func (c *Channel) Write(data []byte) {
-	c.looper.Post(func() {
-		if c.active {
-			c.Pipeline.FireWrite(data)
+	c.write(data, nil)
+}
+
+// WriteAndFlush 写数据，返回的Promise在处理器经HandlerContext.WriteDirect写出到连接后成功，
+// 通道关闭、任务被拒绝、写出失败或到达流水线头部时仍未写出则失败；写出前取消Promise可放弃本次写入
+func (c *Channel) WriteAndFlush(data []byte) *Promise[struct{}] {
+	op := &writeOp{promise: NewPromise[struct{}]()}
+	c.write(data, op)
+	return op.promise
+}
+
+// write 经事件循环写数据，op非空时跟踪写出结果
+func (c *Channel) write(data []byte, op *writeOp) {
+	c.incPending(len(data))
+	c.post(func() {
+		defer c.decPending(len(data))
+		if op != nil && op.promise.IsDone() {
+			return
 		}
+		if !c.active.Load() {
+			op.finishWith(io.ErrClosedPipe)
+			return
+		}
+		c.Pipeline.fireWrite(data, op)
+	}, func(err error) {
+		c.decPending(len(data))
+		op.finishWith(err)
 	})
 }
 
+// ErrNotWritten 写操作到达流水线头部时没有处理器经HandlerContext.WriteDirect写出数据
+var ErrNotWritten = errors.New("ag_netty: write reached pipeline head without being written")
+
+// writeOp 一次经流水线的写操作，随处理器上下文传递，到达流水线头部时按WriteDirect的结果完成：
+// 有写出失败时以首个错误失败，没有写出时以 ErrNotWritten 失败
+type writeOp struct {
+	promise *Promise[struct{}]
+	mu      sync.Mutex
+	err     error
+	written bool
+}
+
+// record 记录一次写出的结果，op为nil时忽略
+func (op *writeOp) record(err error) {
+	if op == nil {
+		return
+	}
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	if err == nil {
+		op.written = true
+	} else if op.err == nil {
+		op.err = err
+	}
+}
+
+func (op *writeOp) finish() {
+	op.mu.Lock()
+	err := op.err
+	if err == nil && !op.written {
+		err = ErrNotWritten
+	}
+	op.mu.Unlock()
+	if err != nil {
+		op.promise.Failure(err)
+		return
+	}
+	op.promise.Success(struct{}{})
+}
+
+// finishWith 以错误完成，op为nil时忽略
+func (op *writeOp) finishWith(err error) {
+	if op == nil {
+		return
+	}
+	op.record(err)
+	op.finish()
+}
+
+// taskExecutor 可返回拒绝原因的事件循环
+type taskExecutor interface {
+	Execute(task func()) error
+}
+
+// post 投递任务到事件循环，任务被拒绝时回调onReject
+func (c *Channel) post(task func(), onReject func(err error)) {
+	if te, ok := c.looper.(taskExecutor); ok {
+		if err := te.Execute(task); err != nil {
+			onReject(err)
+		}
+		return
+	}
+	c.looper.Post(task)
+}
+
 // WriteDirect 直接写数据（无流水线处理）
+// 结果不记入写操作，处理器在HandleWrite中应调用 HandlerContext.WriteDirect
 func (c *Channel) WriteDirect(data []byte) error {
-	if !c.active {
+	return c.writeDirect(data)
+}
+
+func (c *Channel) writeDirect(data []byte) error {
+	if !c.active.Load() {
 		return io.ErrClosedPipe
 	}
-	_, err := c.conn.Write(data)
+	var n int
+	var err error
+	if c.tlsConn != nil {
+		n, err = c.tlsConn.Write(data)
+	} else {
+		n, err = c.conn.Write(data)
+	}
+	c.metrics.bytesWritten(n)
 	return err
 }
 
-// WriteAsync 异步写数据
+// WriteAsync 异步写数据，返回等待应答的Future，由应答处理器通过Channel.Future()完成
+// 写入失败时以该错误作为Future的结果完成
 func (c *Channel) WriteAsync(data []byte) *Future {
 	future := NewFuture()
-	c.looper.Post(func() {
-		if c.active {
-			c.Pipeline.FireWrite(data)
-			//future.Complete(nil)
-		} else {
-			future.Complete(io.ErrClosedPipe)
+	c.future = future
+	c.WriteAndFlush(data).AddListener(func(_ struct{}, err error) {
+		if err != nil {
+			future.Complete(err)
 		}
 	})
-	c.future = future
 	return future
 }
 
@@ -69,15 +353,51 @@ func (c *Channel) WriteAsync(data []byte) *Future {
 func (c *Channel) Close() {
 	c.closeOnce.Do(func() {
 		//c.looper.Post(func() {
-		if c.active {
-			c.active = false
+		if c.active.CompareAndSwap(true, false) {
+			close(c.closed)
 			c.conn.Close()
 			c.Pipeline.FireInactive()
+
+			c.closeMu.Lock()
+			listeners := c.closeListeners
+			c.closeListeners = nil
+			c.closeMu.Unlock()
+			for _, l := range listeners {
+				l.f()
+			}
 		}
 		//})
 	})
 }
 
+// closeListener 通道关闭回调，指针作为注销时的句柄
+type closeListener struct {
+	f func()
+}
+
+// addCloseListener 注册通道关闭回调，通道已关闭时立即执行，返回用于注销的句柄
+func (c *Channel) addCloseListener(f func()) *closeListener {
+	l := &closeListener{f: f}
+	c.closeMu.Lock()
+	if c.active.Load() {
+		c.closeListeners = append(c.closeListeners, l)
+		c.closeMu.Unlock()
+		return l
+	}
+	c.closeMu.Unlock()
+	f()
+	return l
+}
+
+// removeCloseListener 注销关闭回调，回调已执行或已注销时忽略
+func (c *Channel) removeCloseListener(l *closeListener) {
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+	if i := slices.Index(c.closeListeners, l); i >= 0 {
+		c.closeListeners = slices.Delete(c.closeListeners, i, i+1)
+	}
+}
+
 // RemoteAddr 获取远程地址
 func (c *Channel) RemoteAddr() net.Addr {
 	return c.conn.RemoteAddr()
@@ -90,5 +410,5 @@ func (c *Channel) LocalAddr() net.Addr {
 
 // IsActive 检查通道是否活跃
 func (c *Channel) IsActive() bool {
-	return c.active
+	return c.active.Load()
 }
@@ -0,0 +1,270 @@
+package ag_netty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ChannelMatcher 通道过滤条件
+type ChannelMatcher func(ch *Channel) bool
+
+// MatchAttr 按通道属性过滤，例如已登录的终端号
+// 属性值或value不可比较(如切片、map)时不匹配，需按内容匹配时使用 MatchAttrFunc
+func MatchAttr(key string, value any) ChannelMatcher {
+	return func(ch *Channel) bool {
+		v, ok := ch.Attrs().Get(key)
+		return ok && isComparable(v) && isComparable(value) && v == value
+	}
+}
+
+// MatchAttrFunc 按通道属性过滤，属性值为T类型且满足match时匹配
+func MatchAttrFunc[T any](key string, match func(v T) bool) ChannelMatcher {
+	return func(ch *Channel) bool {
+		v, ok := AttrOf[T](ch.Attrs(), key)
+		return ok && match(v)
+	}
+}
+
+// isComparable 判断v能否以==比较而不panic，nil可比较
+func isComparable(v any) bool {
+	return v == nil || reflect.ValueOf(v).Comparable()
+}
+
+// ChannelGroup 活跃通道集合，用于按ID或属性查找通道及广播推送
+// 通道关闭时自动从集合中移除
+type ChannelGroup struct {
+	name     string
+	channels sync.Map // id -> *groupMember
+}
+
+// groupMember 集合中的通道及其关闭回调句柄，移除时注销回调
+type groupMember struct {
+	ch      *Channel
+	onClose *closeListener
+}
+
+// NewChannelGroup 创建通道集合
+func NewChannelGroup(name string) *ChannelGroup {
+	return &ChannelGroup{name: name}
+}
+
+// Name 集合名称
+func (g *ChannelGroup) Name() string {
+	return g.name
+}
+
+// Add 加入通道，通道已在集合中时返回false
+func (g *ChannelGroup) Add(ch *Channel) bool {
+	m := &groupMember{ch: ch}
+	m.onClose = ch.addCloseListener(func() {
+		g.channels.CompareAndDelete(ch.ID(), m)
+	})
+	if _, loaded := g.channels.LoadOrStore(ch.ID(), m); loaded {
+		ch.removeCloseListener(m.onClose)
+		return false
+	}
+	// 通道在加入前已关闭时关闭回调已执行，需在此移除
+	if !ch.IsActive() {
+		g.channels.CompareAndDelete(ch.ID(), m)
+	}
+	return true
+}
+
+// Remove 移除通道并注销其关闭回调，不关闭通道
+func (g *ChannelGroup) Remove(ch *Channel) bool {
+	v, ok := g.channels.Load(ch.ID())
+	if !ok {
+		return false
+	}
+	m := v.(*groupMember)
+	if m.ch != ch || !g.channels.CompareAndDelete(ch.ID(), m) {
+		return false
+	}
+	ch.removeCloseListener(m.onClose)
+	return true
+}
+
+// Find 按ID查找通道，不存在时返回nil
+func (g *ChannelGroup) Find(id uint64) *Channel {
+	if v, ok := g.channels.Load(id); ok {
+		return v.(*groupMember).ch
+	}
+	return nil
+}
+
+// FindByAttr 按属性查找通道
+func (g *ChannelGroup) FindByAttr(key string, value any) []*Channel {
+	return g.Filter(MatchAttr(key, value))
+}
+
+// Filter 返回满足条件的通道，matcher为nil时返回全部
+func (g *ChannelGroup) Filter(matcher ChannelMatcher) []*Channel {
+	chs := make([]*Channel, 0)
+	g.channels.Range(func(_, v any) bool {
+		ch := v.(*groupMember).ch
+		if matcher == nil || matcher(ch) {
+			chs = append(chs, ch)
+		}
+		return true
+	})
+	return chs
+}
+
+// Size 集合内通道数
+func (g *ChannelGroup) Size() int {
+	n := 0
+	g.channels.Range(func(_, _ any) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// WriteAndFlush 向集合内全部通道写数据
+func (g *ChannelGroup) WriteAndFlush(data []byte) *GroupFuture {
+	return g.WriteAndFlushMatched(data, nil)
+}
+
+// WriteAndFlushMatched 向满足条件的通道写数据，返回汇总各通道写结果的future
+func (g *ChannelGroup) WriteAndFlushMatched(data []byte, matcher ChannelMatcher) *GroupFuture {
+	chs := g.Filter(matcher)
+	f := newGroupFuture(len(chs))
+	for _, ch := range chs {
+		ch := ch
+		ch.WriteAndFlush(data).AddListener(func(_ struct{}, err error) {
+			f.complete(ch, err)
+		})
+	}
+	return f
+}
+
+// Close 关闭集合内全部通道
+func (g *ChannelGroup) Close() {
+	g.CloseMatched(nil)
+}
+
+// CloseMatched 关闭满足条件的通道
+func (g *ChannelGroup) CloseMatched(matcher ChannelMatcher) {
+	for _, ch := range g.Filter(matcher) {
+		ch.Close()
+	}
+}
+
+// ChannelGroupHandler 通道激活时加入集合的处理器，通道关闭时由集合自动移除
+type ChannelGroupHandler struct {
+	group *ChannelGroup
+}
+
+// NewChannelGroupHandler 创建通道集合处理器
+func NewChannelGroupHandler(group *ChannelGroup) *ChannelGroupHandler {
+	return &ChannelGroupHandler{group: group}
+}
+
+func (h *ChannelGroupHandler) HandleActive(ctx *HandlerContext) {
+	h.group.Add(ctx.Channel())
+}
+
+func (h *ChannelGroupHandler) HandleInactive(ctx *HandlerContext) {
+	h.group.Remove(ctx.Channel())
+}
+
+func (h *ChannelGroupHandler) HandleRead(ctx *HandlerContext, data []byte)  {}
+func (h *ChannelGroupHandler) HandleWrite(ctx *HandlerContext, data []byte) {}
+func (h *ChannelGroupHandler) HandleError(ctx *HandlerContext, err error)   {}
+
+// GroupFuture 广播写结果，全部通道写完成(写出或失败)后完成
+type GroupFuture struct {
+	done    chan struct{}
+	mu      sync.Mutex
+	pending int
+	total   int
+	failed  map[*Channel]error
+}
+
+func newGroupFuture(total int) *GroupFuture {
+	f := &GroupFuture{
+		done:    make(chan struct{}),
+		pending: total,
+		total:   total,
+		failed:  make(map[*Channel]error),
+	}
+	if total == 0 {
+		close(f.done)
+	}
+	return f
+}
+
+func (f *GroupFuture) complete(ch *Channel, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err != nil {
+		f.failed[ch] = err
+	}
+	f.pending--
+	if f.pending == 0 {
+		close(f.done)
+	}
+}
+
+// Done 全部通道写完成时关闭
+func (f *GroupFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Await 等待全部通道写完成，存在失败时返回汇总错误
+func (f *GroupFuture) Await(ctx context.Context) error {
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.failed) == 0 {
+		return nil
+	}
+	errs := make([]error, 0, len(f.failed))
+	for ch, err := range f.failed {
+		errs = append(errs, fmt.Errorf("channel %d: %w", ch.ID(), err))
+	}
+	return errors.Join(errs...)
+}
+
+// AwaitTimeout 带超时等待全部通道写完成
+func (f *GroupFuture) AwaitTimeout(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return f.Await(ctx)
+}
+
+// Total 参与写入的通道数
+func (f *GroupFuture) Total() int {
+	return f.total
+}
+
+// Failures 写入失败的通道及原因
+func (f *GroupFuture) Failures() map[*Channel]error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	failed := make(map[*Channel]error, len(f.failed))
+	for ch, err := range f.failed {
+		failed[ch] = err
+	}
+	return failed
+}
+
+// IsSuccess 全部通道写入成功
+func (f *GroupFuture) IsSuccess() bool {
+	select {
+	case <-f.done:
+	default:
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.failed) == 0
+}
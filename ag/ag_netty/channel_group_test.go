@@ -0,0 +1,207 @@
+package ag_netty
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestChannelGroup(t *testing.T) {
+	group := NewChannelGroup("test")
+	a := NewEmbeddedChannel(NewChannelGroupHandler(group), &EchoHandler{})
+	b := NewEmbeddedChannel(NewChannelGroupHandler(group), &EchoHandler{})
+	c := NewEmbeddedChannel(NewChannelGroupHandler(group), &EchoHandler{})
+
+	if group.Size() != 3 {
+		t.Fatalf("Size() = %d, want 3", group.Size())
+	}
+	if group.Find(b.ID()) != b.Channel {
+		t.Error("Find() mismatch")
+	}
+	a.Attrs().Set("terminal", "T-1")
+	if chs := group.FindByAttr("terminal", "T-1"); len(chs) != 1 || chs[0] != a.Channel {
+		t.Errorf("FindByAttr() = %v", chs)
+	}
+
+	// 不可比较的属性值不匹配且不panic，按内容匹配使用MatchAttrFunc
+	b.Attrs().Set("tags", []string{"vip"})
+	if chs := group.FindByAttr("tags", []string{"vip"}); len(chs) != 0 {
+		t.Errorf("FindByAttr() with slice = %v", chs)
+	}
+	if chs := group.Filter(MatchAttrFunc("tags", func(tags []string) bool { return slices.Contains(tags, "vip") })); len(chs) != 1 || chs[0] != b.Channel {
+		t.Errorf("Filter(MatchAttrFunc) = %v", chs)
+	}
+
+	// 按属性推送
+	f := group.WriteAndFlushMatched([]byte("only-a"), MatchAttr("terminal", "T-1"))
+	a.RunPendingTasks()
+	if err := f.AwaitTimeout(time.Second); err != nil || f.Total() != 1 {
+		t.Fatalf("WriteAndFlushMatched() = %v, total %d", err, f.Total())
+	}
+	if got := string(a.ReadOutbound()); got != "only-a" {
+		t.Errorf("a outbound = %q", got)
+	}
+	if b.ReadOutbound() != nil || c.ReadOutbound() != nil {
+		t.Error("unmatched channels received data")
+	}
+
+	// 写任务执行前通道关闭，汇总结果包含失败通道
+	f = group.WriteAndFlush([]byte("all"))
+	b.Close()
+	a.RunPendingTasks()
+	b.RunPendingTasks()
+	c.RunPendingTasks()
+	err := f.AwaitTimeout(time.Second)
+	if !errors.Is(err, io.ErrClosedPipe) || f.IsSuccess() {
+		t.Fatalf("WriteAndFlush() = %v, want ErrClosedPipe", err)
+	}
+	if failed := f.Failures(); len(failed) != 1 || failed[b.Channel] == nil {
+		t.Errorf("Failures() = %v", failed)
+	}
+	if string(a.ReadOutbound()) != "all" || string(c.ReadOutbound()) != "all" {
+		t.Error("active channels did not receive broadcast")
+	}
+
+	// 关闭的通道自动移除
+	if group.Size() != 2 || group.Find(b.ID()) != nil {
+		t.Fatalf("Size() after close = %d", group.Size())
+	}
+
+	group.Close()
+	if group.Size() != 0 || a.IsActive() || c.IsActive() {
+		t.Fatalf("Close() left size=%d", group.Size())
+	}
+	if err := group.WriteAndFlush([]byte("none")).AwaitTimeout(time.Second); err != nil {
+		t.Errorf("WriteAndFlush() on empty group = %v", err)
+	}
+}
+
+func TestChannelGroupRemoveListener(t *testing.T) {
+	group := NewChannelGroup("test")
+	ch := NewEmbeddedChannel()
+	listeners := func() int {
+		ch.closeMu.Lock()
+		defer ch.closeMu.Unlock()
+		return len(ch.closeListeners)
+	}
+	base := listeners()
+
+	// 反复加入及移除不累积关闭回调
+	for i := 0; i < 10; i++ {
+		if !group.Add(ch.Channel) || group.Add(ch.Channel) {
+			t.Fatal("Add() mismatch")
+		}
+		if !group.Remove(ch.Channel) || group.Remove(ch.Channel) {
+			t.Fatal("Remove() mismatch")
+		}
+	}
+	if n := listeners(); n != base {
+		t.Fatalf("close listeners = %d, want %d", n, base)
+	}
+
+	// 已关闭的通道加入后立即移除
+	ch.Close()
+	group.Add(ch.Channel)
+	if group.Size() != 0 {
+		t.Fatalf("Size() after adding closed channel = %d", group.Size())
+	}
+}
+
+func TestServerChannelGroupBroadcast(t *testing.T) {
+	s, err := NewServer("127.0.0.1:0", func(ch *Channel) {
+		ch.Pipeline.AddLast("echo", &EchoHandler{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Start()
+	defer s.Shutdown()
+
+	addr := s.listener.Addr().String()
+	handlers := make([]*tlsRecvHandler, 2)
+	for i := range handlers {
+		h := newTLSRecvHandler()
+		handlers[i] = h
+		looper := NewClientEventLoop(func(ch *Channel) {
+			ch.Pipeline.AddLast("recv", h)
+		})
+		defer looper.Shutdown()
+		ch, err := Dial(addr, time.Second, time.Second, time.Second, time.Minute, looper)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ch.Close()
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for s.ChannelGroup().Size() != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("ChannelGroup().Size() = %d, want 2", s.ChannelGroup().Size())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := s.ChannelGroup().WriteAndFlush([]byte("push")).AwaitTimeout(3 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	for i, h := range handlers {
+		select {
+		case data := <-h.recv:
+			if string(data) != "push" {
+				t.Errorf("client %d received %q", i, data)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("client %d did not receive push", i)
+		}
+	}
+}
+
+// errorRecorder 记录错误事件
+type errorRecorder struct {
+	EchoHandler
+	errs chan error
+}
+
+func (h *errorRecorder) HandleRead(ctx *HandlerContext, data []byte) {}
+
+func (h *errorRecorder) HandleError(ctx *HandlerContext, err error) {
+	h.errs <- err
+}
+
+func TestServerChannelGroupErrors(t *testing.T) {
+	rec := &errorRecorder{errs: make(chan error, 1)}
+	s, err := NewServer("127.0.0.1:0", func(ch *Channel) {
+		ch.Pipeline.AddLast("recorder", rec)
+		ch.Pipeline.AddLast("frame", NewFrameHandler(NewLengthFieldFramer(2, true), func(ctx *HandlerContext, frame []byte) {}))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Start()
+	defer s.Shutdown()
+
+	looper := NewClientEventLoop(func(ch *Channel) {
+		ch.Pipeline.AddLast("echo", &EchoHandler{})
+	})
+	defer looper.Shutdown()
+	ch, err := Dial(s.listener.Addr().String(), time.Second, time.Second, time.Second, time.Minute, looper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ch.Close()
+
+	// 帧解码错误送达业务处理器，而不是被通道集合处理器截获
+	if _, err := ch.WriteAndFlush([]byte("xx")).Get(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-rec.errs:
+		if err == nil {
+			t.Fatal("nil error event")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("business handler received no error event")
+	}
+}
@@ -0,0 +1,114 @@
+package ag_netty
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// writabilityRecorder 记录可写性变化及读到的数据
+type writabilityRecorder struct {
+	EchoHandler
+	mu      sync.Mutex
+	changes []bool
+	reads   []string
+}
+
+func (h *writabilityRecorder) HandleRead(ctx *HandlerContext, data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.reads = append(h.reads, string(data))
+}
+
+func (h *writabilityRecorder) HandleWritabilityChanged(ctx *HandlerContext, writable bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.changes = append(h.changes, writable)
+}
+
+func (h *writabilityRecorder) snapshot() ([]bool, []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]bool(nil), h.changes...), append([]string(nil), h.reads...)
+}
+
+func TestWriteBufferWatermark(t *testing.T) {
+	h := &writabilityRecorder{}
+	ch := NewEmbeddedChannel(h)
+	ch.SetWriteBufferWatermark(4, 8)
+
+	// 待写字节数超过高水位时不可写，写出后回落到低水位恢复可写
+	ch.Write([]byte("12345"))
+	if !ch.IsWritable() {
+		t.Fatal("unwritable below high watermark")
+	}
+	ch.Write([]byte("6789"))
+	if ch.IsWritable() || ch.PendingWriteBytes() != 9 {
+		t.Fatalf("writable = %v, pending = %d", ch.IsWritable(), ch.PendingWriteBytes())
+	}
+	// 不可写期间定时重新检查，套接字发送队列回落时没有事件通知
+	if n := ch.Loop().ScheduledTasks(); n != 1 {
+		t.Fatalf("scheduled checks = %d", n)
+	}
+	ch.RunPendingTasks()
+	if !ch.IsWritable() || ch.PendingWriteBytes() != 0 {
+		t.Fatalf("writable = %v, pending = %d", ch.IsWritable(), ch.PendingWriteBytes())
+	}
+	if got := string(ch.DrainOutbound()); got != "123456789" {
+		t.Fatalf("outbound = %q", got)
+	}
+	if changes, _ := h.snapshot(); !reflect.DeepEqual(changes, []bool{false, true}) {
+		t.Fatalf("changes = %v", changes)
+	}
+	// 恢复可写后的检查任务不再重新调度
+	ch.AdvanceTime(writabilityPollInterval)
+	if n := ch.Loop().ScheduledTasks(); n != 0 {
+		t.Fatalf("scheduled checks after writable = %d", n)
+	}
+
+	// 未设置水位线时始终可写
+	plain := NewEmbeddedChannel()
+	plain.Write(make([]byte, 1<<20))
+	if !plain.IsWritable() {
+		t.Fatal("unwritable without watermark")
+	}
+}
+
+func TestSuspendReadOnUnwritable(t *testing.T) {
+	h := &writabilityRecorder{}
+	ch := NewEmbeddedChannel(h)
+	ch.SetWriteBufferWatermark(0, 1)
+	ch.suspendRead = true
+
+	ch.WriteInbound([]byte("a"))
+	ch.Write([]byte("xy"))
+	if ch.IsWritable() {
+		t.Fatal("writable above high watermark")
+	}
+
+	// 不可写期间读到的数据暂存，不进入流水线
+	dispatch := func(task func()) { task() }
+	ch.deliverRead([]byte("b"), dispatch)
+	ch.deliverRead([]byte("c"), dispatch)
+	if _, reads := h.snapshot(); !reflect.DeepEqual(reads, []string{"a"}) {
+		t.Fatalf("reads while unwritable = %v", reads)
+	}
+
+	// 恢复可写后按序投递，之后的数据排在暂存数据之后
+	ch.RunPendingTasks()
+	ch.WriteInbound([]byte("d"))
+	if _, reads := h.snapshot(); !reflect.DeepEqual(reads, []string{"a", "b", "c", "d"}) {
+		t.Fatalf("reads after writable = %v", reads)
+	}
+	if changes, _ := h.snapshot(); !reflect.DeepEqual(changes, []bool{false, true}) {
+		t.Fatalf("changes = %v", changes)
+	}
+
+	// 未启用暂停读取时不可写也照常投递
+	ch.suspendRead = false
+	ch.Write([]byte("xy"))
+	ch.WriteInbound([]byte("e"))
+	if _, reads := h.snapshot(); len(reads) != 5 {
+		t.Fatalf("reads without suspend = %v", reads)
+	}
+}
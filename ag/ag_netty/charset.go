@@ -0,0 +1,69 @@
+package ag_netty
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// Charset 报文字符集，程序内部统一使用UTF-8
+type Charset string
+
+const (
+	CharsetUTF8    Charset = "utf-8"
+	CharsetGBK     Charset = "gbk"
+	CharsetGB18030 Charset = "gb18030"
+)
+
+// ParseCharset 按名称解析字符集，不区分大小写，空串为UTF-8
+func ParseCharset(name string) (Charset, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "utf-8", "utf8":
+		return CharsetUTF8, nil
+	case "gbk", "cp936":
+		return CharsetGBK, nil
+	case "gb18030":
+		return CharsetGB18030, nil
+	default:
+		return "", fmt.Errorf("ag_netty: unsupported charset %q", name)
+	}
+}
+
+// Encode UTF-8文本转为字符集编码，目标字符集无法表示的字符返回错误
+func (c Charset) Encode(s string) ([]byte, error) {
+	enc := c.encoding()
+	if enc == nil {
+		return []byte(s), nil
+	}
+	b, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		return nil, fmt.Errorf("ag_netty: encode %s: %w", c, err)
+	}
+	return b, nil
+}
+
+// Decode 字符集编码转为UTF-8文本，非法字节替换为U+FFFD
+func (c Charset) Decode(b []byte) (string, error) {
+	enc := c.encoding()
+	if enc == nil {
+		return string(b), nil
+	}
+	s, err := enc.NewDecoder().Bytes(b)
+	if err != nil {
+		return "", fmt.Errorf("ag_netty: decode %s: %w", c, err)
+	}
+	return string(s), nil
+}
+
+func (c Charset) encoding() encoding.Encoding {
+	switch c {
+	case CharsetGBK:
+		return simplifiedchinese.GBK
+	case CharsetGB18030:
+		return simplifiedchinese.GB18030
+	default:
+		return nil
+	}
+}
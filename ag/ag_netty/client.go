@@ -15,6 +15,7 @@ type Client struct {
 	readTimeout    time.Duration
 	writeTimeout   time.Duration
 	idleTimeout    time.Duration
+	loopOptions    []LoopOption
 	mu             sync.Mutex
 }
 
@@ -26,6 +27,7 @@ func NewClient(
 	writeTimeout time.Duration,
 	idleTimeout time.Duration,
 	initFunc func(ch *Channel),
+	loopOptions ...LoopOption,
 ) *Client {
 	return &Client{
 		addr:           addr,
@@ -34,6 +36,7 @@ func NewClient(
 		writeTimeout:   writeTimeout,
 		idleTimeout:    idleTimeout,
 		initFunc:       initFunc,
+		loopOptions:    loopOptions,
 	}
 }
 
@@ -47,7 +50,7 @@ func (c *Client) Connect() error {
 	}
 
 	// 创建事件循环
-	eventLoop := NewClientEventLoop(c.initFunc)
+	eventLoop := NewClientEventLoop(c.initFunc, c.loopOptions...)
 	c.eventLoop = eventLoop
 
 	// 建立连接
@@ -1,7 +1,10 @@
 package client
 
 import (
+	"context"
 	"fmt"
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/loadbalance"
 	"github.com/frochyzhang/ag-core/ag/ag_netty"
 	"log/slog"
 )
@@ -11,10 +14,19 @@ type Client struct {
 	props    NettyClientProperties
 	handlers []ag_netty.ChannelHandler
 	logger   *slog.Logger
+
+	certReloader *ag_netty.CertReloader
+	metrics      *ag_netty.Metrics
+
+	// 服务发现：配置service-name及resolver时按服务名解析实例，不再使用固定addr
+	resolver discovery.Resolver
+	balancer loadbalance.Loadbalancer
+	pool     *instancePool
 }
 
 type Option struct {
-	opt func(client *Client)
+	opt  func(client *Client)
+	echo bool // 回显应答处理器选项，见 NettyOptionSuite.WithoutEcho
 }
 
 func WithProps(props NettyClientProperties) Option {
@@ -32,6 +44,33 @@ func AppendHandler(ch ag_netty.ChannelHandler) Option {
 	}
 }
 
+// WithResolver 设置服务发现解析器，配合service-name使用
+func WithResolver(resolver discovery.Resolver) Option {
+	return Option{
+		opt: func(c *Client) {
+			c.resolver = resolver
+		},
+	}
+}
+
+// WithLoadbalancer 设置负载均衡器，优先于load-balance配置
+func WithLoadbalancer(lb loadbalance.Loadbalancer) Option {
+	return Option{
+		opt: func(c *Client) {
+			c.balancer = lb
+		},
+	}
+}
+
+// WithMetrics 采集客户端运行指标
+func WithMetrics(m *ag_netty.Metrics) Option {
+	return Option{
+		opt: func(c *Client) {
+			c.metrics = m
+		},
+	}
+}
+
 func newClient(logger *slog.Logger, opts ...Option) *Client {
 	c := &Client{
 		handlers: make([]ag_netty.ChannelHandler, 0),
@@ -51,18 +90,137 @@ func newClient(logger *slog.Logger, opts ...Option) *Client {
 		}
 	}
 
-	client := ag_netty.NewClient(
-		c.props.Addr,
-		ag_netty.ToTimeoutDuration(c.props.ConnectTimeout),
-		ag_netty.ToTimeoutDuration(c.props.ReadTimeout),
-		ag_netty.ToTimeoutDuration(c.props.WriteTimeout),
-		ag_netty.ToTimeoutDuration(c.props.IdleTimeout),
-		initFunc,
-	)
-	c.Client = client
+	rejectPolicy, err := ag_netty.ParseRejectPolicy(c.props.RejectPolicy)
+	if err != nil {
+		logger.Warn("ag_netty client reject policy invalid, fallback to block", "error", err)
+		rejectPolicy = ag_netty.RejectBlock
+	}
+
+	network, err := ag_netty.ParseNetwork(c.props.Network)
+	if err != nil {
+		panic(err)
+	}
+
+	loopOpts := []ag_netty.LoopOption{
+		ag_netty.WithDialNetwork(network),
+		ag_netty.WithTaskQueueSize(c.props.TaskQueueSize),
+		ag_netty.WithRejectPolicy(rejectPolicy),
+		ag_netty.WithWriteBufferWatermark(c.props.WriteBufferLowWatermark, c.props.WriteBufferHighWatermark),
+		ag_netty.WithSuspendReadOnUnwritable(c.props.SuspendReadOnUnwritable),
+		ag_netty.WithMetrics(c.metrics),
+	}
+
+	if c.props.TLS.Enable {
+		tlsConfig, reloader, err := c.props.TLS.BuildClientConfig()
+		// TLS配置错误时不能降级为明文连接
+		if err != nil {
+			panic(fmt.Errorf("ag_netty client tls config error: %w", err))
+		}
+		c.certReloader = reloader
+		loopOpts = append(loopOpts, ag_netty.WithTLS(tlsConfig, ag_netty.ToTimeoutDuration(c.props.TLS.HandshakeTimeout)))
+	}
+
+	dial := func(addr string) *ag_netty.Client {
+		return ag_netty.NewClient(
+			addr,
+			ag_netty.ToTimeoutDuration(c.props.ConnectTimeout),
+			ag_netty.ToTimeoutDuration(c.props.ReadTimeout),
+			ag_netty.ToTimeoutDuration(c.props.WriteTimeout),
+			ag_netty.ToTimeoutDuration(c.props.IdleTimeout),
+			initFunc,
+			loopOpts...,
+		)
+	}
+
+	if c.props.ServiceName != "" {
+		// 配置了服务名却无解析器时不能静默改用addr
+		if c.resolver == nil {
+			panic(fmt.Errorf("ag_netty client service-name %s requires a resolver", c.props.ServiceName))
+		}
+		if c.balancer == nil {
+			lb, err := NewLoadbalancer(c.props.LoadBalance)
+			if err != nil {
+				panic(err)
+			}
+			c.balancer = lb
+		}
+		logger.Info("ag_netty client enable service discovery",
+			"service", c.props.ServiceName,
+			"resolver", c.resolver.Name(),
+			"loadBalance", c.balancer.Name())
+		c.pool = newInstancePool(c.props.ServiceName, c.resolver, c.balancer,
+			ag_netty.ToTimeoutDuration(c.props.RefreshInterval), dial)
+		return c
+	}
+
+	c.Client = dial(c.props.Addr)
 	return c
 }
 
+// Connect 连接服务端，启用服务发现时解析实例并启动实例变化跟踪，连接在发送时按实例建立
+func (c *Client) Connect() error {
+	if c.pool != nil {
+		return c.pool.start()
+	}
+	return c.Client.Connect()
+}
+
+// Channel 获取通道，启用服务发现时经负载均衡选取实例，无可用实例时返回nil
+func (c *Client) Channel() *ag_netty.Channel {
+	if c.pool != nil {
+		pc, err := c.pick()
+		if err != nil {
+			c.logger.Error("ag_netty client pick instance failed", "service", c.props.ServiceName, "error", err)
+			return nil
+		}
+		return pc.Channel()
+	}
+	return c.Client.Channel()
+}
+
+func (c *Client) Send(data []byte) {
+	if c.pool != nil {
+		if ch := c.Channel(); ch != nil {
+			ch.Write(data)
+		}
+		return
+	}
+	c.Client.Send(data)
+}
+
+func (c *Client) SendAndGet(data []byte) (any, error) {
+	if c.pool != nil {
+		pc, err := c.pick()
+		if err != nil {
+			return nil, err
+		}
+		// 通道只记录一个等待中的Future，应答返回前独占连接，避免并发请求互相覆盖
+		pc.mu.Lock()
+		defer pc.mu.Unlock()
+		return pc.Channel().WriteAsync(data).GetWithTimeout(ag_netty.ToTimeoutDuration(c.props.ReadTimeout))
+	}
+	return c.Client.SendAndGet(data)
+}
+
+func (c *Client) pick() (*pooledConn, error) {
+	if err := c.pool.start(); err != nil {
+		return nil, err
+	}
+	return c.pool.channel(context.Background())
+}
+
+// Close 关闭客户端并停止证书热加载
+func (c *Client) Close() {
+	if c.pool != nil {
+		c.pool.close()
+	} else {
+		c.Client.Close()
+	}
+	if c.certReloader != nil {
+		c.certReloader.Stop()
+	}
+}
+
 type NettyOptionSuite struct {
 	Opts []Option
 }
@@ -76,6 +234,24 @@ func NewNettyClientWithSuite(
 	return newClient(logger, suite.options()...)
 }
 
+// WithoutEcho 返回去掉 WithEchoHandler 选项的套件，用于在基础配置上创建自行编解码应答的客户端(如RPC客户端)
+func (s *NettyOptionSuite) WithoutEcho() *NettyOptionSuite {
+	opts := make([]Option, 0, len(s.Opts))
+	for _, o := range s.Opts {
+		if !o.echo {
+			opts = append(opts, o)
+		}
+	}
+	return &NettyOptionSuite{Opts: opts}
+}
+
+// WithEchoHandler 追加回显应答处理器，读到的数据原样作为SendAndGet的应答
+func WithEchoHandler() Option {
+	o := AppendHandler(&EchoHandler{EchoHandler: &ag_netty.EchoHandler{}})
+	o.echo = true
+	return o
+}
+
 // EchoHandler 回显处理器(复用服务器端实现)
 type EchoHandler struct {
 	*ag_netty.EchoHandler
@@ -83,5 +259,8 @@ type EchoHandler struct {
 
 func (h *EchoHandler) HandleRead(ctx *ag_netty.HandlerContext, data []byte) {
 	slog.Info("Received response", "data", string(data))
-	ctx.Channel().Future().Complete(string(data))
+	// 未经SendAndGet发送的请求没有等待中的Future，应答被丢弃
+	if f := ctx.Channel().Future(); f != nil {
+		f.Complete(string(data))
+	}
 }
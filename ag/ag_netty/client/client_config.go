@@ -1,13 +1,36 @@
 package client
 
+import "github.com/frochyzhang/ag-core/ag/ag_netty"
+
 const (
 	NettyClientPropertiesPrefix = "netty.client"
 )
 
 type NettyClientProperties struct {
-	Addr           string `value:"${addr}"`
+	// Network 传输协议：tcp|udp|unix，unix协议的addr为套接字文件路径
+	Network        string `value:"${network:tcp}"`
+	Addr           string `value:"${addr:}"`
 	ConnectTimeout int    `value:"${connect-timeout:50}"`
 	ReadTimeout    int    `value:"${read-timeout:200}"`
 	WriteTimeout   int    `value:"${write-timeout:200}"`
 	IdleTimeout    int    `value:"${idle-timeout:10000}"`
+	TaskQueueSize  int    `value:"${task-queue-size:1024}"`
+	RejectPolicy   string `value:"${reject-policy:block}"`
+
+	// 写缓冲水位线(字节)，默认不启用，见服务端同名配置
+	WriteBufferHighWatermark int  `value:"${write-buffer-high-watermark:0}"`
+	WriteBufferLowWatermark  int  `value:"${write-buffer-low-watermark:0}"`
+	SuspendReadOnUnwritable  bool `value:"${suspend-read-on-unwritable:false}"`
+
+	// 服务发现：配置service-name且存在nacos naming client时按服务名解析实例，addr不再生效
+	ServiceName string `value:"${service-name:}"`
+	Cluster     string `value:"${cluster:DEFAULT}"`
+	Group       string `value:"${group:DEFAULT_GROUP}"`
+	// LoadBalance 负载均衡：weighted-round-robin|interleaved-weighted-round-robin|weighted-random
+	LoadBalance string `value:"${load-balance:weighted-round-robin}"`
+	// RefreshInterval 实例列表刷新间隔(毫秒)
+	RefreshInterval int `value:"${refresh-interval:5000}"`
+
+	// TLS/mTLS配置，见 ag_netty.TLSConfig
+	TLS ag_netty.TLSConfig `value:"${tls}"`
 }
@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/loadbalance"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/frochyzhang/ag-core/ag/ag_ext"
+	"github.com/frochyzhang/ag-core/ag/ag_netty"
+)
+
+var (
+	// ErrNoInstance 服务无可用实例
+	ErrNoInstance = errors.New("ag_netty: no instance available")
+)
+
+// NewLoadbalancer 按名称创建负载均衡器：weighted-round-robin|interleaved-weighted-round-robin|weighted-random
+func NewLoadbalancer(name string) (loadbalance.Loadbalancer, error) {
+	switch name {
+	case "", "weighted-round-robin":
+		return loadbalance.NewWeightedRoundRobinBalancer(), nil
+	case "interleaved-weighted-round-robin":
+		return loadbalance.NewInterleavedWeightedRoundRobinBalancer(), nil
+	case "weighted-random":
+		return loadbalance.NewWeightedRandomBalancer(), nil
+	default:
+		return nil, fmt.Errorf("ag_netty unknown load-balance: %s", name)
+	}
+}
+
+// instancePool 按服务名解析实例并维护到各实例的连接
+// 与kitex的服务发现模型一致：定时Resolve，经Diff得到实例变化，变化时更新负载均衡器并关闭已下线实例的连接
+type instancePool struct {
+	serviceName string
+	resolver    discovery.Resolver
+	balancer    loadbalance.Loadbalancer
+	dial        func(addr string) *ag_netty.Client
+	interval    time.Duration
+
+	mu      sync.RWMutex
+	desc    string
+	result  discovery.Result
+	picker  loadbalance.Picker
+	clients map[string]*pooledConn
+
+	quit      chan struct{}
+	unwatch   func()
+	startOnce sync.Once
+	closeOnce sync.Once
+}
+
+func newInstancePool(
+	serviceName string,
+	resolver discovery.Resolver,
+	balancer loadbalance.Loadbalancer,
+	interval time.Duration,
+	dial func(addr string) *ag_netty.Client,
+) *instancePool {
+	return &instancePool{
+		serviceName: serviceName,
+		resolver:    resolver,
+		balancer:    balancer,
+		dial:        dial,
+		interval:    interval,
+		clients:     make(map[string]*pooledConn),
+		quit:        make(chan struct{}),
+	}
+}
+
+// start 启动实例变化跟踪并在尚未得到实例列表时解析实例；
+// 首次解析失败(如nacos不可用)时跟踪照常启动，之后每次调用在得到实例列表前重新解析
+func (p *instancePool) start() error {
+	p.startOnce.Do(func() {
+		p.desc = p.resolver.Target(context.Background(), rpcinfo.NewEndpointInfo(p.serviceName, "", nil, nil))
+		// resolver支持推送时实例变化立即刷新，定时刷新仍作为兜底
+		if notifier, ok := p.resolver.(ag_ext.ChangeNotifier); ok {
+			p.unwatch = notifier.Watch(func(change discovery.Change) {
+				if change.Result.CacheKey != p.desc {
+					return
+				}
+				if err := p.refresh(); err != nil {
+					slog.Warn("ag_netty client resolve failed", "service", p.serviceName, "error", err)
+				}
+			})
+		}
+		if p.interval > 0 {
+			go p.watch()
+		}
+	})
+
+	p.mu.RLock()
+	resolved := p.picker != nil
+	p.mu.RUnlock()
+	if resolved {
+		return nil
+	}
+	return p.refresh()
+}
+
+func (p *instancePool) watch() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// 解析失败时保留上次的实例列表
+			if err := p.refresh(); err != nil {
+				slog.Warn("ag_netty client resolve failed", "service", p.serviceName, "error", err)
+			}
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// refresh 解析实例，实例有变化时更新负载均衡器并关闭已下线实例的连接
+func (p *instancePool) refresh() error {
+	next, err := p.resolver.Resolve(context.Background(), p.desc)
+	if err != nil {
+		return err
+	}
+	if next.CacheKey == "" {
+		next.CacheKey = p.desc
+	}
+
+	p.mu.Lock()
+	change, changed := p.resolver.Diff(next.CacheKey, p.result, next)
+	if !changed && p.picker != nil {
+		p.mu.Unlock()
+		return nil
+	}
+	if rb, ok := p.balancer.(loadbalance.Rebalancer); ok && p.picker != nil {
+		rb.Rebalance(change)
+	}
+	p.result = next
+	p.picker = p.balancer.GetPicker(next)
+
+	removed := make([]*pooledConn, 0, len(change.Removed))
+	for _, inst := range change.Removed {
+		addr := inst.Address().String()
+		if c, ok := p.clients[addr]; ok {
+			removed = append(removed, c)
+			delete(p.clients, addr)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, c := range removed {
+		c.Close()
+	}
+	if changed {
+		slog.Info("ag_netty client instances changed",
+			"service", p.serviceName,
+			"instances", len(next.Instances),
+			"added", len(change.Added),
+			"removed", len(change.Removed))
+	}
+	return nil
+}
+
+// pooledConn 到实例的连接，mu保证SendAndGet独占连接，使应答与请求一一对应
+type pooledConn struct {
+	*ag_netty.Client
+	mu sync.Mutex
+}
+
+// channel 经负载均衡选取实例并返回可用连接，连接失败时依次尝试其他实例
+func (p *instancePool) channel(ctx context.Context) (*pooledConn, error) {
+	p.mu.RLock()
+	picker := p.picker
+	n := len(p.result.Instances)
+	p.mu.RUnlock()
+
+	if picker == nil || n == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoInstance, p.serviceName)
+	}
+
+	var lastErr error
+	for i := 0; i < n; i++ {
+		inst := picker.Next(ctx, nil)
+		if inst == nil {
+			break
+		}
+		pc, err := p.connect(inst.Address().String())
+		if err == nil {
+			return pc, nil
+		}
+		slog.Warn("ag_netty client connect instance failed", "service", p.serviceName, "addr", inst.Address(), "error", err)
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("%w: %s", ErrNoInstance, p.serviceName)
+	}
+	return nil, lastErr
+}
+
+// connect 复用到实例的活跃连接，连接不存在或已断开时在锁外重新建立
+func (p *instancePool) connect(addr string) (*pooledConn, error) {
+	p.mu.RLock()
+	pc, ok := p.clients[addr]
+	p.mu.RUnlock()
+	if ok && pc.active() {
+		return pc, nil
+	}
+
+	c := p.dial(addr)
+	if err := c.Connect(); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	// 建连期间其他调用已建立活跃连接时使用已有连接
+	if cur, ok := p.clients[addr]; ok && cur.active() {
+		p.mu.Unlock()
+		c.Close()
+		return cur, nil
+	}
+	stale := p.clients[addr]
+	pc = &pooledConn{Client: c}
+	p.clients[addr] = pc
+	p.mu.Unlock()
+
+	if stale != nil {
+		stale.Close()
+	}
+	return pc, nil
+}
+
+func (pc *pooledConn) active() bool {
+	ch := pc.Channel()
+	return ch != nil && ch.IsActive()
+}
+
+// close 停止刷新并关闭所有连接
+func (p *instancePool) close() {
+	p.closeOnce.Do(func() {
+		close(p.quit)
+		if p.unwatch != nil {
+			p.unwatch()
+		}
+		p.mu.Lock()
+		clients := p.clients
+		p.clients = make(map[string]*pooledConn)
+		p.mu.Unlock()
+		for _, c := range clients {
+			c.Close()
+		}
+	})
+}
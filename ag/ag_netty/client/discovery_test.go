@@ -0,0 +1,278 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/frochyzhang/ag-core/ag/ag_netty"
+)
+
+// fakeResolver 可动态修改实例列表的解析器
+type fakeResolver struct {
+	mu        sync.Mutex
+	instances []discovery.Instance
+	err       error
+}
+
+func (r *fakeResolver) fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+func (r *fakeResolver) set(addrs ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.instances = r.instances[:0]
+	for _, addr := range addrs {
+		r.instances = append(r.instances, discovery.NewInstance("tcp", addr, 1, nil))
+	}
+}
+
+func (r *fakeResolver) Target(_ context.Context, target rpcinfo.EndpointInfo) string {
+	return target.ServiceName()
+}
+
+func (r *fakeResolver) Resolve(_ context.Context, desc string) (discovery.Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return discovery.Result{}, r.err
+	}
+	instances := make([]discovery.Instance, len(r.instances))
+	copy(instances, r.instances)
+	return discovery.Result{Cacheable: true, CacheKey: desc, Instances: instances}, nil
+}
+
+func (r *fakeResolver) Diff(cacheKey string, prev, next discovery.Result) (discovery.Change, bool) {
+	return discovery.DefaultDiff(cacheKey, prev, next)
+}
+
+func (r *fakeResolver) Name() string { return "fake" }
+
+// idHandler 服务端处理器，收到数据后回复服务端标识
+type idHandler struct {
+	ag_netty.EchoHandler
+	id string
+}
+
+func (h *idHandler) HandleRead(ctx *ag_netty.HandlerContext, data []byte) {
+	ctx.Write([]byte(h.id))
+}
+
+// recvHandler 客户端接收处理器
+type recvHandler struct {
+	ag_netty.EchoHandler
+	recv chan string
+}
+
+func (h *recvHandler) HandleRead(ctx *ag_netty.HandlerContext, data []byte) {
+	h.recv <- string(data)
+}
+
+func startIDServer(t *testing.T, id string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	s, err := ag_netty.NewServer(addr, func(ch *ag_netty.Channel) {
+		ch.Pipeline.AddLast("id", &idHandler{id: id})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Start()
+	t.Cleanup(s.Shutdown)
+	return addr
+}
+
+func TestClientDiscovery(t *testing.T) {
+	addrA := startIDServer(t, "A")
+	addrB := startIDServer(t, "B")
+
+	resolver := &fakeResolver{}
+	resolver.set(addrA, addrB)
+
+	h := &recvHandler{recv: make(chan string, 16)}
+	c := newClient(slog.Default(),
+		WithProps(NettyClientProperties{
+			ServiceName:     "netty-echo",
+			ConnectTimeout:  1000,
+			ReadTimeout:     1000,
+			WriteTimeout:    1000,
+			IdleTimeout:     60000,
+			TaskQueueSize:   1024,
+			RefreshInterval: 20,
+		}),
+		WithResolver(resolver),
+		AppendHandler(h),
+	)
+	defer c.Close()
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	// sendN 发送n次并统计各服务端的应答次数
+	sendN := func(n int) map[string]int {
+		got := make(map[string]int)
+		for i := 0; i < n; i++ {
+			c.Send([]byte("ping"))
+			select {
+			case id := <-h.recv:
+				got[id]++
+			case <-time.After(3 * time.Second):
+				t.Fatal("response timeout")
+			}
+		}
+		return got
+	}
+
+	if got := sendN(4); got["A"] != 2 || got["B"] != 2 {
+		t.Fatalf("round robin responses = %v, want A:2 B:2", got)
+	}
+
+	// 实例B下线后不再被选中
+	resolver.set(addrA)
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		c.pool.mu.RLock()
+		n := len(c.pool.result.Instances)
+		c.pool.mu.RUnlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("instance change not applied")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := sendN(3); got["A"] != 3 {
+		t.Fatalf("responses after removal = %v, want A:3", got)
+	}
+
+	// 解析结果为空时返回ErrNoInstance
+	resolver.set()
+	time.Sleep(100 * time.Millisecond)
+	if _, err := c.SendAndGet([]byte("ping")); !errors.Is(err, ErrNoInstance) {
+		t.Fatalf("SendAndGet() with no instance = %v, want ErrNoInstance", err)
+	}
+}
+
+func TestClientDiscoveryFirstResolveFailed(t *testing.T) {
+	addr := startIDServer(t, "A")
+	resolver := &fakeResolver{}
+	resolver.set(addr)
+	resolver.fail(errors.New("nacos unavailable"))
+
+	h := &recvHandler{recv: make(chan string, 16)}
+	c := newClient(slog.Default(),
+		WithProps(NettyClientProperties{
+			ServiceName:    "netty-echo",
+			ConnectTimeout: 1000,
+			ReadTimeout:    1000,
+			WriteTimeout:   1000,
+			IdleTimeout:    60000,
+			TaskQueueSize:  1024,
+			// 不定时刷新，验证发送时重新解析
+			RefreshInterval: 0,
+		}),
+		WithResolver(resolver),
+		AppendHandler(h),
+	)
+	defer c.Close()
+	if err := c.Connect(); err == nil {
+		t.Fatal("Connect() with failing resolver succeeded")
+	}
+	if _, err := c.SendAndGet([]byte("ping")); err == nil {
+		t.Fatal("SendAndGet() with failing resolver succeeded")
+	}
+
+	// 解析恢复后发送时重新解析实例
+	resolver.fail(nil)
+	c.Send([]byte("ping"))
+	select {
+	case id := <-h.recv:
+		if id != "A" {
+			t.Fatalf("response = %s", id)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("response timeout after resolver recovered")
+	}
+}
+
+func TestClientServiceNameWithoutResolver(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("newClient() with service-name and no resolver did not panic")
+		}
+	}()
+	newClient(slog.Default(), WithProps(NettyClientProperties{ServiceName: "netty-echo", Addr: "127.0.0.1:1"}))
+}
+
+// slowEchoHandler 服务端处理器，延迟后回显，使并发请求的应答时间交错
+type slowEchoHandler struct {
+	ag_netty.EchoHandler
+}
+
+func (h *slowEchoHandler) HandleRead(ctx *ag_netty.HandlerContext, data []byte) {
+	time.Sleep(20 * time.Millisecond)
+	ctx.Write(data)
+}
+
+func TestClientDiscoveryConcurrentSendAndGet(t *testing.T) {
+	s, err := ag_netty.NewServer("127.0.0.1:0", func(ch *ag_netty.Channel) {
+		ch.Pipeline.AddLast("echo", &slowEchoHandler{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Start()
+	defer s.Shutdown()
+
+	resolver := &fakeResolver{}
+	resolver.set(s.Addr().String())
+	c := newClient(slog.Default(),
+		WithProps(NettyClientProperties{
+			ServiceName:    "netty-echo",
+			ConnectTimeout: 1000,
+			ReadTimeout:    3000,
+			WriteTimeout:   1000,
+			IdleTimeout:    60000,
+			TaskQueueSize:  1024,
+		}),
+		WithResolver(resolver),
+		WithEchoHandler(),
+	)
+	defer c.Close()
+
+	// 共享连接上的并发请求各自得到自己的应答
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(req string) {
+			defer wg.Done()
+			got, err := c.SendAndGet([]byte(req))
+			if err != nil || got != req {
+				errs <- fmt.Errorf("SendAndGet(%s) = %v, %v", req, got, err)
+			}
+		}(strconv.Itoa(i))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -1,41 +1,48 @@
 package ag_netty
 
 import (
+	"log/slog"
 	"sync"
 	"time"
 )
 
 // ClientEventLoop 客户端事件循环
 type ClientEventLoop struct {
-	taskQueue chan func()
-	quit      chan struct{}
-	wg        sync.WaitGroup
-	initFunc  func(ch *Channel)
+	executor *EventExecutor
+	quit     chan struct{}
+	quitOnce sync.Once
+	initFunc func(ch *Channel)
+	options  *loopOptions
+	loopName string // 任务队列长度指标的loop标签
 }
 
 // NewClientEventLoop 创建客户端事件循环
-func NewClientEventLoop(initFunc func(ch *Channel)) *ClientEventLoop {
+func NewClientEventLoop(initFunc func(ch *Channel), opts ...LoopOption) *ClientEventLoop {
+	o := newLoopOptions(opts...)
 	el := &ClientEventLoop{
-		taskQueue: make(chan func(), 1024),
-		quit:      make(chan struct{}),
-		initFunc:  initFunc,
+		// 启动任务处理协程
+		executor: NewEventExecutor(o.taskQueueSize, o.rejectPolicy),
+		quit:     make(chan struct{}),
+		initFunc: initFunc,
+		options:  o,
 	}
-
-	// 启动任务处理协程
-	el.wg.Add(1)
-	go el.runTaskLoop()
+	el.loopName = o.metrics.observeLoop("client-", el.executor.Pending)
 
 	return el
 }
 
-// Post 实现EventLooper接口
+// Post 投递任务到事件循环，队列已满时按拒绝策略处理
 func (el *ClientEventLoop) Post(task func()) {
-	select {
-	case el.taskQueue <- task:
-	case <-el.quit:
+	if err := el.Execute(task); err != nil && err != ErrExecutorShutdown {
+		slog.Warn("ag_netty client event loop post failed", "error", err)
 	}
 }
 
+// Execute 投递任务到事件循环并返回拒绝原因，任务未被执行时返回非nil
+func (el *ClientEventLoop) Execute(task func()) error {
+	return el.executor.Execute(task)
+}
+
 func (el *ClientEventLoop) Schedule(delay time.Duration, task func()) {
 	go func() {
 		select {
@@ -46,9 +53,13 @@ func (el *ClientEventLoop) Schedule(delay time.Duration, task func()) {
 	}()
 }
 
+// Shutdown 关闭事件循环，可重复及并发调用
 func (el *ClientEventLoop) Shutdown() {
-	close(el.quit)
-	el.wg.Wait()
+	el.quitOnce.Do(func() {
+		close(el.quit)
+		el.options.metrics.forgetLoop(el.loopName)
+		el.executor.Shutdown()
+	})
 }
 
 func (el *ClientEventLoop) IsShutdown() bool {
@@ -59,17 +70,3 @@ func (el *ClientEventLoop) IsShutdown() bool {
 		return false
 	}
 }
-
-// runTaskLoop 运行任务处理循环
-func (el *ClientEventLoop) runTaskLoop() {
-	defer el.wg.Done()
-
-	for {
-		select {
-		case task := <-el.taskQueue:
-			task()
-		case <-el.quit:
-			return
-		}
-	}
-}
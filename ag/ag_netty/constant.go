@@ -2,6 +2,9 @@ package ag_netty
 
 import "time"
 
+// DefaultEventLoops 服务端默认事件循环数量
+const DefaultEventLoops = 4
+
 var TimeoutUnit = time.Millisecond
 
 func ToTimeoutDuration(timeout int) time.Duration {
@@ -1,20 +1,37 @@
 package ag_netty
 
+import (
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/frochyzhang/ag-core/ag/ag_metrics"
+)
+
 // HandlerContext 处理器上下文
 type HandlerContext struct {
 	name     string
 	handler  ChannelHandler
 	pipeline *Pipeline
-	next     *HandlerContext
-	prev     *HandlerContext
+	next     atomic.Pointer[HandlerContext]
+	prev     atomic.Pointer[HandlerContext]
+	executor *EventExecutor // 非空时处理器在该执行器上执行，否则在调用方协程执行
+	removed  atomic.Bool    // 已从流水线移除，传播中的事件越过该处理器
+	write    *writeOp       // 仅HandleWrite收到的上下文副本非空，记录WriteDirect的结果
+
+	// 读写事件耗时直方图，通道未启用指标时为nil
+	readLatency  *ag_metrics.Histogram
+	writeLatency *ag_metrics.Histogram
 }
 
 // newHandlerContext 创建处理器上下文
 func newHandlerContext(name string, handler ChannelHandler, pipeline *Pipeline) *HandlerContext {
+	m := pipeline.channel.metrics
 	return &HandlerContext{
-		name:     name,
-		handler:  handler,
-		pipeline: pipeline,
+		name:         name,
+		handler:      handler,
+		pipeline:     pipeline,
+		readLatency:  m.handlerLatency(name, handlerEventRead),
+		writeLatency: m.handlerLatency(name, handlerEventWrite),
 	}
 }
 
@@ -36,40 +53,144 @@ func (ctx *HandlerContext) Channel() *Channel {
 // FireActive 触发激活事件
 func (ctx *HandlerContext) FireActive() {
 	if ctx.handler != nil {
-		ctx.handler.HandleActive(ctx)
-		ctx.next.FireActive()
+		ctx.invokeLifecycle(func() {
+			if !ctx.removed.Load() {
+				ctx.handler.HandleActive(ctx)
+			}
+			ctx.next.Load().FireActive()
+		})
 	}
 }
 
 // FireInactive 触发失活事件
 func (ctx *HandlerContext) FireInactive() {
 	if ctx.handler != nil {
-		ctx.handler.HandleInactive(ctx)
-		ctx.next.FireInactive()
+		ctx.invokeLifecycle(func() {
+			if !ctx.removed.Load() {
+				ctx.handler.HandleInactive(ctx)
+			}
+			ctx.next.Load().FireInactive()
+		})
 	}
 }
 
 // FireRead 触发读事件
 func (ctx *HandlerContext) FireRead(data []byte) {
+	if ctx.handler == nil && ctx.pipeline != nil && ctx == ctx.pipeline.tail {
+		if f := ctx.pipeline.tailRead; f != nil {
+			f(data)
+		}
+		return
+	}
 	if ctx.handler != nil {
-		ctx.handler.HandleRead(ctx, data)
-		ctx.next.FireRead(data)
+		ctx.invoke(func() {
+			if !ctx.removed.Load() {
+				start := startTimer(ctx.readLatency)
+				ctx.handler.HandleRead(ctx, data)
+				observeSince(ctx.readLatency, start)
+			}
+			ctx.next.Load().FireRead(data)
+		})
 	}
 }
 
 // FireWrite 触发写事件
 func (ctx *HandlerContext) FireWrite(data []byte) {
-	if ctx.handler != nil {
-		ctx.handler.HandleWrite(ctx, data)
-		ctx.prev.FireWrite(data)
+	ctx.fireWrite(data, nil)
+}
+
+// fireWrite 向流水线头部传递写事件，到达头部时写操作完成
+func (ctx *HandlerContext) fireWrite(data []byte, op *writeOp) {
+	if ctx.handler == nil {
+		if op != nil {
+			op.finish()
+		}
+		return
 	}
+	err := ctx.invoke(func() {
+		if !ctx.removed.Load() {
+			start := startTimer(ctx.writeLatency)
+			ctx.handler.HandleWrite(ctx.withWrite(op), data)
+			observeSince(ctx.writeLatency, start)
+		}
+		ctx.prev.Load().fireWrite(data, op)
+	})
+	if err != nil {
+		op.finishWith(err)
+	}
+}
+
+// withWrite 返回携带写操作的上下文副本，写操作随上下文传递而不记在通道上，
+// 执行器上并发处理的不同写操作互不影响；op为nil时返回自身
+func (ctx *HandlerContext) withWrite(op *writeOp) *HandlerContext {
+	if op == nil {
+		return ctx
+	}
+	wc := &HandlerContext{
+		name:         ctx.name,
+		handler:      ctx.handler,
+		pipeline:     ctx.pipeline,
+		executor:     ctx.executor,
+		write:        op,
+		readLatency:  ctx.readLatency,
+		writeLatency: ctx.writeLatency,
+	}
+	wc.next.Store(ctx.next.Load())
+	wc.prev.Store(ctx.prev.Load())
+	return wc
+}
+
+// WriteDirect 直接写出到连接，在HandleWrite中调用时结果记入本次写操作，决定WriteAndFlush返回的Promise
+func (ctx *HandlerContext) WriteDirect(data []byte) error {
+	err := ctx.Channel().writeDirect(data)
+	ctx.write.record(err)
+	return err
+}
+
+// failWrite 处理器在HandleWrite中放弃写出时，以err记入本次写操作
+func (ctx *HandlerContext) failWrite(err error) {
+	ctx.write.record(err)
 }
 
 // FireError 触发错误事件
 func (ctx *HandlerContext) FireError(err error) {
 	if ctx.handler != nil {
-		ctx.handler.HandleError(ctx, err)
-		ctx.prev.FireError(err)
+		ctx.invoke(func() {
+			if !ctx.removed.Load() {
+				ctx.handler.HandleError(ctx, err)
+			}
+			ctx.prev.Load().FireError(err)
+		})
+	}
+}
+
+// Executor 获取处理器绑定的执行器，未绑定时返回nil
+func (ctx *HandlerContext) Executor() *EventExecutor {
+	return ctx.executor
+}
+
+// invoke 在处理器绑定的执行器上执行事件，未绑定时直接执行
+// 执行器按拒绝策略拒绝任务时事件无法送达后续处理器，流中的数据已不完整，因此关闭通道并返回该错误
+func (ctx *HandlerContext) invoke(task func()) error {
+	if ctx.executor == nil {
+		task()
+		return nil
+	}
+	if err := ctx.executor.Execute(task); err != nil {
+		slog.Warn("ag_netty handler task rejected, close channel", "handler", ctx.name, "error", err)
+		ctx.Channel().metrics.errorFired()
+		// 关闭时的失活事件需提交到执行器，异步关闭避免在执行器协程上等待自身队列
+		go ctx.Channel().Close()
+		return err
+	}
+	return nil
+}
+
+// invokeLifecycle 在绑定的执行器上执行激活及失活事件，忽略拒绝策略等待入队，执行器已关闭时直接执行，
+// 保证处理器总能收到通道的生命周期事件
+func (ctx *HandlerContext) invokeLifecycle(task func()) {
+	if ctx.executor == nil || ctx.executor.submit(task) != nil {
+		task()
 	}
 }
 
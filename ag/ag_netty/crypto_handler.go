@@ -0,0 +1,211 @@
+package ag_netty
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/frochyzhang/ag-core/ag/ag_crypto"
+)
+
+var (
+	// ErrMacMismatch 报文MAC校验不通过
+	ErrMacMismatch = errors.New("ag_netty: mac mismatch")
+	// ErrMacMissing 报文长度不足以包含MAC
+	ErrMacMissing = errors.New("ag_netty: mac missing")
+	// ErrCipherKeyUnknown 密文的密钥标识与当前密钥及旧密钥均不匹配
+	ErrCipherKeyUnknown = errors.New("ag_netty: cipher key unknown")
+	// ErrDecrypt 解密失败，不区分填充错误等具体原因，避免形成填充预言
+	ErrDecrypt = errors.New("ag_netty: decrypt failed")
+)
+
+// VerifyError 验签或解密失败时经流水线触发的错误事件，处理器可通过errors.As识别
+type VerifyError struct {
+	Op        string // verify 或 decrypt
+	Algorithm string
+	Remote    net.Addr
+	Err       error
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("ag_netty: %s %s from %v: %v", e.Op, e.Algorithm, e.Remote, e.Err)
+}
+
+func (e *VerifyError) Unwrap() error {
+	return e.Err
+}
+
+// MacTransformer 写出时在帧尾追加MAC，读入时校验并去掉MAC
+// 校验依次尝试当前密钥及旧密钥，密钥轮换期间对端仍使用旧密钥的报文可通过
+type MacTransformer struct {
+	algorithm string
+	keys      KeyProvider
+	size      int
+	hex       bool
+}
+
+// MacOption MacTransformer选项
+type MacOption func(t *MacTransformer)
+
+// WithMacSize 截取MAC前size字节，0表示完整长度
+func WithMacSize(size int) MacOption {
+	return func(t *MacTransformer) {
+		t.size = size
+	}
+}
+
+// WithMacHex MAC以大写十六进制字符写入报文
+func WithMacHex() MacOption {
+	return func(t *MacTransformer) {
+		t.hex = true
+	}
+}
+
+// NewMacTransformer 创建MAC变换，algorithm为ag_crypto.MacHmacSHA256或ag_crypto.MacHmacSM3
+func NewMacTransformer(algorithm string, keys KeyProvider, opts ...MacOption) (*MacTransformer, error) {
+	t := &MacTransformer{algorithm: algorithm, keys: keys}
+	for _, opt := range opts {
+		opt(t)
+	}
+	h, err := ag_crypto.NewMac(algorithm, nil)
+	if err != nil {
+		return nil, err
+	}
+	if t.size <= 0 || t.size > h.Size() {
+		t.size = h.Size()
+	}
+	return t, nil
+}
+
+func (t *MacTransformer) Outbound(ctx *HandlerContext, frame []byte) ([]byte, error) {
+	key, _, err := t.keys.Keys()
+	if err != nil {
+		return nil, err
+	}
+	mac, err := t.mac(key, frame)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(make([]byte, 0, len(frame)+len(mac)), frame...), mac...), nil
+}
+
+func (t *MacTransformer) Inbound(ctx *HandlerContext, frame []byte) ([]byte, error) {
+	size := t.size
+	if t.hex {
+		size *= 2
+	}
+	if len(frame) < size {
+		return nil, t.verifyError(ctx, ErrMacMissing)
+	}
+	body, mac := frame[:len(frame)-size], frame[len(frame)-size:]
+
+	current, previous, err := t.keys.Keys()
+	if err != nil {
+		return nil, t.verifyError(ctx, err)
+	}
+	for _, key := range append([][]byte{current}, previous...) {
+		expected, err := t.mac(key, body)
+		if err != nil {
+			return nil, t.verifyError(ctx, err)
+		}
+		if hmac.Equal(expected, mac) {
+			return body, nil
+		}
+	}
+	return nil, t.verifyError(ctx, ErrMacMismatch)
+}
+
+func (t *MacTransformer) mac(key, data []byte) ([]byte, error) {
+	sum, err := ag_crypto.Mac(t.algorithm, key, data)
+	if err != nil {
+		return nil, err
+	}
+	sum = sum[:t.size]
+	if t.hex {
+		return []byte(fmt.Sprintf("%X", sum)), nil
+	}
+	return sum, nil
+}
+
+func (t *MacTransformer) verifyError(ctx *HandlerContext, err error) error {
+	return &VerifyError{Op: "verify", Algorithm: t.algorithm, Remote: ctx.Channel().RemoteAddr(), Err: err}
+}
+
+// cipherKeyIDSize 密文头部的密钥标识长度
+const cipherKeyIDSize = 4
+
+// CipherTransformer 写出时加密帧，读入时解密帧，密文为4字节密钥标识、随机IV及CBC密文
+// 解密按密钥标识选取当前密钥或旧密钥且只解密一次；CBC密文可被篡改，应与MacTransformer配合使用(先加密后签名)
+type CipherTransformer struct {
+	algorithm string
+	keys      KeyProvider
+	hex       bool
+}
+
+// NewCipherTransformer 创建加密变换，algorithm为ag_crypto.CipherSM4CBC或ag_crypto.CipherAESCBC
+// hexText为true时密文以大写十六进制字符写入报文，用于文本协议
+func NewCipherTransformer(algorithm string, keys KeyProvider, hexText bool) (*CipherTransformer, error) {
+	if _, err := ag_crypto.NewBlockCipher(algorithm, make([]byte, 16)); err != nil {
+		return nil, err
+	}
+	return &CipherTransformer{algorithm: algorithm, keys: keys, hex: hexText}, nil
+}
+
+func (t *CipherTransformer) Outbound(ctx *HandlerContext, frame []byte) ([]byte, error) {
+	key, _, err := t.keys.Keys()
+	if err != nil {
+		return nil, err
+	}
+	out, err := ag_crypto.EncryptCBC(t.algorithm, key, frame)
+	if err != nil {
+		return nil, err
+	}
+	out = append(cipherKeyID(key), out...)
+	if t.hex {
+		return []byte(fmt.Sprintf("%X", out)), nil
+	}
+	return out, nil
+}
+
+func (t *CipherTransformer) Inbound(ctx *HandlerContext, frame []byte) ([]byte, error) {
+	if t.hex {
+		b, err := hex.DecodeString(string(frame))
+		if err != nil {
+			return nil, t.decryptError(ctx, err)
+		}
+		frame = b
+	}
+	if len(frame) < cipherKeyIDSize {
+		return nil, t.decryptError(ctx, ErrDecrypt)
+	}
+	id, frame := frame[:cipherKeyIDSize], frame[cipherKeyIDSize:]
+	current, previous, err := t.keys.Keys()
+	if err != nil {
+		return nil, t.decryptError(ctx, err)
+	}
+	for _, key := range append([][]byte{current}, previous...) {
+		if !bytes.Equal(cipherKeyID(key), id) {
+			continue
+		}
+		plain, err := ag_crypto.DecryptCBC(t.algorithm, key, frame)
+		if err != nil {
+			return nil, t.decryptError(ctx, ErrDecrypt)
+		}
+		return plain, nil
+	}
+	return nil, t.decryptError(ctx, ErrCipherKeyUnknown)
+}
+
+// cipherKeyID 密钥标识，取密钥摘要的前4字节，不泄露密钥本身
+func cipherKeyID(key []byte) []byte {
+	sum := sha256.Sum256(append([]byte("ag_netty cipher key id:"), key...))
+	return sum[:cipherKeyIDSize]
+}
+
+func (t *CipherTransformer) decryptError(ctx *HandlerContext, err error) error {
+	return &VerifyError{Op: "decrypt", Algorithm: t.algorithm, Remote: ctx.Channel().RemoteAddr(), Err: err}
+}
@@ -0,0 +1,150 @@
+package ag_netty
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/frochyzhang/ag-core/ag/ag_conf"
+	"github.com/frochyzhang/ag-core/ag/ag_crypto"
+)
+
+const (
+	testKeyA = "0123456789abcdeffedcba9876543210"
+	testKeyB = "00112233445566778899aabbccddeeff"
+)
+
+func newCryptoChannel(t *testing.T, keys KeyProvider, macAlg string, recv *[]string) *EmbeddedChannel {
+	t.Helper()
+	sm4, err := NewCipherTransformer(ag_crypto.CipherSM4CBC, keys, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mac, err := NewMacTransformer(macAlg, keys, WithMacSize(8), WithMacHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewFrameHandler(NewLengthFieldFramer(4, true), func(ctx *HandlerContext, frame []byte) {
+		*recv = append(*recv, string(frame))
+	}).With(sm4, mac)
+	return NewEmbeddedChannel(h)
+}
+
+func TestCryptoTransformers(t *testing.T) {
+	env := ag_conf.NewStandardEnvironment()
+	env.GetPropertySources().AddFirst(ag_conf.NewPropertiesPropertySource("netty", map[string]any{
+		"netty.crypto.key": testKeyA,
+	}))
+	keys := NewConfKeyProvider(env, "netty.crypto.key", nil)
+
+	for _, alg := range []string{ag_crypto.MacHmacSHA256, ag_crypto.MacHmacSM3} {
+		var sent, recv []string
+		peer := newCryptoChannel(t, keys, alg, &sent)
+		local := newCryptoChannel(t, keys, alg, &recv)
+
+		// 加密签名后的报文经对端还原
+		peer.WriteOutbound([]byte("pay 100.00"))
+		wire := peer.ReadOutbound()
+		if bytes.Contains(wire, []byte("pay")) {
+			t.Fatalf("%s: plaintext on wire: %q", alg, wire)
+		}
+		local.WriteInbound(wire)
+		if len(recv) != 1 || recv[0] != "pay 100.00" {
+			t.Fatalf("%s: received %q", alg, recv)
+		}
+
+		// 篡改报文触发VerifyError，通道保持连接
+		tampered := append([]byte(nil), wire...)
+		tampered[10] ^= 0xFF
+		local.WriteInbound(tampered)
+		var verr *VerifyError
+		if errs := local.Errors(); len(errs) != 1 || !errors.As(errs[0], &verr) || verr.Op != "verify" || !errors.Is(verr, ErrMacMismatch) {
+			t.Fatalf("%s: errors = %v", alg, errs)
+		}
+		if len(recv) != 1 || !local.IsActive() {
+			t.Fatalf("%s: tampered frame delivered", alg)
+		}
+	}
+}
+
+func TestCryptoKeyRotation(t *testing.T) {
+	env := ag_conf.NewStandardEnvironment()
+	env.GetPropertySources().AddFirst(ag_conf.NewPropertiesPropertySource("netty", map[string]any{
+		"netty.crypto.key": testKeyA,
+	}))
+	keys := NewConfKeyProvider(env, "netty.crypto.key", nil)
+
+	keyA, _ := hex.DecodeString(testKeyA)
+	var sent, recv []string
+	peer := newCryptoChannel(t, &StaticKeys{Current: keyA}, ag_crypto.MacHmacSHA256, &sent)
+	local := newCryptoChannel(t, keys, ag_crypto.MacHmacSHA256, &recv)
+	peer.WriteOutbound([]byte("old key"))
+	oldWire := peer.ReadOutbound()
+
+	// 配置刷新：切换到新密钥，旧密钥保留为previous(以{cipher}加密配置)
+	cipherA, _ := ag_crypto.Base64Encryptor.Encrypt(testKeyA)
+	env.GetPropertySources().Replace("netty", ag_conf.NewPropertiesPropertySource("netty", map[string]any{
+		"netty.crypto.key":          testKeyB,
+		"netty.crypto.key.previous": ag_conf.ConstEncryptKeyWords + cipherA,
+	}))
+	local.WriteInbound(oldWire)
+	if len(recv) != 1 || recv[0] != "old key" {
+		t.Fatalf("message with previous key rejected: %v", local.Errors())
+	}
+
+	// 写出使用新密钥，只持有旧密钥的对端无法验签
+	local.WriteOutbound([]byte("new key"))
+	peer.WriteInbound(local.ReadOutbound())
+	var verr *VerifyError
+	if len(sent) != 0 || len(peer.Errors()) != 1 || !errors.As(peer.Errors()[0], &verr) {
+		t.Fatalf("peer accepted message signed with rotated key: %v", peer.Errors())
+	}
+
+	// 旧密钥下线
+	env.GetPropertySources().Replace("netty", ag_conf.NewPropertiesPropertySource("netty", map[string]any{
+		"netty.crypto.key": testKeyB,
+	}))
+	local.WriteInbound(oldWire)
+	if len(recv) != 1 || len(local.Errors()) != 1 {
+		t.Fatalf("retired key still accepted")
+	}
+}
+
+func TestCipherKeyID(t *testing.T) {
+	keyA, _ := hex.DecodeString(testKeyA)
+	keyB, _ := hex.DecodeString(testKeyB)
+	newChannel := func(keys KeyProvider, recv *[]string) *EmbeddedChannel {
+		sm4, err := NewCipherTransformer(ag_crypto.CipherSM4CBC, keys, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewEmbeddedChannel(NewFrameHandler(NewLengthFieldFramer(4, true), func(ctx *HandlerContext, frame []byte) {
+			*recv = append(*recv, string(frame))
+		}).With(sm4))
+	}
+	var sent, recv []string
+	peer := newChannel(&StaticKeys{Current: keyB}, &sent)
+	local := newChannel(&StaticKeys{Current: keyA}, &recv)
+
+	// 密钥标识不匹配时不尝试解密
+	peer.WriteOutbound([]byte("pay 100.00"))
+	wire := peer.ReadOutbound()
+	local.WriteInbound(wire)
+	if errs := local.Errors(); len(errs) != 1 || !errors.Is(errs[0], ErrCipherKeyUnknown) {
+		t.Fatalf("errors = %v", errs)
+	}
+
+	// 密钥标识匹配但密文被篡改，篡改前一分组末字节使填充必然无效，填充错误与其他解密错误同为ErrDecrypt
+	local = newChannel(&StaticKeys{Current: keyA, Previous: [][]byte{keyB}}, &recv)
+	tampered := append([]byte(nil), wire...)
+	tampered[len(tampered)-17] ^= 0xFF
+	local.WriteInbound(tampered)
+	if errs := local.Errors(); len(errs) != 1 || !errors.Is(errs[0], ErrDecrypt) || errors.Is(errs[0], ag_crypto.ErrInvalidPadding) {
+		t.Fatalf("errors = %v", errs)
+	}
+	local.WriteInbound(wire)
+	if len(recv) != 1 || recv[0] != "pay 100.00" {
+		t.Fatalf("received %q", recv)
+	}
+}
@@ -0,0 +1,252 @@
+package ag_netty
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultDatagramIdleTimeout UDP会话默认空闲超时
+	DefaultDatagramIdleTimeout = time.Minute
+	// maxDatagramSize UDP数据报最大长度
+	maxDatagramSize = 65535
+)
+
+// datagramConn UDP会话的底层连接，写出时发送到会话对端，关闭时不关闭共享的套接字
+type datagramConn struct {
+	pc         net.PacketConn
+	remote     net.Addr
+	closed     atomic.Bool
+	lastActive atomic.Int64
+}
+
+func (c *datagramConn) Write(b []byte) (int, error) {
+	if c.closed.Load() {
+		return 0, io.ErrClosedPipe
+	}
+	c.touch()
+	return c.pc.WriteTo(b, c.remote)
+}
+
+func (c *datagramConn) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+func (c *datagramConn) RemoteAddr() net.Addr { return c.remote }
+func (c *datagramConn) LocalAddr() net.Addr  { return c.pc.LocalAddr() }
+
+func (c *datagramConn) touch() {
+	c.lastActive.Store(time.Now().UnixNano())
+}
+
+func (c *datagramConn) idle(now time.Time, timeout time.Duration) bool {
+	return now.Sub(time.Unix(0, c.lastActive.Load())) > timeout
+}
+
+type datagramSession struct {
+	ch   *Channel
+	conn *datagramConn
+	loop *EventLoop
+}
+
+// datagramServer UDP服务端，按发送方地址维护会话，每个会话对应一个通道，通道的RemoteAddr为数据报发送方
+// 每个数据报触发一次读事件，同一会话的数据报按到达顺序在其事件循环上处理；会话空闲超时后关闭
+type datagramServer struct {
+	pc          net.PacketConn
+	group       *EventLoopGroup
+	idleTimeout time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*datagramSession
+	done     chan struct{}
+	closed   atomic.Bool
+}
+
+func newDatagramServer(pc net.PacketConn, group *EventLoopGroup, idleTimeout time.Duration) *datagramServer {
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultDatagramIdleTimeout
+	}
+	return &datagramServer{
+		pc:          pc,
+		group:       group,
+		idleTimeout: idleTimeout,
+		sessions:    make(map[string]*datagramSession),
+		done:        make(chan struct{}),
+	}
+}
+
+// serve 读取数据报直到套接字关闭
+func (s *datagramServer) serve() error {
+	go s.sweep()
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := s.pc.ReadFrom(buf)
+		if err != nil {
+			if s.closed.Load() {
+				return nil
+			}
+			return err
+		}
+		sess := s.session(addr)
+		if sess == nil {
+			continue
+		}
+		data := append([]byte(nil), buf[:n]...)
+		sess.conn.touch()
+		sess.loop.Post(func() {
+			sess.ch.Pipeline.FireRead(data)
+		})
+	}
+}
+
+// session 获取或创建发送方的会话，连接准入拒绝时返回nil
+func (s *datagramServer) session(addr net.Addr) *datagramSession {
+	key := addr.String()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.sessions[key]; ok {
+		return sess
+	}
+
+	loop := s.group.Next()
+	limiter := loop.options.connLimiter
+	if limiter != nil {
+		if err := limiter.Acquire(addr); err != nil {
+			slog.Warn("ag_netty datagram refused", "remote", addr, "reason", err)
+			return nil
+		}
+	}
+
+	loop.options.metrics.connAccepted()
+	conn := &datagramConn{pc: s.pc, remote: addr}
+	conn.touch()
+	ch := newChannel(conn, loop)
+	loop.options.applyChannel(ch)
+	if loop.initFunc != nil {
+		loop.initFunc(ch)
+	}
+	// 初始化失败时已关闭通道，不建立会话
+	if !ch.IsActive() {
+		if limiter != nil {
+			limiter.Release(addr)
+		}
+		return nil
+	}
+	sess := &datagramSession{ch: ch, conn: conn, loop: loop}
+	s.sessions[key] = sess
+	ch.addCloseListener(func() {
+		s.mu.Lock()
+		if s.sessions[key] == sess {
+			delete(s.sessions, key)
+		}
+		s.mu.Unlock()
+		if limiter != nil {
+			limiter.Release(addr)
+		}
+	})
+
+	loop.Post(ch.Pipeline.FireActive)
+	return sess
+}
+
+// sweep 定期关闭空闲会话
+func (s *datagramServer) sweep() {
+	interval := s.idleTimeout / 2
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			for _, sess := range s.snapshot() {
+				if sess.conn.idle(now, s.idleTimeout) {
+					sess.ch.Close()
+				}
+			}
+		}
+	}
+}
+
+func (s *datagramServer) snapshot() []*datagramSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessions := make([]*datagramSession, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	return sessions
+}
+
+// close 关闭套接字及全部会话
+func (s *datagramServer) close() {
+	if !s.closed.CompareAndSwap(false, true) {
+		return
+	}
+	close(s.done)
+	s.pc.Close()
+	for _, sess := range s.snapshot() {
+		sess.ch.Close()
+	}
+}
+
+// dialDatagram 创建已连接的UDP通道，每个收到的数据报触发一次读事件
+// 对端不可达(ICMP端口不可达)时触发错误事件，通道保持打开
+func dialDatagram(addr string, connTimeout time.Duration, looper EventLooper) (*Channel, error) {
+	clientLooper, _ := looper.(*ClientEventLoop)
+	if clientLooper != nil && clientLooper.options.tlsConfig != nil {
+		return nil, errors.New("ag_netty tls is not supported over udp")
+	}
+	conn, err := net.DialTimeout(string(NetworkUDP), addr, connTimeout)
+	if err != nil {
+		return nil, err
+	}
+	channel := newChannel(conn, looper)
+	if clientLooper != nil {
+		clientLooper.options.applyChannel(channel)
+		if clientLooper.initFunc != nil {
+			clientLooper.initFunc(channel)
+		}
+	}
+	looper.Post(channel.Pipeline.FireActive)
+
+	go func() {
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				if !channel.IsActive() || looper.IsShutdown() {
+					return
+				}
+				if errors.Is(err, syscall.ECONNREFUSED) {
+					looper.Post(func() {
+						channel.Pipeline.FireError(err)
+					})
+					continue
+				}
+				looper.Post(func() {
+					channel.Pipeline.FireError(err)
+					channel.Close()
+				})
+				return
+			}
+			data := append([]byte(nil), buf[:n]...)
+			looper.Post(func() {
+				channel.Pipeline.FireRead(data)
+			})
+		}
+	}()
+
+	slog.Info("Connected to server", "network", NetworkUDP, "addr", addr)
+	return channel, nil
+}
@@ -1,8 +1,12 @@
 package ag_netty
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"github.com/cloudwego/netpoll"
 	"log/slog"
+	"net"
 	"time"
 )
 
@@ -15,7 +19,16 @@ func Dial(
 	idleTimeout time.Duration,
 	looper EventLooper,
 ) (*Channel, error) {
-	conn, err := netpoll.DialConnection("tcp", addr, connTimeout)
+	// 传输协议由客户端事件循环的 WithDialNetwork 指定
+	network := NetworkTCP
+	if clientLooper, ok := looper.(*ClientEventLoop); ok && clientLooper.options.network != "" {
+		network = clientLooper.options.network
+	}
+	if network.IsDatagram() {
+		return dialDatagram(addr, connTimeout, looper)
+	}
+
+	conn, err := netpoll.DialConnection(string(network), addr, connTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -24,12 +37,21 @@ func Dial(
 	channel := NewChannel(conn, looper)
 
 	// 初始化Pipeline
+	var tlsConfig *tls.Config
+	var tlsHandshake time.Duration
 	if clientLooper, ok := looper.(*ClientEventLoop); ok {
+		clientLooper.options.applyChannel(channel)
+		tlsConfig = clientLooper.options.tlsConfig
+		tlsHandshake = clientLooper.options.tlsHandshake
 		if clientLooper.initFunc != nil {
 			clientLooper.initFunc(channel)
 		}
 	}
 
+	if tlsConfig != nil {
+		return dialTLS(addr, conn, channel, tlsConfig, tlsHandshake, readTimeout, writeTimeout, idleTimeout, looper)
+	}
+
 	// 触发激活事件
 	looper.Post(func() {
 		channel.Pipeline.FireActive()
@@ -42,46 +64,117 @@ func Dial(
 	// 设置空闲超时
 	conn.SetIdleTimeout(idleTimeout)
 
-	// 启动读循环
-	go readLoop(conn, channel, looper)
+	// 注册读事件回调，由netpoll在数据到达时驱动读取，替代轮询读循环
+	if err := conn.SetOnRequest(func(ctx context.Context, conn netpoll.Connection) error {
+		if looper.IsShutdown() {
+			return nil
+		}
+		return readInbound(conn, channel, readDispatcher(channel))
+	}); err != nil {
+		channel.Close()
+		return nil, err
+	}
+	// 对端关闭或连接异常时同步关闭通道
+	// Channel.Close内部关闭连接时也会回调此处，需先判断活跃状态避免重入
+	conn.AddCloseCallback(func(conn netpoll.Connection) error {
+		if channel.IsActive() {
+			channel.Close()
+		}
+		return nil
+	})
 
-	slog.Info("Connected to server", "addr", addr)
+	slog.Info("Connected to server", "network", network, "addr", addr)
 	return channel, nil
 }
 
-// readLoop 读取数据循环
-func readLoop(
+// readDispatcher 客户端读事件投递到事件循环，任务被拒绝时读到的数据已丢失，流已不完整，因此关闭通道
+func readDispatcher(channel *Channel) func(task func()) {
+	return func(task func()) {
+		channel.post(task, func(err error) {
+			if errors.Is(err, ErrExecutorShutdown) {
+				return
+			}
+			slog.Warn("ag_netty client read task rejected, close channel", "remote", channel.RemoteAddr(), "error", err)
+			channel.metrics.errorFired()
+			// 读回调中关闭连接会回调连接关闭事件，异步关闭避免重入
+			go channel.Close()
+		})
+	}
+}
+
+// dialTLS 完成TLS握手后触发激活事件，之后由netpoll读回调解密并投递读事件
+func dialTLS(
+	addr string,
 	conn netpoll.Connection,
 	channel *Channel,
+	config *tls.Config,
+	handshakeTimeout time.Duration,
+	readTimeout time.Duration,
+	writeTimeout time.Duration,
+	idleTimeout time.Duration,
 	looper EventLooper,
-) {
-	reader := conn.Reader()
-	for {
-		if looper.IsShutdown() {
-			return
+) (*Channel, error) {
+	if config.ServerName == "" {
+		config = config.Clone()
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			config.ServerName = host
+		}
+	}
+	// 连接状态中的ServerName不含IP地址，校验回调中补全为拨号使用的主机名
+	if verify := config.VerifyConnection; verify != nil {
+		config = config.Clone()
+		serverName := config.ServerName
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			if cs.ServerName == "" {
+				cs.ServerName = serverName
+			}
+			return verify(cs)
 		}
+	}
+	channel.initTLS(conn, config, true)
 
-		// 检查可读数据
-		n := reader.Len()
+	// 握手及之后的读取均不阻塞等待超过读超时
+	conn.SetReadTimeout(readTimeout)
+	conn.SetWriteTimeout(writeTimeout)
+	conn.SetIdleTimeout(idleTimeout)
 
-		if n == 0 {
-			time.Sleep(10 * time.Millisecond)
-			continue
-		}
+	ctx := context.Background()
+	if handshakeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, handshakeTimeout)
+		defer cancel()
+	}
+	if err := channel.tlsConn.HandshakeContext(ctx); err != nil {
+		channel.Close()
+		return nil, err
+	}
+	channel.tlsRaw.handshakeDone()
 
-		// 读取数据
-		data, err := reader.ReadBinary(n)
-		if err != nil {
-			looper.Post(func() {
-				channel.Pipeline.FireError(err)
-			})
+	looper.Post(func() {
+		channel.Pipeline.FireActive()
+	})
+
+	// 与明文连接相同由netpoll读回调驱动解密，不占用常驻读协程
+	if err := conn.SetOnRequest(func(ctx context.Context, conn netpoll.Connection) error {
+		if looper.IsShutdown() {
+			return nil
+		}
+		return drainTLS(channel, readDispatcher(channel))
+	}); err != nil {
+		channel.Close()
+		return nil, err
+	}
+	conn.AddCloseCallback(func(conn netpoll.Connection) error {
+		if channel.IsActive() {
 			channel.Close()
-			return
 		}
-
-		// 触发读事件
-		looper.Post(func() {
-			channel.Pipeline.FireRead(data)
-		})
+		return nil
+	})
+	// 握手期间可能已读入应用数据，读回调只在有新数据时触发
+	if err := drainTLS(channel, readDispatcher(channel)); err != nil {
+		return nil, err
 	}
+
+	slog.Info("Connected to server with tls", "addr", addr)
+	return channel, nil
 }
@@ -0,0 +1,92 @@
+package ag_netty
+
+import (
+	"testing"
+	"time"
+)
+
+// benchRecvHandler 客户端接收处理器，按字节数通知收包完成
+type benchRecvHandler struct {
+	recv chan int
+}
+
+func (h *benchRecvHandler) HandleActive(ctx *HandlerContext)   {}
+func (h *benchRecvHandler) HandleInactive(ctx *HandlerContext) {}
+func (h *benchRecvHandler) HandleRead(ctx *HandlerContext, data []byte) {
+	h.recv <- len(data)
+}
+func (h *benchRecvHandler) HandleWrite(ctx *HandlerContext, data []byte) {
+	ctx.Channel().WriteDirect(data)
+}
+func (h *benchRecvHandler) HandleError(ctx *HandlerContext, err error) {}
+
+func startBenchEchoServer(b *testing.B) *Server {
+	s, err := NewServer("127.0.0.1:0", func(ch *Channel) {
+		ch.Pipeline.AddLast("echo", &EchoHandler{})
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	go s.Start()
+	b.Cleanup(s.Shutdown)
+	return s
+}
+
+func dialBenchClient(b *testing.B, addr string) (*Channel, *benchRecvHandler) {
+	h := &benchRecvHandler{recv: make(chan int, 1024)}
+	looper := NewClientEventLoop(func(ch *Channel) {
+		ch.Pipeline.AddLast("recv", h)
+	})
+	ch, err := Dial(addr, time.Second, time.Second, time.Second, time.Minute, looper)
+	if err != nil {
+		looper.Shutdown()
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		ch.Close()
+		looper.Shutdown()
+	})
+	return ch, h
+}
+
+// waitBytes 等待收满n个字节
+func waitBytes(b *testing.B, h *benchRecvHandler, n int) {
+	for n > 0 {
+		select {
+		case got := <-h.recv:
+			n -= got
+		case <-time.After(5 * time.Second):
+			b.Fatal("echo timeout")
+		}
+	}
+}
+
+// BenchmarkClientRoundTrip 单连接请求-应答往返延迟
+func BenchmarkClientRoundTrip(b *testing.B) {
+	s := startBenchEchoServer(b)
+	ch, h := dialBenchClient(b, s.listener.Addr().String())
+	payload := make([]byte, 128)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ch.Write(payload)
+		waitBytes(b, h, len(payload))
+	}
+}
+
+// BenchmarkClientThroughput 多连接并发往返吞吐
+func BenchmarkClientThroughput(b *testing.B) {
+	s := startBenchEchoServer(b)
+	addr := s.listener.Addr().String()
+	payload := make([]byte, 1024)
+
+	b.SetBytes(int64(len(payload)))
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		ch, h := dialBenchClient(b, addr)
+		for pb.Next() {
+			ch.Write(payload)
+			waitBytes(b, h, len(payload))
+		}
+	})
+}
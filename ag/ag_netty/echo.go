@@ -21,7 +21,7 @@ func (h *EchoHandler) HandleRead(ctx *HandlerContext, data []byte) {
 
 func (h *EchoHandler) HandleWrite(ctx *HandlerContext, data []byte) {
 	// 直接写入连接
-	ctx.Channel().WriteDirect(data)
+	ctx.WriteDirect(data)
 }
 
 func (h *EchoHandler) HandleError(ctx *HandlerContext, err error) {
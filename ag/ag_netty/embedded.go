@@ -0,0 +1,393 @@
+package ag_netty
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EmbeddedEventLoop 测试用事件循环，任务在调用RunPendingTasks时于调用方协程顺序执行
+// Schedule的延时任务按假时钟计时，由AdvanceTime推进
+type EmbeddedEventLoop struct {
+	mu        sync.Mutex
+	tasks     []func()
+	scheduled []scheduledTask
+	seq       int
+	now       time.Time
+	shutdown  bool
+}
+
+type scheduledTask struct {
+	deadline time.Time
+	seq      int
+	task     func()
+}
+
+// NewEmbeddedEventLoop 创建测试用事件循环
+func NewEmbeddedEventLoop() *EmbeddedEventLoop {
+	return &EmbeddedEventLoop{now: time.Unix(0, 0)}
+}
+
+// Post 提交任务，等待RunPendingTasks执行
+func (l *EmbeddedEventLoop) Post(task func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.shutdown {
+		return
+	}
+	l.tasks = append(l.tasks, task)
+}
+
+// Schedule 按假时钟延时执行任务
+func (l *EmbeddedEventLoop) Schedule(delay time.Duration, task func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.shutdown {
+		return
+	}
+	l.seq++
+	l.scheduled = append(l.scheduled, scheduledTask{deadline: l.now.Add(delay), seq: l.seq, task: task})
+}
+
+// Shutdown 关闭事件循环，未执行的任务被丢弃
+func (l *EmbeddedEventLoop) Shutdown() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.shutdown = true
+	l.tasks = nil
+	l.scheduled = nil
+}
+
+// IsShutdown 判断事件循环是否已关闭
+func (l *EmbeddedEventLoop) IsShutdown() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.shutdown
+}
+
+// RunPendingTasks 执行所有待执行任务，包括执行过程中新提交的任务
+func (l *EmbeddedEventLoop) RunPendingTasks() {
+	for {
+		l.mu.Lock()
+		if len(l.tasks) == 0 {
+			l.mu.Unlock()
+			return
+		}
+		task := l.tasks[0]
+		l.tasks = l.tasks[1:]
+		l.mu.Unlock()
+		task()
+	}
+}
+
+// Pending 待执行任务数，不含未到期的延时任务
+func (l *EmbeddedEventLoop) Pending() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.tasks)
+}
+
+// Now 假时钟当前时间
+func (l *EmbeddedEventLoop) Now() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.now
+}
+
+// AdvanceTime 推进假时钟，按到期时间顺序执行已到期的延时任务
+func (l *EmbeddedEventLoop) AdvanceTime(d time.Duration) {
+	l.mu.Lock()
+	target := l.now.Add(d)
+	l.mu.Unlock()
+
+	for {
+		l.mu.Lock()
+		sort.Slice(l.scheduled, func(i, j int) bool {
+			a, b := l.scheduled[i], l.scheduled[j]
+			if a.deadline.Equal(b.deadline) {
+				return a.seq < b.seq
+			}
+			return a.deadline.Before(b.deadline)
+		})
+		if len(l.scheduled) == 0 || l.scheduled[0].deadline.After(target) {
+			l.now = target
+			l.mu.Unlock()
+			l.RunPendingTasks()
+			return
+		}
+		next := l.scheduled[0]
+		l.scheduled = l.scheduled[1:]
+		// 任务执行时的时间为其到期时间，任务内再次Schedule按该时间计算
+		l.now = next.deadline
+		l.mu.Unlock()
+
+		next.task()
+		l.RunPendingTasks()
+	}
+}
+
+// ScheduledTasks 未到期的延时任务数
+func (l *EmbeddedEventLoop) ScheduledTasks() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.scheduled)
+}
+
+// embeddedAddr EmbeddedChannel的本地及远程地址
+type embeddedAddr struct{}
+
+func (embeddedAddr) Network() string { return "embedded" }
+func (embeddedAddr) String() string  { return "embedded" }
+
+// embeddedConn 内存连接，记录写出到连接的数据
+type embeddedConn struct {
+	mu       sync.Mutex
+	outbound [][]byte
+	closed   bool
+}
+
+func (c *embeddedConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, io.ErrClosedPipe
+	}
+	data := make([]byte, len(b))
+	copy(data, b)
+	c.outbound = append(c.outbound, data)
+	return len(b), nil
+}
+
+func (c *embeddedConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *embeddedConn) RemoteAddr() net.Addr { return embeddedAddr{} }
+func (c *embeddedConn) LocalAddr() net.Addr  { return embeddedAddr{} }
+
+// embeddedRecorder 位于流水线头部，记录激活、失活及错误事件
+type embeddedRecorder struct {
+	mu       sync.Mutex
+	active   int
+	inactive int
+	errors   []error
+}
+
+func (r *embeddedRecorder) HandleActive(ctx *HandlerContext) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.active++
+}
+
+func (r *embeddedRecorder) HandleInactive(ctx *HandlerContext) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inactive++
+}
+
+func (r *embeddedRecorder) HandleRead(ctx *HandlerContext, data []byte)  {}
+func (r *embeddedRecorder) HandleWrite(ctx *HandlerContext, data []byte) {}
+
+func (r *embeddedRecorder) HandleError(ctx *HandlerContext, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, err)
+}
+
+const embeddedRecorderName = "embedded-recorder"
+
+// EmbeddedChannel 内存通道，用于不启动服务端即可确定性地测试处理器
+// 与运行时通道共用Channel及Pipeline实现，事件循环为EmbeddedEventLoop，写出到连接的数据可通过ReadOutbound读取，
+// 到达流水线尾部的读事件可通过ReadInbound读取
+// 事件记录处理器位于流水线头部，之后通过AddFirst添加的处理器产生的错误事件不会被记录
+type EmbeddedChannel struct {
+	*Channel
+	loop     *EmbeddedEventLoop
+	conn     *embeddedConn
+	recorder *embeddedRecorder
+
+	inboundMu sync.Mutex
+	inbound   [][]byte
+}
+
+// NewEmbeddedChannel 创建内存通道，按顺序添加处理器(名称为handler0、handler1...)并触发激活事件
+func NewEmbeddedChannel(handlers ...ChannelHandler) *EmbeddedChannel {
+	e := &EmbeddedChannel{
+		loop:     NewEmbeddedEventLoop(),
+		conn:     &embeddedConn{},
+		recorder: &embeddedRecorder{},
+	}
+	e.Channel = newChannel(e.conn, e.loop)
+	e.Pipeline.tailRead = e.recordInbound
+	e.Pipeline.AddFirst(embeddedRecorderName, e.recorder)
+	for i, h := range handlers {
+		e.Pipeline.AddLast(fmt.Sprintf("handler%d", i), h)
+	}
+
+	e.loop.Post(func() {
+		e.Pipeline.FireActive()
+	})
+	e.loop.RunPendingTasks()
+	return e
+}
+
+// Loop 通道的事件循环
+func (e *EmbeddedChannel) Loop() *EmbeddedEventLoop {
+	return e.loop
+}
+
+// WriteInbound 模拟收到数据，每段数据触发一次读事件
+// 与运行时通道相同，启用暂停读取且通道不可写时数据暂存，恢复可写后投递
+func (e *EmbeddedChannel) WriteInbound(data ...[]byte) {
+	dispatch := func(task func()) {
+		e.loop.Post(func() {
+			if e.IsActive() {
+				task()
+			}
+		})
+	}
+	for _, d := range data {
+		e.deliverRead(d, dispatch)
+	}
+	e.loop.RunPendingTasks()
+}
+
+// WriteInboundAt 从名为name的处理器开始触发读事件，之前的处理器不参与，
+// 用于跳过帧解码等编解码器，向业务处理器直接注入已解码的报文
+func (e *EmbeddedChannel) WriteInboundAt(name string, msgs ...[]byte) error {
+	ctx := e.Pipeline.GetContext(name)
+	if ctx == nil {
+		return fmt.Errorf("%w: %s", ErrHandlerNotFound, name)
+	}
+	for _, m := range msgs {
+		e.loop.Post(func() {
+			if e.IsActive() {
+				ctx.FireRead(m)
+			}
+		})
+	}
+	e.loop.RunPendingTasks()
+	return nil
+}
+
+// WriteInboundFrames 按framer编码每个报文后模拟收到数据，每帧触发一次读事件
+func (e *EmbeddedChannel) WriteInboundFrames(framer Framer, frames ...[]byte) error {
+	wire := make([][]byte, 0, len(frames))
+	for _, f := range frames {
+		data, err := framer.Encode(f)
+		if err != nil {
+			return err
+		}
+		wire = append(wire, data)
+	}
+	e.WriteInbound(wire...)
+	return nil
+}
+
+// ReadInbound 按到达顺序读取一次经过全部处理器到达流水线尾部的读事件数据，无数据时返回nil
+func (e *EmbeddedChannel) ReadInbound() []byte {
+	e.inboundMu.Lock()
+	defer e.inboundMu.Unlock()
+	if len(e.inbound) == 0 {
+		return nil
+	}
+	data := e.inbound[0]
+	e.inbound = e.inbound[1:]
+	return data
+}
+
+// recordInbound 记录到达流水线尾部的读事件数据
+func (e *EmbeddedChannel) recordInbound(data []byte) {
+	e.inboundMu.Lock()
+	defer e.inboundMu.Unlock()
+	e.inbound = append(e.inbound, data)
+}
+
+// WriteOutbound 经流水线写出数据，等同于业务调用Channel.Write
+func (e *EmbeddedChannel) WriteOutbound(data ...[]byte) {
+	for _, d := range data {
+		e.Write(d)
+	}
+	e.loop.RunPendingTasks()
+}
+
+// ReadOutbound 按写出顺序读取一次写出到连接的数据，无数据时返回nil
+func (e *EmbeddedChannel) ReadOutbound() []byte {
+	e.conn.mu.Lock()
+	defer e.conn.mu.Unlock()
+	if len(e.conn.outbound) == 0 {
+		return nil
+	}
+	data := e.conn.outbound[0]
+	e.conn.outbound = e.conn.outbound[1:]
+	return data
+}
+
+// DrainOutbound 读取全部写出到连接的数据并拼接
+func (e *EmbeddedChannel) DrainOutbound() []byte {
+	e.conn.mu.Lock()
+	defer e.conn.mu.Unlock()
+	var out []byte
+	for _, d := range e.conn.outbound {
+		out = append(out, d...)
+	}
+	e.conn.outbound = nil
+	return out
+}
+
+// FireError 模拟连接异常
+func (e *EmbeddedChannel) FireError(err error) {
+	e.loop.Post(func() {
+		e.Pipeline.FireError(err)
+	})
+	e.loop.RunPendingTasks()
+}
+
+// AdvanceTime 推进假时钟并执行到期的延时任务
+func (e *EmbeddedChannel) AdvanceTime(d time.Duration) {
+	e.loop.AdvanceTime(d)
+}
+
+// RunPendingTasks 执行事件循环中待执行的任务
+func (e *EmbeddedChannel) RunPendingTasks() {
+	e.loop.RunPendingTasks()
+}
+
+// ActiveEvents 已触发的激活事件次数
+func (e *EmbeddedChannel) ActiveEvents() int {
+	e.recorder.mu.Lock()
+	defer e.recorder.mu.Unlock()
+	return e.recorder.active
+}
+
+// InactiveEvents 已触发的失活事件次数
+func (e *EmbeddedChannel) InactiveEvents() int {
+	e.recorder.mu.Lock()
+	defer e.recorder.mu.Unlock()
+	return e.recorder.inactive
+}
+
+// Errors 已触发的错误事件
+func (e *EmbeddedChannel) Errors() []error {
+	e.recorder.mu.Lock()
+	defer e.recorder.mu.Unlock()
+	errs := make([]error, len(e.recorder.errors))
+	copy(errs, e.recorder.errors)
+	return errs
+}
+
+// Finish 执行剩余任务后关闭通道，返回是否还有未读取的写出数据
+func (e *EmbeddedChannel) Finish() bool {
+	e.loop.RunPendingTasks()
+	e.Close()
+	e.loop.RunPendingTasks()
+	e.conn.mu.Lock()
+	defer e.conn.mu.Unlock()
+	return len(e.conn.outbound) > 0
+}
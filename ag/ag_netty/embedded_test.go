@@ -0,0 +1,151 @@
+package ag_netty
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// idleCloseHandler 激活后定时检查，超过空闲时间未收到数据则关闭通道
+type idleCloseHandler struct {
+	EchoHandler
+	idle     time.Duration
+	lastRead time.Time
+}
+
+func (h *idleCloseHandler) HandleActive(ctx *HandlerContext) {
+	loop := ctx.Channel().EventLoop().(*EmbeddedEventLoop)
+	h.lastRead = loop.Now()
+	h.check(ctx, loop)
+}
+
+func (h *idleCloseHandler) check(ctx *HandlerContext, loop *EmbeddedEventLoop) {
+	loop.Schedule(h.idle, func() {
+		if loop.Now().Sub(h.lastRead) >= h.idle {
+			ctx.Close()
+			return
+		}
+		h.check(ctx, loop)
+	})
+}
+
+func (h *idleCloseHandler) HandleRead(ctx *HandlerContext, data []byte) {
+	h.lastRead = ctx.Channel().EventLoop().(*EmbeddedEventLoop).Now()
+}
+
+// failHandler 收到数据即触发错误事件
+type failHandler struct {
+	EchoHandler
+	err error
+}
+
+func (h *failHandler) HandleRead(ctx *HandlerContext, data []byte) {
+	ctx.FireError(h.err)
+}
+
+func TestEmbeddedChannelEcho(t *testing.T) {
+	ch := NewEmbeddedChannel(&EchoHandler{})
+	if ch.ActiveEvents() != 1 {
+		t.Fatalf("ActiveEvents() = %d, want 1", ch.ActiveEvents())
+	}
+
+	ch.WriteInbound([]byte("hello"), []byte("world"))
+	if got := string(ch.ReadOutbound()); got != "hello" {
+		t.Errorf("ReadOutbound() = %q, want hello", got)
+	}
+	if got := string(ch.ReadOutbound()); got != "world" {
+		t.Errorf("ReadOutbound() = %q, want world", got)
+	}
+	if got := ch.ReadOutbound(); got != nil {
+		t.Errorf("ReadOutbound() = %q, want nil", got)
+	}
+
+	ch.WriteOutbound([]byte("ab"), []byte("cd"))
+	if got := string(ch.DrainOutbound()); got != "abcd" {
+		t.Errorf("DrainOutbound() = %q, want abcd", got)
+	}
+
+	if ch.Finish() {
+		t.Error("Finish() = true, want no unread outbound")
+	}
+	if ch.InactiveEvents() != 1 || ch.IsActive() {
+		t.Errorf("after Finish() inactive=%d active=%v", ch.InactiveEvents(), ch.IsActive())
+	}
+	ch.WriteInbound([]byte("closed"))
+	if got := ch.ReadOutbound(); got != nil {
+		t.Errorf("ReadOutbound() after close = %q, want nil", got)
+	}
+}
+
+func TestEmbeddedChannelSchedule(t *testing.T) {
+	ch := NewEmbeddedChannel(&idleCloseHandler{idle: 10 * time.Second})
+
+	ch.AdvanceTime(9 * time.Second)
+	ch.WriteInbound([]byte("ping"))
+	ch.AdvanceTime(time.Second)
+	if !ch.IsActive() {
+		t.Fatal("channel closed before idle timeout")
+	}
+
+	ch.AdvanceTime(9 * time.Second)
+	if !ch.IsActive() {
+		t.Fatal("channel closed before idle timeout")
+	}
+	ch.AdvanceTime(10 * time.Second)
+	if ch.IsActive() || ch.InactiveEvents() != 1 {
+		t.Fatalf("channel not closed after idle timeout, active=%v inactive=%d", ch.IsActive(), ch.InactiveEvents())
+	}
+}
+
+func TestEmbeddedChannelError(t *testing.T) {
+	errBad := errors.New("bad frame")
+	errConn := errors.New("connection reset")
+	ch := NewEmbeddedChannel(&failHandler{err: errBad})
+
+	ch.WriteInbound([]byte("x"))
+	ch.FireError(errConn)
+	errs := ch.Errors()
+	if len(errs) != 2 || !errors.Is(errs[0], errBad) || !errors.Is(errs[1], errConn) {
+		t.Fatalf("Errors() = %v, want [bad frame, connection reset]", errs)
+	}
+}
+
+func TestEmbeddedChannelInbound(t *testing.T) {
+	var frames []string
+	framer := NewLengthFieldFramer(2, false)
+	decoder := NewFrameHandler(framer, func(ctx *HandlerContext, frame []byte) {
+		frames = append(frames, string(frame))
+	})
+	recv := &orderHandler{done: make(chan struct{}), want: -1}
+	ch := NewEmbeddedChannel(decoder, recv)
+
+	// 报文经帧解码后交给回调，读事件数据经过全部处理器到达尾部
+	if err := ch.WriteInboundFrames(framer, []byte("ab"), []byte("cd")); err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 2 || frames[0] != "ab" || frames[1] != "cd" {
+		t.Fatalf("frames = %q", frames)
+	}
+	for _, want := range []string{"\x00\x02ab", "\x00\x02cd"} {
+		if got := string(ch.ReadInbound()); got != want {
+			t.Errorf("ReadInbound() = %q, want %q", got, want)
+		}
+	}
+	if got := ch.ReadInbound(); got != nil {
+		t.Errorf("ReadInbound() = %q, want nil", got)
+	}
+
+	// 越过帧解码直接向业务处理器注入报文
+	if err := ch.WriteInboundAt("handler1", []byte("decoded")); err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 2 || len(recv.got) != 3 || recv.got[2] != "decoded" {
+		t.Fatalf("frames = %q, handler1 got %q", frames, recv.got)
+	}
+	if got := string(ch.ReadInbound()); got != "decoded" {
+		t.Errorf("ReadInbound() = %q, want decoded", got)
+	}
+	if err := ch.WriteInboundAt("missing", []byte("x")); !errors.Is(err, ErrHandlerNotFound) {
+		t.Errorf("WriteInboundAt(missing) = %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"github.com/cloudwego/netpoll"
+	"log/slog"
 	"net"
 	"sync"
 	"time"
@@ -11,20 +12,20 @@ import (
 
 // EventLoop 事件循环
 type EventLoop struct {
-	loop      netpoll.EventLoop
-	taskQueue chan func()
-	quit      chan struct{}
-	wg        sync.WaitGroup
-	connMap   sync.Map // 存储连接的映射
-	initFunc  func(ch *Channel)
+	loop     netpoll.EventLoop
+	executor *EventExecutor
+	connMap  sync.Map // 存储连接的映射
+	initFunc func(ch *Channel)
+	options  *loopOptions
+	loopName string // 任务队列长度指标的loop标签
 }
 
 // NewEventLoop 创建新事件循环
-func NewEventLoop(initFunc func(ch *Channel)) (*EventLoop, error) {
+func NewEventLoop(initFunc func(ch *Channel), opts ...LoopOption) (*EventLoop, error) {
+	o := newLoopOptions(opts...)
 	el := &EventLoop{
-		taskQueue: make(chan func(), 1024),
-		quit:      make(chan struct{}),
-		initFunc:  initFunc,
+		initFunc: initFunc,
+		options:  o,
 	}
 
 	// 使用闭包捕获 EventLoop 实例
@@ -41,21 +42,48 @@ func NewEventLoop(initFunc func(ch *Channel)) (*EventLoop, error) {
 	el.loop = loop
 
 	// 启动任务处理协程
-	el.wg.Add(1)
-	go el.runTaskLoop()
+	el.executor = NewEventExecutor(o.taskQueueSize, o.rejectPolicy)
+	el.loopName = o.metrics.observeLoop("loop-", el.Pending)
 
 	return el, nil
 }
 
 // handlePrepare 处理新连接准备
 func (el *EventLoop) handlePrepare(conn netpoll.Connection) context.Context {
+	// 连接准入控制
+	if limiter := el.options.connLimiter; limiter != nil {
+		if err := limiter.Acquire(conn.RemoteAddr()); err != nil {
+			slog.Warn("ag_netty connection refused", "remote", conn.RemoteAddr(), "reason", err)
+			conn.Close()
+			return context.Background()
+		}
+		conn.AddCloseCallback(func(conn netpoll.Connection) error {
+			limiter.Release(conn.RemoteAddr())
+			return nil
+		})
+	}
+
+	el.options.metrics.connAccepted()
+
 	// 创建新通道
 	channel := NewChannel(conn, el)
+	el.options.applyChannel(channel)
 	el.connMap.Store(conn, channel)
 
 	if el.initFunc != nil {
 		el.initFunc(channel)
 	}
+	// 初始化失败时已关闭通道
+	if !channel.IsActive() {
+		return context.Background()
+	}
+
+	// TLS通道在握手完成后触发激活事件
+	if el.options.tlsConfig != nil {
+		channel.initTLS(conn, el.options.tlsConfig, false)
+		return context.WithValue(context.Background(), "channel", channel)
+	}
+
 	// 触发激活事件
 	channel.Pipeline.FireActive()
 
@@ -67,7 +95,6 @@ func (el *EventLoop) handleDisconnect(ctx context.Context, conn netpoll.Connecti
 	if !ok {
 		return
 	}
-	context.WithValue(ctx, "channel", nil)
 	c.(*Channel).Close()
 }
 
@@ -79,7 +106,16 @@ func (el *EventLoop) handleRequest(ctx context.Context, conn netpoll.Connection)
 		return errors.New("channel not found in context")
 	}
 
-	// 读取数据
+	if channel.tlsConn != nil {
+		return serveTLS(channel, el.options.tlsHandshake, channel.Pipeline.FireActive, func(task func()) { task() })
+	}
+
+	return readInbound(conn, channel, func(task func()) { task() })
+}
+
+// readInbound 读取连接当前全部可读数据，并通过dispatch投递读/错误事件
+// 服务端在netpoll回调中直接执行，客户端投递到ClientEventLoop保证与激活事件的顺序
+func readInbound(conn netpoll.Connection, channel *Channel, dispatch func(task func())) error {
 	reader := conn.Reader()
 	n := reader.Len()
 	if n == 0 {
@@ -88,44 +124,36 @@ func (el *EventLoop) handleRequest(ctx context.Context, conn netpoll.Connection)
 
 	data, err := reader.ReadBinary(n)
 	if err != nil {
-		channel.Pipeline.FireError(err)
+		dispatch(func() {
+			channel.Pipeline.FireError(err)
+		})
 		return err
 	}
 
-	// 触发读事件
-	channel.Pipeline.FireRead(data)
+	// 触发读事件，通道不可写且启用暂停读取时暂存
+	channel.deliverRead(data, dispatch)
 	return nil
 }
 
-// runTaskLoop 运行任务处理循环
-func (el *EventLoop) runTaskLoop() {
-	defer el.wg.Done()
+func (el *EventLoop) IsShutdown() bool {
+	return el.executor.IsShutdown()
+}
 
-	for {
-		select {
-		case task := <-el.taskQueue:
-			task()
-		case <-el.quit:
-			return
-		}
+// Post 投递任务到事件循环，队列已满时按拒绝策略处理
+func (el *EventLoop) Post(task func()) {
+	if err := el.Execute(task); err != nil && err != ErrExecutorShutdown {
+		slog.Warn("ag_netty event loop post failed", "error", err)
 	}
 }
 
-func (el *EventLoop) IsShutdown() bool {
-	select {
-	case <-el.quit:
-		return true
-	default:
-		return false
-	}
+// Execute 投递任务到事件循环并返回拒绝原因，任务未被执行时返回非nil
+func (el *EventLoop) Execute(task func()) error {
+	return el.executor.Execute(task)
 }
 
-// Post 投递任务到事件循环
-func (el *EventLoop) Post(task func()) {
-	select {
-	case el.taskQueue <- task:
-	case <-el.quit:
-	}
+// Pending 事件循环任务队列中等待执行的任务数
+func (el *EventLoop) Pending() int {
+	return el.executor.Pending()
 }
 
 // Schedule 调度延迟任务
@@ -142,8 +170,8 @@ func (el *EventLoop) Run(listener net.Listener) error {
 
 // Shutdown 关闭事件循环
 func (el *EventLoop) Shutdown() {
-	close(el.quit)
-	el.wg.Wait()
+	el.options.metrics.forgetLoop(el.loopName)
+	el.executor.Shutdown()
 	el.loop.Shutdown(context.Background())
 
 	// 关闭所有连接
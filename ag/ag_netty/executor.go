@@ -0,0 +1,209 @@
+package ag_netty
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrTaskRejected 任务队列已满且拒绝策略为abort时返回
+	ErrTaskRejected = errors.New("ag_netty: task rejected, queue is full")
+	// ErrTaskDiscarded 任务队列已满且拒绝策略为discard时返回，任务已被丢弃
+	ErrTaskDiscarded = errors.New("ag_netty: task discarded, queue is full")
+	// ErrExecutorShutdown 执行器已关闭
+	ErrExecutorShutdown = errors.New("ag_netty: executor is shutdown")
+)
+
+const (
+	DefaultTaskQueueSize = 1024
+)
+
+// RejectPolicy 任务队列已满时的拒绝策略
+type RejectPolicy string
+
+const (
+	// RejectBlock 阻塞提交者直到队列有空位(默认，与原有行为一致)
+	RejectBlock RejectPolicy = "block"
+	// RejectAbort 拒绝任务并返回 ErrTaskRejected
+	RejectAbort RejectPolicy = "abort"
+	// RejectDiscard 丢弃任务并返回 ErrTaskDiscarded
+	RejectDiscard RejectPolicy = "discard"
+	// RejectCallerRuns 由提交任务的协程直接执行。被执行的任务越过队列中已有的任务，
+	// 执行器组上同一通道的事件不再有序，且与执行器协程并发执行处理器，仅适用于无序且并发安全的处理器
+	RejectCallerRuns RejectPolicy = "caller-runs"
+)
+
+// ParseRejectPolicy 解析拒绝策略配置，空字符串视为 RejectBlock
+func ParseRejectPolicy(policy string) (RejectPolicy, error) {
+	switch p := RejectPolicy(policy); p {
+	case "":
+		return RejectBlock, nil
+	case RejectBlock, RejectAbort, RejectDiscard, RejectCallerRuns:
+		return p, nil
+	default:
+		return "", fmt.Errorf("ag_netty: unknown reject policy: %s", policy)
+	}
+}
+
+// EventExecutor 单协程顺序执行器，提交到同一执行器的任务按提交顺序执行
+type EventExecutor struct {
+	taskQueue chan func()
+	quit      chan struct{}
+	quitOnce  sync.Once
+	wg        sync.WaitGroup
+	policy    RejectPolicy
+}
+
+// NewEventExecutor 创建顺序执行器
+func NewEventExecutor(queueSize int, policy RejectPolicy) *EventExecutor {
+	if queueSize <= 0 {
+		queueSize = DefaultTaskQueueSize
+	}
+	if policy == "" {
+		policy = RejectBlock
+	}
+	e := &EventExecutor{
+		taskQueue: make(chan func(), queueSize),
+		quit:      make(chan struct{}),
+		policy:    policy,
+	}
+
+	e.wg.Add(1)
+	go e.run()
+
+	return e
+}
+
+// Execute 提交任务，队列已满时按拒绝策略处理
+func (e *EventExecutor) Execute(task func()) error {
+	if e.IsShutdown() {
+		return ErrExecutorShutdown
+	}
+
+	if e.policy == RejectBlock {
+		select {
+		case e.taskQueue <- task:
+			return nil
+		case <-e.quit:
+			return ErrExecutorShutdown
+		}
+	}
+
+	select {
+	case e.taskQueue <- task:
+		return nil
+	default:
+	}
+
+	switch e.policy {
+	case RejectDiscard:
+		return ErrTaskDiscarded
+	case RejectCallerRuns:
+		task()
+		return nil
+	default:
+		return ErrTaskRejected
+	}
+}
+
+// submit 忽略拒绝策略，阻塞直到任务入队，用于不可丢失的任务；执行器已关闭时返回 ErrExecutorShutdown
+func (e *EventExecutor) submit(task func()) error {
+	if e.IsShutdown() {
+		return ErrExecutorShutdown
+	}
+	select {
+	case e.taskQueue <- task:
+		return nil
+	case <-e.quit:
+		return ErrExecutorShutdown
+	}
+}
+
+// Pending 队列中等待执行的任务数
+func (e *EventExecutor) Pending() int {
+	return len(e.taskQueue)
+}
+
+// Shutdown 关闭执行器，等待正在执行的任务完成，队列中剩余任务被丢弃
+func (e *EventExecutor) Shutdown() {
+	e.quitOnce.Do(func() {
+		close(e.quit)
+	})
+	e.wg.Wait()
+}
+
+// IsShutdown 判断执行器是否已关闭
+func (e *EventExecutor) IsShutdown() bool {
+	select {
+	case <-e.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// run 运行任务处理循环
+func (e *EventExecutor) run() {
+	defer e.wg.Done()
+
+	for {
+		select {
+		case task := <-e.taskQueue:
+			e.safeRun(task)
+		case <-e.quit:
+			return
+		}
+	}
+}
+
+// safeRun 执行单个任务，防止业务处理器panic导致执行器退出
+func (e *EventExecutor) safeRun(task func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("ag_netty executor task panic", "panic", r)
+		}
+	}()
+	task()
+}
+
+// EventExecutorGroup 执行器组，用于承载阻塞型处理器
+// 每个通道在添加处理器时绑定组内固定的执行器，保证同一通道内事件有序，不同通道之间并行执行
+type EventExecutorGroup struct {
+	executors []*EventExecutor
+	next      atomic.Uint32
+}
+
+// NewEventExecutorGroup 创建执行器组
+func NewEventExecutorGroup(size int, queueSize int, policy RejectPolicy) *EventExecutorGroup {
+	if size <= 0 {
+		size = 1
+	}
+	group := &EventExecutorGroup{
+		executors: make([]*EventExecutor, size),
+	}
+	for i := 0; i < size; i++ {
+		group.executors[i] = NewEventExecutor(queueSize, policy)
+	}
+	return group
+}
+
+// Next 轮询获取下一个执行器
+func (g *EventExecutorGroup) Next() *EventExecutor {
+	n := g.next.Add(1) - 1
+	return g.executors[int(n%uint32(len(g.executors)))]
+}
+
+// Size 执行器数量
+func (g *EventExecutorGroup) Size() int {
+	return len(g.executors)
+}
+
+// Shutdown 关闭执行器组
+func (g *EventExecutorGroup) Shutdown() {
+	for _, e := range g.executors {
+		e.Shutdown()
+	}
+}
@@ -0,0 +1,255 @@
+package ag_netty
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseRejectPolicy(t *testing.T) {
+	for in, want := range map[string]RejectPolicy{
+		"":            RejectBlock,
+		"block":       RejectBlock,
+		"abort":       RejectAbort,
+		"discard":     RejectDiscard,
+		"caller-runs": RejectCallerRuns,
+	} {
+		if got, err := ParseRejectPolicy(in); err != nil || got != want {
+			t.Errorf("ParseRejectPolicy(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseRejectPolicy("drop"); err == nil {
+		t.Error("ParseRejectPolicy(drop) succeeded")
+	}
+}
+
+// busyExecutor 创建队列容量为1的执行器，执行器协程阻塞在首个任务上且队列已满，返回解除阻塞的函数
+func busyExecutor(t *testing.T, policy RejectPolicy) (*EventExecutor, func()) {
+	t.Helper()
+	e := NewEventExecutor(1, policy)
+	started, release := make(chan struct{}), make(chan struct{})
+	if err := e.Execute(func() { close(started); <-release }); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if err := e.Execute(func() {}); err != nil {
+		t.Fatal(err)
+	}
+	var once sync.Once
+	unblock := func() { once.Do(func() { close(release) }) }
+	t.Cleanup(func() {
+		unblock()
+		e.Shutdown()
+	})
+	return e, unblock
+}
+
+func TestRejectPolicies(t *testing.T) {
+	e, _ := busyExecutor(t, RejectAbort)
+	if err := e.Execute(func() {}); !errors.Is(err, ErrTaskRejected) {
+		t.Fatalf("abort: err = %v", err)
+	}
+
+	e, _ = busyExecutor(t, RejectDiscard)
+	if err := e.Execute(func() {}); !errors.Is(err, ErrTaskDiscarded) {
+		t.Fatalf("discard: err = %v", err)
+	}
+
+	// caller-runs在提交方协程立即执行
+	e, _ = busyExecutor(t, RejectCallerRuns)
+	ran := false
+	if err := e.Execute(func() { ran = true }); err != nil || !ran {
+		t.Fatalf("caller-runs: err = %v, ran = %v", err, ran)
+	}
+
+	// block等待队列空位
+	e, unblock := busyExecutor(t, RejectBlock)
+	done := make(chan error, 1)
+	go func() { done <- e.Execute(func() {}) }()
+	select {
+	case err := <-done:
+		t.Fatalf("block: returned before queue drained, err = %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	unblock()
+	if err := <-done; err != nil {
+		t.Fatalf("block: err = %v", err)
+	}
+
+	e.Shutdown()
+	if err := e.Execute(func() {}); !errors.Is(err, ErrExecutorShutdown) {
+		t.Fatalf("shutdown: err = %v", err)
+	}
+}
+
+// orderHandler 记录读到的数据
+type orderHandler struct {
+	EchoHandler
+	mu   sync.Mutex
+	got  []string
+	done chan struct{}
+	want int
+}
+
+func (h *orderHandler) HandleRead(ctx *HandlerContext, data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.got = append(h.got, string(data))
+	if len(h.got) == h.want {
+		close(h.done)
+	}
+}
+
+func TestExecutorGroupChannelOrdering(t *testing.T) {
+	group := NewEventExecutorGroup(4, 1024, RejectBlock)
+	defer group.Shutdown()
+
+	const channels, reads = 8, 200
+	handlers := make([]*orderHandler, channels)
+	var wg sync.WaitGroup
+	for i := range handlers {
+		h := &orderHandler{done: make(chan struct{}), want: reads}
+		handlers[i] = h
+		ch := NewEmbeddedChannel()
+		if err := ch.Pipeline.AddLastWithExecutor(group, "order", h); err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < reads; j++ {
+				ch.Pipeline.FireRead([]byte{byte(j)})
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := make([]string, reads)
+	for j := range want {
+		want[j] = string([]byte{byte(j)})
+	}
+	for i, h := range handlers {
+		select {
+		case <-h.done:
+		case <-time.After(3 * time.Second):
+			t.Fatalf("channel %d: timeout", i)
+		}
+		h.mu.Lock()
+		if !reflect.DeepEqual(h.got, want) {
+			t.Fatalf("channel %d: reads out of order", i)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// blockingHandler 读事件阻塞直到release关闭，记录失活事件
+type blockingHandler struct {
+	EchoHandler
+	started  chan struct{}
+	release  chan struct{}
+	inactive chan struct{}
+}
+
+func (h *blockingHandler) HandleRead(ctx *HandlerContext, data []byte) {
+	select {
+	case h.started <- struct{}{}:
+	default:
+	}
+	<-h.release
+}
+
+func (h *blockingHandler) HandleInactive(ctx *HandlerContext) {
+	close(h.inactive)
+}
+
+func TestHandlerRejectClosesChannel(t *testing.T) {
+	group := NewEventExecutorGroup(1, 1, RejectAbort)
+	defer group.Shutdown()
+
+	h := &blockingHandler{started: make(chan struct{}, 1), release: make(chan struct{}), inactive: make(chan struct{})}
+	ch := NewEmbeddedChannel()
+	if err := ch.Pipeline.AddLastWithExecutor(group, "blocking", h); err != nil {
+		t.Fatal(err)
+	}
+	ch.Pipeline.FireRead([]byte("1"))
+	<-h.started
+	ch.Pipeline.FireRead([]byte("2"))
+
+	// 队列已满，第三个读事件被拒绝，通道关闭且处理器仍收到失活事件
+	ch.Pipeline.FireRead([]byte("3"))
+	deadline := time.Now().Add(3 * time.Second)
+	for ch.IsActive() {
+		if time.Now().After(deadline) {
+			t.Fatal("channel not closed after rejection")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(h.release)
+	select {
+	case <-h.inactive:
+	case <-time.After(3 * time.Second):
+		t.Fatal("inactive event lost")
+	}
+}
+
+// pushHandler 服务端处理器，收到数据后分多次推送
+type pushHandler struct {
+	EchoHandler
+}
+
+func (h *pushHandler) HandleRead(ctx *HandlerContext, data []byte) {
+	for _, msg := range []string{"1", "2", "3", "4"} {
+		ctx.Write([]byte(msg))
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestClientReadRejectClosesChannel(t *testing.T) {
+	s, err := NewServer("127.0.0.1:0", func(ch *Channel) {
+		ch.Pipeline.AddLast("push", &pushHandler{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Start()
+	defer s.Shutdown()
+
+	h := &blockingHandler{started: make(chan struct{}, 1), release: make(chan struct{}), inactive: make(chan struct{})}
+	looper := NewClientEventLoop(func(ch *Channel) {
+		ch.Pipeline.AddLast("blocking", h)
+	}, WithTaskQueueSize(2), WithRejectPolicy(RejectAbort))
+	// 并发重复关闭不panic
+	defer func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				looper.Shutdown()
+			}()
+		}
+		wg.Wait()
+	}()
+	defer close(h.release)
+
+	ch, err := Dial(s.Addr().String(), time.Second, time.Second, time.Second, time.Minute, looper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ch.Close()
+	if _, err := ch.WriteAndFlush([]byte("go")).Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 首个读事件阻塞事件循环，之后两个排队，第四个被拒绝，数据已不完整，通道关闭
+	<-h.started
+	deadline := time.Now().Add(3 * time.Second)
+	for ch.IsActive() {
+		if time.Now().After(deadline) {
+			t.Fatal("channel not closed after read rejection")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,228 @@
+package ag_netty
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const (
+	// DefaultMaxFrameLength 帧最大长度的默认值，避免对端声明超大长度时按其缓存数据耗尽内存
+	DefaultMaxFrameLength = 4 << 20
+	// UnlimitedFrameLength 作为MaxLength时不限制帧长度，仅用于可信对端
+	UnlimitedFrameLength = -1
+)
+
+var (
+	// ErrFrameTooLong 帧长度超过上限，通道无法再同步帧边界
+	ErrFrameTooLong = errors.New("ag_netty: frame too long")
+	// ErrInvalidFrame 帧头非法或帧长度不符
+	ErrInvalidFrame = errors.New("ag_netty: invalid frame")
+)
+
+// FrameDecoder 从字节流中切分帧
+type FrameDecoder interface {
+	// Decode 从buf头部切出一帧，返回帧及消耗的字节数，数据不足一帧时n为0
+	Decode(buf []byte) (frame []byte, n int, err error)
+}
+
+// FrameEncoder 为待写出的数据加上帧边界
+type FrameEncoder interface {
+	Encode(frame []byte) ([]byte, error)
+}
+
+// Framer 帧编解码
+type Framer interface {
+	FrameDecoder
+	FrameEncoder
+}
+
+// FixedLengthFramer 定长帧
+type FixedLengthFramer struct {
+	Length int
+}
+
+// NewFixedLengthFramer 创建定长帧编解码
+func NewFixedLengthFramer(length int) *FixedLengthFramer {
+	return &FixedLengthFramer{Length: length}
+}
+
+func (f *FixedLengthFramer) Decode(buf []byte) ([]byte, int, error) {
+	if len(buf) < f.Length {
+		return nil, 0, nil
+	}
+	return buf[:f.Length], f.Length, nil
+}
+
+func (f *FixedLengthFramer) Encode(frame []byte) ([]byte, error) {
+	if len(frame) != f.Length {
+		return nil, fmt.Errorf("%w: length %d, want %d", ErrInvalidFrame, len(frame), f.Length)
+	}
+	return frame, nil
+}
+
+// DelimiterFramer 分隔符结尾的帧，解码后的帧不含分隔符
+// GBK/GB18030的多字节字符均不含0x00-0x2F的字节，以换行等控制字符作分隔符是安全的
+type DelimiterFramer struct {
+	Delimiter []byte
+	MaxLength int // 帧最大长度(不含分隔符)，0为 DefaultMaxFrameLength，UnlimitedFrameLength 表示不限制
+}
+
+// NewDelimiterFramer 创建分隔符帧编解码，maxLength为0时使用 DefaultMaxFrameLength
+func NewDelimiterFramer(delimiter []byte, maxLength int) *DelimiterFramer {
+	return &DelimiterFramer{Delimiter: delimiter, MaxLength: maxLength}
+}
+
+func (f *DelimiterFramer) Decode(buf []byte) ([]byte, int, error) {
+	limit := frameLimit(f.MaxLength)
+	i := bytes.Index(buf, f.Delimiter)
+	if i < 0 {
+		if limit > 0 && len(buf) > limit+len(f.Delimiter) {
+			return nil, 0, ErrFrameTooLong
+		}
+		return nil, 0, nil
+	}
+	if limit > 0 && i > limit {
+		return nil, 0, ErrFrameTooLong
+	}
+	return buf[:i], i + len(f.Delimiter), nil
+}
+
+func (f *DelimiterFramer) Encode(frame []byte) ([]byte, error) {
+	if bytes.Contains(frame, f.Delimiter) {
+		return nil, fmt.Errorf("%w: frame contains delimiter", ErrInvalidFrame)
+	}
+	out := make([]byte, 0, len(frame)+len(f.Delimiter))
+	return append(append(out, frame...), f.Delimiter...), nil
+}
+
+// frameLimit 帧最大长度，0为默认上限，负数为不限制
+func frameLimit(maxLength int) int {
+	if maxLength == 0 {
+		return DefaultMaxFrameLength
+	}
+	return maxLength
+}
+
+// LengthFieldFramer 长度域帧，帧头为Offset字节的前置数据加Size字节的长度域
+// 长度域取值加Adjustment为长度域之后的字节数；ASCII为true时长度域为左补0的十进制数字，否则为大端无符号整数
+type LengthFieldFramer struct {
+	Offset     int
+	Size       int
+	ASCII      bool
+	Adjustment int
+	Strip      bool // 解码时去掉帧头
+	MaxLength  int  // 帧最大长度(含帧头)，0为 DefaultMaxFrameLength，UnlimitedFrameLength 表示不限制
+}
+
+// NewLengthFieldFramer 创建长度域帧编解码，长度域位于帧首且只计帧体长度，帧最大长度为 DefaultMaxFrameLength
+// 例如核心系统常见的4位十进制报文长度头：NewLengthFieldFramer(4, true)
+func NewLengthFieldFramer(size int, ascii bool) *LengthFieldFramer {
+	return &LengthFieldFramer{Size: size, ASCII: ascii, Strip: true, MaxLength: DefaultMaxFrameLength}
+}
+
+func (f *LengthFieldFramer) Decode(buf []byte) ([]byte, int, error) {
+	header := f.Offset + f.Size
+	if len(buf) < header {
+		return nil, 0, nil
+	}
+	length, err := f.readLength(buf[f.Offset:header])
+	if err != nil {
+		return nil, 0, err
+	}
+	body := length + f.Adjustment
+	if body < 0 {
+		return nil, 0, fmt.Errorf("%w: length %d", ErrInvalidFrame, length)
+	}
+	total := header + body
+	if limit := frameLimit(f.MaxLength); limit > 0 && total > limit {
+		return nil, 0, ErrFrameTooLong
+	}
+	if len(buf) < total {
+		return nil, 0, nil
+	}
+	if f.Strip {
+		return buf[header:total], total, nil
+	}
+	return buf[:total], total, nil
+}
+
+// Encode 为帧体加上长度域，Offset大于0时前置数据需由调用方写入frame头部
+func (f *LengthFieldFramer) Encode(frame []byte) ([]byte, error) {
+	if len(frame) < f.Offset {
+		return nil, fmt.Errorf("%w: frame shorter than offset", ErrInvalidFrame)
+	}
+	length := len(frame) - f.Offset - f.Adjustment
+	field, err := f.writeLength(length)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(frame)+len(field))
+	out = append(out, frame[:f.Offset]...)
+	out = append(out, field...)
+	return append(out, frame[f.Offset:]...), nil
+}
+
+func (f *LengthFieldFramer) readLength(field []byte) (int, error) {
+	if f.ASCII {
+		n, err := strconv.Atoi(string(bytes.TrimSpace(field)))
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("%w: length field %q", ErrInvalidFrame, field)
+		}
+		return n, nil
+	}
+	switch f.Size {
+	case 1:
+		return int(field[0]), nil
+	case 2:
+		return int(binary.BigEndian.Uint16(field)), nil
+	case 4:
+		return int(binary.BigEndian.Uint32(field)), nil
+	case 8:
+		n := binary.BigEndian.Uint64(field)
+		if n > uint64(^uint(0)>>1) {
+			return 0, fmt.Errorf("%w: length %d", ErrInvalidFrame, n)
+		}
+		return int(n), nil
+	default:
+		return 0, fmt.Errorf("%w: unsupported length field size %d", ErrInvalidFrame, f.Size)
+	}
+}
+
+func (f *LengthFieldFramer) writeLength(length int) ([]byte, error) {
+	if length < 0 {
+		return nil, fmt.Errorf("%w: length %d", ErrInvalidFrame, length)
+	}
+	if f.ASCII {
+		s := strconv.Itoa(length)
+		if len(s) > f.Size {
+			return nil, ErrFrameTooLong
+		}
+		return []byte(fmt.Sprintf("%0*d", f.Size, length)), nil
+	}
+	field := make([]byte, f.Size)
+	switch f.Size {
+	case 1:
+		if length > 0xFF {
+			return nil, ErrFrameTooLong
+		}
+		field[0] = byte(length)
+	case 2:
+		if length > 0xFFFF {
+			return nil, ErrFrameTooLong
+		}
+		binary.BigEndian.PutUint16(field, uint16(length))
+	case 4:
+		if uint64(length) > 0xFFFFFFFF {
+			return nil, ErrFrameTooLong
+		}
+		binary.BigEndian.PutUint32(field, uint32(length))
+	case 8:
+		binary.BigEndian.PutUint64(field, uint64(length))
+	default:
+		return nil, fmt.Errorf("%w: unsupported length field size %d", ErrInvalidFrame, f.Size)
+	}
+	return field, nil
+}
@@ -0,0 +1,175 @@
+package ag_netty
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+const cumulationAttrPrefix = "ag_netty.cumulation."
+
+// FrameTransformer 帧变换，如签名验签、加密解密
+type FrameTransformer interface {
+	// Outbound 写出前变换帧
+	Outbound(ctx *HandlerContext, frame []byte) ([]byte, error)
+	// Inbound 读入后还原帧
+	Inbound(ctx *HandlerContext, frame []byte) ([]byte, error)
+}
+
+// FrameHandler 成帧处理器
+// 读路径：按Framer切分字节流，不足一帧的数据按通道缓存，每帧经变换还原、decode转换后交由onFrame处理；
+// 写路径：数据经encode转换、变换并加上帧边界后直接写出，是流水线中最终写出连接的处理器
+// 帧解码失败时触发错误事件并关闭通道；单帧还原或转换失败时只触发错误事件并丢弃该帧
+type FrameHandler struct {
+	framer       Framer
+	decode       func(ctx *HandlerContext, frame []byte) error
+	encode       func(data []byte) ([]byte, error)
+	transformers []FrameTransformer
+}
+
+// NewFrameHandler 创建成帧处理器，framer为nil时每次读到的数据作为一帧
+func NewFrameHandler(framer Framer, onFrame func(ctx *HandlerContext, frame []byte)) *FrameHandler {
+	return &FrameHandler{
+		framer: framer,
+		decode: func(ctx *HandlerContext, frame []byte) error {
+			onFrame(ctx, frame)
+			return nil
+		},
+	}
+}
+
+// NewCharsetHandler 创建字符集转换处理器，读到的帧转为UTF-8文本交由onText处理，写出的UTF-8文本转为charset编码
+func NewCharsetHandler(framer Framer, charset Charset, onText func(ctx *HandlerContext, text string)) *FrameHandler {
+	return &FrameHandler{
+		framer: framer,
+		decode: func(ctx *HandlerContext, frame []byte) error {
+			text, err := charset.Decode(frame)
+			if err != nil {
+				return err
+			}
+			onText(ctx, text)
+			return nil
+		},
+		encode: func(data []byte) ([]byte, error) {
+			return charset.Encode(string(data))
+		},
+	}
+}
+
+// NewRecordHandler 创建报文处理器，读到的帧按marshaller解码为T交由onRecord处理
+// 应答报文使用 WriteRecord 写出
+func NewRecordHandler[T any](framer Framer, marshaller *RecordMarshaller, onRecord func(ctx *HandlerContext, record *T)) *FrameHandler {
+	return &FrameHandler{
+		framer: framer,
+		decode: func(ctx *HandlerContext, frame []byte) error {
+			record := new(T)
+			if err := marshaller.Unmarshal(frame, record); err != nil {
+				return err
+			}
+			onRecord(ctx, record)
+			return nil
+		},
+	}
+}
+
+// WriteRecord 按marshaller编码报文并写出，编码失败时返回失败的Promise
+func WriteRecord(ch *Channel, marshaller *RecordMarshaller, record any) *Promise[struct{}] {
+	data, err := marshaller.Marshal(record)
+	if err != nil {
+		p := NewPromise[struct{}]()
+		p.Failure(err)
+		return p
+	}
+	return ch.WriteAndFlush(data)
+}
+
+// With 追加帧变换，写出时按添加顺序执行，读入时按相反顺序还原
+// 例如先加密再签名：With(cipherTransformer, macTransformer)
+func (h *FrameHandler) With(transformers ...FrameTransformer) *FrameHandler {
+	h.transformers = append(h.transformers, transformers...)
+	return h
+}
+
+func (h *FrameHandler) HandleActive(ctx *HandlerContext) {}
+
+func (h *FrameHandler) HandleInactive(ctx *HandlerContext) {
+	ctx.Channel().Attrs().Delete(h.cumulationKey(ctx))
+}
+
+func (h *FrameHandler) HandleRead(ctx *HandlerContext, data []byte) {
+	if h.framer == nil {
+		h.fire(ctx, data)
+		return
+	}
+
+	key := h.cumulationKey(ctx)
+	buf, _ := AttrOf[[]byte](ctx.Channel().Attrs(), key)
+	buf = append(buf, data...)
+	for len(buf) > 0 {
+		frame, n, err := h.framer.Decode(buf)
+		if err != nil {
+			ctx.Channel().Attrs().Delete(key)
+			slog.Warn("ag_netty frame decode failed, closing channel", "handler", ctx.Name(), "remote", ctx.Channel().RemoteAddr(), "error", err)
+			ctx.Pipeline().FireError(err)
+			ctx.Close()
+			return
+		}
+		if n == 0 {
+			break
+		}
+		h.fire(ctx, frame)
+		buf = buf[n:]
+	}
+
+	if len(buf) == 0 {
+		ctx.Channel().Attrs().Delete(key)
+		return
+	}
+	// 剩余数据复制到新的缓冲区，已交付的帧不受后续追加影响
+	ctx.Channel().Attrs().Set(key, append([]byte(nil), buf...))
+}
+
+func (h *FrameHandler) fire(ctx *HandlerContext, frame []byte) {
+	for i := len(h.transformers) - 1; i >= 0; i-- {
+		var err error
+		if frame, err = h.transformers[i].Inbound(ctx, frame); err != nil {
+			slog.Warn("ag_netty frame dropped", "handler", ctx.Name(), "remote", ctx.Channel().RemoteAddr(), "error", err)
+			ctx.Pipeline().FireError(err)
+			return
+		}
+	}
+	if err := h.decode(ctx, frame); err != nil {
+		ctx.Pipeline().FireError(fmt.Errorf("ag_netty frame handler %s: %w", ctx.Name(), err))
+		return
+	}
+	ctx.Channel().metrics.frameDecoded()
+}
+
+func (h *FrameHandler) HandleWrite(ctx *HandlerContext, data []byte) {
+	var err error
+	if h.encode != nil {
+		if data, err = h.encode(data); err != nil {
+			ctx.failWrite(err)
+			return
+		}
+	}
+	for _, t := range h.transformers {
+		if data, err = t.Outbound(ctx, data); err != nil {
+			ctx.failWrite(err)
+			return
+		}
+	}
+	if h.framer != nil {
+		if data, err = h.framer.Encode(data); err != nil {
+			ctx.failWrite(err)
+			return
+		}
+	}
+	ctx.WriteDirect(data)
+}
+
+func (h *FrameHandler) HandleError(ctx *HandlerContext, err error) {}
+
+// cumulationKey 按处理器名称区分缓存，同一处理器实例可用于多个通道
+func (h *FrameHandler) cumulationKey(ctx *HandlerContext) string {
+	return cumulationAttrPrefix + ctx.Name()
+}
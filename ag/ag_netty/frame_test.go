@@ -0,0 +1,130 @@
+package ag_netty
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestFramers(t *testing.T) {
+	tests := []struct {
+		name   string
+		framer Framer
+		frame  []byte
+		wire   []byte
+	}{
+		{"fixed", NewFixedLengthFramer(4), []byte("abcd"), []byte("abcd")},
+		{"delimiter", NewDelimiterFramer([]byte("\r\n"), 16), []byte("hello"), []byte("hello\r\n")},
+		{"ascii length", NewLengthFieldFramer(4, true), []byte("hello"), []byte("0005hello")},
+		{"binary length", NewLengthFieldFramer(2, false), []byte("hi"), []byte{0, 2, 'h', 'i'}},
+		{"length includes header", &LengthFieldFramer{Size: 4, ASCII: true, Adjustment: -4, Strip: true}, []byte("ab"), []byte("0006ab")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wire, err := tt.framer.Encode(tt.frame)
+			if err != nil || !bytes.Equal(wire, tt.wire) {
+				t.Fatalf("Encode() = %q, %v, want %q", wire, err, tt.wire)
+			}
+			if _, n, _ := tt.framer.Decode(wire[:len(wire)-1]); n != 0 {
+				t.Fatalf("Decode() partial consumed %d", n)
+			}
+			frame, n, err := tt.framer.Decode(append(wire, 'x'))
+			if err != nil || n != len(wire) || !bytes.Equal(frame, tt.frame) {
+				t.Fatalf("Decode() = %q, %d, %v", frame, n, err)
+			}
+		})
+	}
+
+	if _, _, err := NewDelimiterFramer([]byte("\n"), 4).Decode([]byte("toolong")); !errors.Is(err, ErrFrameTooLong) {
+		t.Errorf("Decode() without delimiter = %v", err)
+	}
+	// 未设置最大长度时使用默认上限
+	if _, _, err := NewDelimiterFramer([]byte("\n"), 0).Decode(make([]byte, DefaultMaxFrameLength+2)); !errors.Is(err, ErrFrameTooLong) {
+		t.Errorf("Decode() over default limit = %v", err)
+	}
+	if _, _, err := NewLengthFieldFramer(4, true).Decode([]byte("00x1")); !errors.Is(err, ErrInvalidFrame) {
+		t.Errorf("Decode() bad length = %v", err)
+	}
+
+	// 长度域声明的帧超过默认上限时报错，不再等待缓存后续数据；显式设置不限制时等待
+	huge := []byte{0x7F, 0xFF, 0xFF, 0xFF, 'x'}
+	if _, _, err := NewLengthFieldFramer(4, false).Decode(huge); !errors.Is(err, ErrFrameTooLong) {
+		t.Errorf("Decode() huge frame = %v, want ErrFrameTooLong", err)
+	}
+	if _, _, err := (&LengthFieldFramer{Size: 4}).Decode(huge); !errors.Is(err, ErrFrameTooLong) {
+		t.Errorf("Decode() huge frame with zero MaxLength = %v, want ErrFrameTooLong", err)
+	}
+	unlimited := NewLengthFieldFramer(4, false)
+	unlimited.MaxLength = UnlimitedFrameLength
+	if _, n, err := unlimited.Decode(huge); err != nil || n != 0 {
+		t.Errorf("Decode() huge frame unlimited = %d, %v", n, err)
+	}
+}
+
+type transferResp struct {
+	Code string `record:"len=4"`
+	Msg  string `record:"len=20"`
+}
+
+func TestRecordHandler(t *testing.T) {
+	m := NewRecordMarshaller(WithRecordCharset(CharsetGBK))
+	var got []*transferReq
+	ch := NewEmbeddedChannel(NewRecordHandler(NewLengthFieldFramer(4, true), m, func(ctx *HandlerContext, req *transferReq) {
+		got = append(got, req)
+		WriteRecord(ctx.Channel(), m, &transferResp{Code: "0000", Msg: "成功"})
+	}))
+
+	body, err := m.Marshal(transferReq{TxCode: "TR01", Name: "李四", Count: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wire, _ := NewLengthFieldFramer(4, true).Encode(body)
+	wire = append(wire, wire...)
+
+	// 两帧拆成三次到达
+	ch.WriteInbound(wire[:3], wire[3:50], wire[50:])
+	if len(got) != 2 || got[0].Name != "李四" || got[1].Count != 1 {
+		t.Fatalf("records = %+v", got)
+	}
+	ch.RunPendingTasks()
+	resp := ch.ReadOutbound()
+	var decoded transferResp
+	if !bytes.HasPrefix(resp, []byte("0024")) || m.Unmarshal(resp[4:], &decoded) != nil || decoded.Msg != "成功" {
+		t.Fatalf("response = %q", resp)
+	}
+	if _, err := WriteRecord(ch.Channel, m, &queryResp{}).Get(); err == nil {
+		t.Error("WriteRecord() accepted fixed-width record without len")
+	}
+
+	// 非法长度头关闭通道
+	ch.WriteInbound([]byte("ABCD"))
+	if ch.IsActive() || len(ch.Errors()) != 1 || !errors.Is(ch.Errors()[0], ErrInvalidFrame) {
+		t.Fatalf("active=%v errors=%v", ch.IsActive(), ch.Errors())
+	}
+}
+
+func TestCharsetHandler(t *testing.T) {
+	var texts []string
+	ch := NewEmbeddedChannel(NewCharsetHandler(NewDelimiterFramer([]byte("\n"), 0), CharsetGBK, func(ctx *HandlerContext, text string) {
+		texts = append(texts, text)
+		ctx.Write([]byte("收到:" + text))
+	}))
+
+	wire, _ := CharsetGBK.Encode("你好\n世界\n")
+	ch.WriteInbound(wire[:3], wire[3:])
+	if len(texts) != 2 || texts[0] != "你好" || texts[1] != "世界" {
+		t.Fatalf("texts = %q", texts)
+	}
+	ch.RunPendingTasks()
+	want, _ := CharsetGBK.Encode("收到:你好\n")
+	if out := ch.ReadOutbound(); !bytes.Equal(out, want) {
+		t.Errorf("outbound = %q, want %q", out, want)
+	}
+
+	// 目标字符集无法表示的字符写出失败
+	p := ch.WriteAndFlush([]byte("emoji 😀"))
+	ch.RunPendingTasks()
+	if _, err := p.Get(); err == nil {
+		t.Error("WriteAndFlush() of unencodable text succeeded")
+	}
+}
@@ -2,57 +2,53 @@ package ag_netty
 
 import (
 	"errors"
-	"sync"
 	"time"
 )
 
-// Future 异步操作结果
+// ErrFutureTimeout 等待Future超时
+var ErrFutureTimeout = errors.New("read timeout")
+
+// Future 请求应答结果，由应答处理器通过Complete完成
+// 基于Promise实现，重复Complete不生效
 type Future struct {
-	done   chan struct{}
-	result interface{}
-	mu     sync.Mutex
+	promise *Promise[any]
 }
 
 // NewFuture 创建新Future
 func NewFuture() *Future {
-	return &Future{done: make(chan struct{})}
+	return &Future{promise: NewPromise[any]()}
 }
 
-// Complete 完成Future
+// Complete 完成Future，result为error时同样作为结果返回
 func (f *Future) Complete(result interface{}) {
-	f.mu.Lock()
-	defer f.mu.Unlock()
-
-	if f.done == nil {
-		return
-	}
+	f.promise.Success(result)
+}
 
-	f.result = result
-	close(f.done)
+// Promise 底层Promise，可添加回调或按context等待
+func (f *Future) Promise() *Promise[any] {
+	return f.promise
 }
 
-// Get 获取结果（阻塞）
+// Get 获取结果（阻塞），失败时返回错误本身
 func (f *Future) Get() interface{} {
-	<-f.done
-	return f.result
+	value, err := f.promise.Get()
+	if err != nil {
+		return err
+	}
+	return value
 }
 
 // GetWithTimeout 带超时获取结果
 func (f *Future) GetWithTimeout(timeout time.Duration) (interface{}, error) {
 	select {
-	case <-f.done:
-		return f.result, nil
+	case <-f.promise.Done():
+		return f.promise.Get()
 	case <-time.After(timeout):
-		return nil, errors.New("read timeout")
+		return nil, ErrFutureTimeout
 	}
 }
 
 // IsDone 判断是否完成
 func (f *Future) IsDone() bool {
-	select {
-	case <-f.done:
-		return true
-	default:
-		return false
-	}
+	return f.promise.IsDone()
 }
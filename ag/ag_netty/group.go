@@ -10,13 +10,13 @@ type EventLoopGroup struct {
 }
 
 // NewEventLoopGroup 创建事件循环组
-func NewEventLoopGroup(size int, initFunc func(ch *Channel)) (*EventLoopGroup, error) {
+func NewEventLoopGroup(size int, initFunc func(ch *Channel), opts ...LoopOption) (*EventLoopGroup, error) {
 	group := &EventLoopGroup{
 		loops: make([]*EventLoop, size),
 	}
 
 	for i := 0; i < size; i++ {
-		loop, err := NewEventLoop(initFunc)
+		loop, err := NewEventLoop(initFunc, opts...)
 		if err != nil {
 			// 清理已创建的循环
 			for j := 0; j < i; j++ {
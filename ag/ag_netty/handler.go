@@ -8,3 +8,14 @@ type ChannelHandler interface {
 	HandleWrite(ctx *HandlerContext, data []byte)
 	HandleError(ctx *HandlerContext, err error)
 }
+
+// WritabilityHandler 可选接口，处理器实现后可感知通道可写状态变化(写缓冲越过高/低水位线)
+type WritabilityHandler interface {
+	HandleWritabilityChanged(ctx *HandlerContext, writable bool)
+}
+
+// LifecycleHandler 可选接口，处理器被加入或移出流水线时回调，可用于初始化或释放处理器持有的资源
+type LifecycleHandler interface {
+	HandlerAdded(ctx *HandlerContext)
+	HandlerRemoved(ctx *HandlerContext)
+}
@@ -0,0 +1,248 @@
+package iso8583
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrFieldUndefined 规格中未定义该字段
+	ErrFieldUndefined = errors.New("iso8583: field undefined")
+	// ErrFieldLength 字段长度不符合定义
+	ErrFieldLength = errors.New("iso8583: invalid field length")
+	// ErrFieldContent 字段内容不符合类型定义
+	ErrFieldContent = errors.New("iso8583: invalid field content")
+	// ErrShortMessage 报文长度不足
+	ErrShortMessage = errors.New("iso8583: short message")
+)
+
+// pack 按定义编码字段值，value为n/z的数字串、ans的文本或b的原始字节
+func (f *FieldSpec) pack(value []byte) ([]byte, error) {
+	if err := f.validate(value); err != nil {
+		return nil, err
+	}
+	units := len(value)
+	var out []byte
+	if f.LenType == Fixed {
+		if units > f.Length {
+			return nil, fmt.Errorf("%w: %d, want %d", ErrFieldLength, units, f.Length)
+		}
+		if units < f.Length {
+			switch f.Content {
+			case ContentN:
+				value = append(bytes.Repeat([]byte{'0'}, f.Length-units), value...)
+			case ContentANS:
+				value = append(append([]byte(nil), value...), bytes.Repeat([]byte{' '}, f.Length-units)...)
+			default:
+				return nil, fmt.Errorf("%w: %d, want %d", ErrFieldLength, units, f.Length)
+			}
+		}
+	} else {
+		if units > f.Length {
+			return nil, fmt.Errorf("%w: %d, max %d", ErrFieldLength, units, f.Length)
+		}
+		prefix, err := f.packLength(units)
+		if err != nil {
+			return nil, err
+		}
+		out = prefix
+	}
+
+	body, err := f.packBody(value)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, body...), nil
+}
+
+// unpack 从data头部解码字段，返回字段值及消耗的字节数
+func (f *FieldSpec) unpack(data []byte) ([]byte, int, error) {
+	units, read := f.Length, 0
+	if f.LenType != Fixed {
+		var err error
+		units, read, err = f.unpackLength(data)
+		if err != nil {
+			return nil, 0, err
+		}
+		if units > f.Length {
+			return nil, 0, fmt.Errorf("%w: %d, max %d", ErrFieldLength, units, f.Length)
+		}
+	}
+
+	size := f.bodySize(units)
+	if len(data) < read+size {
+		return nil, 0, ErrShortMessage
+	}
+	value, err := f.unpackBody(data[read:read+size], units)
+	if err != nil {
+		return nil, 0, err
+	}
+	return value, read + size, nil
+}
+
+func (f *FieldSpec) validate(value []byte) error {
+	switch f.Content {
+	case ContentN:
+		for _, c := range value {
+			if c < '0' || c > '9' {
+				return fmt.Errorf("%w: non-digit %q in numeric field", ErrFieldContent, c)
+			}
+		}
+	case ContentZ:
+		for _, c := range value {
+			if (c < '0' || c > '9') && c != '=' && c != 'D' {
+				return fmt.Errorf("%w: %q in track field", ErrFieldContent, c)
+			}
+		}
+	}
+	return nil
+}
+
+// bodySize 字段内容在报文中占用的字节数
+func (f *FieldSpec) bodySize(units int) int {
+	switch {
+	case f.Encoding == EncodingBCD:
+		return (units + 1) / 2
+	case f.Content == ContentB && f.Encoding == EncodingASCII:
+		return units * 2
+	default:
+		return units
+	}
+}
+
+func (f *FieldSpec) packBody(value []byte) ([]byte, error) {
+	switch f.Encoding {
+	case EncodingASCII:
+		if f.Content == ContentB {
+			return []byte(strings.ToUpper(hex.EncodeToString(value))), nil
+		}
+		return value, nil
+	case EncodingBCD:
+		switch f.Content {
+		case ContentN:
+			return packBCD(value, true), nil
+		case ContentZ:
+			return packBCD(bytes.ReplaceAll(value, []byte{'='}, []byte{'D'}), false), nil
+		}
+	case EncodingBinary:
+		if f.Content == ContentB {
+			return value, nil
+		}
+	}
+	return nil, fmt.Errorf("iso8583: unsupported encoding %d for content %d", f.Encoding, f.Content)
+}
+
+func (f *FieldSpec) unpackBody(data []byte, units int) ([]byte, error) {
+	switch f.Encoding {
+	case EncodingASCII:
+		if f.Content == ContentB {
+			value, err := hex.DecodeString(string(data))
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrFieldContent, err)
+			}
+			return value, nil
+		}
+		value := append([]byte(nil), data...)
+		return value, f.validate(value)
+	case EncodingBCD:
+		switch f.Content {
+		case ContentN:
+			value := unpackBCD(data, units, true)
+			return value, f.validate(value)
+		case ContentZ:
+			value := bytes.ReplaceAll(unpackBCD(data, units, false), []byte{'D'}, []byte{'='})
+			return value, f.validate(value)
+		}
+	case EncodingBinary:
+		if f.Content == ContentB {
+			return append([]byte(nil), data...), nil
+		}
+	}
+	return nil, fmt.Errorf("iso8583: unsupported encoding %d for content %d", f.Encoding, f.Content)
+}
+
+func (f *FieldSpec) lengthDigits() int {
+	if f.LenType == LLLVAR {
+		return 3
+	}
+	return 2
+}
+
+func (f *FieldSpec) packLength(units int) ([]byte, error) {
+	digits := f.lengthDigits()
+	s := fmt.Sprintf("%0*d", digits, units)
+	if len(s) > digits {
+		return nil, fmt.Errorf("%w: %d", ErrFieldLength, units)
+	}
+	if f.LenEncoding == EncodingBCD {
+		return packBCD([]byte(s), true), nil
+	}
+	return []byte(s), nil
+}
+
+func (f *FieldSpec) unpackLength(data []byte) (int, int, error) {
+	digits := f.lengthDigits()
+	size := digits
+	if f.LenEncoding == EncodingBCD {
+		size = (digits + 1) / 2
+	}
+	if len(data) < size {
+		return 0, 0, ErrShortMessage
+	}
+	s := data[:size]
+	if f.LenEncoding == EncodingBCD {
+		s = unpackBCD(s, digits, true)
+	}
+	n, err := strconv.Atoi(string(s))
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: length prefix %q", ErrFieldLength, data[:size])
+	}
+	return n, size, nil
+}
+
+// packBCD 数字串压缩为BCD，奇数位时leftPad为true左补0，否则右补F
+func packBCD(digits []byte, leftPad bool) []byte {
+	if len(digits)%2 == 1 {
+		if leftPad {
+			digits = append([]byte{'0'}, digits...)
+		} else {
+			digits = append(append([]byte(nil), digits...), 'F')
+		}
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		out[i] = nibble(digits[2*i])<<4 | nibble(digits[2*i+1])
+	}
+	return out
+}
+
+// unpackBCD BCD解压为units位数字串
+func unpackBCD(data []byte, units int, leftPad bool) []byte {
+	const hexDigits = "0123456789ABCDEF"
+	out := make([]byte, 0, len(data)*2)
+	for _, b := range data {
+		out = append(out, hexDigits[b>>4], hexDigits[b&0x0F])
+	}
+	if len(out) > units {
+		if leftPad {
+			return out[len(out)-units:]
+		}
+		return out[:units]
+	}
+	return out
+}
+
+func nibble(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10
+	default:
+		return 0
+	}
+}
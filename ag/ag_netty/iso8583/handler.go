@@ -0,0 +1,96 @@
+package iso8583
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/frochyzhang/ag-core/ag/ag_netty"
+)
+
+// ErrNoRoute 报文MTI未注册处理函数
+var ErrNoRoute = errors.New("iso8583: no route for MTI")
+
+// HandlerFunc 报文处理函数
+type HandlerFunc func(ctx *ag_netty.HandlerContext, msg *Message)
+
+// Router 按MTI分发报文
+type Router struct {
+	mu       sync.RWMutex
+	routes   map[string]HandlerFunc
+	notFound HandlerFunc
+}
+
+// NewRouter 创建MTI路由
+func NewRouter() *Router {
+	return &Router{routes: make(map[string]HandlerFunc)}
+}
+
+// Handle 注册MTI处理函数
+func (r *Router) Handle(mti string, h HandlerFunc) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes[mti] = h
+	return r
+}
+
+// NotFound 未注册MTI的处理函数，未设置时触发 ErrNoRoute 错误事件
+func (r *Router) NotFound(h HandlerFunc) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notFound = h
+	return r
+}
+
+// Dispatch 分发报文
+func (r *Router) Dispatch(ctx *ag_netty.HandlerContext, msg *Message) error {
+	r.mu.RLock()
+	h, ok := r.routes[msg.MTI]
+	if !ok {
+		h = r.notFound
+	}
+	r.mu.RUnlock()
+	if h == nil {
+		return fmt.Errorf("%w %s", ErrNoRoute, msg.MTI)
+	}
+	h(ctx, msg)
+	return nil
+}
+
+// NewHandler 创建ISO 8583编解码处理器
+// 读路径按framer切分报文、按spec解码后经router分发；写路径为framer加帧头后写出，报文由 Write 按spec编码
+// 解码或分发失败时触发错误事件，通道保持连接
+func NewHandler(spec *Spec, framer ag_netty.Framer, router *Router) *ag_netty.FrameHandler {
+	return ag_netty.NewFrameHandler(framer, func(ctx *ag_netty.HandlerContext, frame []byte) {
+		msg, err := spec.Unpack(frame)
+		if err == nil {
+			err = router.Dispatch(ctx, msg)
+		}
+		if err != nil {
+			slog.Warn("ag_netty iso8583 message dropped", "remote", ctx.Channel().RemoteAddr(), "error", err)
+			ctx.Pipeline().FireError(err)
+		}
+	})
+}
+
+// Write 按spec编码报文并写出，编码失败时返回失败的Promise
+func Write(ch *ag_netty.Channel, spec *Spec, msg *Message) *ag_netty.Promise[struct{}] {
+	data, err := spec.Pack(msg)
+	if err != nil {
+		p := ag_netty.NewPromise[struct{}]()
+		p.Failure(err)
+		return p
+	}
+	return ch.WriteAndFlush(data)
+}
+
+// ResponseMTI 请求或通知MTI对应的应答MTI，例如0200->0210、0220->0230，其他MTI原样返回
+func ResponseMTI(mti string) string {
+	if len(mti) != 4 || mti[2] < '0' || mti[2] > '8' || (mti[2]-'0')%2 != 0 {
+		return mti
+	}
+	b := []byte(mti)
+	b[2]++
+	return string(b)
+}
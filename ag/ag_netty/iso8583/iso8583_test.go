@@ -0,0 +1,185 @@
+package iso8583
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/frochyzhang/ag-core/ag/ag_netty"
+)
+
+type purchase struct {
+	MTI      string `iso8583:"mti"`
+	PAN      string `iso8583:"2"`
+	Proc     string `iso8583:"3"`
+	Amount   int64  `iso8583:"4"`
+	STAN     int    `iso8583:"11"`
+	Terminal string `iso8583:"41"`
+	Currency string `iso8583:"49"`
+	PIN      []byte `iso8583:"52"`
+	Ignored  string
+}
+
+// ASCII 0200消费请求样例
+const samplePurchase = "0200" + "7020000000808000" +
+	"16" + "4111111111111111" +
+	"000000" +
+	"000000001000" +
+	"123456" +
+	"TERM0001" +
+	"156"
+
+func TestASCIISpecSample(t *testing.T) {
+	spec := NewASCIISpec()
+	msg, err := spec.Unpack([]byte(samplePurchase))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.MTI != "0200" || msg.Get(2) != "4111111111111111" || msg.Get(4) != "000000001000" || msg.Get(41) != "TERM0001" {
+		t.Fatalf("Unpack() = %+v", msg)
+	}
+	if fields := msg.Fields(); len(fields) != 6 {
+		t.Errorf("Fields() = %v", fields)
+	}
+
+	var p purchase
+	if err := Unmarshal(msg, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Amount != 1000 || p.STAN != 123456 || p.Proc != "000000" || p.PIN != nil {
+		t.Errorf("Unmarshal() = %+v", p)
+	}
+
+	// 结构体编码后与样例一致，定长n字段左补0
+	p.Proc = ""
+	out, err := Marshal(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Set(3, "0")
+	data, err := spec.Pack(out)
+	if err != nil || string(data) != samplePurchase {
+		t.Fatalf("Pack() = %q, %v", data, err)
+	}
+
+	// b字段以十六进制字符编码
+	out.SetBytes(52, []byte{0x12, 0x34, 0x56, 0x78, 0x9A, 0xBC, 0xDE, 0xF0})
+	data, _ = spec.Pack(out)
+	if !bytes.HasSuffix(data, []byte("156123456789ABCDEF0")) {
+		t.Errorf("Pack() with field 52 = %q", data)
+	}
+	back, err := spec.Unpack(data)
+	if err != nil || !bytes.Equal(back.GetBytes(52), out.GetBytes(52)) {
+		t.Errorf("Unpack() field 52 = %x, %v", back.GetBytes(52), err)
+	}
+}
+
+type reversal struct {
+	MTI    string `iso8583:"mti"`
+	Amount int64  `iso8583:"4"`
+	Fee    *int64 `iso8583:"28"`
+	Memo   string `iso8583:"48,omitempty"`
+	Reason *int   `iso8583:"25"`
+}
+
+func TestTagZeroValues(t *testing.T) {
+	fee := int64(0)
+	m, err := Marshal(reversal{MTI: "0400", Fee: &fee})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 零值照常写入，nil指针及omitempty零值不写入
+	if m.Get(4) != "0" || m.Get(28) != "0" || m.Has(48) || m.Has(25) {
+		t.Fatalf("Marshal() fields = %v", m.Fields())
+	}
+
+	var got reversal
+	if err := Unmarshal(m, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Amount != 0 || got.Fee == nil || *got.Fee != 0 || got.Reason != nil {
+		t.Fatalf("Unmarshal() = %+v", got)
+	}
+
+	if _, err := Marshal(struct {
+		PAN string `iso8583:"2,omitzero"`
+	}{}); err == nil {
+		t.Error("Marshal() with unknown tag option succeeded")
+	}
+}
+
+// BCD 0800网络管理样例，含第二位图及奇数位磁道数据
+const sampleEcho = "0800" + "8020000020000000" + "0400000000000000" +
+	"000001" +
+	"21" + "4111111111111111D2512F" +
+	"0301"
+
+func TestBCDSpecSample(t *testing.T) {
+	wire, _ := hex.DecodeString(sampleEcho)
+	spec := NewBCDSpec()
+	msg, err := spec.Unpack(wire)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.MTI != "0800" || msg.Get(11) != "000001" || msg.Get(35) != "4111111111111111=2512" || msg.Get(70) != "301" {
+		t.Fatalf("Unpack() = %+v", msg)
+	}
+	data, err := spec.Pack(msg)
+	if err != nil || !bytes.Equal(data, wire) {
+		t.Fatalf("Pack() = %X, %v", data, err)
+	}
+}
+
+func TestSpecErrors(t *testing.T) {
+	spec := NewASCIISpec()
+	if _, err := spec.Pack(NewMessage("0200").Set(4, "12a")); !errors.Is(err, ErrFieldContent) {
+		t.Errorf("Pack() non-digit = %v", err)
+	}
+	if _, err := spec.Pack(NewMessage("0200").Set(41, "TOO-LONG-TERMINAL")); !errors.Is(err, ErrFieldLength) {
+		t.Errorf("Pack() overflow = %v", err)
+	}
+	if _, err := spec.Pack(NewMessage("0200").Set(5, "1")); !errors.Is(err, ErrFieldUndefined) {
+		t.Errorf("Pack() undefined = %v", err)
+	}
+	if _, err := spec.Unpack([]byte(samplePurchase[:30])); !errors.Is(err, ErrShortMessage) {
+		t.Errorf("Unpack() short = %v", err)
+	}
+	if _, err := spec.Unpack([]byte(samplePurchase + "X")); err == nil {
+		t.Error("Unpack() accepted trailing bytes")
+	}
+	if ResponseMTI("0200") != "0210" || ResponseMTI("0820") != "0830" || ResponseMTI("0210") != "0210" {
+		t.Error("ResponseMTI() mismatch")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	spec := NewASCIISpec()
+	framer := ag_netty.NewLengthFieldFramer(2, false)
+	router := NewRouter().Handle("0200", func(ctx *ag_netty.HandlerContext, msg *Message) {
+		resp := NewMessage(ResponseMTI(msg.MTI)).Set(11, msg.Get(11)).Set(39, "00")
+		Write(ctx.Channel(), spec, resp)
+	})
+	ch := ag_netty.NewEmbeddedChannel(NewHandler(spec, framer, router))
+
+	wire, _ := framer.Encode([]byte(samplePurchase))
+	ch.WriteInbound(wire[:10], wire[10:])
+	ch.RunPendingTasks()
+	out := ch.ReadOutbound()
+	frame, n, err := framer.Decode(out)
+	if err != nil || n != len(out) {
+		t.Fatalf("response frame = %q, %v", out, err)
+	}
+	resp, err := spec.Unpack(frame)
+	if err != nil || resp.MTI != "0210" || resp.Get(11) != "123456" || resp.Get(39) != "00" {
+		t.Fatalf("response = %+v, %v", resp, err)
+	}
+
+	// 未注册MTI触发错误事件，通道保持连接
+	msg, _ := spec.Pack(NewMessage("0800").Set(70, "301"))
+	wire, _ = framer.Encode(msg)
+	ch.WriteInbound(wire)
+	if errs := ch.Errors(); len(errs) != 1 || !errors.Is(errs[0], ErrNoRoute) || !ch.IsActive() {
+		t.Fatalf("errors = %v, active %v", errs, ch.IsActive())
+	}
+}
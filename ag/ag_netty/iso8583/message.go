@@ -0,0 +1,190 @@
+package iso8583
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Message ISO 8583报文
+// 字段值为n/z字段的数字串、ans字段的文本或b字段的原始字节，定长n字段解包后保留前导0
+type Message struct {
+	MTI    string
+	fields map[int][]byte
+}
+
+// NewMessage 创建报文
+func NewMessage(mti string) *Message {
+	return &Message{MTI: mti, fields: make(map[int][]byte)}
+}
+
+// Set 设置字段
+func (m *Message) Set(n int, value string) *Message {
+	return m.SetBytes(n, []byte(value))
+}
+
+// SetBytes 设置字段
+func (m *Message) SetBytes(n int, value []byte) *Message {
+	m.fields[n] = value
+	return m
+}
+
+// Get 获取字段，不存在时返回空串
+func (m *Message) Get(n int) string {
+	return string(m.fields[n])
+}
+
+// GetBytes 获取字段，不存在时返回nil
+func (m *Message) GetBytes(n int) []byte {
+	return m.fields[n]
+}
+
+// Has 字段是否存在
+func (m *Message) Has(n int) bool {
+	_, ok := m.fields[n]
+	return ok
+}
+
+// Unset 删除字段
+func (m *Message) Unset(n int) {
+	delete(m.fields, n)
+}
+
+// Fields 按编号排序的已设置字段
+func (m *Message) Fields() []int {
+	ns := make([]int, 0, len(m.fields))
+	for n := range m.fields {
+		ns = append(ns, n)
+	}
+	sort.Ints(ns)
+	return ns
+}
+
+// Pack 按规格编码报文：MTI、位图(存在65-128字段时带第二位图)及各字段
+func (s *Spec) Pack(m *Message) ([]byte, error) {
+	out, err := s.packMTI(m.MTI)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := m.Fields()
+	bitmap := make([]byte, 8, 16)
+	for _, n := range fields {
+		if n < 2 || n > 128 {
+			return nil, fmt.Errorf("iso8583: field %d out of range", n)
+		}
+		if n > 64 && len(bitmap) == 8 {
+			bitmap = bitmap[:16]
+			bitmap[0] |= 0x80
+		}
+		bitmap[(n-1)/8] |= 0x80 >> ((n - 1) % 8)
+	}
+	if s.BitmapEncoding == EncodingASCII {
+		out = append(out, strings.ToUpper(hex.EncodeToString(bitmap))...)
+	} else {
+		out = append(out, bitmap...)
+	}
+
+	for _, n := range fields {
+		spec := s.fields[n]
+		if spec == nil {
+			return nil, fmt.Errorf("%w: %d", ErrFieldUndefined, n)
+		}
+		b, err := spec.pack(m.fields[n])
+		if err != nil {
+			return nil, fmt.Errorf("iso8583: pack field %d: %w", n, err)
+		}
+		out = append(out, b...)
+	}
+	return out, nil
+}
+
+// Unpack 按规格解码报文，报文末尾多余的字节视为错误
+func (s *Spec) Unpack(data []byte) (*Message, error) {
+	mti, pos, err := s.unpackMTI(data)
+	if err != nil {
+		return nil, err
+	}
+	m := NewMessage(mti)
+
+	bitmap, n, err := s.unpackBitmap(data[pos:])
+	if err != nil {
+		return nil, err
+	}
+	pos += n
+	if bitmap[0]&0x80 != 0 {
+		secondary, n, err := s.unpackBitmap(data[pos:])
+		if err != nil {
+			return nil, err
+		}
+		bitmap = append(bitmap, secondary...)
+		pos += n
+	}
+
+	for i := 2; i <= len(bitmap)*8; i++ {
+		if bitmap[(i-1)/8]&(0x80>>((i-1)%8)) == 0 {
+			continue
+		}
+		spec := s.fields[i]
+		if spec == nil {
+			return nil, fmt.Errorf("%w: %d", ErrFieldUndefined, i)
+		}
+		value, n, err := spec.unpack(data[pos:])
+		if err != nil {
+			return nil, fmt.Errorf("iso8583: unpack field %d: %w", i, err)
+		}
+		m.fields[i] = value
+		pos += n
+	}
+	if pos != len(data) {
+		return nil, fmt.Errorf("iso8583: %d trailing bytes", len(data)-pos)
+	}
+	return m, nil
+}
+
+func (s *Spec) packMTI(mti string) ([]byte, error) {
+	if len(mti) != 4 || strings.Trim(mti, "0123456789") != "" {
+		return nil, fmt.Errorf("iso8583: invalid MTI %q", mti)
+	}
+	if s.MTIEncoding == EncodingBCD {
+		return packBCD([]byte(mti), true), nil
+	}
+	return []byte(mti), nil
+}
+
+func (s *Spec) unpackMTI(data []byte) (string, int, error) {
+	size := 4
+	if s.MTIEncoding == EncodingBCD {
+		size = 2
+	}
+	if len(data) < size {
+		return "", 0, ErrShortMessage
+	}
+	mti := data[:size]
+	if s.MTIEncoding == EncodingBCD {
+		mti = unpackBCD(mti, 4, true)
+	}
+	if strings.Trim(string(mti), "0123456789") != "" {
+		return "", 0, fmt.Errorf("iso8583: invalid MTI %q", mti)
+	}
+	return string(mti), size, nil
+}
+
+// unpackBitmap 解码8字节位图
+func (s *Spec) unpackBitmap(data []byte) ([]byte, int, error) {
+	if s.BitmapEncoding == EncodingASCII {
+		if len(data) < 16 {
+			return nil, 0, ErrShortMessage
+		}
+		bitmap, err := hex.DecodeString(string(data[:16]))
+		if err != nil {
+			return nil, 0, fmt.Errorf("iso8583: invalid bitmap: %w", err)
+		}
+		return bitmap, 16, nil
+	}
+	if len(data) < 8 {
+		return nil, 0, ErrShortMessage
+	}
+	return append([]byte(nil), data[:8]...), 8, nil
+}
@@ -0,0 +1,179 @@
+package iso8583
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Content 字段内容类型，对应ISO 8583的n/ans/b/z
+type Content int
+
+const (
+	ContentN   Content = iota // 数字
+	ContentANS                // 字母、数字及特殊字符
+	ContentB                  // 二进制
+	ContentZ                  // 磁道数据，数字及分隔符'='
+)
+
+// Encoding 字段编码
+type Encoding int
+
+const (
+	EncodingASCII  Encoding = iota // ASCII字符，b字段为十六进制字符
+	EncodingBCD                    // 压缩BCD，仅用于n/z字段及长度前缀
+	EncodingBinary                 // 原始字节，仅用于b字段及位图
+)
+
+// LengthType 字段长度类型
+type LengthType int
+
+const (
+	Fixed  LengthType = iota // 定长
+	LLVAR                    // 2位长度前缀的变长
+	LLLVAR                   // 3位长度前缀的变长
+)
+
+// FieldSpec 字段定义
+// Length为定长字段长度或变长字段最大长度，n/z字段按位数计，ans按字符计，b按字节计
+// BCD编码的奇数位n字段左补0，z字段右补F
+type FieldSpec struct {
+	Content     Content
+	Length      int
+	LenType     LengthType
+	Encoding    Encoding
+	LenEncoding Encoding // 变长字段长度前缀编码，ASCII或BCD
+	Description string
+}
+
+// Spec 报文规格：MTI编码、位图编码及各字段定义
+type Spec struct {
+	MTIEncoding    Encoding // ASCII或BCD
+	BitmapEncoding Encoding // Binary或ASCII(十六进制)
+	fields         map[int]*FieldSpec
+}
+
+// NewSpec 创建不含字段定义的报文规格
+func NewSpec(mtiEncoding, bitmapEncoding Encoding) *Spec {
+	return &Spec{
+		MTIEncoding:    mtiEncoding,
+		BitmapEncoding: bitmapEncoding,
+		fields:         make(map[int]*FieldSpec),
+	}
+}
+
+// Field 定义或覆盖字段，n为2-128
+func (s *Spec) Field(n int, spec *FieldSpec) *Spec {
+	if n < 2 || n > 128 {
+		panic(fmt.Sprintf("iso8583: field %d out of range", n))
+	}
+	s.fields[n] = spec
+	return s
+}
+
+// FieldSpec 获取字段定义，未定义时返回nil
+func (s *Spec) FieldSpec(n int) *FieldSpec {
+	return s.fields[n]
+}
+
+// Fields 已定义的字段编号
+func (s *Spec) Fields() []int {
+	ns := make([]int, 0, len(s.fields))
+	for n := range s.fields {
+		ns = append(ns, n)
+	}
+	sort.Ints(ns)
+	return ns
+}
+
+// defaultField 常用字段的ISO 8583:1987定义
+type defaultField struct {
+	n           int
+	content     Content
+	length      int
+	lenType     LengthType
+	description string
+}
+
+var defaultFields = []defaultField{
+	{2, ContentN, 19, LLVAR, "Primary account number"},
+	{3, ContentN, 6, Fixed, "Processing code"},
+	{4, ContentN, 12, Fixed, "Amount, transaction"},
+	{7, ContentN, 10, Fixed, "Transmission date & time"},
+	{11, ContentN, 6, Fixed, "System trace audit number"},
+	{12, ContentN, 6, Fixed, "Time, local transaction"},
+	{13, ContentN, 4, Fixed, "Date, local transaction"},
+	{14, ContentN, 4, Fixed, "Date, expiration"},
+	{15, ContentN, 4, Fixed, "Date, settlement"},
+	{18, ContentN, 4, Fixed, "Merchant type"},
+	{22, ContentN, 3, Fixed, "Point of service entry mode"},
+	{23, ContentN, 3, Fixed, "Card sequence number"},
+	{25, ContentN, 2, Fixed, "Point of service condition code"},
+	{26, ContentN, 2, Fixed, "Point of service PIN capture code"},
+	{32, ContentN, 11, LLVAR, "Acquiring institution identification code"},
+	{33, ContentN, 11, LLVAR, "Forwarding institution identification code"},
+	{35, ContentZ, 37, LLVAR, "Track 2 data"},
+	{36, ContentZ, 104, LLLVAR, "Track 3 data"},
+	{37, ContentANS, 12, Fixed, "Retrieval reference number"},
+	{38, ContentANS, 6, Fixed, "Authorization identification response"},
+	{39, ContentANS, 2, Fixed, "Response code"},
+	{41, ContentANS, 8, Fixed, "Card acceptor terminal identification"},
+	{42, ContentANS, 15, Fixed, "Card acceptor identification code"},
+	{43, ContentANS, 40, Fixed, "Card acceptor name/location"},
+	{44, ContentANS, 25, LLVAR, "Additional response data"},
+	{48, ContentANS, 999, LLLVAR, "Additional data - private"},
+	{49, ContentANS, 3, Fixed, "Currency code, transaction"},
+	{52, ContentB, 8, Fixed, "Personal identification number data"},
+	{53, ContentN, 16, Fixed, "Security related control information"},
+	{54, ContentANS, 120, LLLVAR, "Additional amounts"},
+	{55, ContentB, 255, LLLVAR, "ICC data"},
+	{60, ContentANS, 999, LLLVAR, "Reserved (national)"},
+	{61, ContentANS, 999, LLLVAR, "Reserved (national)"},
+	{62, ContentANS, 999, LLLVAR, "Reserved (private)"},
+	{63, ContentANS, 999, LLLVAR, "Reserved (private)"},
+	{64, ContentB, 8, Fixed, "Message authentication code"},
+	{70, ContentN, 3, Fixed, "Network management information code"},
+	{90, ContentN, 42, Fixed, "Original data elements"},
+	{100, ContentN, 11, LLVAR, "Receiving institution identification code"},
+	{102, ContentANS, 28, LLVAR, "Account identification 1"},
+	{103, ContentANS, 28, LLVAR, "Account identification 2"},
+	{128, ContentB, 8, Fixed, "Message authentication code"},
+}
+
+// NewASCIISpec 常用字段的ASCII规格：MTI、数字及长度前缀为ASCII，位图为十六进制字符，b字段为十六进制字符
+func NewASCIISpec() *Spec {
+	s := NewSpec(EncodingASCII, EncodingASCII)
+	for _, f := range defaultFields {
+		s.Field(f.n, &FieldSpec{
+			Content:     f.content,
+			Length:      f.length,
+			LenType:     f.lenType,
+			Encoding:    EncodingASCII,
+			LenEncoding: EncodingASCII,
+			Description: f.description,
+		})
+	}
+	return s
+}
+
+// NewBCDSpec 常用字段的BCD规格(常见于POS与银联类报文)：MTI、n/z字段及长度前缀为BCD，位图与b字段为原始字节
+func NewBCDSpec() *Spec {
+	s := NewSpec(EncodingBCD, EncodingBinary)
+	for _, f := range defaultFields {
+		enc := EncodingBCD
+		switch f.content {
+		case ContentANS:
+			enc = EncodingASCII
+		case ContentB:
+			enc = EncodingBinary
+		}
+		s.Field(f.n, &FieldSpec{
+			Content:     f.content,
+			Length:      f.length,
+			LenType:     f.lenType,
+			Encoding:    enc,
+			LenEncoding: EncodingBCD,
+			Description: f.description,
+		})
+	}
+	return s
+}
@@ -0,0 +1,146 @@
+package iso8583
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const tagName = "iso8583"
+
+// Marshal 按结构体标签 iso8583 生成报文，标签值为字段编号或mti：
+//
+//	type Purchase struct {
+//		MTI    string `iso8583:"mti"`
+//		PAN    string `iso8583:"2"`
+//		Amount int64  `iso8583:"4"`
+//		STAN   int    `iso8583:"11"`
+//		Fee    *int64 `iso8583:"28"`
+//		Memo   string `iso8583:"48,omitempty"`
+//		PIN    []byte `iso8583:"52"`
+//	}
+//
+// 支持string、[]byte、整数类型及其指针，零值照常写入报文；
+// nil指针及nil切片不写入，标签带omitempty时零值也不写入
+func Marshal(v any) (*Message, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("iso8583: marshal %T: not a struct", v)
+	}
+	m := NewMessage("")
+	err := walkTags(rv, func(n int, fv reflect.Value, omitEmpty bool) error {
+		if n == 0 {
+			m.MTI = fv.String()
+			return nil
+		}
+		if (fv.Kind() == reflect.Pointer || fv.Kind() == reflect.Slice) && fv.IsNil() {
+			return nil
+		}
+		fv = reflect.Indirect(fv)
+		if omitEmpty && fv.IsZero() {
+			return nil
+		}
+		switch {
+		case fv.Kind() == reflect.String:
+			m.Set(n, fv.String())
+		case fv.Kind() == reflect.Slice:
+			m.SetBytes(n, fv.Bytes())
+		case fv.CanInt():
+			m.Set(n, strconv.FormatInt(fv.Int(), 10))
+		case fv.CanUint():
+			m.Set(n, strconv.FormatUint(fv.Uint(), 10))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Unmarshal 按结构体标签 iso8583 将报文字段写入结构体指针，报文中不存在的字段置零值，指针字段置nil
+// 定长n字段映射到string时保留前导0
+func Unmarshal(m *Message, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("iso8583: unmarshal %T: not a struct pointer", v)
+	}
+	return walkTags(rv.Elem(), func(n int, fv reflect.Value, _ bool) error {
+		if n == 0 {
+			fv.SetString(m.MTI)
+			return nil
+		}
+		if !m.Has(n) {
+			fv.SetZero()
+			return nil
+		}
+		if fv.Kind() == reflect.Pointer {
+			fv.Set(reflect.New(fv.Type().Elem()))
+			fv = fv.Elem()
+		}
+		value := m.GetBytes(n)
+		switch {
+		case fv.Kind() == reflect.String:
+			fv.SetString(string(value))
+		case fv.Kind() == reflect.Slice:
+			fv.SetBytes(append([]byte(nil), value...))
+		case fv.CanInt():
+			i, err := strconv.ParseInt(strings.TrimSpace(string(value)), 10, fv.Type().Bits())
+			if err != nil {
+				return fmt.Errorf("iso8583: field %d: %w", n, err)
+			}
+			fv.SetInt(i)
+		case fv.CanUint():
+			u, err := strconv.ParseUint(strings.TrimSpace(string(value)), 10, fv.Type().Bits())
+			if err != nil {
+				return fmt.Errorf("iso8583: field %d: %w", n, err)
+			}
+			fv.SetUint(u)
+		}
+		return nil
+	})
+}
+
+// walkTags 遍历带标签的结构体字段，mti对应编号0，omitEmpty对应标签的omitempty选项
+func walkTags(rv reflect.Value, f func(n int, fv reflect.Value, omitEmpty bool) error) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup(tagName)
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+		tag, opt, _ := strings.Cut(tag, ",")
+		if opt != "" && opt != "omitempty" {
+			return fmt.Errorf("iso8583: %s.%s: invalid tag option %q", t.Name(), sf.Name, opt)
+		}
+
+		n := 0
+		if tag != "mti" {
+			var err error
+			n, err = strconv.Atoi(tag)
+			if err != nil || n < 2 || n > 128 {
+				return fmt.Errorf("iso8583: %s.%s: invalid tag %q", t.Name(), sf.Name, tag)
+			}
+		}
+		fv := rv.Field(i)
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer && n != 0 {
+			ft = ft.Elem()
+		}
+		switch k := ft.Kind(); {
+		case n == 0 && k != reflect.String:
+			return fmt.Errorf("iso8583: %s.%s: mti must be string", t.Name(), sf.Name)
+		case k == reflect.String:
+		case k >= reflect.Int && k <= reflect.Uint64:
+		case k == reflect.Slice && ft.Elem().Kind() == reflect.Uint8:
+		default:
+			return fmt.Errorf("iso8583: %s.%s: unsupported type %s", t.Name(), sf.Name, sf.Type)
+		}
+		if err := f(n, fv, opt == "omitempty"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
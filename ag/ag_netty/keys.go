@@ -0,0 +1,94 @@
+package ag_netty
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/frochyzhang/ag-core/ag/ag_conf"
+	"github.com/frochyzhang/ag-core/ag/ag_crypto"
+)
+
+// KeyProvider 密钥来源，返回当前密钥及轮换期内仍可用于验签、解密的旧密钥
+type KeyProvider interface {
+	Keys() (current []byte, previous [][]byte, err error)
+}
+
+// StaticKeys 固定密钥
+type StaticKeys struct {
+	Current  []byte
+	Previous [][]byte
+}
+
+func (k *StaticKeys) Keys() ([]byte, [][]byte, error) {
+	return k.Current, k.Previous, nil
+}
+
+// ConfKeyProvider 从ag_conf读取十六进制密钥，每次使用时读取配置，配置刷新(如Nacos推送)后即切换到新密钥
+// 属性key为当前密钥，key.previous为逗号分隔的旧密钥；以{cipher}开头的值先经encryptor解密
+type ConfKeyProvider struct {
+	resolver  ag_conf.IPropertyResolver
+	key       string
+	encryptor ag_crypto.ITextEncryptor
+
+	mu     sync.Mutex
+	raw    string
+	parsed [][]byte
+}
+
+// NewConfKeyProvider 创建配置密钥来源，encryptor为nil时使用ag_crypto.Base64Encryptor
+func NewConfKeyProvider(resolver ag_conf.IPropertyResolver, key string, encryptor ag_crypto.ITextEncryptor) *ConfKeyProvider {
+	if encryptor == nil {
+		encryptor = ag_crypto.Base64Encryptor
+	}
+	return &ConfKeyProvider{resolver: resolver, key: key, encryptor: encryptor}
+}
+
+func (p *ConfKeyProvider) Keys() ([]byte, [][]byte, error) {
+	current := p.resolver.GetProperty(p.key)
+	if current == "" {
+		return nil, nil, fmt.Errorf("ag_netty: key %s not configured", p.key)
+	}
+	raw := current + "\n" + p.resolver.GetProperty(p.key+".previous")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if raw != p.raw {
+		parsed, err := p.parse(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.raw, p.parsed = raw, parsed
+	}
+	return p.parsed[0], p.parsed[1:], nil
+}
+
+// parse 解析当前密钥及旧密钥，结果按配置原文缓存
+func (p *ConfKeyProvider) parse(raw string) ([][]byte, error) {
+	current, previous, _ := strings.Cut(raw, "\n")
+	values := []string{current}
+	for _, v := range strings.Split(previous, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	keys := make([][]byte, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if strings.HasPrefix(v, ag_conf.ConstEncryptKeyWords) {
+			plain, err := p.encryptor.Decrypt(strings.TrimPrefix(v, ag_conf.ConstEncryptKeyWords))
+			if err != nil {
+				return nil, fmt.Errorf("ag_netty: decrypt key %s: %w", p.key, err)
+			}
+			v = strings.TrimSpace(plain)
+		}
+		key, err := hex.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("ag_netty: key %s is not hex: %w", p.key, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
@@ -0,0 +1,128 @@
+package ag_netty
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/frochyzhang/ag-core/ag/ag_ext/ip"
+)
+
+var (
+	// ErrTooManyConnections 超过服务端最大连接数
+	ErrTooManyConnections = errors.New("ag_netty: too many connections")
+	// ErrTooManyConnectionsPerIP 超过单IP最大连接数
+	ErrTooManyConnectionsPerIP = errors.New("ag_netty: too many connections from ip")
+	// ErrIPForbidden 对端IP不在白名单内或命中黑名单
+	ErrIPForbidden = errors.New("ag_netty: ip forbidden")
+)
+
+// ConnLimiter 服务端连接准入控制：最大连接数、单IP最大连接数及IP黑白名单
+// IP范围格式与 ag_ext/ip.IPRanger 一致，仅支持IPv4；配置白名单时非IPv4对端一律拒绝
+type ConnLimiter struct {
+	maxConns    int
+	maxConnsIP  int
+	allowRanger *ip.IPRanger
+	denyRanger  *ip.IPRanger
+
+	mu    sync.Mutex
+	total int
+	perIP map[string]int
+}
+
+// NewConnLimiter 创建连接准入控制器，maxConns/maxConnsPerIP为0表示不限制，allow/deny为空表示不启用
+func NewConnLimiter(maxConns, maxConnsPerIP int, allowIPRange, denyIPRange string) (*ConnLimiter, error) {
+	l := &ConnLimiter{
+		maxConns:   maxConns,
+		maxConnsIP: maxConnsPerIP,
+		perIP:      make(map[string]int),
+	}
+	if allowIPRange != "" {
+		r, err := ip.NewIPRanger(allowIPRange)
+		if err != nil {
+			return nil, fmt.Errorf("ag_netty allow ip range: %w", err)
+		}
+		l.allowRanger = r
+	}
+	if denyIPRange != "" {
+		r, err := ip.NewIPRanger(denyIPRange)
+		if err != nil {
+			return nil, fmt.Errorf("ag_netty deny ip range: %w", err)
+		}
+		l.denyRanger = r
+	}
+	return l, nil
+}
+
+// Acquire 新连接准入，成功后必须在连接关闭时调用Release
+func (l *ConnLimiter) Acquire(addr net.Addr) error {
+	host := hostOf(addr)
+
+	if l.allowRanger != nil || l.denyRanger != nil {
+		ipv4 := toIPv4(host)
+		if l.allowRanger != nil && (ipv4 == "" || !l.allowRanger.IPIsRangeAvailable(ipv4)) {
+			return ErrIPForbidden
+		}
+		if l.denyRanger != nil && ipv4 != "" && l.denyRanger.IPIsRangeAvailable(ipv4) {
+			return ErrIPForbidden
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxConns > 0 && l.total >= l.maxConns {
+		return ErrTooManyConnections
+	}
+	if l.maxConnsIP > 0 && l.perIP[host] >= l.maxConnsIP {
+		return ErrTooManyConnectionsPerIP
+	}
+	l.total++
+	l.perIP[host]++
+	return nil
+}
+
+// Release 释放连接占用的配额
+func (l *ConnLimiter) Release(addr net.Addr) {
+	host := hostOf(addr)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.total > 0 {
+		l.total--
+	}
+	if n := l.perIP[host]; n <= 1 {
+		delete(l.perIP, host)
+	} else {
+		l.perIP[host] = n - 1
+	}
+}
+
+// Connections 当前已准入的连接数
+func (l *ConnLimiter) Connections() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.total
+}
+
+func hostOf(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// toIPv4 返回点分十进制的IPv4地址，非IPv4地址返回空串
+func toIPv4(host string) string {
+	parsed := net.ParseIP(host)
+	if parsed == nil || parsed.To4() == nil {
+		return ""
+	}
+	return parsed.To4().String()
+}
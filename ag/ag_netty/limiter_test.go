@@ -0,0 +1,66 @@
+package ag_netty
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestConnLimiter(t *testing.T) {
+	addr := func(s string) net.Addr {
+		a, _ := net.ResolveTCPAddr("tcp", s)
+		return a
+	}
+
+	t.Run("MaxConnections", func(t *testing.T) {
+		l, err := NewConnLimiter(2, 0, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Acquire(addr("10.0.0.1:1000")); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Acquire(addr("10.0.0.2:1000")); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Acquire(addr("10.0.0.3:1000")); !errors.Is(err, ErrTooManyConnections) {
+			t.Errorf("Acquire() = %v, want ErrTooManyConnections", err)
+		}
+		l.Release(addr("10.0.0.1:1000"))
+		if err := l.Acquire(addr("10.0.0.3:1000")); err != nil {
+			t.Errorf("Acquire() after release = %v, want nil", err)
+		}
+	})
+
+	t.Run("MaxConnectionsPerIP", func(t *testing.T) {
+		l, _ := NewConnLimiter(0, 1, "", "")
+		if err := l.Acquire(addr("10.0.0.1:1000")); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Acquire(addr("10.0.0.1:1001")); !errors.Is(err, ErrTooManyConnectionsPerIP) {
+			t.Errorf("Acquire() = %v, want ErrTooManyConnectionsPerIP", err)
+		}
+		if err := l.Acquire(addr("10.0.0.2:1000")); err != nil {
+			t.Errorf("Acquire() other ip = %v, want nil", err)
+		}
+	})
+
+	t.Run("AllowDeny", func(t *testing.T) {
+		l, err := NewConnLimiter(0, 0, "10.0", "10.0.9")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Acquire(addr("10.0.1.1:1000")); err != nil {
+			t.Errorf("Acquire() allowed ip = %v, want nil", err)
+		}
+		if err := l.Acquire(addr("10.0.9.1:1000")); !errors.Is(err, ErrIPForbidden) {
+			t.Errorf("Acquire() denied ip = %v, want ErrIPForbidden", err)
+		}
+		if err := l.Acquire(addr("192.168.0.1:1000")); !errors.Is(err, ErrIPForbidden) {
+			t.Errorf("Acquire() outside allow = %v, want ErrIPForbidden", err)
+		}
+		if err := l.Acquire(addr("[::1]:1000")); !errors.Is(err, ErrIPForbidden) {
+			t.Errorf("Acquire() ipv6 with allow list = %v, want ErrIPForbidden", err)
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package ag_netty
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/frochyzhang/ag-core/ag/ag_metrics"
+)
+
+const (
+	handlerEventRead  = "read"
+	handlerEventWrite = "write"
+)
+
+// Metrics 运行指标，经WithMetrics设置到事件循环后由通道及事件循环自动采集
+// 所有指标带name标签以区分同一进程内的多个服务端或客户端
+type Metrics struct {
+	name string
+
+	active        *ag_metrics.Gauge
+	total         *ag_metrics.Counter
+	accepted      *ag_metrics.Counter
+	closed        *ag_metrics.Counter
+	errors        *ag_metrics.Counter
+	bytesIn       *ag_metrics.Counter
+	bytesOut      *ag_metrics.Counter
+	framesDecoded *ag_metrics.Counter
+	pendingTasks  *ag_metrics.GaugeVec
+	latency       *ag_metrics.HistogramVec
+
+	loopSeq atomic.Int64
+}
+
+// NewMetrics 在registry中注册运行指标，同一registry中同名的Metrics共享时间序列
+func NewMetrics(registry *ag_metrics.Registry, name string) *Metrics {
+	return &Metrics{
+		name:          name,
+		active:        registry.Gauge("ag_netty_connections_active", "当前活跃的通道数", "name").With(name),
+		total:         registry.Counter("ag_netty_connections_total", "累计建立的通道数", "name").With(name),
+		accepted:      registry.Counter("ag_netty_connections_accepted_total", "服务端通过准入的连接数", "name").With(name),
+		closed:        registry.Counter("ag_netty_connections_closed_total", "累计关闭的通道数", "name").With(name),
+		errors:        registry.Counter("ag_netty_errors_total", "流水线错误事件数", "name").With(name),
+		bytesIn:       registry.Counter("ag_netty_read_bytes_total", "读入流水线的字节数", "name").With(name),
+		bytesOut:      registry.Counter("ag_netty_write_bytes_total", "写出连接的字节数", "name").With(name),
+		framesDecoded: registry.Counter("ag_netty_frames_decoded_total", "FrameHandler解码成功的帧数", "name").With(name),
+		pendingTasks:  registry.Gauge("ag_netty_eventloop_pending_tasks", "事件循环任务队列中等待执行的任务数", "name", "loop"),
+		latency: registry.Histogram("ag_netty_handler_duration_seconds", "处理器处理读写事件的耗时",
+			ag_metrics.DefaultBuckets, "name", "handler", "event"),
+	}
+}
+
+// Name 指标的name标签
+func (m *Metrics) Name() string {
+	return m.name
+}
+
+// WithMetrics 设置运行指标，为nil时不采集
+func WithMetrics(m *Metrics) LoopOption {
+	return func(o *loopOptions) {
+		o.metrics = m
+	}
+}
+
+// channelOpened 记录新建通道，通道关闭时记录关闭
+func (m *Metrics) channelOpened(ch *Channel) {
+	if m == nil {
+		return
+	}
+	ch.metrics = m
+	m.active.Inc()
+	m.total.Inc()
+	ch.addCloseListener(func() {
+		m.active.Dec()
+		m.closed.Inc()
+	})
+}
+
+func (m *Metrics) connAccepted() {
+	if m != nil {
+		m.accepted.Inc()
+	}
+}
+
+func (m *Metrics) errorFired() {
+	if m != nil {
+		m.errors.Inc()
+	}
+}
+
+func (m *Metrics) bytesRead(n int) {
+	if m != nil {
+		m.bytesIn.Add(float64(n))
+	}
+}
+
+func (m *Metrics) bytesWritten(n int) {
+	if m != nil && n > 0 {
+		m.bytesOut.Add(float64(n))
+	}
+}
+
+func (m *Metrics) frameDecoded() {
+	if m != nil {
+		m.framesDecoded.Inc()
+	}
+}
+
+// handlerLatency 处理器某类事件的耗时直方图，未启用指标时返回nil
+func (m *Metrics) handlerLatency(handler, event string) *ag_metrics.Histogram {
+	if m == nil {
+		return nil
+	}
+	return m.latency.With(m.name, handler, event)
+}
+
+// observeLoop 采集事件循环任务队列长度，返回的标签值用于事件循环关闭时删除该序列
+func (m *Metrics) observeLoop(prefix string, pending func() int) string {
+	if m == nil {
+		return ""
+	}
+	loop := prefix + strconv.FormatInt(m.loopSeq.Add(1)-1, 10)
+	m.pendingTasks.SetFunc(func() float64 { return float64(pending()) }, m.name, loop)
+	return loop
+}
+
+func (m *Metrics) forgetLoop(loop string) {
+	if m != nil && loop != "" {
+		m.pendingTasks.Delete(m.name, loop)
+	}
+}
+
+// startTimer 启用耗时统计时返回当前时间
+func startTimer(h *ag_metrics.Histogram) time.Time {
+	if h == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+func observeSince(h *ag_metrics.Histogram, start time.Time) {
+	if h != nil {
+		h.Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,87 @@
+package ag_netty
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frochyzhang/ag-core/ag/ag_metrics"
+)
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func exported(t *testing.T, registry *ag_metrics.Registry) string {
+	t.Helper()
+	var buf strings.Builder
+	if err := registry.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestServerMetrics(t *testing.T) {
+	registry := ag_metrics.NewRegistry()
+	m := NewMetrics(registry, "test")
+	echo := NewFrameHandler(NewDelimiterFramer([]byte("\n"), 8), func(ctx *HandlerContext, frame []byte) {
+		ctx.Write(frame)
+	})
+	s, err := NewServer("127.0.0.1:0", func(ch *Channel) {
+		ch.Pipeline.AddLast("handler", echo)
+	}, WithEventLoops(2), WithLoopOptions(WithMetrics(m)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Start()
+
+	h := newTLSRecvHandler()
+	ch := dialNetwork(t, NetworkTCP, s.Addr().String(), h)
+	ch.Write([]byte("ping\npong\n"))
+	var received string
+	for len(received) < 10 {
+		received += recvWithin(t, h)
+	}
+
+	waitFor(t, "bytes written", func() bool { return m.bytesOut.Value() == 10 })
+	if m.accepted.Value() != 1 || m.total.Value() != 1 || m.active.Value() != 1 {
+		t.Errorf("connections accepted=%v total=%v active=%v", m.accepted.Value(), m.total.Value(), m.active.Value())
+	}
+	if m.bytesIn.Value() != 10 || m.framesDecoded.Value() != 2 {
+		t.Errorf("bytesIn=%v framesDecoded=%v", m.bytesIn.Value(), m.framesDecoded.Value())
+	}
+	if n := m.handlerLatency("handler", handlerEventRead).Count(); n == 0 {
+		t.Error("handler read latency not observed")
+	}
+
+	text := exported(t, registry)
+	for _, want := range []string{
+		`ag_netty_eventloop_pending_tasks{name="test",loop="loop-0"} 0`,
+		`ag_netty_eventloop_pending_tasks{name="test",loop="loop-1"} 0`,
+		`ag_netty_handler_duration_seconds_count{name="test",handler="handler",event="write"}`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("exported metrics missing %q\n%s", want, text)
+		}
+	}
+
+	// 超长帧解码失败，触发错误事件并关闭通道
+	ch.Write([]byte("too long line"))
+	waitFor(t, "channel closed", func() bool { return m.closed.Value() == 1 })
+	if m.active.Value() != 0 || m.errors.Value() == 0 {
+		t.Errorf("after decode failure active=%v errors=%v", m.active.Value(), m.errors.Value())
+	}
+
+	// 事件循环关闭后不再导出其任务队列长度
+	s.Shutdown()
+	if text := exported(t, registry); strings.Contains(text, "ag_netty_eventloop_pending_tasks{") {
+		t.Errorf("pending tasks exported after shutdown\n%s", text)
+	}
+}
@@ -0,0 +1,49 @@
+package ag_netty
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Network 传输协议
+type Network string
+
+const (
+	NetworkTCP  Network = "tcp"
+	NetworkUDP  Network = "udp"
+	NetworkUnix Network = "unix" // Unix域套接字，地址为套接字文件路径
+)
+
+// ParseNetwork 解析传输协议配置，空串为tcp
+func ParseNetwork(s string) (Network, error) {
+	switch n := Network(strings.ToLower(strings.TrimSpace(s))); n {
+	case "":
+		return NetworkTCP, nil
+	case NetworkTCP, NetworkUDP, NetworkUnix:
+		return n, nil
+	default:
+		return "", fmt.Errorf("ag_netty unknown network: %s", s)
+	}
+}
+
+// IsDatagram 是否为数据报协议
+func (n Network) IsDatagram() bool {
+	return n == NetworkUDP
+}
+
+// removeStaleSocket 删除上次运行残留的Unix套接字文件，路径存在但不是套接字时返回错误
+func removeStaleSocket(path string) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("ag_netty unix socket path exists and is not a socket: %s", path)
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,128 @@
+package ag_netty
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// senderEchoHandler 回显数据报并附带通道的对端地址
+type senderEchoHandler struct{}
+
+func (h *senderEchoHandler) HandleActive(ctx *HandlerContext)   {}
+func (h *senderEchoHandler) HandleInactive(ctx *HandlerContext) {}
+func (h *senderEchoHandler) HandleRead(ctx *HandlerContext, data []byte) {
+	ctx.Write(append(append(data, '@'), ctx.Channel().RemoteAddr().String()...))
+}
+func (h *senderEchoHandler) HandleWrite(ctx *HandlerContext, data []byte) {
+	ctx.Channel().WriteDirect(data)
+}
+func (h *senderEchoHandler) HandleError(ctx *HandlerContext, err error) {}
+
+func startServer(t *testing.T, network Network, addr string, handler ChannelHandler, opts ...ServerOption) *Server {
+	t.Helper()
+	s, err := NewServer(addr, func(ch *Channel) {
+		ch.Pipeline.AddLast("handler", handler)
+	}, append(opts, WithNetwork(network))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Start()
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+func dialNetwork(t *testing.T, network Network, addr string, h *tlsRecvHandler) *Channel {
+	t.Helper()
+	looper := NewClientEventLoop(func(ch *Channel) {
+		ch.Pipeline.AddLast("recv", h)
+	}, WithDialNetwork(network))
+	t.Cleanup(looper.Shutdown)
+	ch, err := Dial(addr, time.Second, time.Second, time.Second, time.Minute, looper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(ch.Close)
+	return ch
+}
+
+func recvWithin(t *testing.T, h *tlsRecvHandler) string {
+	t.Helper()
+	select {
+	case data := <-h.recv:
+		return string(data)
+	case <-time.After(3 * time.Second):
+		t.Fatal("no data received")
+		return ""
+	}
+}
+
+func TestParseNetwork(t *testing.T) {
+	for in, want := range map[string]Network{"": NetworkTCP, "TCP": NetworkTCP, "udp": NetworkUDP, " unix ": NetworkUnix} {
+		if got, err := ParseNetwork(in); err != nil || got != want {
+			t.Errorf("ParseNetwork(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseNetwork("sctp"); err == nil {
+		t.Error("ParseNetwork(sctp) succeeded")
+	}
+}
+
+func TestServerUDP(t *testing.T) {
+	s := startServer(t, NetworkUDP, "127.0.0.1:0", &senderEchoHandler{}, WithDatagramIdleTimeout(100*time.Millisecond))
+	addr := s.Addr().String()
+
+	// 每个发送方对应一个通道，应答发回对应的发送方
+	clients := make([]*Channel, 2)
+	handlers := make([]*tlsRecvHandler, 2)
+	for i := range clients {
+		handlers[i] = newTLSRecvHandler()
+		clients[i] = dialNetwork(t, NetworkUDP, addr, handlers[i])
+	}
+	for i, ch := range clients {
+		ch.Write([]byte{'a' + byte(i)})
+	}
+	for i, ch := range clients {
+		want := string(rune('a'+i)) + "@" + ch.LocalAddr().String()
+		if got := recvWithin(t, handlers[i]); got != want {
+			t.Errorf("client %d received %q, want %q", i, got, want)
+		}
+	}
+	if size := s.ChannelGroup().Size(); size != 2 {
+		t.Errorf("ChannelGroup().Size() = %d, want 2", size)
+	}
+
+	// 会话空闲超时后关闭
+	deadline := time.Now().Add(3 * time.Second)
+	for s.ChannelGroup().Size() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("idle sessions not closed, size %d", s.ChannelGroup().Size())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServerUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "netty.sock")
+	s := startServer(t, NetworkUnix, path, &EchoHandler{})
+	if s.Addr().String() != path {
+		t.Fatalf("Addr() = %s", s.Addr())
+	}
+
+	h := newTLSRecvHandler()
+	ch := dialNetwork(t, NetworkUnix, path, h)
+	ch.Write([]byte("ping"))
+	if got := recvWithin(t, h); got != "ping" {
+		t.Fatalf("received %q", got)
+	}
+
+	// 路径被普通文件占用时拒绝监听
+	file := filepath.Join(t.TempDir(), "plain")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewServer(file, nil, WithNetwork(NetworkUnix)); err == nil {
+		t.Fatal("listen on regular file succeeded")
+	}
+}
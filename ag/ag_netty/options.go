@@ -0,0 +1,155 @@
+package ag_netty
+
+import (
+	"crypto/tls"
+	"time"
+)
+
+// LoopOption 事件循环配置项
+type LoopOption func(o *loopOptions)
+
+type loopOptions struct {
+	taskQueueSize int
+	rejectPolicy  RejectPolicy
+	connLimiter   *ConnLimiter
+	lowWatermark  int
+	highWatermark int
+	suspendRead   bool
+	tlsConfig     *tls.Config
+	tlsHandshake  time.Duration
+	network       Network
+	metrics       *Metrics
+}
+
+func newLoopOptions(opts ...LoopOption) *loopOptions {
+	o := &loopOptions{
+		taskQueueSize: DefaultTaskQueueSize,
+		rejectPolicy:  RejectBlock,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithTaskQueueSize 设置事件循环任务队列长度
+func WithTaskQueueSize(size int) LoopOption {
+	return func(o *loopOptions) {
+		if size > 0 {
+			o.taskQueueSize = size
+		}
+	}
+}
+
+// WithRejectPolicy 设置事件循环任务队列已满时的拒绝策略
+func WithRejectPolicy(policy RejectPolicy) LoopOption {
+	return func(o *loopOptions) {
+		if policy != "" {
+			o.rejectPolicy = policy
+		}
+	}
+}
+
+// WithConnLimiter 设置连接准入控制器，仅对服务端事件循环生效
+func WithConnLimiter(limiter *ConnLimiter) LoopOption {
+	return func(o *loopOptions) {
+		o.connLimiter = limiter
+	}
+}
+
+// WithWriteBufferWatermark 设置通道写缓冲高低水位线(字节)，high<=0时不启用
+func WithWriteBufferWatermark(low, high int) LoopOption {
+	return func(o *loopOptions) {
+		o.lowWatermark = low
+		o.highWatermark = high
+	}
+}
+
+// WithSuspendReadOnUnwritable 通道不可写时暂停向流水线投递读事件，直到写缓冲回落到低水位
+// 暂停期间连接上的数据仍被读出并暂存在通道中，不占用读回调协程，恢复可写后按序投递
+func WithSuspendReadOnUnwritable(suspend bool) LoopOption {
+	return func(o *loopOptions) {
+		o.suspendRead = suspend
+	}
+}
+
+// WithTLS 启用TLS，服务端事件循环使用服务端配置，客户端事件循环使用客户端配置
+func WithTLS(config *tls.Config, handshakeTimeout time.Duration) LoopOption {
+	return func(o *loopOptions) {
+		o.tlsConfig = config
+		o.tlsHandshake = handshakeTimeout
+	}
+}
+
+// WithDialNetwork 设置客户端连接的传输协议，默认为tcp，仅对客户端事件循环生效
+// unix协议的地址为套接字文件路径；udp协议不支持TLS
+func WithDialNetwork(network Network) LoopOption {
+	return func(o *loopOptions) {
+		o.network = network
+	}
+}
+
+// applyChannel 将通道级配置应用到新建通道
+func (o *loopOptions) applyChannel(ch *Channel) {
+	if o.highWatermark > 0 {
+		ch.SetWriteBufferWatermark(o.lowWatermark, o.highWatermark)
+	}
+	ch.suspendRead = o.suspendRead
+	o.metrics.channelOpened(ch)
+}
+
+// ServerOption 服务端配置项
+type ServerOption func(o *serverOptions)
+
+type serverOptions struct {
+	eventLoops          int
+	loopOptions         []LoopOption
+	network             Network
+	datagramIdleTimeout time.Duration
+}
+
+func newServerOptions(opts ...ServerOption) *serverOptions {
+	o := &serverOptions{
+		eventLoops:          DefaultEventLoops,
+		network:             NetworkTCP,
+		datagramIdleTimeout: DefaultDatagramIdleTimeout,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithEventLoops 设置事件循环数量
+func WithEventLoops(n int) ServerOption {
+	return func(o *serverOptions) {
+		if n > 0 {
+			o.eventLoops = n
+		}
+	}
+}
+
+// WithLoopOptions 设置每个事件循环的配置项
+func WithLoopOptions(opts ...LoopOption) ServerOption {
+	return func(o *serverOptions) {
+		o.loopOptions = append(o.loopOptions, opts...)
+	}
+}
+
+// WithNetwork 设置服务端传输协议，默认为tcp；unix协议的地址为套接字文件路径
+func WithNetwork(network Network) ServerOption {
+	return func(o *serverOptions) {
+		if network != "" {
+			o.network = network
+		}
+	}
+}
+
+// WithDatagramIdleTimeout 设置UDP会话空闲超时，超时未收发数据的会话通道被关闭
+func WithDatagramIdleTimeout(timeout time.Duration) ServerOption {
+	return func(o *serverOptions) {
+		if timeout > 0 {
+			o.datagramIdleTimeout = timeout
+		}
+	}
+}
@@ -1,13 +1,27 @@
 package ag_netty
 
-import "sync"
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+var (
+	// ErrDuplicateHandlerName 流水线中已存在同名处理器
+	ErrDuplicateHandlerName = errors.New("ag_netty: duplicate handler name")
+	// ErrHandlerNotFound 流水线中不存在指定名称的处理器
+	ErrHandlerNotFound = errors.New("ag_netty: handler not found")
+)
 
 // Pipeline 处理器流水线
+// 增删处理器与事件传播可并发进行：被移除的处理器保留前后链接，正在传播的事件会越过该处理器继续传递
 type Pipeline struct {
 	head      *HandlerContext
 	tail      *HandlerContext
 	channel   *Channel
 	handlerMu sync.RWMutex
+	tailRead  func(data []byte) // 读事件到达尾部时回调，EmbeddedChannel用于记录入站数据
 }
 
 // NewPipeline 创建处理器流水线
@@ -15,49 +29,192 @@ func NewPipeline(channel *Channel) *Pipeline {
 	p := &Pipeline{
 		channel: channel,
 		head:    &HandlerContext{name: "HEAD"},
-		tail:    &HandlerContext{name: "TAIL"},
 	}
+	p.tail = &HandlerContext{name: "TAIL", pipeline: p}
 
-	p.head.next = p.tail
-	p.tail.prev = p.head
+	p.head.next.Store(p.tail)
+	p.tail.prev.Store(p.head)
 
 	return p
 }
 
-// AddFirst 在头部添加处理器
+// AddFirst 在头部添加处理器，名称重复时记录错误日志且不添加，需要处理错误时使用TryAddFirst
 func (p *Pipeline) AddFirst(name string, handler ChannelHandler) {
-	p.handlerMu.Lock()
-	defer p.handlerMu.Unlock()
+	if err := p.TryAddFirst(name, handler); err != nil {
+		slog.Error("ag_netty add handler failed", "handler", name, "error", err)
+	}
+}
 
-	ctx := newHandlerContext(name, handler, p)
+// TryAddFirst 在头部添加处理器，名称重复时返回 ErrDuplicateHandlerName
+func (p *Pipeline) TryAddFirst(name string, handler ChannelHandler) error {
+	return p.AddFirstWithExecutor(nil, name, handler)
+}
 
-	next := p.head.next
-	p.head.next = ctx
-	ctx.prev = p.head
-	ctx.next = next
-	next.prev = ctx
+// AddFirstWithExecutor 在头部添加处理器，处理器事件在group中为该通道绑定的执行器上执行
+// group为nil时等同于TryAddFirst
+func (p *Pipeline) AddFirstWithExecutor(group *EventExecutorGroup, name string, handler ChannelHandler) error {
+	return p.add(group, name, handler, func() (*HandlerContext, error) {
+		return p.head, nil
+	})
 }
 
-// AddLast 在尾部添加处理器
+// AddLast 在尾部添加处理器，名称重复时记录错误日志且不添加，需要处理错误时使用TryAddLast
 func (p *Pipeline) AddLast(name string, handler ChannelHandler) {
+	if err := p.TryAddLast(name, handler); err != nil {
+		slog.Error("ag_netty add handler failed", "handler", name, "error", err)
+	}
+}
+
+// TryAddLast 在尾部添加处理器，名称重复时返回 ErrDuplicateHandlerName
+func (p *Pipeline) TryAddLast(name string, handler ChannelHandler) error {
+	return p.AddLastWithExecutor(nil, name, handler)
+}
+
+// AddLastWithExecutor 在尾部添加处理器，处理器事件在group中为该通道绑定的执行器上执行
+// 用于数据库访问等阻塞型处理器，避免阻塞事件循环上的其他连接
+// group为nil时等同于TryAddLast
+func (p *Pipeline) AddLastWithExecutor(group *EventExecutorGroup, name string, handler ChannelHandler) error {
+	return p.add(group, name, handler, func() (*HandlerContext, error) {
+		return p.tail.prev.Load(), nil
+	})
+}
+
+// AddBefore 在baseName处理器之前添加处理器
+func (p *Pipeline) AddBefore(baseName string, name string, handler ChannelHandler) error {
+	return p.AddBeforeWithExecutor(nil, baseName, name, handler)
+}
+
+// AddBeforeWithExecutor 在baseName处理器之前添加处理器，处理器事件在group中为该通道绑定的执行器上执行
+// group为nil时等同于AddBefore
+func (p *Pipeline) AddBeforeWithExecutor(group *EventExecutorGroup, baseName string, name string, handler ChannelHandler) error {
+	return p.add(group, name, handler, func() (*HandlerContext, error) {
+		base, err := p.mustContext(baseName)
+		if err != nil {
+			return nil, err
+		}
+		return base.prev.Load(), nil
+	})
+}
+
+// AddAfter 在baseName处理器之后添加处理器
+func (p *Pipeline) AddAfter(baseName string, name string, handler ChannelHandler) error {
+	return p.AddAfterWithExecutor(nil, baseName, name, handler)
+}
+
+// AddAfterWithExecutor 在baseName处理器之后添加处理器，处理器事件在group中为该通道绑定的执行器上执行
+// group为nil时等同于AddAfter
+func (p *Pipeline) AddAfterWithExecutor(group *EventExecutorGroup, baseName string, name string, handler ChannelHandler) error {
+	return p.add(group, name, handler, func() (*HandlerContext, error) {
+		return p.mustContext(baseName)
+	})
+}
+
+// Remove 移除处理器并返回被移除的处理器
+func (p *Pipeline) Remove(name string) (ChannelHandler, error) {
 	p.handlerMu.Lock()
-	defer p.handlerMu.Unlock()
+	ctx, err := p.mustContext(name)
+	if err != nil {
+		p.handlerMu.Unlock()
+		return nil, err
+	}
+	prev, next := ctx.prev.Load(), ctx.next.Load()
+	prev.next.Store(next)
+	next.prev.Store(prev)
+	ctx.removed.Store(true)
+	p.handlerMu.Unlock()
 
-	ctx := newHandlerContext(name, handler, p)
+	callHandlerRemoved(ctx)
+	return ctx.handler, nil
+}
 
-	prev := p.tail.prev
-	p.tail.prev = ctx
-	ctx.next = p.tail
-	ctx.prev = prev
-	prev.next = ctx
+// Replace 以新处理器替换oldName处理器，新处理器沿用原处理器的位置及执行器，返回被替换的处理器
+func (p *Pipeline) Replace(oldName string, newName string, handler ChannelHandler) (ChannelHandler, error) {
+	p.handlerMu.Lock()
+	old, err := p.mustContext(oldName)
+	if err != nil {
+		p.handlerMu.Unlock()
+		return nil, err
+	}
+	if newName != oldName && p.context(newName) != nil {
+		p.handlerMu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrDuplicateHandlerName, newName)
+	}
+
+	ctx := newHandlerContext(newName, handler, p)
+	ctx.executor = old.executor
+	prev, next := old.prev.Load(), old.next.Load()
+	ctx.prev.Store(prev)
+	ctx.next.Store(next)
+	prev.next.Store(ctx)
+	next.prev.Store(ctx)
+	old.removed.Store(true)
+	p.handlerMu.Unlock()
+
+	// 先通知新处理器加入，再通知旧处理器移除，旧处理器可在移除回调中向新处理器转交状态
+	callHandlerAdded(ctx)
+	callHandlerRemoved(old)
+	return old.handler, nil
+}
+
+// Get 获取指定名称的处理器，不存在时返回nil
+func (p *Pipeline) Get(name string) ChannelHandler {
+	if ctx := p.GetContext(name); ctx != nil {
+		return ctx.handler
+	}
+	return nil
 }
 
 // GetContext 获取指定名称的处理器上下文
 func (p *Pipeline) GetContext(name string) *HandlerContext {
 	p.handlerMu.RLock()
 	defer p.handlerMu.RUnlock()
+	return p.context(name)
+}
+
+// Names 按流水线顺序返回处理器名称
+func (p *Pipeline) Names() []string {
+	p.handlerMu.RLock()
+	defer p.handlerMu.RUnlock()
+
+	names := make([]string, 0)
+	for ctx := p.head.next.Load(); ctx != p.tail; ctx = ctx.next.Load() {
+		names = append(names, ctx.name)
+	}
+	return names
+}
+
+// add 在position返回的处理器之后插入处理器
+func (p *Pipeline) add(group *EventExecutorGroup, name string, handler ChannelHandler, position func() (*HandlerContext, error)) error {
+	p.handlerMu.Lock()
+	if p.context(name) != nil {
+		p.handlerMu.Unlock()
+		return fmt.Errorf("%w: %s", ErrDuplicateHandlerName, name)
+	}
+	prev, err := position()
+	if err != nil {
+		p.handlerMu.Unlock()
+		return err
+	}
+
+	ctx := newHandlerContext(name, handler, p)
+	if group != nil {
+		ctx.executor = group.Next()
+	}
+
+	next := prev.next.Load()
+	ctx.prev.Store(prev)
+	ctx.next.Store(next)
+	prev.next.Store(ctx)
+	next.prev.Store(ctx)
+	p.handlerMu.Unlock()
 
-	for ctx := p.head.next; ctx != p.tail; ctx = ctx.next {
+	callHandlerAdded(ctx)
+	return nil
+}
+
+// context 查找处理器上下文，调用方需持有锁
+func (p *Pipeline) context(name string) *HandlerContext {
+	for ctx := p.head.next.Load(); ctx != p.tail; ctx = ctx.next.Load() {
 		if ctx.name == name {
 			return ctx
 		}
@@ -65,27 +222,76 @@ func (p *Pipeline) GetContext(name string) *HandlerContext {
 	return nil
 }
 
+func (p *Pipeline) mustContext(name string) (*HandlerContext, error) {
+	ctx := p.context(name)
+	if ctx == nil {
+		return nil, fmt.Errorf("%w: %s", ErrHandlerNotFound, name)
+	}
+	return ctx, nil
+}
+
+func callHandlerAdded(ctx *HandlerContext) {
+	if lh, ok := ctx.handler.(LifecycleHandler); ok {
+		ctx.invoke(func() {
+			lh.HandlerAdded(ctx)
+		})
+	}
+}
+
+func callHandlerRemoved(ctx *HandlerContext) {
+	if lh, ok := ctx.handler.(LifecycleHandler); ok {
+		ctx.invoke(func() {
+			lh.HandlerRemoved(ctx)
+		})
+	}
+}
+
 // FireActive 触发激活事件
 func (p *Pipeline) FireActive() {
-	p.head.next.FireActive()
+	p.head.next.Load().FireActive()
 }
 
 // FireInactive 触发失活事件
 func (p *Pipeline) FireInactive() {
-	p.head.next.FireInactive()
+	p.head.next.Load().FireInactive()
 }
 
 // FireRead 触发读事件
 func (p *Pipeline) FireRead(data []byte) {
-	p.head.next.FireRead(data)
+	p.channel.metrics.bytesRead(len(data))
+	p.head.next.Load().FireRead(data)
 }
 
 // FireWrite 触发写事件
 func (p *Pipeline) FireWrite(data []byte) {
-	p.tail.prev.FireWrite(data)
+	p.fireWrite(data, nil)
+}
+
+func (p *Pipeline) fireWrite(data []byte, op *writeOp) {
+	p.tail.prev.Load().fireWrite(data, op)
 }
 
 // FireError 触发错误事件
 func (p *Pipeline) FireError(err error) {
-	p.head.next.FireError(err)
+	p.channel.metrics.errorFired()
+	p.head.next.Load().FireError(err)
+}
+
+// FireWritabilityChanged 通知实现了WritabilityHandler的处理器通道可写状态变化
+func (p *Pipeline) FireWritabilityChanged(writable bool) {
+	p.handlerMu.RLock()
+	ctxs := make([]*HandlerContext, 0)
+	for ctx := p.head.next.Load(); ctx != p.tail; ctx = ctx.next.Load() {
+		ctxs = append(ctxs, ctx)
+	}
+	p.handlerMu.RUnlock()
+
+	for _, ctx := range ctxs {
+		if wh, ok := ctx.handler.(WritabilityHandler); ok {
+			c := ctx
+			c.invoke(func() {
+				wh.HandleWritabilityChanged(c, writable)
+			})
+		}
+	}
 }
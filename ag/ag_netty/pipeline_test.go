@@ -0,0 +1,185 @@
+package ag_netty
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// nopHandler 记录生命周期回调的空处理器
+type nopHandler struct {
+	EchoHandler
+	events []string
+}
+
+func (h *nopHandler) HandleRead(ctx *HandlerContext, data []byte) {}
+
+func (h *nopHandler) HandlerAdded(ctx *HandlerContext) {
+	h.events = append(h.events, "added:"+ctx.Name())
+}
+
+func (h *nopHandler) HandlerRemoved(ctx *HandlerContext) {
+	h.events = append(h.events, "removed:"+ctx.Name())
+}
+
+// handshakeHandler 首个数据包视为握手，完成后以帧处理器替换自身，并将会话写入通道属性
+type handshakeHandler struct {
+	EchoHandler
+}
+
+func (h *handshakeHandler) HandleRead(ctx *HandlerContext, data []byte) {
+	ctx.Channel().Attrs().Set("session", string(data))
+	ctx.Write([]byte("ok"))
+	if _, err := ctx.Pipeline().Replace(ctx.Name(), "frame", &frameHandler{}); err != nil {
+		ctx.FireError(err)
+	}
+}
+
+// frameHandler 握手后的业务处理器，回复会话及数据
+type frameHandler struct {
+	EchoHandler
+}
+
+func (h *frameHandler) HandleRead(ctx *HandlerContext, data []byte) {
+	session, _ := AttrOf[string](ctx.Channel().Attrs(), "session")
+	ctx.Write([]byte(session + ":" + string(data)))
+}
+
+func TestPipelineManipulation(t *testing.T) {
+	ch := NewEmbeddedChannel()
+	p := ch.Pipeline
+	a, b, c := &nopHandler{}, &nopHandler{}, &nopHandler{}
+
+	p.AddLast("b", b)
+	if err := p.AddBefore("b", "a", a); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AddAfter("b", "c", c); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{embeddedRecorderName, "a", "b", "c"}
+	if got := p.Names(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Names() = %v, want %v", got, want)
+	}
+
+	if err := p.TryAddLast("a", &nopHandler{}); !errors.Is(err, ErrDuplicateHandlerName) {
+		t.Errorf("TryAddLast(duplicate) = %v, want ErrDuplicateHandlerName", err)
+	}
+	if err := p.TryAddFirst("b", &nopHandler{}); !errors.Is(err, ErrDuplicateHandlerName) {
+		t.Errorf("TryAddFirst(duplicate) = %v, want ErrDuplicateHandlerName", err)
+	}
+	// AddLast名称重复时不添加
+	p.AddLast("a", &nopHandler{})
+	if got := p.Names(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Names() after AddLast(duplicate) = %v, want %v", got, want)
+	}
+	if err := p.AddAfter("missing", "d", &nopHandler{}); !errors.Is(err, ErrHandlerNotFound) {
+		t.Errorf("AddAfter(missing) = %v, want ErrHandlerNotFound", err)
+	}
+	if _, err := p.Replace("a", "c", &nopHandler{}); !errors.Is(err, ErrDuplicateHandlerName) {
+		t.Errorf("Replace(to duplicate) = %v, want ErrDuplicateHandlerName", err)
+	}
+
+	removed, err := p.Remove("b")
+	if err != nil || removed != b {
+		t.Fatalf("Remove(b) = %v, %v", removed, err)
+	}
+	if _, err := p.Remove("b"); !errors.Is(err, ErrHandlerNotFound) {
+		t.Errorf("Remove(b) twice = %v, want ErrHandlerNotFound", err)
+	}
+
+	d := &nopHandler{}
+	old, err := p.Replace("c", "d", d)
+	if err != nil || old != c {
+		t.Fatalf("Replace(c, d) = %v, %v", old, err)
+	}
+	want = []string{embeddedRecorderName, "a", "d"}
+	if got := p.Names(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Names() = %v, want %v", got, want)
+	}
+	if p.Get("d") != d || p.Get("c") != nil {
+		t.Error("Get() after replace mismatch")
+	}
+
+	if !reflect.DeepEqual(b.events, []string{"added:b", "removed:b"}) {
+		t.Errorf("b lifecycle = %v", b.events)
+	}
+	if !reflect.DeepEqual(c.events, []string{"added:c", "removed:c"}) {
+		t.Errorf("c lifecycle = %v", c.events)
+	}
+	if !reflect.DeepEqual(d.events, []string{"added:d"}) {
+		t.Errorf("d lifecycle = %v", d.events)
+	}
+}
+
+func TestPipelineAddWithExecutor(t *testing.T) {
+	group := NewEventExecutorGroup(2, 16, RejectBlock)
+	defer group.Shutdown()
+	ch := NewEmbeddedChannel()
+	p := ch.Pipeline
+
+	p.AddLast("b", &nopHandler{})
+	if err := p.AddBeforeWithExecutor(group, "b", "a", &nopHandler{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AddAfterWithExecutor(group, "b", "c", &nopHandler{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AddAfterWithExecutor(group, "missing", "d", &nopHandler{}); !errors.Is(err, ErrHandlerNotFound) {
+		t.Errorf("AddAfterWithExecutor(missing) = %v, want ErrHandlerNotFound", err)
+	}
+	want := []string{embeddedRecorderName, "a", "b", "c"}
+	if got := p.Names(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Names() = %v, want %v", got, want)
+	}
+	for name, offloaded := range map[string]bool{"a": true, "b": false, "c": true} {
+		if got := p.GetContext(name).Executor() != nil; got != offloaded {
+			t.Errorf("%s offloaded = %v, want %v", name, got, offloaded)
+		}
+	}
+}
+
+func TestPipelineUpgrade(t *testing.T) {
+	ch := NewEmbeddedChannel(&handshakeHandler{})
+
+	ch.WriteInbound([]byte("sid-1"))
+	if got := string(ch.ReadOutbound()); got != "ok" {
+		t.Fatalf("handshake response = %q, want ok", got)
+	}
+	if ch.Pipeline.Get("frame") == nil || ch.Pipeline.Get("handler0") != nil {
+		t.Fatalf("Names() after upgrade = %v", ch.Pipeline.Names())
+	}
+
+	ch.WriteInbound([]byte("hello"))
+	if got := string(ch.ReadOutbound()); got != "sid-1:hello" {
+		t.Fatalf("frame response = %q, want sid-1:hello", got)
+	}
+	if errs := ch.Errors(); len(errs) != 0 {
+		t.Fatalf("Errors() = %v", errs)
+	}
+}
+
+func TestAttributeMap(t *testing.T) {
+	var attrs AttributeMap
+	if actual, loaded := attrs.SetIfAbsent("n", 1); loaded || actual != 1 {
+		t.Errorf("SetIfAbsent() = %v, %v", actual, loaded)
+	}
+	if actual, loaded := attrs.SetIfAbsent("n", 2); !loaded || actual != 1 {
+		t.Errorf("SetIfAbsent() existing = %v, %v", actual, loaded)
+	}
+	if !attrs.CompareAndSwap("n", 1, 3) || attrs.CompareAndSwap("n", 1, 4) {
+		t.Error("CompareAndSwap() mismatch")
+	}
+	if v, ok := AttrOf[int](&attrs, "n"); !ok || v != 3 {
+		t.Errorf("AttrOf[int]() = %v, %v", v, ok)
+	}
+	if _, ok := AttrOf[string](&attrs, "n"); ok {
+		t.Error("AttrOf[string]() on int want ok=false")
+	}
+	if v, ok := attrs.Delete("n"); !ok || v != 3 {
+		t.Errorf("Delete() = %v, %v", v, ok)
+	}
+	if _, ok := attrs.Get("n"); ok {
+		t.Error("Get() after Delete want ok=false")
+	}
+}
@@ -0,0 +1,186 @@
+package ag_netty
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrPromiseCanceled Promise已取消
+	ErrPromiseCanceled = errors.New("ag_netty: promise canceled")
+	// ErrNoPromise Any未传入任何Promise
+	ErrNoPromise = errors.New("ag_netty: no promise")
+)
+
+// Promise 异步操作结果，只能完成一次，后续的Success/Failure/Cancel返回false
+type Promise[T any] struct {
+	mu        sync.Mutex
+	done      chan struct{}
+	completed bool
+	value     T
+	err       error
+	listeners []func(value T, err error)
+}
+
+// NewPromise 创建Promise
+func NewPromise[T any]() *Promise[T] {
+	return &Promise[T]{done: make(chan struct{})}
+}
+
+// Success 以结果完成
+func (p *Promise[T]) Success(value T) bool {
+	return p.complete(value, nil)
+}
+
+// Failure 以错误完成
+func (p *Promise[T]) Failure(err error) bool {
+	var zero T
+	return p.complete(zero, err)
+}
+
+// Cancel 取消，以 ErrPromiseCanceled 完成
+func (p *Promise[T]) Cancel() bool {
+	return p.Failure(ErrPromiseCanceled)
+}
+
+func (p *Promise[T]) complete(value T, err error) bool {
+	p.mu.Lock()
+	if p.completed {
+		p.mu.Unlock()
+		return false
+	}
+	p.completed = true
+	p.value = value
+	p.err = err
+	listeners := p.listeners
+	p.listeners = nil
+	close(p.done)
+	p.mu.Unlock()
+
+	for _, l := range listeners {
+		l(value, err)
+	}
+	return true
+}
+
+// AddListener 添加完成回调，在完成Promise的协程中执行，已完成时立即在调用方协程执行
+func (p *Promise[T]) AddListener(listener func(value T, err error)) {
+	p.mu.Lock()
+	if !p.completed {
+		p.listeners = append(p.listeners, listener)
+		p.mu.Unlock()
+		return
+	}
+	value, err := p.value, p.err
+	p.mu.Unlock()
+	listener(value, err)
+}
+
+// Done 完成时关闭
+func (p *Promise[T]) Done() <-chan struct{} {
+	return p.done
+}
+
+// IsDone 是否已完成
+func (p *Promise[T]) IsDone() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsSuccess 是否已成功完成
+func (p *Promise[T]) IsSuccess() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.completed && p.err == nil
+}
+
+// IsCanceled 是否已取消
+func (p *Promise[T]) IsCanceled() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.completed && errors.Is(p.err, ErrPromiseCanceled)
+}
+
+// Get 阻塞等待结果
+func (p *Promise[T]) Get() (T, error) {
+	<-p.done
+	return p.value, p.err
+}
+
+// Await 等待结果，ctx结束时返回ctx的错误，不会取消Promise
+func (p *Promise[T]) Await(ctx context.Context) (T, error) {
+	select {
+	case <-p.done:
+		return p.value, p.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// All 全部成功时按传入顺序汇总结果，任一失败时以该错误失败
+func All[T any](promises ...*Promise[T]) *Promise[[]T] {
+	result := NewPromise[[]T]()
+	values := make([]T, len(promises))
+	if len(promises) == 0 {
+		result.Success(values)
+		return result
+	}
+
+	var mu sync.Mutex
+	remaining := len(promises)
+	for i, p := range promises {
+		i := i
+		p.AddListener(func(value T, err error) {
+			if err != nil {
+				result.Failure(err)
+				return
+			}
+			mu.Lock()
+			values[i] = value
+			remaining--
+			last := remaining == 0
+			mu.Unlock()
+			if last {
+				result.Success(values)
+			}
+		})
+	}
+	return result
+}
+
+// Any 任一成功时以其结果成功，全部失败时以汇总错误失败
+func Any[T any](promises ...*Promise[T]) *Promise[T] {
+	result := NewPromise[T]()
+	if len(promises) == 0 {
+		result.Failure(ErrNoPromise)
+		return result
+	}
+
+	var mu sync.Mutex
+	errs := make([]error, len(promises))
+	remaining := len(promises)
+	for i, p := range promises {
+		i := i
+		p.AddListener(func(value T, err error) {
+			if err == nil {
+				result.Success(value)
+				return
+			}
+			mu.Lock()
+			errs[i] = err
+			remaining--
+			last := remaining == 0
+			mu.Unlock()
+			if last {
+				result.Failure(errors.Join(errs...))
+			}
+		})
+	}
+	return result
+}
@@ -0,0 +1,173 @@
+package ag_netty
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestPromise(t *testing.T) {
+	p := NewPromise[int]()
+	var got []int
+	p.AddListener(func(v int, err error) { got = append(got, v) })
+
+	if !p.Success(1) || p.Success(2) || p.Failure(errors.New("late")) || p.Cancel() {
+		t.Fatal("Promise completed more than once")
+	}
+	p.AddListener(func(v int, err error) { got = append(got, v*10) })
+	if len(got) != 2 || got[0] != 1 || got[1] != 10 {
+		t.Errorf("listeners = %v", got)
+	}
+	if v, err := p.Await(context.Background()); v != 1 || err != nil || !p.IsSuccess() {
+		t.Errorf("Await() = %d, %v", v, err)
+	}
+
+	// 等待超时不影响Promise
+	p2 := NewPromise[string]()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p2.Await(ctx); !errors.Is(err, context.DeadlineExceeded) || p2.IsDone() {
+		t.Fatalf("Await() = %v, done %v", err, p2.IsDone())
+	}
+	if !p2.Cancel() || !p2.IsCanceled() {
+		t.Fatal("Cancel() failed")
+	}
+	if _, err := p2.Get(); !errors.Is(err, ErrPromiseCanceled) {
+		t.Errorf("Get() after cancel = %v", err)
+	}
+}
+
+func TestPromiseAllAny(t *testing.T) {
+	a, b, c := NewPromise[int](), NewPromise[int](), NewPromise[int]()
+	all := All(a, b, c)
+	first := Any(a, b, c)
+	c.Success(3)
+	if v, _ := first.Get(); v != 3 {
+		t.Errorf("Any() = %d, want 3", v)
+	}
+	a.Success(1)
+	if all.IsDone() {
+		t.Fatal("All() done before all promises")
+	}
+	b.Success(2)
+	if v, err := all.Get(); err != nil || len(v) != 3 || v[0] != 1 || v[1] != 2 || v[2] != 3 {
+		t.Errorf("All() = %v, %v", v, err)
+	}
+
+	errBad := errors.New("bad")
+	x, y := NewPromise[int](), NewPromise[int]()
+	all = All(x, y)
+	first = Any(x, y)
+	x.Failure(errBad)
+	if _, err := all.Get(); !errors.Is(err, errBad) {
+		t.Errorf("All() = %v, want fail fast", err)
+	}
+	y.Cancel()
+	if _, err := first.Get(); !errors.Is(err, errBad) || !errors.Is(err, ErrPromiseCanceled) {
+		t.Errorf("Any() = %v, want joined errors", err)
+	}
+	if _, err := Any[int]().Get(); !errors.Is(err, ErrNoPromise) {
+		t.Errorf("Any() without promises = %v", err)
+	}
+}
+
+func TestChannelWriteAndFlush(t *testing.T) {
+	ch := NewEmbeddedChannel(&EchoHandler{})
+
+	// 写任务执行后成功
+	p := ch.WriteAndFlush([]byte("hello"))
+	if p.IsDone() {
+		t.Fatal("promise done before flush")
+	}
+	ch.RunPendingTasks()
+	if _, err := p.Get(); err != nil || string(ch.ReadOutbound()) != "hello" {
+		t.Fatalf("WriteAndFlush() = %v", err)
+	}
+
+	// 写出前取消，不再写出
+	p = ch.WriteAndFlush([]byte("canceled"))
+	p.Cancel()
+	ch.RunPendingTasks()
+	if ch.ReadOutbound() != nil {
+		t.Error("canceled write was flushed")
+	}
+
+	// 连接写失败
+	ch.conn.Close()
+	p = ch.WriteAndFlush([]byte("reset"))
+	ch.RunPendingTasks()
+	if _, err := p.Get(); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("WriteAndFlush() on broken conn = %v", err)
+	}
+
+	// 写任务执行前通道关闭
+	p = ch.WriteAndFlush([]byte("closed"))
+	ch.Close()
+	ch.RunPendingTasks()
+	if _, err := p.Get(); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("WriteAndFlush() after close = %v", err)
+	}
+}
+
+func TestWriteAndFlushNotWritten(t *testing.T) {
+	// 没有处理器写出时，到达流水线头部以ErrNotWritten失败
+	ch := NewEmbeddedChannel()
+	p := ch.WriteAndFlush([]byte("dropped"))
+	ch.RunPendingTasks()
+	if _, err := p.Get(); !errors.Is(err, ErrNotWritten) {
+		t.Fatalf("WriteAndFlush() without writer = %v", err)
+	}
+}
+
+// rejectHandler 在执行器上拒绝写出"bad"，其余数据放行
+type rejectHandler struct {
+	EchoHandler
+}
+
+func (h *rejectHandler) HandleWrite(ctx *HandlerContext, data []byte) {
+	if string(data) == "bad" {
+		ctx.failWrite(errors.New("rejected"))
+	}
+}
+
+func TestWriteAndFlushOffloadedErrors(t *testing.T) {
+	group := NewEventExecutorGroup(1, 1024, RejectBlock)
+	defer group.Shutdown()
+	ch := NewEmbeddedChannel(&EchoHandler{})
+	if err := ch.Pipeline.AddLastWithExecutor(group, "reject", &rejectHandler{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 卸载处理器上的写出错误只记入所属的写操作
+	const n = 100
+	promises := make([]*Promise[struct{}], n)
+	for i := range promises {
+		data := "good"
+		if i%2 == 1 {
+			data = "bad"
+		}
+		promises[i] = ch.WriteAndFlush([]byte(data))
+	}
+	ch.RunPendingTasks()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	for i, p := range promises {
+		if _, err := p.Await(ctx); (err != nil) != (i%2 == 1) {
+			t.Fatalf("write %d: err = %v", i, err)
+		}
+	}
+}
+
+func TestFutureCompleteWithError(t *testing.T) {
+	// 以error完成时作为结果返回，与Future原有行为一致
+	f := NewFuture()
+	f.Complete(io.ErrClosedPipe)
+	if v, err := f.GetWithTimeout(time.Second); err != nil || v != io.ErrClosedPipe {
+		t.Fatalf("GetWithTimeout() = %v, %v", v, err)
+	}
+	if _, err := NewFuture().GetWithTimeout(time.Millisecond); !errors.Is(err, ErrFutureTimeout) {
+		t.Fatalf("GetWithTimeout() timeout = %v", err)
+	}
+}
@@ -0,0 +1,434 @@
+package ag_netty
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrFieldOverflow 字段值超过定义长度
+	ErrFieldOverflow = errors.New("ag_netty: record field overflow")
+	// ErrShortRecord 报文长度不足或字段数不足
+	ErrShortRecord = errors.New("ag_netty: short record")
+	// ErrFieldDelimiter 分隔符报文的字段值包含分隔符，解码时无法还原
+	ErrFieldDelimiter = errors.New("ag_netty: record field contains delimiter")
+)
+
+const (
+	recordTag         = "record"
+	defaultTimeLayout = "20060102150405"
+)
+
+// RecordMarshaller 定长/分隔符报文与结构体互转，字段由结构体标签 record 描述：
+//
+//	type Req struct {
+//		Code   string  `record:"len=6"`
+//		Name   string  `record:"len=20"`
+//		Amount float64 `record:"len=12,scale=2,implied"`
+//		Date   time.Time `record:"len=8,layout=20060102"`
+//	}
+//
+// 标签项：
+//   - off 字段起始字节偏移，缺省紧接上一字段，仅定长报文有效
+//   - len 字段字节长度(按报文字符集计)，定长报文必填；分隔符报文中为最大长度并按其补齐
+//   - align left/right，字符串缺省左对齐，数值缺省右对齐
+//   - pad 补齐字符，单个ASCII字符或space，字符串缺省空格，数值缺省0
+//   - scale 浮点数小数位数，缺省按最短表示写出；implied 表示隐含小数点(不写出小数点)，缺省scale时为0位小数
+//   - layout time.Time的格式，缺省20060102150405
+//
+// 不带标签或标签为"-"的字段忽略。定长报文按报文字符集的字节切分字段，多字节字符不会被截断，超长时返回ErrFieldOverflow
+// 分隔符报文不转义，字段值(含补齐字符)包含分隔符时返回ErrFieldDelimiter
+type RecordMarshaller struct {
+	charset   Charset
+	delimiter string
+}
+
+// RecordOption RecordMarshaller选项
+type RecordOption func(m *RecordMarshaller)
+
+// WithRecordCharset 报文字符集，缺省UTF-8
+func WithRecordCharset(charset Charset) RecordOption {
+	return func(m *RecordMarshaller) {
+		m.charset = charset
+	}
+}
+
+// WithRecordDelimiter 使用分隔符报文，字段按结构体定义顺序以delimiter分隔
+func WithRecordDelimiter(delimiter string) RecordOption {
+	return func(m *RecordMarshaller) {
+		m.delimiter = delimiter
+	}
+}
+
+// NewRecordMarshaller 创建报文编解码，缺省为UTF-8定长报文
+func NewRecordMarshaller(opts ...RecordOption) *RecordMarshaller {
+	m := &RecordMarshaller{charset: CharsetUTF8}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Charset 报文字符集
+func (m *RecordMarshaller) Charset() Charset {
+	return m.charset
+}
+
+// Marshal 结构体(或其指针)编码为报文
+func (m *RecordMarshaller) Marshal(v any) ([]byte, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("ag_netty: record marshal %T: not a struct", v)
+	}
+	layout, err := recordLayoutOf(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	if m.delimiter != "" {
+		parts := make([]string, len(layout.fields))
+		for i, f := range layout.fields {
+			b, err := m.encodeField(f, rv.Field(f.index))
+			if err != nil {
+				return nil, err
+			}
+			parts[i] = string(b)
+		}
+		// 字段值含分隔符，或与相邻分隔符拼出分隔符时，按分隔符切分无法还原字段
+		text := strings.Join(parts, m.delimiter)
+		for i, part := range strings.Split(text, m.delimiter) {
+			if i >= len(parts) || part != parts[i] {
+				return nil, fmt.Errorf("%w: %s", ErrFieldDelimiter, layout.fields[min(i, len(parts)-1)].name)
+			}
+		}
+		return m.charset.Encode(text)
+	}
+
+	if err := layout.checkFixed(); err != nil {
+		return nil, err
+	}
+	out := bytes.Repeat([]byte{' '}, layout.size)
+	for _, f := range layout.fields {
+		b, err := m.encodeField(f, rv.Field(f.index))
+		if err != nil {
+			return nil, err
+		}
+		copy(out[f.off:], b)
+	}
+	return out, nil
+}
+
+// Unmarshal 报文解码到结构体指针
+func (m *RecordMarshaller) Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("ag_netty: record unmarshal %T: not a struct pointer", v)
+	}
+	rv = rv.Elem()
+	layout, err := recordLayoutOf(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	if m.delimiter != "" {
+		text, err := m.charset.Decode(data)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(text, m.delimiter)
+		if len(parts) < len(layout.fields) {
+			return fmt.Errorf("%w: %d fields, want %d", ErrShortRecord, len(parts), len(layout.fields))
+		}
+		for i, f := range layout.fields {
+			if err := f.set(rv.Field(f.index), trimPad(f, parts[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := layout.checkFixed(); err != nil {
+		return err
+	}
+	if len(data) < layout.size {
+		return fmt.Errorf("%w: %d bytes, want %d", ErrShortRecord, len(data), layout.size)
+	}
+	for _, f := range layout.fields {
+		text, err := m.charset.Decode(data[f.off : f.off+f.length])
+		if err != nil {
+			return err
+		}
+		if err := f.set(rv.Field(f.index), trimPad(f, text)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeField 字段格式化、转码并补齐，长度按报文字符集的字节数计
+// 分隔符报文返回补齐后的UTF-8文本，由Marshal拼接后整体转码
+func (m *RecordMarshaller) encodeField(f *recordField, v reflect.Value) ([]byte, error) {
+	text, err := f.format(v)
+	if err != nil {
+		return nil, err
+	}
+	b, err := m.charset.Encode(text)
+	if err != nil {
+		return nil, fmt.Errorf("ag_netty: record field %s: %w", f.name, err)
+	}
+	width := len(b)
+	if m.delimiter != "" {
+		b = []byte(text)
+	}
+	if f.length == 0 {
+		return b, nil
+	}
+	if width > f.length {
+		return nil, fmt.Errorf("%w: %s is %d bytes, max %d", ErrFieldOverflow, f.name, width, f.length)
+	}
+
+	padding := bytes.Repeat([]byte{f.pad}, f.length-width)
+	if !f.alignRight {
+		return append(b, padding...), nil
+	}
+	if f.numeric && f.pad == '0' && len(b) > 0 && b[0] == '-' {
+		return append(append([]byte{'-'}, padding...), b[1:]...), nil
+	}
+	return append(padding, b...), nil
+}
+
+func trimPad(f *recordField, text string) string {
+	if f.alignRight {
+		if !(f.numeric && f.pad == '0') {
+			text = strings.TrimLeft(text, string(f.pad))
+		}
+	} else {
+		text = strings.TrimRight(text, string(f.pad))
+	}
+	if f.numeric {
+		text = strings.TrimSpace(text)
+	}
+	return text
+}
+
+// recordLayout 结构体的报文布局
+type recordLayout struct {
+	fields []*recordField
+	size   int // 定长报文总长度
+}
+
+// checkFixed 定长报文的字段必须指定长度
+func (l *recordLayout) checkFixed() error {
+	for _, f := range l.fields {
+		if f.length <= 0 {
+			return fmt.Errorf("ag_netty: record field %s: len is required for fixed-width record", f.name)
+		}
+	}
+	return nil
+}
+
+type recordField struct {
+	name       string
+	index      int
+	off        int
+	length     int
+	alignRight bool
+	pad        byte
+	scale      int // 小数位数，-1为未设置
+	implied    bool
+	timeLayout string
+	numeric    bool
+}
+
+var recordLayouts sync.Map // reflect.Type -> *recordLayout
+
+func recordLayoutOf(t reflect.Type) (*recordLayout, error) {
+	if l, ok := recordLayouts.Load(t); ok {
+		return l.(*recordLayout), nil
+	}
+	l, err := parseRecordLayout(t)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := recordLayouts.LoadOrStore(t, l)
+	return actual.(*recordLayout), nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func parseRecordLayout(t reflect.Type) (*recordLayout, error) {
+	l := &recordLayout{}
+	next := 0
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup(recordTag)
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+		f := &recordField{name: sf.Name, index: i, off: -1, scale: -1, timeLayout: defaultTimeLayout}
+		switch {
+		case sf.Type == timeType:
+		case sf.Type.Kind() == reflect.String:
+		case isIntKind(sf.Type.Kind()), isUintKind(sf.Type.Kind()), isFloatKind(sf.Type.Kind()):
+			f.numeric = true
+		default:
+			return nil, fmt.Errorf("ag_netty: record field %s.%s: unsupported type %s", t.Name(), sf.Name, sf.Type)
+		}
+		f.alignRight = f.numeric
+		padSet := false
+
+		for _, item := range strings.Split(tag, ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(item), "=")
+			var err error
+			switch key {
+			case "":
+			case "off":
+				f.off, err = strconv.Atoi(value)
+			case "len":
+				f.length, err = strconv.Atoi(value)
+			case "align":
+				switch value {
+				case "left":
+					f.alignRight = false
+				case "right":
+					f.alignRight = true
+				default:
+					err = fmt.Errorf("invalid align %q", value)
+				}
+			case "pad":
+				switch {
+				case value == "space":
+					f.pad, padSet = ' ', true
+				case len(value) == 1:
+					f.pad, padSet = value[0], true
+				default:
+					err = fmt.Errorf("invalid pad %q", value)
+				}
+			case "scale":
+				f.scale, err = strconv.Atoi(value)
+				if err == nil && f.scale < 0 {
+					err = fmt.Errorf("invalid scale %q", value)
+				}
+			case "implied":
+				f.implied = true
+			case "layout":
+				f.timeLayout = value
+			default:
+				err = fmt.Errorf("unknown option %q", key)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("ag_netty: record field %s.%s: %w", t.Name(), sf.Name, err)
+			}
+		}
+		if !padSet {
+			f.pad = ' '
+			if f.numeric && f.alignRight {
+				f.pad = '0'
+			}
+		}
+		if f.off < 0 {
+			f.off = next
+		}
+		next = f.off + f.length
+		if next > l.size {
+			l.size = next
+		}
+		l.fields = append(l.fields, f)
+	}
+	return l, nil
+}
+
+func (f *recordField) format(v reflect.Value) (string, error) {
+	switch {
+	case v.Type() == timeType:
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return "", nil
+		}
+		return t.Format(f.timeLayout), nil
+	case v.Kind() == reflect.String:
+		return v.String(), nil
+	case isIntKind(v.Kind()):
+		return strconv.FormatInt(v.Int(), 10), nil
+	case isUintKind(v.Kind()):
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case isFloatKind(v.Kind()):
+		if !f.implied {
+			return strconv.FormatFloat(v.Float(), 'f', f.scale, v.Type().Bits()), nil
+		}
+		scaled := math.Round(v.Float() * math.Pow10(f.impliedScale()))
+		if math.Abs(scaled) >= 1<<63 {
+			return "", fmt.Errorf("%w: %s", ErrFieldOverflow, f.name)
+		}
+		return strconv.FormatInt(int64(scaled), 10), nil
+	}
+	return "", fmt.Errorf("ag_netty: record field %s: unsupported type %s", f.name, v.Type())
+}
+
+// impliedScale 隐含小数点的小数位数，未设置scale时为0
+func (f *recordField) impliedScale() int {
+	return max(f.scale, 0)
+}
+
+func (f *recordField) set(v reflect.Value, text string) error {
+	if f.numeric && text == "" {
+		v.SetZero()
+		return nil
+	}
+	var err error
+	switch {
+	case v.Type() == timeType:
+		if text == "" {
+			v.SetZero()
+			return nil
+		}
+		var t time.Time
+		t, err = time.ParseInLocation(f.timeLayout, text, time.Local)
+		if err == nil {
+			v.Set(reflect.ValueOf(t))
+		}
+	case v.Kind() == reflect.String:
+		v.SetString(text)
+	case isIntKind(v.Kind()):
+		var n int64
+		n, err = strconv.ParseInt(text, 10, v.Type().Bits())
+		v.SetInt(n)
+	case isUintKind(v.Kind()):
+		var n uint64
+		n, err = strconv.ParseUint(text, 10, v.Type().Bits())
+		v.SetUint(n)
+	case isFloatKind(v.Kind()):
+		if f.implied {
+			var n int64
+			n, err = strconv.ParseInt(text, 10, 64)
+			v.SetFloat(float64(n) / math.Pow10(f.impliedScale()))
+		} else {
+			var n float64
+			n, err = strconv.ParseFloat(text, v.Type().Bits())
+			v.SetFloat(n)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("ag_netty: record field %s: %w", f.name, err)
+	}
+	return nil
+}
+
+func isIntKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUintKind(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uint64
+}
+
+func isFloatKind(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
@@ -0,0 +1,147 @@
+package ag_netty
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+type transferReq struct {
+	TxCode  string    `record:"len=6"`
+	Name    string    `record:"len=10"`
+	Amount  float64   `record:"len=12,scale=2,implied"`
+	Balance float64   `record:"len=10,scale=2"`
+	Count   int       `record:"len=5"`
+	Date    time.Time `record:"len=8,layout=20060102"`
+	Memo    string    `record:"off=60,len=8,align=right,pad=*"`
+	Skip    string
+}
+
+func TestRecordMarshallerFixedGBK(t *testing.T) {
+	m := NewRecordMarshaller(WithRecordCharset(CharsetGBK))
+	req := transferReq{
+		TxCode:  "TR01",
+		Name:    "张三",
+		Amount:  1234.5,
+		Balance: -12.3,
+		Count:   7,
+		Date:    time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local),
+		Memo:    "备注",
+		Skip:    "ignored",
+	}
+	data, err := m.Marshal(&req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 中文按GBK计2字节，定长字段按字节补齐
+	name, _ := CharsetGBK.Encode("张三")
+	want := []byte("TR01  ")
+	want = append(want, name...)
+	want = append(want, "      000000123450-000012.300000720240501"...)
+	want = append(want, "         "...)
+	memo, _ := CharsetGBK.Encode("备注")
+	want = append(want, "****"...)
+	want = append(want, memo...)
+	if !bytes.Equal(data, want) {
+		t.Fatalf("Marshal() =\n%q\nwant\n%q", data, want)
+	}
+
+	var got transferReq
+	if err := m.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	req.Skip = ""
+	if got != req {
+		t.Errorf("Unmarshal() = %+v\nwant %+v", got, req)
+	}
+
+	if err := m.Unmarshal(data[:20], &got); !errors.Is(err, ErrShortRecord) {
+		t.Errorf("Unmarshal() short = %v", err)
+	}
+	req.Name = "一二三四五六"
+	if _, err := m.Marshal(req); !errors.Is(err, ErrFieldOverflow) {
+		t.Errorf("Marshal() overflow = %v", err)
+	}
+}
+
+type queryResp struct {
+	Code   string  `record:""`
+	Msg    string  `record:""`
+	Amount float64 `record:"scale=2"`
+	Seq    int64   `record:"len=6"`
+}
+
+func TestRecordMarshallerDelimited(t *testing.T) {
+	m := NewRecordMarshaller(WithRecordCharset(CharsetGB18030), WithRecordDelimiter("|"))
+	resp := queryResp{Code: "0000", Msg: "交易成功", Amount: 99.9, Seq: 42}
+	data, err := m.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, _ := CharsetGB18030.Decode(data)
+	if text != "0000|交易成功|99.90|000042" {
+		t.Fatalf("Marshal() = %q", text)
+	}
+
+	var got queryResp
+	if err := m.Unmarshal(data, &got); err != nil || got != resp {
+		t.Fatalf("Unmarshal() = %+v, %v", got, err)
+	}
+	if err := m.Unmarshal([]byte("0000|ok"), &got); !errors.Is(err, ErrShortRecord) {
+		t.Errorf("Unmarshal() short = %v", err)
+	}
+
+	// 字段值含分隔符时拒绝编码，避免解码时字段错位
+	if _, err := m.Marshal(queryResp{Code: "0000", Msg: "a|b"}); !errors.Is(err, ErrFieldDelimiter) {
+		t.Errorf("Marshal() with delimiter in field = %v", err)
+	}
+
+	// 多字符分隔符往返，字段值可含分隔符的部分字符
+	m = NewRecordMarshaller(WithRecordDelimiter("||"))
+	for _, resp := range []queryResp{
+		{Code: "0001", Msg: "a|b", Amount: -1.5, Seq: 7},
+		{Code: "", Msg: "", Amount: 0, Seq: 0},
+		{Code: "9999", Msg: "多字节", Amount: 12345.67, Seq: 999999},
+	} {
+		data, err := m.Marshal(resp)
+		if err != nil {
+			t.Fatalf("Marshal(%+v) = %v", resp, err)
+		}
+		var got queryResp
+		if err := m.Unmarshal(data, &got); err != nil || got != resp {
+			t.Fatalf("round trip %+v = %+v, %v", resp, got, err)
+		}
+	}
+	// 字段末尾与其后的分隔符拼出分隔符同样无法还原
+	for _, msg := range []string{"a||b", "a|"} {
+		if _, err := m.Marshal(queryResp{Msg: msg}); !errors.Is(err, ErrFieldDelimiter) {
+			t.Errorf("Marshal(%q) = %v, want ErrFieldDelimiter", msg, err)
+		}
+	}
+}
+
+type rateRecord struct {
+	Rate  float64 `record:""`
+	Ratio float32 `record:""`
+	Fee   float64 `record:"len=6,implied"`
+}
+
+func TestRecordFloatWithoutScale(t *testing.T) {
+	m := NewRecordMarshaller(WithRecordDelimiter("|"))
+	rec := rateRecord{Rate: 0.0375, Ratio: 0.1, Fee: 12}
+
+	// 未设置scale时按最短表示写出，不截断小数；implied按0位小数
+	data, err := m.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "0.0375|0.1|000012" {
+		t.Fatalf("Marshal() = %q", data)
+	}
+	var got rateRecord
+	if err := m.Unmarshal(data, &got); err != nil || got != rec {
+		t.Fatalf("Unmarshal() = %+v, %v", got, err)
+	}
+}
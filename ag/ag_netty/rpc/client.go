@@ -0,0 +1,179 @@
+package rpc
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/frochyzhang/ag-core/ag/ag_netty"
+)
+
+const pendingAttrKey = "ag_netty.rpc.pending"
+
+// Transport 提供发送请求的通道，ag_netty/client.Client 满足该接口
+// 通道流水线中须包含 NewClientHandler 创建的处理器
+type Transport interface {
+	Channel() *ag_netty.Channel
+}
+
+// Invoker 执行一次RPC调用
+type Invoker func(ctx context.Context, method string, req, resp any) error
+
+// ClientInterceptor 客户端调用拦截器，通过invoker继续调用，可用于熔断、重试等
+type ClientInterceptor func(ctx context.Context, method string, req, resp any, invoker Invoker) error
+
+// Client RPC客户端，按请求ID关联应答，同一通道上的请求可并发
+type Client struct {
+	transport Transport
+	opts      *options
+	invoker   Invoker
+}
+
+// NewClient 创建RPC客户端
+func NewClient(transport Transport, opts ...Option) *Client {
+	c := &Client{transport: transport, opts: newOptions(opts)}
+	c.invoker = c.invoke
+	for i := len(c.opts.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := c.opts.interceptors[i], c.invoker
+		c.invoker = func(ctx context.Context, method string, req, resp any) error {
+			return interceptor(ctx, method, req, resp, next)
+		}
+	}
+	return c
+}
+
+// Invoke 调用方法并等待应答，method为完整方法名(/package.Service/Method)
+// 业务错误返回BizStatusError，框架错误返回 *RemoteError；ctx未设置截止时间时使用默认超时
+func (c *Client) Invoke(ctx context.Context, method string, req, resp any) error {
+	return c.invoker(ctx, method, req, resp)
+}
+
+func (c *Client) invoke(ctx context.Context, method string, req, resp any) error {
+	ch := c.transport.Channel()
+	if ch == nil {
+		return ErrNoChannel
+	}
+	payload, err := c.opts.codec.Marshal(req)
+	if err != nil {
+		return err
+	}
+	// 请求ID由通道分配，同一通道上的多个Client不会重复
+	calls := pendingOf(ch)
+	id, p := calls.add()
+	defer calls.remove(id)
+	f := &Frame{
+		Type:      TypeRequest,
+		RequestID: id,
+		MethodID:  MethodID(method),
+		Payload:   payload,
+	}
+	f.Metadata, _ = FromOutgoingContext(ctx)
+	data, err := EncodeFrame(f)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok && c.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.timeout)
+		defer cancel()
+	}
+
+	ch.WriteAndFlush(data).AddListener(func(_ struct{}, err error) {
+		if err != nil {
+			p.Failure(err)
+		}
+	})
+
+	reply, err := p.Await(ctx)
+	if err != nil {
+		return err
+	}
+	if reply.IsError() {
+		return frameError(reply)
+	}
+	return c.opts.codec.Unmarshal(reply.Payload, resp)
+}
+
+// pendingCalls 通道上等待应答的请求，请求ID按通道递增分配
+type pendingCalls struct {
+	mu     sync.Mutex
+	nextID uint64
+	calls  map[uint64]*ag_netty.Promise[*Frame]
+	closed bool
+}
+
+func pendingOf(ch *ag_netty.Channel) *pendingCalls {
+	v, _ := ch.Attrs().SetIfAbsent(pendingAttrKey, &pendingCalls{calls: make(map[uint64]*ag_netty.Promise[*Frame])})
+	return v.(*pendingCalls)
+}
+
+// add 分配请求ID并登记等待应答的请求
+func (p *pendingCalls) add() (uint64, *ag_netty.Promise[*Frame]) {
+	promise := ag_netty.NewPromise[*Frame]()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nextID++
+	if p.closed {
+		promise.Failure(ErrChannelClosed)
+		return p.nextID, promise
+	}
+	p.calls[p.nextID] = promise
+	return p.nextID, promise
+}
+
+func (p *pendingCalls) remove(id uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.calls, id)
+}
+
+func (p *pendingCalls) complete(f *Frame) bool {
+	p.mu.Lock()
+	promise, ok := p.calls[f.RequestID]
+	delete(p.calls, f.RequestID)
+	p.mu.Unlock()
+	return ok && promise.Success(f)
+}
+
+func (p *pendingCalls) close() {
+	p.mu.Lock()
+	calls := p.calls
+	p.calls = make(map[uint64]*ag_netty.Promise[*Frame])
+	p.closed = true
+	p.mu.Unlock()
+	for _, promise := range calls {
+		promise.Failure(ErrChannelClosed)
+	}
+}
+
+// clientHandler 客户端成帧处理器，通道关闭时使等待中的请求失败
+type clientHandler struct {
+	*ag_netty.FrameHandler
+}
+
+// NewClientHandler 创建客户端处理器，读到应答帧后完成对应请求，超时后到达的应答被丢弃
+func NewClientHandler(opts ...Option) ag_netty.ChannelHandler {
+	o := newOptions(opts)
+	return &clientHandler{
+		FrameHandler: ag_netty.NewFrameHandler(o.framer, func(ctx *ag_netty.HandlerContext, data []byte) {
+			f, err := DecodeFrame(data)
+			if err != nil {
+				ctx.Pipeline().FireError(err)
+				return
+			}
+			if f.Type == TypeRequest {
+				slog.Warn("ag_netty rpc client dropped request frame", "remote", ctx.Channel().RemoteAddr())
+				return
+			}
+			if !pendingOf(ctx.Channel()).complete(f) {
+				slog.Debug("ag_netty rpc response without pending call", "remote", ctx.Channel().RemoteAddr(), "requestID", f.RequestID)
+			}
+		}),
+	}
+}
+
+func (h *clientHandler) HandleInactive(ctx *ag_netty.HandlerContext) {
+	h.FrameHandler.HandleInactive(ctx)
+	pendingOf(ctx.Channel()).close()
+}
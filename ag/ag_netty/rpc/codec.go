@@ -0,0 +1,113 @@
+package rpc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/frochyzhang/ag-core/ag/ag_netty"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// DefaultMaxFrameLength 默认最大帧长度
+	DefaultMaxFrameLength = ag_netty.DefaultMaxFrameLength
+	// DefaultTimeout 默认调用超时，ctx未设置截止时间时生效
+	DefaultTimeout = 3 * time.Second
+	// DefaultMaxConcurrency 服务端默认同时处理的请求数上限
+	DefaultMaxConcurrency = 256
+)
+
+// Codec 请求及应答消息的编解码
+type Codec interface {
+	Name() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// ProtoCodec protobuf编解码，消息须实现proto.Message
+type ProtoCodec struct{}
+
+func (ProtoCodec) Name() string { return "protobuf" }
+
+func (ProtoCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("ag_netty rpc: %T is not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (ProtoCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("ag_netty rpc: %T is not a proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// DefaultFramer 默认成帧：4字节长度字段，不含长度字段本身
+func DefaultFramer() ag_netty.Framer {
+	f := ag_netty.NewLengthFieldFramer(4, false)
+	f.MaxLength = DefaultMaxFrameLength
+	return f
+}
+
+type options struct {
+	codec        Codec
+	framer       ag_netty.Framer
+	timeout      time.Duration
+	interceptors []ClientInterceptor
+	concurrency  int
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		codec:       ProtoCodec{},
+		framer:      DefaultFramer(),
+		timeout:     DefaultTimeout,
+		concurrency: DefaultMaxConcurrency,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Option 服务端及客户端配置项，两端的编解码及成帧须一致
+type Option func(*options)
+
+// WithCodec 设置消息编解码，默认为 ProtoCodec
+func WithCodec(codec Codec) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
+
+// WithFramer 设置成帧方式，默认为 DefaultFramer
+func WithFramer(framer ag_netty.Framer) Option {
+	return func(o *options) {
+		o.framer = framer
+	}
+}
+
+// WithTimeout 设置客户端默认调用超时，仅对客户端生效
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithClientInterceptors 追加客户端调用拦截器，按追加顺序由外向内执行，仅对客户端生效
+func WithClientInterceptors(interceptors ...ClientInterceptor) Option {
+	return func(o *options) {
+		o.interceptors = append(o.interceptors, interceptors...)
+	}
+}
+
+// WithMaxConcurrency 设置服务端同时处理的请求数上限，默认为 DefaultMaxConcurrency，
+// 达到上限时暂停读取新请求；小于等于0时在读取协程中逐个处理，仅对服务端生效
+func WithMaxConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
@@ -0,0 +1,215 @@
+package rpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"sort"
+)
+
+// FrameType 帧类型
+type FrameType uint8
+
+const (
+	TypeRequest  FrameType = iota + 1 // 请求
+	TypeResponse                      // 正常应答
+	TypeBizError                      // 业务错误应答，携带BizStatusError
+	TypeError                         // 框架错误应答，如方法不存在、请求解码失败
+)
+
+const (
+	magic0  = 'A'
+	magic1  = 'G'
+	version = 1
+
+	// headerSize 固定头部：魔数2、版本1、类型1、请求ID8、方法ID4
+	headerSize = 16
+)
+
+var (
+	// ErrInvalidFrame 帧格式错误
+	ErrInvalidFrame = errors.New("ag_netty rpc: invalid frame")
+	// ErrFieldTooLong 元数据或状态字段超过65535字节
+	ErrFieldTooLong = errors.New("ag_netty rpc: field too long")
+)
+
+// Frame RPC帧，经长度字段成帧后在连接上传输，格式为：
+//
+//	magic(2)="AG" version(1) type(1) request-id(8) method-id(4)
+//	metadata: count(2) {key-len(2) key value-len(2) value}...
+//	status(仅错误应答): code(4) message-len(2) message extra-count(2) {key-len(2) key value-len(2) value}...
+//	payload: 剩余字节
+//
+// 多字节整数均为大端序
+type Frame struct {
+	Type      FrameType
+	RequestID uint64
+	MethodID  uint32
+	Metadata  Metadata
+	Status    *Status
+	Payload   []byte
+}
+
+// Status 错误应答状态，业务错误时对应BizStatusError的code、msg及extra
+type Status struct {
+	Code    int32
+	Message string
+	Extra   map[string]string
+}
+
+// MethodID 方法ID，为完整方法名(/package.Service/Method)的CRC32
+func MethodID(fullMethod string) uint32 {
+	return crc32.ChecksumIEEE([]byte(fullMethod))
+}
+
+// IsError 是否为错误应答
+func (f *Frame) IsError() bool {
+	return f.Type == TypeBizError || f.Type == TypeError
+}
+
+// EncodeFrame 编码帧，不含长度字段
+func EncodeFrame(f *Frame) ([]byte, error) {
+	out := make([]byte, headerSize, headerSize+len(f.Payload)+64)
+	out[0], out[1], out[2], out[3] = magic0, magic1, version, byte(f.Type)
+	binary.BigEndian.PutUint64(out[4:], f.RequestID)
+	binary.BigEndian.PutUint32(out[12:], f.MethodID)
+
+	var err error
+	if out, err = appendMap(out, f.Metadata); err != nil {
+		return nil, err
+	}
+	if f.IsError() {
+		s := f.Status
+		if s == nil {
+			s = &Status{}
+		}
+		out = binary.BigEndian.AppendUint32(out, uint32(s.Code))
+		if out, err = appendString(out, s.Message); err != nil {
+			return nil, err
+		}
+		if out, err = appendMap(out, s.Extra); err != nil {
+			return nil, err
+		}
+	}
+	return append(out, f.Payload...), nil
+}
+
+// DecodeFrame 解码帧，Payload与data共享底层数组
+func DecodeFrame(data []byte) (*Frame, error) {
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrInvalidFrame, len(data))
+	}
+	if data[0] != magic0 || data[1] != magic1 {
+		return nil, fmt.Errorf("%w: bad magic %#x", ErrInvalidFrame, data[:2])
+	}
+	if data[2] != version {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidFrame, data[2])
+	}
+	f := &Frame{
+		Type:      FrameType(data[3]),
+		RequestID: binary.BigEndian.Uint64(data[4:]),
+		MethodID:  binary.BigEndian.Uint32(data[12:]),
+	}
+	if f.Type < TypeRequest || f.Type > TypeError {
+		return nil, fmt.Errorf("%w: unknown type %d", ErrInvalidFrame, f.Type)
+	}
+
+	r := reader{data: data, pos: headerSize}
+	f.Metadata = r.readMap()
+	if f.IsError() {
+		s := &Status{Code: int32(r.readUint32())}
+		s.Message = r.readString()
+		s.Extra = r.readMap()
+		f.Status = s
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	f.Payload = data[r.pos:]
+	return f, nil
+}
+
+func appendString(out []byte, s string) ([]byte, error) {
+	if len(s) > math.MaxUint16 {
+		return nil, fmt.Errorf("%w: %d bytes", ErrFieldTooLong, len(s))
+	}
+	out = binary.BigEndian.AppendUint16(out, uint16(len(s)))
+	return append(out, s...), nil
+}
+
+// appendMap 按键排序编码，相同内容的帧编码结果一致
+func appendMap(out []byte, m map[string]string) ([]byte, error) {
+	if len(m) > math.MaxUint16 {
+		return nil, fmt.Errorf("%w: %d entries", ErrFieldTooLong, len(m))
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out = binary.BigEndian.AppendUint16(out, uint16(len(keys)))
+	var err error
+	for _, k := range keys {
+		if out, err = appendString(out, k); err != nil {
+			return nil, err
+		}
+		if out, err = appendString(out, m[k]); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// reader 顺序读取帧字段，首个错误后的读取均返回零值
+type reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.data)-r.pos < n {
+		r.err = fmt.Errorf("%w: short frame", ErrInvalidFrame)
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *reader) readUint16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) readUint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) readString() string {
+	return string(r.next(int(r.readUint16())))
+}
+
+func (r *reader) readMap() map[string]string {
+	n := int(r.readUint16())
+	if n == 0 {
+		return nil
+	}
+	m := make(map[string]string, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		k := r.readString()
+		m[k] = r.readString()
+	}
+	return m
+}
@@ -0,0 +1,53 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/frochyzhang/ag-core/ag/ag_netty"
+)
+
+// Metadata 随请求传递的元数据，如链路ID、调用方标识
+type Metadata map[string]string
+
+type outgoingKey struct{}
+type incomingKey struct{}
+type channelKey struct{}
+
+// NewOutgoingContext 设置客户端请求携带的元数据，覆盖ctx中已有的元数据
+func NewOutgoingContext(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, outgoingKey{}, md)
+}
+
+// AppendToOutgoingContext 追加客户端请求携带的元数据，kv为键值交替的列表
+func AppendToOutgoingContext(ctx context.Context, kv ...string) context.Context {
+	if len(kv)%2 == 1 {
+		panic("ag_netty rpc: AppendToOutgoingContext got an odd number of kv")
+	}
+	old, _ := ctx.Value(outgoingKey{}).(Metadata)
+	md := make(Metadata, len(old)+len(kv)/2)
+	for k, v := range old {
+		md[k] = v
+	}
+	for i := 0; i < len(kv); i += 2 {
+		md[kv[i]] = kv[i+1]
+	}
+	return NewOutgoingContext(ctx, md)
+}
+
+// FromOutgoingContext 获取客户端请求携带的元数据
+func FromOutgoingContext(ctx context.Context) (Metadata, bool) {
+	md, ok := ctx.Value(outgoingKey{}).(Metadata)
+	return md, ok
+}
+
+// FromIncomingContext 服务端获取请求携带的元数据
+func FromIncomingContext(ctx context.Context) (Metadata, bool) {
+	md, ok := ctx.Value(incomingKey{}).(Metadata)
+	return md, ok
+}
+
+// ChannelFromContext 服务端获取请求所在的通道
+func ChannelFromContext(ctx context.Context) (*ag_netty.Channel, bool) {
+	ch, ok := ctx.Value(channelKey{}).(*ag_netty.Channel)
+	return ch, ok
+}
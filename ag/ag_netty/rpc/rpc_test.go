@@ -0,0 +1,266 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/frochyzhang/ag-core/ag/ag_error"
+	"github.com/frochyzhang/ag-core/ag/ag_netty"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	methodEcho  = "/test.Echo/Echo"
+	methodFail  = "/test.Echo/Fail"
+	methodPanic = "/test.Echo/Panic"
+	methodBlock = "/test.Echo/Block"
+)
+
+// blockRelease 阻塞方法等待的信号，测试并发处理
+var blockRelease chan struct{}
+
+type echoServer interface {
+	Echo(ctx context.Context, in *wrapperspb.StringValue) (*wrapperspb.StringValue, error)
+}
+
+type echoImpl struct{}
+
+func (echoImpl) Echo(ctx context.Context, in *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+	md, _ := FromIncomingContext(ctx)
+	return wrapperspb.String(in.GetValue() + md["suffix"]), nil
+}
+
+func unary(f func(srv echoServer, ctx context.Context, in *wrapperspb.StringValue) (any, error)) MethodHandler {
+	return func(srv any, ctx context.Context, dec func(any) error) (any, error) {
+		in := new(wrapperspb.StringValue)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		return f(srv.(echoServer), ctx, in)
+	}
+}
+
+var echoDesc = ServiceDesc{
+	ServiceName: "test.Echo",
+	HandlerType: (*echoServer)(nil),
+	Methods: []MethodDesc{
+		{Name: methodEcho, Handler: unary(func(srv echoServer, ctx context.Context, in *wrapperspb.StringValue) (any, error) {
+			return srv.Echo(ctx, in)
+		})},
+		{Name: methodFail, Handler: unary(func(srv echoServer, ctx context.Context, in *wrapperspb.StringValue) (any, error) {
+			return nil, ag_error.NewBizStatusError(1001, in.GetValue(), map[string]string{"field": "name"})
+		})},
+		{Name: methodPanic, Handler: unary(func(srv echoServer, ctx context.Context, in *wrapperspb.StringValue) (any, error) {
+			panic("boom")
+		})},
+		{Name: methodBlock, Handler: unary(func(srv echoServer, ctx context.Context, in *wrapperspb.StringValue) (any, error) {
+			<-blockRelease
+			return in, nil
+		})},
+	},
+}
+
+type channelTransport struct {
+	ch *ag_netty.Channel
+}
+
+func (t channelTransport) Channel() *ag_netty.Channel { return t.ch }
+
+// call 在内存通道上完成一次调用，客户端写出的数据交给服务端，服务端的应答交回客户端
+func call(t *testing.T, cli, srv *ag_netty.EmbeddedChannel, invoke func() error) error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- invoke() }()
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-deadline:
+			t.Fatal("call timeout")
+		default:
+		}
+		cli.RunPendingTasks()
+		if out := cli.DrainOutbound(); len(out) > 0 {
+			srv.WriteInbound(out)
+		}
+		// 服务端在独立协程处理请求，应答写出任务随时可能提交
+		srv.RunPendingTasks()
+		if out := srv.DrainOutbound(); len(out) > 0 {
+			cli.WriteInbound(out)
+			cli.RunPendingTasks()
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	in := &Frame{
+		Type:      TypeBizError,
+		RequestID: 42,
+		MethodID:  MethodID(methodEcho),
+		Metadata:  Metadata{"trace-id": "abc", "caller": "svc-a"},
+		Status:    &Status{Code: 1001, Message: "余额不足", Extra: map[string]string{"k": "v"}},
+		Payload:   []byte("payload"),
+	}
+	data, err := EncodeFrame(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := DecodeFrame(data)
+	if err != nil || !reflect.DeepEqual(in, out) {
+		t.Fatalf("decode = %+v, %v", out, err)
+	}
+
+	for _, bad := range [][]byte{data[:10], append([]byte("XX"), data[2:]...), data[:20]} {
+		if _, err := DecodeFrame(bad); !errors.Is(err, ErrInvalidFrame) {
+			t.Errorf("decode %q: err = %v", bad, err)
+		}
+	}
+}
+
+func TestInvoke(t *testing.T) {
+	server := NewServer()
+	server.Register(&echoDesc, echoImpl{})
+	srv := ag_netty.NewEmbeddedChannel(server.Handler())
+	cli := ag_netty.NewEmbeddedChannel(NewClientHandler())
+	client := NewClient(channelTransport{cli.Channel})
+
+	// 正常应答，元数据随请求传递
+	ctx := AppendToOutgoingContext(context.Background(), "suffix", "!")
+	out := new(wrapperspb.StringValue)
+	err := call(t, cli, srv, func() error { return client.Invoke(ctx, methodEcho, wrapperspb.String("hi"), out) })
+	if err != nil || out.GetValue() != "hi!" {
+		t.Fatalf("echo = %q, %v", out.GetValue(), err)
+	}
+
+	// 业务错误还原为BizStatusError
+	err = call(t, cli, srv, func() error { return client.Invoke(ctx, methodFail, wrapperspb.String("denied"), out) })
+	var bizErr ag_error.BizStatusErrorIface
+	if !errors.As(err, &bizErr) || bizErr.BizCode() != 1001 || bizErr.BizMessage() != "denied" || bizErr.BizExtra()["field"] != "name" {
+		t.Fatalf("fail err = %v", err)
+	}
+
+	// 框架错误
+	var remoteErr *RemoteError
+	err = call(t, cli, srv, func() error { return client.Invoke(ctx, "/test.Echo/Missing", wrapperspb.String(""), out) })
+	if !errors.As(err, &remoteErr) || remoteErr.Code != CodeUnknownMethod {
+		t.Fatalf("unknown method err = %v", err)
+	}
+	err = call(t, cli, srv, func() error { return client.Invoke(ctx, methodPanic, wrapperspb.String(""), out) })
+	if !errors.As(err, &remoteErr) || remoteErr.Code != CodeInternal {
+		t.Fatalf("panic err = %v", err)
+	}
+}
+
+func TestClientInterceptors(t *testing.T) {
+	var trace []string
+	interceptor := func(name string) ClientInterceptor {
+		return func(ctx context.Context, method string, req, resp any, invoker Invoker) error {
+			trace = append(trace, name)
+			return invoker(ctx, method, req, resp)
+		}
+	}
+	rejected := errors.New("rejected")
+	client := NewClient(channelTransport{}, WithClientInterceptors(interceptor("outer"), interceptor("inner")),
+		WithClientInterceptors(func(context.Context, string, any, any, Invoker) error { return rejected }))
+	if err := client.Invoke(context.Background(), methodEcho, wrapperspb.String("hi"), new(wrapperspb.StringValue)); err != rejected {
+		t.Fatalf("err = %v", err)
+	}
+	if !reflect.DeepEqual(trace, []string{"outer", "inner"}) {
+		t.Fatalf("trace = %v", trace)
+	}
+}
+
+func TestInvokeTimeoutAndClose(t *testing.T) {
+	cli := ag_netty.NewEmbeddedChannel(NewClientHandler())
+	client := NewClient(channelTransport{cli.Channel}, WithTimeout(20*time.Millisecond))
+
+	// 无应答时超时，迟到的应答被丢弃
+	err := call(t, cli, ag_netty.NewEmbeddedChannel(), func() error {
+		return client.Invoke(context.Background(), methodEcho, wrapperspb.String("hi"), new(wrapperspb.StringValue))
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("timeout err = %v", err)
+	}
+	late, _ := EncodeFrame(&Frame{Type: TypeResponse, RequestID: 1})
+	cli.WriteInbound(append([]byte{0, 0, 0, byte(len(late))}, late...))
+	if errs := cli.Errors(); len(errs) != 0 {
+		t.Fatalf("late response errors = %v", errs)
+	}
+
+	// 通道关闭时等待中的请求失败
+	done := make(chan error, 1)
+	go func() {
+		done <- NewClient(channelTransport{cli.Channel}).Invoke(context.Background(), methodEcho, wrapperspb.String("hi"), new(wrapperspb.StringValue))
+	}()
+	for {
+		cli.RunPendingTasks()
+		if len(cli.DrainOutbound()) > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cli.Finish()
+	if err := <-done; !errors.Is(err, ErrChannelClosed) {
+		t.Fatalf("close err = %v", err)
+	}
+}
+
+func TestRegisterConflict(t *testing.T) {
+	server := NewServer()
+	server.Register(&echoDesc, echoImpl{})
+	defer func() {
+		if recover() == nil {
+			t.Fatal("duplicate registration did not panic")
+		}
+	}()
+	server.Register(&echoDesc, echoImpl{})
+}
+
+func TestSharedChannelClients(t *testing.T) {
+	server := NewServer()
+	server.Register(&echoDesc, echoImpl{})
+	srv := ag_netty.NewEmbeddedChannel(server.Handler())
+	cli := ag_netty.NewEmbeddedChannel(NewClientHandler())
+
+	// 同一通道上的多个Client共用请求ID序列，应答不会串到其他Client的调用
+	a, b := NewClient(channelTransport{cli.Channel}), NewClient(channelTransport{cli.Channel})
+	outA, outB := new(wrapperspb.StringValue), new(wrapperspb.StringValue)
+	err := call(t, cli, srv, func() error {
+		errs := make(chan error, 2)
+		go func() { errs <- a.Invoke(context.Background(), methodEcho, wrapperspb.String("a"), outA) }()
+		go func() { errs <- b.Invoke(context.Background(), methodEcho, wrapperspb.String("b"), outB) }()
+		return errors.Join(<-errs, <-errs)
+	})
+	if err != nil || outA.GetValue() != "a" || outB.GetValue() != "b" {
+		t.Fatalf("a = %q, b = %q, err = %v", outA.GetValue(), outB.GetValue(), err)
+	}
+}
+
+func TestServerConcurrency(t *testing.T) {
+	blockRelease = make(chan struct{})
+	server := NewServer()
+	server.Register(&echoDesc, echoImpl{})
+	srv := ag_netty.NewEmbeddedChannel(server.Handler())
+	cli := ag_netty.NewEmbeddedChannel(NewClientHandler())
+	client := NewClient(channelTransport{cli.Channel})
+
+	// 阻塞中的请求不影响同一通道上后续请求的处理
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- client.Invoke(context.Background(), methodBlock, wrapperspb.String("slow"), new(wrapperspb.StringValue))
+	}()
+	out := new(wrapperspb.StringValue)
+	err := call(t, cli, srv, func() error { return client.Invoke(context.Background(), methodEcho, wrapperspb.String("fast"), out) })
+	if err != nil || out.GetValue() != "fast" {
+		t.Fatalf("echo while blocked = %q, %v", out.GetValue(), err)
+	}
+	close(blockRelease)
+	if err := call(t, cli, srv, func() error { return <-blocked }); err != nil {
+		t.Fatal(err)
+	}
+}
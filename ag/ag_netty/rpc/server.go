@@ -0,0 +1,155 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"runtime/debug"
+	"sync"
+
+	"github.com/frochyzhang/ag-core/ag/ag_netty"
+)
+
+// MethodHandler 方法处理函数，dec将请求消息解码到指定对象
+type MethodHandler func(srv any, ctx context.Context, dec func(any) error) (any, error)
+
+// MethodDesc 方法描述
+type MethodDesc struct {
+	Name    string // 完整方法名，/package.Service/Method
+	Handler MethodHandler
+}
+
+// ServiceDesc 服务描述，由protoc-gen-go-netty生成
+type ServiceDesc struct {
+	ServiceName string
+	// HandlerType 服务接口的指针，用于检查注册的实现
+	HandlerType any
+	Methods     []MethodDesc
+}
+
+type method struct {
+	name    string
+	srv     any
+	handler MethodHandler
+}
+
+// Server 按方法ID分发请求
+type Server struct {
+	opts    *options
+	mu      sync.RWMutex
+	methods map[uint32]*method
+	// sem 同时处理的请求数，为nil时在读取协程中逐个处理
+	sem chan struct{}
+}
+
+// NewServer 创建RPC服务端
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		opts:    newOptions(opts),
+		methods: make(map[uint32]*method),
+	}
+	if s.opts.concurrency > 0 {
+		s.sem = make(chan struct{}, s.opts.concurrency)
+	}
+	return s
+}
+
+// Register 注册服务实现，实现未满足服务接口或方法ID冲突时panic
+func (s *Server) Register(desc *ServiceDesc, impl any) {
+	if desc.HandlerType != nil {
+		ht := reflect.TypeOf(desc.HandlerType).Elem()
+		if !reflect.TypeOf(impl).Implements(ht) {
+			panic(fmt.Sprintf("ag_netty rpc: %T does not implement %v", impl, ht))
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, md := range desc.Methods {
+		id := MethodID(md.Name)
+		if old, ok := s.methods[id]; ok {
+			panic(fmt.Sprintf("ag_netty rpc: method %s conflicts with %s (id %#x)", md.Name, old.name, id))
+		}
+		s.methods[id] = &method{name: md.Name, srv: impl, handler: md.Handler}
+	}
+	slog.Info("ag_netty rpc service registered", "service", desc.ServiceName, "methods", len(desc.Methods))
+}
+
+// Handler 服务端成帧处理器，读到请求帧后在独立协程执行处理函数并写回应答，同一通道上的请求并发处理，
+// 应答顺序不保证与请求一致；同时处理的请求数达到 WithMaxConcurrency 上限时读取协程等待，
+// 上限小于等于0时在读取协程中逐个处理
+func (s *Server) Handler() *ag_netty.FrameHandler {
+	return ag_netty.NewFrameHandler(s.opts.framer, func(ctx *ag_netty.HandlerContext, data []byte) {
+		req, err := DecodeFrame(data)
+		if err != nil {
+			ctx.Pipeline().FireError(err)
+			return
+		}
+		if req.Type != TypeRequest {
+			slog.Warn("ag_netty rpc server dropped non-request frame", "remote", ctx.Channel().RemoteAddr(), "type", req.Type)
+			return
+		}
+		if s.sem == nil {
+			s.serve(ctx.Channel(), req)
+			return
+		}
+		// 帧数据在回调返回后可能被复用
+		req.Payload = append([]byte(nil), req.Payload...)
+		s.sem <- struct{}{}
+		go func() {
+			defer func() { <-s.sem }()
+			s.serve(ctx.Channel(), req)
+		}()
+	})
+}
+
+// serve 处理请求并写回应答
+func (s *Server) serve(ch *ag_netty.Channel, req *Frame) {
+	resp := s.dispatch(ch, req)
+	out, err := EncodeFrame(resp)
+	if err != nil {
+		out, _ = EncodeFrame(errorFrame(req, err))
+	}
+	ch.WriteAndFlush(out).AddListener(func(_ struct{}, err error) {
+		if err != nil {
+			slog.Warn("ag_netty rpc response write failed", "remote", ch.RemoteAddr(), "requestID", req.RequestID, "error", err)
+		}
+	})
+}
+
+func (s *Server) dispatch(ch *ag_netty.Channel, req *Frame) (resp *Frame) {
+	s.mu.RLock()
+	m, ok := s.methods[req.MethodID]
+	s.mu.RUnlock()
+	if !ok {
+		return errorFrame(req, &RemoteError{Code: CodeUnknownMethod, Message: fmt.Sprintf("unknown method id %#x", req.MethodID)})
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("ag_netty rpc handler panic", "method", m.name, "panic", r, "stack", string(debug.Stack()))
+			resp = errorFrame(req, &RemoteError{Code: CodeInternal, Message: fmt.Sprintf("panic: %v", r)})
+		}
+	}()
+
+	ctx := context.WithValue(context.Background(), channelKey{}, ch)
+	if req.Metadata != nil {
+		ctx = context.WithValue(ctx, incomingKey{}, req.Metadata)
+	}
+	dec := func(v any) error {
+		if err := s.opts.codec.Unmarshal(req.Payload, v); err != nil {
+			return &RemoteError{Code: CodeBadRequest, Message: err.Error()}
+		}
+		return nil
+	}
+	reply, err := m.handler(m.srv, ctx, dec)
+	if err != nil {
+		return errorFrame(req, err)
+	}
+	payload, err := s.opts.codec.Marshal(reply)
+	if err != nil {
+		return errorFrame(req, err)
+	}
+	return &Frame{Type: TypeResponse, RequestID: req.RequestID, MethodID: req.MethodID, Payload: payload}
+}
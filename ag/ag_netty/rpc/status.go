@@ -0,0 +1,63 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/frochyzhang/ag-core/ag/ag_error"
+)
+
+// 框架错误码，随 TypeError 应答返回
+const (
+	CodeUnknownMethod int32 = 1 // 服务端未注册该方法
+	CodeBadRequest    int32 = 2 // 请求消息解码失败
+	CodeInternal      int32 = 3 // 处理函数返回非业务错误或panic
+)
+
+var (
+	// ErrNoChannel 客户端无可用通道
+	ErrNoChannel = errors.New("ag_netty rpc: no available channel")
+	// ErrChannelClosed 等待应答期间通道关闭
+	ErrChannelClosed = errors.New("ag_netty rpc: channel closed")
+)
+
+// RemoteError 服务端返回的框架错误
+type RemoteError struct {
+	Code    int32
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("ag_netty rpc remote error: code=%d, msg=%s", e.Code, e.Message)
+}
+
+// errorFrame 处理函数的错误转换为应答帧，BizStatusError转为业务错误应答，其他错误转为框架错误应答
+func errorFrame(req *Frame, err error) *Frame {
+	resp := &Frame{RequestID: req.RequestID, MethodID: req.MethodID}
+	var bizErr ag_error.BizStatusErrorIface
+	var remoteErr *RemoteError
+	switch {
+	case errors.As(err, &bizErr):
+		resp.Type = TypeBizError
+		resp.Status = &Status{Code: bizErr.BizCode(), Message: bizErr.BizMessage(), Extra: bizErr.BizExtra()}
+	case errors.As(err, &remoteErr):
+		resp.Type = TypeError
+		resp.Status = &Status{Code: remoteErr.Code, Message: remoteErr.Message}
+	default:
+		resp.Type = TypeError
+		resp.Status = &Status{Code: CodeInternal, Message: err.Error()}
+	}
+	return resp
+}
+
+// frameError 错误应答帧还原为错误，业务错误还原为BizStatusError
+func frameError(f *Frame) error {
+	s := f.Status
+	if f.Type == TypeBizError {
+		if len(s.Extra) > 0 {
+			return ag_error.NewBizStatusError(s.Code, s.Message, s.Extra)
+		}
+		return ag_error.NewBizStatusError(s.Code, s.Message)
+	}
+	return &RemoteError{Code: s.Code, Message: s.Message}
+}
@@ -0,0 +1,18 @@
+//go:build linux
+
+package ag_netty
+
+import "golang.org/x/sys/unix"
+
+// socketSendQueue 套接字发送队列中尚未被对端确认的字节数，无法获取时返回0
+func socketSendQueue(conn transport) int {
+	fc, ok := conn.(interface{ Fd() int })
+	if !ok {
+		return 0
+	}
+	n, err := unix.IoctlGetInt(fc.Fd(), unix.SIOCOUTQ)
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package ag_netty
+
+// socketSendQueue 非Linux平台不统计套接字发送队列
+func socketSendQueue(conn transport) int {
+	return 0
+}
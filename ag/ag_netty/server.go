@@ -1,49 +1,111 @@
 package ag_netty
 
 import (
+	"errors"
 	"log/slog"
 	"net"
 )
 
-// Server 服务器
+// Server 服务器，tcp及unix协议每个连接对应一个通道，udp协议每个发送方对应一个通道
 type Server struct {
 	group    *EventLoopGroup
+	channels *ChannelGroup
+	network  Network
 	listener net.Listener
+	datagram *datagramServer
 	shutdown chan struct{}
 }
 
+const channelGroupHandlerName = "channel-group"
+
 // NewServer 创建新服务器
-func NewServer(addr string, initFunc func(ch *Channel)) (*Server, error) {
-	listener, err := net.Listen("tcp", addr)
-	if err != nil {
-		return nil, err
+func NewServer(addr string, initFunc func(ch *Channel), opts ...ServerOption) (*Server, error) {
+	o := newServerOptions(opts...)
+
+	// 通道激活后加入服务端通道集合，供业务按ID或属性推送
+	// 集合处理器置于流水线末尾：错误事件从首个处理器开始向头部传递，置于首位会截获全部错误事件
+	channels := NewChannelGroup(addr)
+	serverInit := func(ch *Channel) {
+		if initFunc != nil {
+			initFunc(ch)
+		}
+		ch.Pipeline.AddLast(channelGroupHandlerName, NewChannelGroupHandler(channels))
 	}
 
 	// 创建事件循环组
-	group, err := NewEventLoopGroup(4, initFunc)
+	group, err := NewEventLoopGroup(o.eventLoops, serverInit, o.loopOptions...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Server{
+	s := &Server{
 		group:    group,
-		listener: listener,
+		channels: channels,
+		network:  o.network,
 		shutdown: make(chan struct{}),
-	}, nil
+	}
+	if err := s.listen(addr, o); err != nil {
+		group.Shutdown()
+		return nil, err
+	}
+	return s, nil
+}
+
+// listen 按传输协议监听地址
+func (s *Server) listen(addr string, o *serverOptions) error {
+	switch s.network {
+	case NetworkUDP:
+		if newLoopOptions(o.loopOptions...).tlsConfig != nil {
+			return errors.New("ag_netty tls is not supported over udp")
+		}
+		pc, err := net.ListenPacket(string(NetworkUDP), addr)
+		if err != nil {
+			return err
+		}
+		s.datagram = newDatagramServer(pc, s.group, o.datagramIdleTimeout)
+		return nil
+	case NetworkUnix:
+		if err := removeStaleSocket(addr); err != nil {
+			return err
+		}
+	}
+	listener, err := net.Listen(string(s.network), addr)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	return nil
+}
+
+// Addr 监听地址
+func (s *Server) Addr() net.Addr {
+	if s.datagram != nil {
+		return s.datagram.pc.LocalAddr()
+	}
+	return s.listener.Addr()
 }
 
 // Start 启动服务器
 func (s *Server) Start() {
-	// 为每个事件循环创建监听器
-	for _, loop := range s.group.loops {
-		go func(l *EventLoop) {
-			if err := l.Run(s.listener); err != nil {
-				slog.Error("EventLoop exited: ", "error", err)
+	if s.datagram != nil {
+		// 数据报由单个读协程接收，按会话投递到各事件循环
+		go func() {
+			if err := s.datagram.serve(); err != nil {
+				slog.Error("ag_netty datagram server exited", "error", err)
 			}
-		}(loop)
+		}()
+	} else {
+		// 为每个事件循环创建监听器
+		for _, loop := range s.group.loops {
+			go func(l *EventLoop) {
+				if err := l.Run(s.listener); err != nil {
+					slog.Error("EventLoop exited: ", "error", err)
+				}
+			}(loop)
+		}
 	}
 
-	slog.Info("Server started!", "port", s.listener.Addr())
+	slog.Info("Server started!", "network", s.network, "addr", s.Addr())
 
 	// 等待关闭信号
 	select {
@@ -52,9 +114,19 @@ func (s *Server) Start() {
 	}
 }
 
+// ChannelGroup 服务端活跃通道集合
+func (s *Server) ChannelGroup() *ChannelGroup {
+	return s.channels
+}
+
 // Shutdown 关闭服务器
 func (s *Server) Shutdown() {
 	close(s.shutdown)
+	s.channels.Close()
 	s.group.Shutdown()
-	s.listener.Close()
+	if s.datagram != nil {
+		s.datagram.close()
+	} else {
+		s.listener.Close()
+	}
 }
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+
+	"github.com/frochyzhang/ag-core/ag/ag_ext/ip"
+	"github.com/frochyzhang/ag-core/ag/ag_netty"
+	"github.com/nacos-group/nacos-sdk-go/clients/naming_client"
+	"github.com/nacos-group/nacos-sdk-go/vo"
+)
+
+const (
+	// MetaProtocol 注册元数据：传输协议
+	MetaProtocol = "protocol"
+	// MetaCodec 注册元数据：编解码
+	MetaCodec = "codec"
+	// MetaTLS 注册元数据：是否启用TLS
+	MetaTLS = "tls"
+	// MetaNetwork 注册元数据：传输层协议tcp|udp
+	MetaNetwork = "network"
+
+	defaultNettyServiceName = "netty-server"
+	nettyProtocol           = "ag_netty"
+)
+
+// Registry ag_netty服务注册，服务启动时注册、停止时注销
+type Registry interface {
+	Register() error
+	Deregister() error
+}
+
+// nacosRegistry 基于nacos naming client的服务注册
+type nacosRegistry struct {
+	cli   naming_client.INamingClient
+	param vo.RegisterInstanceParam
+}
+
+// NewNacosRegistry 创建nacos服务注册，host为空或未指定地址时自动选取本机IPv4地址
+func NewNacosRegistry(
+	cli naming_client.INamingClient,
+	serviceName string,
+	host string,
+	port int,
+	cluster string,
+	group string,
+	metadata map[string]string,
+) (Registry, error) {
+	if host == "" || net.ParseIP(host).IsUnspecified() {
+		localIP, err := localIPv4()
+		if err != nil {
+			return nil, err
+		}
+		host = localIP
+	}
+	return &nacosRegistry{
+		cli: cli,
+		param: vo.RegisterInstanceParam{
+			Ip:          host,
+			Port:        uint64(port),
+			ServiceName: serviceName,
+			Weight:      1,
+			Enable:      true,
+			Healthy:     true,
+			Metadata:    metadata,
+			ClusterName: cluster,
+			GroupName:   group,
+			Ephemeral:   true,
+		},
+	}, nil
+}
+
+func (r *nacosRegistry) Register() error {
+	if _, err := r.cli.RegisterInstance(r.param); err != nil {
+		return fmt.Errorf("ag_netty register instance error: %w", err)
+	}
+	slog.Info("ag_netty server registered",
+		"service", r.param.ServiceName,
+		"addr", net.JoinHostPort(r.param.Ip, strconv.FormatUint(r.param.Port, 10)))
+	return nil
+}
+
+func (r *nacosRegistry) Deregister() error {
+	_, err := r.cli.DeregisterInstance(vo.DeregisterInstanceParam{
+		Ip:          r.param.Ip,
+		Port:        r.param.Port,
+		ServiceName: r.param.ServiceName,
+		Cluster:     r.param.ClusterName,
+		GroupName:   r.param.GroupName,
+		Ephemeral:   true,
+	})
+	if err != nil {
+		return fmt.Errorf("ag_netty deregister instance error: %w", err)
+	}
+	return nil
+}
+
+// buildNacosRegistry 根据配置构建服务注册信息
+func buildNacosRegistry(cli naming_client.INamingClient, conf NettyServerProperties, network ag_netty.Network, host string, port int) (Registry, error) {
+	// 服务ip范围配置
+	if conf.EnableIPRange != "" {
+		ipranger, err := ip.NewIPRanger(conf.EnableIPRange)
+		if err != nil {
+			return nil, err
+		}
+		rangeHost, ok, err := ipranger.GetLocalIP()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			slog.Info("ag_netty server enable ip range", "regAddr", fmt.Sprintf("%s:%d", rangeHost, port))
+			host = rangeHost
+		}
+	}
+
+	sname := conf.ServiceName
+	if sname == "" {
+		sname = defaultNettyServiceName
+	}
+
+	// 服务元信息配置，协议及编解码供客户端选择实例
+	metadata := make(map[string]string)
+	for k, v := range conf.Tags {
+		metadata[k] = v
+	}
+	metadata["ag_core"] = "All rights reserved"
+	metadata["lang_type"] = "Golang"
+	metadata[MetaProtocol] = nettyProtocol
+	if conf.Codec != "" {
+		metadata[MetaCodec] = conf.Codec
+	}
+	metadata[MetaTLS] = strconv.FormatBool(conf.TLS.Enable)
+	metadata[MetaNetwork] = string(network)
+
+	return NewNacosRegistry(cli, sname, host, port, conf.Cluster, conf.Group, metadata)
+}
+
+func localIPv4() (string, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "", err
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if ok && !ipNet.IP.IsLoopback() {
+			if ipv4 := ipNet.IP.To4(); ipv4 != nil {
+				return ipv4.String(), nil
+			}
+		}
+	}
+	return "", errors.New("ag_netty not found local ipv4 address")
+}
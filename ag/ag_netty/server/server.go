@@ -2,19 +2,42 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"github.com/frochyzhang/ag-core/ag/ag_conf"
 	"github.com/frochyzhang/ag-core/ag/ag_ext/ip"
+	"github.com/frochyzhang/ag-core/ag/ag_metrics"
 	"github.com/frochyzhang/ag-core/ag/ag_netty"
+	"github.com/frochyzhang/ag-core/ag/ag_netty/rpc"
+	"github.com/nacos-group/nacos-sdk-go/clients/naming_client"
 	"log/slog"
+	"slices"
 	"time"
 )
 
 type Server struct {
 	*ag_netty.Server
-	addr     string
-	handlers []ag_netty.ChannelHandler
-	logger   *slog.Logger
+	addr          string
+	handlers      []handlerRegistration
+	serverOpts    []ag_netty.ServerOption
+	executorGroup *ag_netty.EventExecutorGroup
+	certReloader  *ag_netty.CertReloader
+	registry      Registry
+	rpcServices   []rpcService
+	logger        *slog.Logger
+}
+
+// rpcService 经protoc-gen-go-netty生成的服务注册信息
+type rpcService struct {
+	desc *rpc.ServiceDesc
+	impl any
+}
+
+// handlerRegistration 处理器注册信息，offload为true时处理器在执行器组上执行
+type handlerRegistration struct {
+	handler ag_netty.ChannelHandler
+	offload bool
+	echo    bool // 回显处理器，注册RPC服务时不加入流水线
 }
 
 type Option struct {
@@ -31,14 +54,78 @@ func WithAddr(addr string) Option {
 func AppendHandler(ch ag_netty.ChannelHandler) Option {
 	return Option{
 		opt: func(s *Server) {
-			s.handlers = append(s.handlers, ch)
+			s.handlers = append(s.handlers, handlerRegistration{handler: ch})
+		},
+	}
+}
+
+// WithEchoHandler 添加回显处理器，注册了RPC服务时忽略，避免原样回写请求帧
+func WithEchoHandler() Option {
+	return Option{
+		opt: func(s *Server) {
+			s.handlers = append(s.handlers, handlerRegistration{handler: &ag_netty.EchoHandler{}, echo: true})
+		},
+	}
+}
+
+// AppendOffloadHandler 添加阻塞型处理器，其事件在执行器组上执行，同一连接内保持有序
+func AppendOffloadHandler(ch ag_netty.ChannelHandler) Option {
+	return Option{
+		opt: func(s *Server) {
+			s.handlers = append(s.handlers, handlerRegistration{handler: ch, offload: true})
+		},
+	}
+}
+
+// WithServerOptions 设置底层ag_netty服务端配置项
+func WithServerOptions(opts ...ag_netty.ServerOption) Option {
+	return Option{
+		opt: func(s *Server) {
+			s.serverOpts = append(s.serverOpts, opts...)
+		},
+	}
+}
+
+// WithExecutorGroup 设置阻塞型处理器使用的执行器组
+func WithExecutorGroup(group *ag_netty.EventExecutorGroup) Option {
+	return Option{
+		opt: func(s *Server) {
+			s.executorGroup = group
+		},
+	}
+}
+
+// WithTLS 启用TLS，reloader不为空时在服务停止时一并停止证书热加载
+func WithTLS(config *tls.Config, handshakeTimeout time.Duration, reloader *ag_netty.CertReloader) Option {
+	return Option{
+		opt: func(s *Server) {
+			s.serverOpts = append(s.serverOpts, ag_netty.WithLoopOptions(ag_netty.WithTLS(config, handshakeTimeout)))
+			s.certReloader = reloader
+		},
+	}
+}
+
+// WithRegistry 设置服务注册
+func WithRegistry(registry Registry) Option {
+	return Option{
+		opt: func(s *Server) {
+			s.registry = registry
+		},
+	}
+}
+
+// WithRPCService 注册RPC服务，所有服务共用一个按方法ID分发的处理器，请求在独立协程并发处理，见 rpc.Server.Handler
+func WithRPCService(desc *rpc.ServiceDesc, impl any) Option {
+	return Option{
+		opt: func(s *Server) {
+			s.rpcServices = append(s.rpcServices, rpcService{desc: desc, impl: impl})
 		},
 	}
 }
 
 func NewServer(logger *slog.Logger, opts ...Option) *Server {
 	s := &Server{
-		handlers: make([]ag_netty.ChannelHandler, 0),
+		handlers: make([]handlerRegistration, 0),
 		logger:   logger,
 	}
 
@@ -46,16 +133,43 @@ func NewServer(logger *slog.Logger, opts ...Option) *Server {
 		opt.opt(s)
 	}
 
+	if len(s.rpcServices) > 0 {
+		s.handlers = slices.DeleteFunc(s.handlers, func(reg handlerRegistration) bool { return reg.echo })
+		rpcServer := rpc.NewServer()
+		for _, svc := range s.rpcServices {
+			rpcServer.Register(svc.desc, svc.impl)
+		}
+		s.handlers = append(s.handlers, handlerRegistration{handler: rpcServer.Handler(), offload: s.executorGroup != nil})
+	}
+
+	for _, reg := range s.handlers {
+		if reg.offload && s.executorGroup == nil {
+			logger.Warn("ag_netty offload handler registered without executor group, run on event loop",
+				"handler", fmt.Sprintf("%T", reg.handler))
+		}
+	}
+
 	initFunc := func(ch *ag_netty.Channel) {
 		pipeline := ch.Pipeline
 		if pipeline != nil {
-			for i, handler := range s.handlers {
-				pipeline.AddLast(fmt.Sprintf("handler%d", i), handler)
+			for i, reg := range s.handlers {
+				name := fmt.Sprintf("handler%d", i)
+				group := s.executorGroup
+				if !reg.offload {
+					group = nil
+				}
+				// 处理器缺失时通道无法正确处理数据，关闭通道
+				if err := pipeline.AddLastWithExecutor(group, name, reg.handler); err != nil {
+					logger.Error("ag_netty server init channel failed, close channel",
+						"handler", fmt.Sprintf("%T", reg.handler), "error", err)
+					ch.Close()
+					return
+				}
 			}
 		}
 	}
 
-	server, err := ag_netty.NewServer(s.addr, initFunc)
+	server, err := ag_netty.NewServer(s.addr, initFunc, s.serverOpts...)
 	if err != nil {
 		panic(err)
 	}
@@ -79,6 +193,9 @@ func (s *NettyOptionSuite) Options() []Option { return s.Opts }
 type NettySuiteBuilder struct {
 	Binder        ag_conf.IBinder
 	CustomOptions []Option
+	NamingClient  naming_client.INamingClient
+	// MetricsRegistry 不为空时采集运行指标，name标签为service-name，未配置时为netty.server
+	MetricsRegistry *ag_metrics.Registry
 }
 
 func (builder *NettySuiteBuilder) BuildSuite() (*NettyOptionSuite, error) {
@@ -96,21 +213,91 @@ func (builder *NettySuiteBuilder) BuildSuite() (*NettyOptionSuite, error) {
 		return nil, err
 	}
 
-	host, port, err := findHostPort(conf)
+	network, err := ag_netty.ParseNetwork(conf.Network)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	if network.IsDatagram() && conf.TLS.Enable {
+		return nil, fmt.Errorf("ag_netty tls is not supported over %s", network)
 	}
+	suite.Opts = append(suite.Opts, WithServerOptions(
+		ag_netty.WithNetwork(network),
+		ag_netty.WithDatagramIdleTimeout(ag_netty.ToTimeoutDuration(conf.DatagramIdleTimeout)),
+	))
 
-	addr := fmt.Sprintf("%s:%d", host, port)
+	var host, addr string
+	var port int
+	if network == ag_netty.NetworkUnix {
+		if conf.SocketPath == "" {
+			return nil, fmt.Errorf("ag_netty socket-path is required for unix network")
+		}
+		addr = conf.SocketPath
+	} else {
+		host, port, err = findHostPort(conf)
+		if err != nil {
+			panic(err)
+		}
+		addr = fmt.Sprintf("%s:%d", host, port)
+	}
 
-	slog.Info("ag_netty", "host", addr)
+	slog.Info("ag_netty", "network", network, "host", addr)
 	suite.Opts = append(suite.Opts, WithAddr(addr))
 
+	// 事件循环及执行器组配置
+	threadOpts, err := buildThreadOptions(conf)
+	if err != nil {
+		return nil, err
+	}
+	suite.Opts = append(suite.Opts, threadOpts...)
+
+	// 运行指标
+	if builder.MetricsRegistry != nil {
+		name := conf.ServiceName
+		if name == "" {
+			name = defaultMetricsName
+		}
+		slog.Info("ag_netty server enable metrics", "name", name)
+		suite.Opts = append(suite.Opts, WithServerOptions(
+			ag_netty.WithLoopOptions(ag_netty.WithMetrics(ag_netty.NewMetrics(builder.MetricsRegistry, name))),
+		))
+	}
+
+	// TLS配置
+	if conf.TLS.Enable {
+		tlsConfig, reloader, err := conf.TLS.BuildServerConfig()
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("ag_netty server enable tls",
+			"clientAuth", conf.TLS.ClientAuth,
+			"minVersion", conf.TLS.MinVersion,
+			"reloadInterval", conf.TLS.ReloadInterval)
+		suite.Opts = append(suite.Opts, WithTLS(tlsConfig, ag_netty.ToTimeoutDuration(conf.TLS.HandshakeTimeout), reloader))
+	}
+
+	// 注册中心配置
+	if builder.NamingClient != nil && network == ag_netty.NetworkUnix {
+		slog.Info("ag_netty unix socket server skip nacos naming", "path", addr)
+	} else if builder.NamingClient != nil {
+		slog.Info("ag_netty server enable nacos naming")
+		registry, err := buildNacosRegistry(builder.NamingClient, conf, network, host, port)
+		if err != nil {
+			return nil, err
+		}
+		suite.Opts = append(suite.Opts, WithRegistry(registry))
+	}
+
 	return suite, nil
 }
 
 func (s *Server) Start(ctx context.Context) error {
 	s.logger.Info("ag_netty server start")
+	// 监听已在创建时完成，注册后到达的连接在事件循环启动后处理
+	if s.registry != nil {
+		if err := s.registry.Register(); err != nil {
+			return err
+		}
+	}
 	s.Server.Start()
 	return nil
 }
@@ -121,12 +308,97 @@ func (s *Server) Stop(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	// 先注销再关闭，避免客户端继续选中正在关闭的实例
+	if s.registry != nil {
+		if err := s.registry.Deregister(); err != nil {
+			s.logger.Error("ag_netty server deregister failed", "error", err)
+		}
+	}
 	s.Server.Shutdown()
+	if s.executorGroup != nil {
+		s.executorGroup.Shutdown()
+	}
+	if s.certReloader != nil {
+		s.certReloader.Stop()
+	}
 
 	s.logger.Info("Shutting down ag_netty server...")
 	return nil
 }
 
+// buildThreadOptions 根据配置构建事件循环数量、任务队列、执行器组及连接准入与背压
+func buildThreadOptions(conf NettyServerProperties) ([]Option, error) {
+	if conf.EventLoops <= 0 {
+		return nil, fmt.Errorf("ag_netty event-loops invalid:%d", conf.EventLoops)
+	}
+	if conf.TaskQueueSize <= 0 {
+		return nil, fmt.Errorf("ag_netty task-queue-size invalid:%d", conf.TaskQueueSize)
+	}
+	loopPolicy, err := ag_netty.ParseRejectPolicy(conf.RejectPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	loopOpts := []ag_netty.LoopOption{
+		ag_netty.WithTaskQueueSize(conf.TaskQueueSize),
+		ag_netty.WithRejectPolicy(loopPolicy),
+	}
+
+	// 连接准入控制
+	if conf.MaxConnections < 0 || conf.MaxConnectionsPerIP < 0 {
+		return nil, fmt.Errorf("ag_netty max-connections invalid:%d/%d", conf.MaxConnections, conf.MaxConnectionsPerIP)
+	}
+	if conf.MaxConnections > 0 || conf.MaxConnectionsPerIP > 0 || conf.AllowIPRange != "" || conf.DenyIPRange != "" {
+		limiter, err := ag_netty.NewConnLimiter(conf.MaxConnections, conf.MaxConnectionsPerIP, conf.AllowIPRange, conf.DenyIPRange)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("ag_netty server enable connection limiter",
+			"maxConnections", conf.MaxConnections,
+			"maxConnectionsPerIP", conf.MaxConnectionsPerIP,
+			"allowIPRange", conf.AllowIPRange,
+			"denyIPRange", conf.DenyIPRange)
+		loopOpts = append(loopOpts, ag_netty.WithConnLimiter(limiter))
+	}
+
+	// 写缓冲水位线
+	if conf.WriteBufferHighWatermark > 0 {
+		if conf.WriteBufferLowWatermark < 0 || conf.WriteBufferLowWatermark > conf.WriteBufferHighWatermark {
+			return nil, fmt.Errorf("ag_netty write-buffer watermark invalid: low=%d high=%d",
+				conf.WriteBufferLowWatermark, conf.WriteBufferHighWatermark)
+		}
+		loopOpts = append(loopOpts,
+			ag_netty.WithWriteBufferWatermark(conf.WriteBufferLowWatermark, conf.WriteBufferHighWatermark),
+			ag_netty.WithSuspendReadOnUnwritable(conf.SuspendReadOnUnwritable),
+		)
+	}
+
+	opts := []Option{
+		WithServerOptions(
+			ag_netty.WithEventLoops(conf.EventLoops),
+			ag_netty.WithLoopOptions(loopOpts...),
+		),
+	}
+
+	if conf.Executor.Workers > 0 {
+		if conf.Executor.QueueSize <= 0 {
+			return nil, fmt.Errorf("ag_netty executor.queue-size invalid:%d", conf.Executor.QueueSize)
+		}
+		execPolicy, err := ag_netty.ParseRejectPolicy(conf.Executor.RejectPolicy)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("ag_netty server enable executor group",
+			"workers", conf.Executor.Workers,
+			"queueSize", conf.Executor.QueueSize,
+			"rejectPolicy", execPolicy)
+		group := ag_netty.NewEventExecutorGroup(conf.Executor.Workers, conf.Executor.QueueSize, execPolicy)
+		opts = append(opts, WithExecutorGroup(group))
+	}
+
+	return opts, nil
+}
+
 func findHostPort(conf NettyServerProperties) (host string, port int, rerr error) {
 	// 服务ip、端口配置
 	host = conf.Host
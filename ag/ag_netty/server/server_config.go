@@ -1,14 +1,59 @@
 package server
 
+import "github.com/frochyzhang/ag-core/ag/ag_netty"
+
 const (
 	nettyServerPropertiesPrefix = "netty.server"
 	DefaultNettyOriginPort      = 8080
+	defaultMetricsName          = "netty.server"
 )
 
 type NettyServerProperties struct {
+	// Network 传输协议：tcp|udp|unix，unix协议监听socket-path且不注册到注册中心
+	Network    string `value:"${network:tcp}"`
+	SocketPath string `value:"${socket-path:}"`
+	// DatagramIdleTimeout udp会话空闲超时(毫秒)
+	DatagramIdleTimeout int `value:"${datagram-idle-timeout:60000}"`
+
 	Host          string `value:"${host:0.0.0.0}"`
 	Port          int    `value:"${port:0}"`
 	AdaptivePort  bool   `value:"${adaptive-port:false}"`
 	ServiceName   string `value:"${service-name:}"`
 	EnableIPRange string `value:"${enable-ip-range:}"`
+
+	// 注册中心配置，存在nacos naming client时注册服务
+	Cluster string            `value:"${cluster:DEFAULT}"`
+	Group   string            `value:"${group:DEFAULT_GROUP}"`
+	Tags    map[string]string `value:"${tags:}"`
+	// Codec 注册到元数据中的编解码标识，供客户端选择实例
+	Codec string `value:"${codec:}"`
+
+	// 事件循环数量及每个事件循环的任务队列
+	EventLoops    int    `value:"${event-loops:4}"`
+	TaskQueueSize int    `value:"${task-queue-size:1024}"`
+	RejectPolicy  string `value:"${reject-policy:block}"`
+
+	Executor NettyExecutorProperties `value:"${executor}"`
+
+	// 连接准入：最大连接数、单IP最大连接数(0为不限制)及IP黑白名单(格式同enable-ip-range)
+	MaxConnections      int    `value:"${max-connections:0}"`
+	MaxConnectionsPerIP int    `value:"${max-connections-per-ip:0}"`
+	AllowIPRange        string `value:"${allow-ip-range:}"`
+	DenyIPRange         string `value:"${deny-ip-range:}"`
+
+	// 写缓冲水位线(字节)，默认不启用；待写出字节数含套接字发送队列(仅Linux)，不可写时可暂停向流水线投递读事件
+	WriteBufferHighWatermark int  `value:"${write-buffer-high-watermark:0}"`
+	WriteBufferLowWatermark  int  `value:"${write-buffer-low-watermark:0}"`
+	SuspendReadOnUnwritable  bool `value:"${suspend-read-on-unwritable:false}"`
+
+	// TLS/mTLS配置，见 ag_netty.TLSConfig
+	TLS ag_netty.TLSConfig `value:"${tls}"`
+}
+
+// NettyExecutorProperties 阻塞型处理器的执行器组配置，workers为0时不启用；
+// reject-policy为abort或discard时队列已满的连接被关闭，caller-runs不保证同一连接内事件有序
+type NettyExecutorProperties struct {
+	Workers      int    `value:"${workers:0}"`
+	QueueSize    int    `value:"${queue-size:1024}"`
+	RejectPolicy string `value:"${reject-policy:abort}"`
 }
@@ -0,0 +1,437 @@
+package ag_netty
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/netpoll"
+)
+
+// TLSConfig 服务端/客户端TLS配置，可直接嵌入 netty.server / netty.client 配置绑定
+// 时间单位与其他ag_netty超时配置一致，为毫秒
+type TLSConfig struct {
+	Enable   bool   `value:"${enable:false}"`
+	CertFile string `value:"${cert-file:}"`
+	KeyFile  string `value:"${key-file:}"`
+	// CAFile 服务端用于校验客户端证书，客户端用于校验服务端证书，为空时客户端使用系统根证书
+	CAFile string `value:"${ca-file:}"`
+	// ClientAuth 服务端客户端认证模式：none|request|require|verify-if-given|require-and-verify
+	ClientAuth string `value:"${client-auth:none}"`
+	// CipherSuites 逗号分隔的密码套件名称(tls.CipherSuiteName)，为空使用Go默认套件，对TLS1.3不生效
+	CipherSuites string `value:"${cipher-suites:}"`
+	// MinVersion 最低协议版本：1.0|1.1|1.2|1.3
+	MinVersion string `value:"${min-version:1.2}"`
+	// ServerName 客户端SNI及证书主机名校验
+	ServerName         string `value:"${server-name:}"`
+	InsecureSkipVerify bool   `value:"${insecure-skip-verify:false}"`
+	// ReloadInterval 证书文件变更检查间隔，0为不热加载
+	ReloadInterval int `value:"${reload-interval:0}"`
+	// HandshakeTimeout 握手超时
+	HandshakeTimeout int `value:"${handshake-timeout:5000}"`
+}
+
+// CertReloader 证书热加载器，按修改时间检测证书、私钥及CA文件变化并原子替换
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	cert    atomic.Pointer[tls.Certificate]
+	caPool  atomic.Pointer[x509.CertPool]
+	modTime time.Time
+
+	mu       sync.Mutex
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewCertReloader 创建证书热加载器并完成首次加载，certFile/keyFile/caFile均可为空
+func NewCertReloader(certFile, keyFile, caFile string) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		stop:     make(chan struct{}),
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload 重新加载证书文件
+func (r *CertReloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.certFile != "" || r.keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			return fmt.Errorf("ag_netty tls load key pair: %w", err)
+		}
+		r.cert.Store(&cert)
+	}
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("ag_netty tls read ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("ag_netty tls no certificate found in ca file: %s", r.caFile)
+		}
+		r.caPool.Store(pool)
+	}
+	r.modTime = r.latestModTime()
+	return nil
+}
+
+// Watch 按间隔检查文件修改时间，有变化时重新加载，加载失败保留原证书
+func (r *CertReloader) Watch(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.mu.Lock()
+				changed := r.latestModTime().After(r.modTime)
+				r.mu.Unlock()
+				if !changed {
+					continue
+				}
+				if err := r.Reload(); err != nil {
+					slog.Error("ag_netty tls certificate reload failed", "error", err)
+					continue
+				}
+				slog.Info("ag_netty tls certificate reloaded", "cert", r.certFile, "ca", r.caFile)
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止热加载
+func (r *CertReloader) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+}
+
+// Certificate 当前证书
+func (r *CertReloader) Certificate() *tls.Certificate {
+	return r.cert.Load()
+}
+
+// CAPool 当前CA证书池
+func (r *CertReloader) CAPool() *x509.CertPool {
+	return r.caPool.Load()
+}
+
+func (r *CertReloader) latestModTime() time.Time {
+	var latest time.Time
+	for _, f := range []string{r.certFile, r.keyFile, r.caFile} {
+		if f == "" {
+			continue
+		}
+		if info, err := os.Stat(f); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// BuildServerConfig 构建服务端tls.Config，证书与客户端CA均经由reloader获取以支持热加载
+func (c *TLSConfig) BuildServerConfig() (*tls.Config, *CertReloader, error) {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, nil, errors.New("ag_netty tls server requires cert-file and key-file")
+	}
+	clientAuth, err := parseClientAuth(c.ClientAuth)
+	if err != nil {
+		return nil, nil, err
+	}
+	if clientAuth >= tls.VerifyClientCertIfGiven && c.CAFile == "" {
+		return nil, nil, fmt.Errorf("ag_netty tls client-auth %s requires ca-file", c.ClientAuth)
+	}
+	base, err := c.baseConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	reloader, err := NewCertReloader(c.CertFile, c.KeyFile, c.CAFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	base.ClientAuth = clientAuth
+	base.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return reloader.Certificate(), nil
+	}
+	// 每次握手按当前CA池生成配置，使CA热加载生效
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		cfg := base.Clone()
+		cfg.GetConfigForClient = nil
+		cfg.ClientCAs = reloader.CAPool()
+		return cfg, nil
+	}
+
+	reloader.Watch(ToTimeoutDuration(c.ReloadInterval))
+	return base, reloader, nil
+}
+
+// BuildClientConfig 构建客户端tls.Config，配置证书时启用双向认证
+// 客户端证书及用于校验服务端的CA均支持热加载
+func (c *TLSConfig) BuildClientConfig() (*tls.Config, *CertReloader, error) {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return nil, nil, errors.New("ag_netty tls client cert-file and key-file must be set together")
+	}
+	base, err := c.baseConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	reloader, err := NewCertReloader(c.CertFile, c.KeyFile, c.CAFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	base.ServerName = c.ServerName
+	base.InsecureSkipVerify = c.InsecureSkipVerify
+	if c.CAFile != "" && !c.InsecureSkipVerify {
+		// RootCAs在握手时不可替换，改为在VerifyConnection中按当前CA池完成等价的证书链及主机名校验
+		base.InsecureSkipVerify = true
+		base.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyServerCertificate(cs, reloader.CAPool())
+		}
+	}
+	if c.CertFile != "" {
+		base.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return reloader.Certificate(), nil
+		}
+	}
+
+	reloader.Watch(ToTimeoutDuration(c.ReloadInterval))
+	return base, reloader, nil
+}
+
+// verifyServerCertificate 按roots校验服务端证书链及主机名，与tls.Config.RootCAs的校验一致
+func verifyServerCertificate(cs tls.ConnectionState, roots *x509.CertPool) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("ag_netty tls server sent no certificate")
+	}
+	if cs.ServerName == "" {
+		return errors.New("ag_netty tls server-name is required to verify the server certificate")
+	}
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       cs.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+func (c *TLSConfig) baseConfig() (*tls.Config, error) {
+	minVersion, err := parseTLSVersion(c.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	suites, err := parseCipherSuites(c.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: suites,
+	}, nil
+}
+
+func parseClientAuth(mode string) (tls.ClientAuthType, error) {
+	switch strings.ToLower(mode) {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require":
+		return tls.RequireAnyClientCert, nil
+	case "verify-if-given":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require-and-verify":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("ag_netty tls unknown client-auth: %s", mode)
+	}
+}
+
+func parseTLSVersion(v string) (uint16, error) {
+	switch v {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("ag_netty tls unknown min-version: %s", v)
+	}
+}
+
+func parseCipherSuites(names string) ([]uint16, error) {
+	if strings.TrimSpace(names) == "" {
+		return nil, nil
+	}
+	known := make(map[string]uint16)
+	for _, s := range tls.CipherSuites() {
+		known[s.Name] = s.ID
+	}
+	for _, s := range tls.InsecureCipherSuites() {
+		known[s.Name] = s.ID
+	}
+	ids := make([]uint16, 0)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("ag_netty tls unknown cipher suite: %s", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// errTLSWouldBlock 握手完成后netpoll读缓冲中没有数据，tls.Conn视其为临时错误并保留未完整的记录
+var errTLSWouldBlock net.Error = tlsWouldBlockError{}
+
+type tlsWouldBlockError struct{}
+
+func (tlsWouldBlockError) Error() string   { return "ag_netty tls: no buffered input" }
+func (tlsWouldBlockError) Timeout() bool   { return false }
+func (tlsWouldBlockError) Temporary() bool { return true }
+
+// tlsTransport tls.Conn的底层连接：握手期间阻塞读取，握手完成后只读取netpoll读缓冲中已到达的数据，
+// 使解密可以在读回调中增量进行，不足一个记录的数据由tls.Conn保留到下次读回调
+type tlsTransport struct {
+	netpoll.Connection
+	buffered atomic.Bool
+}
+
+// handshakeDone 握手完成，之后的读取不再阻塞
+func (t *tlsTransport) handshakeDone() {
+	t.buffered.Store(true)
+}
+
+func (t *tlsTransport) Read(p []byte) (int, error) {
+	if !t.buffered.Load() {
+		return t.Connection.Read(p)
+	}
+	reader := t.Connection.Reader()
+	n := reader.Len()
+	if n == 0 {
+		return 0, errTLSWouldBlock
+	}
+	if n > len(p) {
+		n = len(p)
+	}
+	buf, err := reader.Next(n)
+	if err != nil {
+		return 0, err
+	}
+	copy(p, buf)
+	return n, reader.Release()
+}
+
+// initTLS 在netpoll连接上创建TLS连接
+func (c *Channel) initTLS(conn netpoll.Connection, config *tls.Config, client bool) {
+	c.tlsRaw = &tlsTransport{Connection: conn}
+	if client {
+		c.tlsConn = tls.Client(c.tlsRaw, config)
+	} else {
+		c.tlsConn = tls.Server(c.tlsRaw, config)
+	}
+}
+
+// serveTLS 服务端TLS通道的读回调：首次回调中完成握手并触发激活事件，之后解密已到达的数据并投递读事件
+// 握手期间回调阻塞读取，不超过握手超时
+func serveTLS(
+	channel *Channel,
+	handshakeTimeout time.Duration,
+	onHandshake func(),
+	dispatch func(task func()),
+) error {
+	if !channel.tlsRaw.buffered.Load() {
+		ctx := context.Background()
+		if handshakeTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, handshakeTimeout)
+			defer cancel()
+		}
+		if err := channel.tlsConn.HandshakeContext(ctx); err != nil {
+			slog.Warn("ag_netty tls handshake failed", "remote", channel.RemoteAddr(), "error", err)
+			dispatch(func() {
+				channel.Pipeline.FireError(err)
+			})
+			channel.Close()
+			return err
+		}
+		channel.tlsRaw.handshakeDone()
+		if onHandshake != nil {
+			onHandshake()
+		}
+	}
+
+	return drainTLS(channel, dispatch)
+}
+
+// drainTLS 解密netpoll读缓冲中已到达的全部数据并投递读事件，连接出错时关闭通道
+func drainTLS(channel *Channel, dispatch func(task func())) error {
+	channel.tlsReadMu.Lock()
+	defer channel.tlsReadMu.Unlock()
+
+	buf := make([]byte, 16*1024)
+	for channel.IsActive() {
+		n, err := channel.tlsConn.Read(buf)
+		if n > 0 {
+			data := make([]byte, n)
+			copy(data, buf[:n])
+			channel.deliverRead(data, dispatch)
+		}
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, errTLSWouldBlock) {
+			return nil
+		}
+		if channel.IsActive() {
+			// 对端正常关闭不视为错误
+			if !errors.Is(err, io.EOF) {
+				dispatch(func() {
+					channel.Pipeline.FireError(err)
+				})
+			}
+			channel.Close()
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+package ag_netty
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA 测试用自签名CA
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ag_netty test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue 签发证书并写入dir，返回证书及私钥文件路径
+func (ca *testCA) issue(t *testing.T, dir, name string, serial int64, usage x509.ExtKeyUsage) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	writeFile(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	writeFile(t, keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+	return certFile, keyFile
+}
+
+func writeFile(t *testing.T, name string, data []byte) {
+	if err := os.WriteFile(name, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tlsRecvHandler 客户端接收处理器
+type tlsRecvHandler struct {
+	recv     chan []byte
+	inactive chan struct{}
+}
+
+func newTLSRecvHandler() *tlsRecvHandler {
+	return &tlsRecvHandler{recv: make(chan []byte, 16), inactive: make(chan struct{})}
+}
+
+func (h *tlsRecvHandler) HandleActive(ctx *HandlerContext) {}
+func (h *tlsRecvHandler) HandleInactive(ctx *HandlerContext) {
+	close(h.inactive)
+}
+func (h *tlsRecvHandler) HandleRead(ctx *HandlerContext, data []byte) {
+	h.recv <- data
+}
+func (h *tlsRecvHandler) HandleWrite(ctx *HandlerContext, data []byte) {
+	ctx.Channel().WriteDirect(data)
+}
+func (h *tlsRecvHandler) HandleError(ctx *HandlerContext, err error) {}
+
+func startTLSEchoServer(t *testing.T, conf *TLSConfig) *Server {
+	serverConfig, reloader, err := conf.BuildServerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(reloader.Stop)
+
+	s, err := NewServer("127.0.0.1:0", func(ch *Channel) {
+		ch.Pipeline.AddLast("echo", &EchoHandler{})
+	}, WithLoopOptions(WithTLS(serverConfig, time.Second)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Start()
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+func dialTLSClient(t *testing.T, addr string, conf *TLSConfig, h *tlsRecvHandler) (*Channel, error) {
+	clientConfig, reloader, err := conf.BuildClientConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(reloader.Stop)
+
+	looper := NewClientEventLoop(func(ch *Channel) {
+		ch.Pipeline.AddLast("recv", h)
+	}, WithTLS(clientConfig, time.Second))
+	t.Cleanup(looper.Shutdown)
+
+	ch, err := Dial(addr, time.Second, time.Second, time.Second, time.Minute, looper)
+	if err == nil {
+		t.Cleanup(ch.Close)
+	}
+	return ch, err
+}
+
+func TestTLSMutualAuth(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	caFile := filepath.Join(dir, "ca.crt")
+	writeFile(t, caFile, ca.pem)
+	serverCert, serverKey := ca.issue(t, dir, "server", 2, x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, dir, "client", 3, x509.ExtKeyUsageClientAuth)
+
+	s := startTLSEchoServer(t, &TLSConfig{
+		CertFile:   serverCert,
+		KeyFile:    serverKey,
+		CAFile:     caFile,
+		ClientAuth: "require-and-verify",
+	})
+	addr := s.listener.Addr().String()
+
+	t.Run("EchoRoundTrip", func(t *testing.T) {
+		h := newTLSRecvHandler()
+		ch, err := dialTLSClient(t, addr, &TLSConfig{CertFile: clientCert, KeyFile: clientKey, CAFile: caFile}, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state, ok := ch.TLSConnectionState(); !ok || !state.HandshakeComplete {
+			t.Fatal("TLSConnectionState() handshake not complete")
+		}
+
+		payload := []byte("hello tls")
+		ch.Write(payload)
+		var got []byte
+		for len(got) < len(payload) {
+			select {
+			case data := <-h.recv:
+				got = append(got, data...)
+			case <-time.After(3 * time.Second):
+				t.Fatal("echo timeout")
+			}
+		}
+		if !bytes.Equal(got, payload) {
+			t.Errorf("echo = %q, want %q", got, payload)
+		}
+	})
+
+	t.Run("RejectClientWithoutCert", func(t *testing.T) {
+		h := newTLSRecvHandler()
+		ch, err := dialTLSClient(t, addr, &TLSConfig{CAFile: caFile}, h)
+		if err != nil {
+			// TLS1.2下握手阶段即失败
+			return
+		}
+		// TLS1.3下客户端先完成握手，服务端校验失败后关闭连接
+		ch.Write([]byte("ping"))
+		select {
+		case data := <-h.recv:
+			t.Fatalf("unexpected echo %q from server", data)
+		case <-h.inactive:
+		case <-time.After(3 * time.Second):
+			t.Fatal("connection not closed by server")
+		}
+	})
+
+	t.Run("RejectUnknownServer", func(t *testing.T) {
+		other := newTestCA(t)
+		otherFile := filepath.Join(dir, "other.crt")
+		writeFile(t, otherFile, other.pem)
+		_, err := dialTLSClient(t, addr, &TLSConfig{CertFile: clientCert, KeyFile: clientKey, CAFile: otherFile}, newTLSRecvHandler())
+		if err == nil {
+			t.Fatal("Dial() with untrusted server certificate succeeded")
+		}
+	})
+}
+
+func TestTLSLargeEcho(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	caFile := filepath.Join(dir, "ca.crt")
+	writeFile(t, caFile, ca.pem)
+	serverCert, serverKey := ca.issue(t, dir, "server", 2, x509.ExtKeyUsageServerAuth)
+	s := startTLSEchoServer(t, &TLSConfig{CertFile: serverCert, KeyFile: serverKey})
+
+	// 跨越多个TLS记录的数据分批到达，读回调中解密不完整的记录须保留到下次回调
+	h := newTLSRecvHandler()
+	h.recv = make(chan []byte, 1024)
+	ch, err := dialTLSClient(t, s.listener.Addr().String(), &TLSConfig{CAFile: caFile}, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := bytes.Repeat([]byte("0123456789abcdef"), 16*1024)
+	ch.Write(payload)
+	var got []byte
+	for len(got) < len(payload) {
+		select {
+		case data := <-h.recv:
+			got = append(got, data...)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("echo timeout, got %d bytes", len(got))
+		}
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatal("echo mismatch")
+	}
+}
+
+func TestTLSClientCAReload(t *testing.T) {
+	dir := t.TempDir()
+	ca, other := newTestCA(t), newTestCA(t)
+	caFile := filepath.Join(dir, "ca.crt")
+	writeFile(t, caFile, other.pem)
+	serverCert, serverKey := ca.issue(t, dir, "server", 2, x509.ExtKeyUsageServerAuth)
+	s := startTLSEchoServer(t, &TLSConfig{CertFile: serverCert, KeyFile: serverKey})
+	addr := s.listener.Addr().String()
+
+	clientConfig, reloader, err := (&TLSConfig{CAFile: caFile}).BuildClientConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reloader.Stop()
+	looper := NewClientEventLoop(func(ch *Channel) {}, WithTLS(clientConfig, time.Second))
+	defer looper.Shutdown()
+	dial := func() error {
+		ch, err := Dial(addr, time.Second, time.Second, time.Second, time.Minute, looper)
+		if err == nil {
+			ch.Close()
+		}
+		return err
+	}
+
+	if err := dial(); err == nil {
+		t.Fatal("Dial() with untrusted server certificate succeeded")
+	}
+	// CA文件更新后，同一tls.Config按新的CA池校验服务端
+	writeFile(t, caFile, ca.pem)
+	if err := reloader.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if err := dial(); err != nil {
+		t.Fatalf("Dial() after ca reload = %v", err)
+	}
+}
+
+func TestCertReloaderWatch(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certFile, keyFile := ca.issue(t, dir, "server", 10, x509.ExtKeyUsageServerAuth)
+
+	r, err := NewCertReloader(certFile, keyFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Stop()
+	r.Watch(10 * time.Millisecond)
+
+	// 重新签发证书并推后修改时间，避免文件系统时间精度导致漏检
+	ca.issue(t, dir, "server", 11, x509.ExtKeyUsageServerAuth)
+	future := time.Now().Add(time.Minute)
+	for _, f := range []string{certFile, keyFile} {
+		if err := os.Chtimes(f, future, future); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		leaf, err := x509.ParseCertificate(r.Certificate().Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if leaf.SerialNumber.Int64() == 11 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("certificate not reloaded")
+}
+
+func TestTLSConfigParse(t *testing.T) {
+	if _, err := parseClientAuth("bogus"); err == nil {
+		t.Error("parseClientAuth(bogus) want error")
+	}
+	if v, _ := parseTLSVersion("1.3"); v != tls.VersionTLS13 {
+		t.Errorf("parseTLSVersion(1.3) = %x", v)
+	}
+	ids, err := parseCipherSuites("TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256")
+	if err != nil || len(ids) != 2 {
+		t.Errorf("parseCipherSuites() = %v, %v", ids, err)
+	}
+	if _, err := parseCipherSuites("TLS_FAKE"); err == nil {
+		t.Error("parseCipherSuites(TLS_FAKE) want error")
+	}
+	if _, _, err := (&TLSConfig{CertFile: "a", KeyFile: "b", ClientAuth: "require-and-verify"}).BuildServerConfig(); err == nil {
+		t.Error("BuildServerConfig() without ca-file want error")
+	}
+}
@@ -0,0 +1,119 @@
+package ag_resilience
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/frochyzhang/ag-core/ag/ag_netty/rpc"
+
+	hertzclient "github.com/cloudwego/hertz/pkg/app/client"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/kerrors"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+)
+
+// KitexMiddleware kitex客户端中间件，name为下游服务名，按方法名判断是否幂等可重试；
+// 每次尝试使用新的应答对象，业务异常记录在该次尝试自己的调用信息中，结束后仅将最后一次尝试的应答及业务异常交给调用方
+func KitexMiddleware(m *Manager, name string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, req, resp interface{}) error {
+			ri := rpcinfo.GetRPCInfo(ctx)
+			var inv invocation
+			if ri != nil {
+				inv, _ = ri.Invocation().(invocation)
+			}
+			if inv == nil {
+				return m.Invoke(ctx, name, "", func(ctx context.Context) error { return next(ctx, req, resp) })
+			}
+			var (
+				attempts int
+				last     *attemptInvocation
+				current  = resp
+			)
+			err := m.Invoke(ctx, name, ri.To().Method(), func(ctx context.Context) error {
+				if attempts++; attempts > 1 {
+					current = newResponse(resp)
+				}
+				last = &attemptInvocation{invocation: inv}
+				ctx = rpcinfo.NewCtxWithRPCInfo(ctx, rpcinfo.NewRPCInfo(ri.From(), ri.To(), last, ri.Config(), ri.Stats()))
+				if err := next(ctx, req, current); err != nil {
+					return err
+				}
+				if last.bizErr != nil {
+					return last.bizErr
+				}
+				return nil
+			})
+			if current != resp {
+				reflect.ValueOf(resp).Elem().Set(reflect.ValueOf(current).Elem())
+			}
+			// 最后一次尝试的业务异常仍由kitex从rpcinfo中取出返回
+			if last != nil && last.bizErr != nil && err == error(last.bizErr) {
+				inv.SetBizStatusErr(last.bizErr)
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// invocation 可写的kitex调用信息
+type invocation interface {
+	rpcinfo.Invocation
+	rpcinfo.InvocationSetter
+}
+
+// attemptInvocation 单次尝试的调用信息，业务异常记录在本次尝试中，其余读写均作用于原调用信息
+type attemptInvocation struct {
+	invocation
+	bizErr kerrors.BizStatusErrorIface
+}
+
+func (i *attemptInvocation) BizStatusErr() kerrors.BizStatusErrorIface {
+	return i.bizErr
+}
+
+func (i *attemptInvocation) SetBizStatusErr(err kerrors.BizStatusErrorIface) {
+	i.bizErr = err
+}
+
+// newResponse 创建与resp同类型的空应答，resp不是非nil指针时原样返回
+func newResponse(resp interface{}) interface{} {
+	v := reflect.ValueOf(resp)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return resp
+	}
+	return reflect.New(v.Type().Elem()).Interface()
+}
+
+// HertzMiddleware hertz客户端熔断及隔离舱中间件，name为下游服务名；应答状态码为5xx时计为失败，
+// 重试在 ag_hertz/client.Client.Invoke 中经 Manager.Retry 完成，每次尝试仅经过本中间件一次
+func HertzMiddleware(m *Manager, name string) hertzclient.Middleware {
+	return func(next hertzclient.Endpoint) hertzclient.Endpoint {
+		return func(ctx context.Context, req *protocol.Request, resp *protocol.Response) error {
+			done, err := m.Acquire(name)
+			if err != nil {
+				return err
+			}
+			err = next(ctx, req, resp)
+			if err == nil && resp.StatusCode() >= http.StatusInternalServerError {
+				done(fmt.Errorf("downstream %s responded %d", name, resp.StatusCode()))
+			} else {
+				done(err)
+			}
+			return err
+		}
+	}
+}
+
+// NettyInterceptor ag_netty RPC客户端拦截器，name为下游服务名，按完整方法名判断是否幂等可重试
+func NettyInterceptor(m *Manager, name string) rpc.ClientInterceptor {
+	return func(ctx context.Context, method string, req, resp any, invoker rpc.Invoker) error {
+		return m.Invoke(ctx, name, method, func(ctx context.Context) error {
+			return invoker(ctx, method, req, resp)
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package ag_resilience
+
+import (
+	"sync"
+	"time"
+)
+
+// State 熔断器状态
+type State int32
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// bucket 统计窗口中的一个桶，idx为桶的时间序号
+type bucket struct {
+	idx                   int64
+	total, failures, slow int
+}
+
+// Breaker 熔断器，状态变化时调用onChange
+type Breaker struct {
+	conf     BreakerProperties
+	width    time.Duration
+	now      func() time.Time
+	onChange func(from, to State)
+
+	mu      sync.Mutex
+	state   State
+	buckets []bucket
+	// gen 状态版本，状态变化前发起的调用结果不再计入
+	gen      uint64
+	openedAt time.Time
+	// probes 半开时已放行的探测调用数，passed为其中已成功的数量
+	probes, passed int
+}
+
+// NewBreaker 按配置创建熔断器，配置须已校验
+func NewBreaker(conf BreakerProperties, onChange func(from, to State)) *Breaker {
+	if onChange == nil {
+		onChange = func(State, State) {}
+	}
+	return &Breaker{
+		conf:     conf,
+		width:    ms(conf.Window) / time.Duration(conf.Buckets),
+		now:      time.Now,
+		onChange: onChange,
+		buckets:  make([]bucket, conf.Buckets),
+	}
+}
+
+// State 当前状态，熔断时长已到但尚无调用时仍为open
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// allow 判断是否放行调用，放行时返回当前状态版本用于记录结果
+func (b *Breaker) allow() (uint64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < ms(b.conf.OpenDuration) {
+			return 0, false
+		}
+		b.transit(StateHalfOpen)
+		fallthrough
+	case StateHalfOpen:
+		if b.probes >= b.conf.HalfOpenCalls {
+			return 0, false
+		}
+		b.probes++
+	}
+	return b.gen, true
+}
+
+// record 记录调用结果
+func (b *Breaker) record(gen uint64, failure bool, elapsed time.Duration) {
+	slow := b.conf.SlowCall > 0 && elapsed >= ms(b.conf.SlowCall)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if gen != b.gen {
+		return
+	}
+	switch b.state {
+	case StateClosed:
+		idx := b.now().UnixNano() / int64(b.width)
+		bk := &b.buckets[idx%int64(len(b.buckets))]
+		if bk.idx != idx {
+			*bk = bucket{idx: idx}
+		}
+		bk.total++
+		if failure {
+			bk.failures++
+		}
+		if slow {
+			bk.slow++
+		}
+		if b.tripped(idx) {
+			b.transit(StateOpen)
+		}
+	case StateHalfOpen:
+		if failure || slow {
+			b.transit(StateOpen)
+			return
+		}
+		if b.passed++; b.passed >= b.conf.HalfOpenCalls {
+			b.transit(StateClosed)
+		}
+	}
+}
+
+// tripped 窗口内的错误率或慢调用率是否达到阈值
+func (b *Breaker) tripped(idx int64) bool {
+	var total, failures, slow int
+	for _, bk := range b.buckets {
+		if idx-bk.idx < int64(len(b.buckets)) {
+			total += bk.total
+			failures += bk.failures
+			slow += bk.slow
+		}
+	}
+	if total < b.conf.MinCalls {
+		return false
+	}
+	if float64(failures) >= b.conf.ErrorRate*float64(total) {
+		return true
+	}
+	return b.conf.SlowCall > 0 && float64(slow) >= b.conf.SlowCallRate*float64(total)
+}
+
+func (b *Breaker) transit(to State) {
+	from := b.state
+	b.state = to
+	b.gen++
+	b.probes, b.passed = 0, 0
+	switch to {
+	case StateOpen:
+		b.openedAt = b.now()
+	case StateClosed:
+		for i := range b.buckets {
+			b.buckets[i] = bucket{}
+		}
+	}
+	b.onChange(from, to)
+}
@@ -0,0 +1,99 @@
+// Package ag_resilience 客户端故障隔离：按下游服务的熔断器(错误率及慢调用率窗口、半开探测)、
+// 隔离舱(最大并发调用数)及重试策略，kitex、hertz及netty客户端共用
+package ag_resilience
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	ResiliencePropertiesPrefix = "resilience"
+)
+
+// ResilienceProperties 故障隔离配置 resilience.*，按下游服务名配置 resilience.services.<name>，
+// 未单独配置的下游服务使用default；支持运行时刷新，策略变更的下游服务熔断状态及重试预算重新开始
+type ResilienceProperties struct {
+	Default  PolicyProperties            `value:"${default}"`
+	Services map[string]PolicyProperties `value:"${services:}"`
+}
+
+// PolicyProperties 单个下游服务的故障隔离策略
+type PolicyProperties struct {
+	CircuitBreaker BreakerProperties  `value:"${circuit-breaker}"`
+	Bulkhead       BulkheadProperties `value:"${bulkhead}"`
+	Retry          RetryProperties    `value:"${retry}"`
+}
+
+// BreakerProperties 熔断配置，时间单位为毫秒；窗口内调用数达到min-calls后，
+// 错误率或慢调用率达到阈值时熔断，open-duration后进入半开，放行half-open-calls个探测调用，
+// 全部成功后恢复，任一失败或慢调用重新熔断
+type BreakerProperties struct {
+	Enable bool `value:"${enable:false}"`
+	// Window 统计窗口，均分为buckets个桶滑动
+	Window    int     `value:"${window:10000}"`
+	Buckets   int     `value:"${buckets:10}"`
+	MinCalls  int     `value:"${min-calls:20}"`
+	ErrorRate float64 `value:"${error-rate:0.5}"`
+	// SlowCall 慢调用耗时阈值，0为不统计慢调用
+	SlowCall      int     `value:"${slow-call:0}"`
+	SlowCallRate  float64 `value:"${slow-call-rate:0.8}"`
+	OpenDuration  int     `value:"${open-duration:5000}"`
+	HalfOpenCalls int     `value:"${half-open-calls:3}"`
+}
+
+// BulkheadProperties 隔离舱配置
+type BulkheadProperties struct {
+	// MaxConcurrency 对下游服务的最大并发调用数，0为不限制
+	MaxConcurrency int `value:"${max-concurrency:0}"`
+}
+
+// policy 返回下游服务的策略
+func (c *ResilienceProperties) policy(name string) PolicyProperties {
+	if p, ok := c.Services[name]; ok {
+		return p
+	}
+	return c.Default
+}
+
+func (c *ResilienceProperties) validate() error {
+	if err := c.Default.validate(); err != nil {
+		return fmt.Errorf("resilience default: %w", err)
+	}
+	for name, p := range c.Services {
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("resilience service %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (p PolicyProperties) validate() error {
+	if p.Bulkhead.MaxConcurrency < 0 {
+		return fmt.Errorf("bulkhead max-concurrency invalid: %d", p.Bulkhead.MaxConcurrency)
+	}
+	if err := p.Retry.validate(); err != nil {
+		return err
+	}
+	cb := p.CircuitBreaker
+	if !cb.Enable {
+		return nil
+	}
+	switch {
+	case cb.Window <= 0 || cb.Buckets <= 0 || cb.Window < cb.Buckets:
+		return fmt.Errorf("circuit-breaker window invalid: window=%d buckets=%d", cb.Window, cb.Buckets)
+	case cb.MinCalls <= 0:
+		return fmt.Errorf("circuit-breaker min-calls invalid: %d", cb.MinCalls)
+	case cb.ErrorRate <= 0 || cb.ErrorRate > 1:
+		return fmt.Errorf("circuit-breaker error-rate invalid: %v, must be in (0, 1]", cb.ErrorRate)
+	case cb.SlowCall < 0 || cb.SlowCall > 0 && (cb.SlowCallRate <= 0 || cb.SlowCallRate > 1):
+		return fmt.Errorf("circuit-breaker slow-call invalid: slow-call=%d slow-call-rate=%v", cb.SlowCall, cb.SlowCallRate)
+	case cb.OpenDuration <= 0 || cb.HalfOpenCalls <= 0:
+		return fmt.Errorf("circuit-breaker half-open invalid: open-duration=%d half-open-calls=%d", cb.OpenDuration, cb.HalfOpenCalls)
+	}
+	return nil
+}
+
+func ms(v int) time.Duration {
+	return time.Duration(v) * time.Millisecond
+}
@@ -0,0 +1,236 @@
+package ag_resilience
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/frochyzhang/ag-core/ag/ag_error"
+	"github.com/frochyzhang/ag-core/ag/ag_metrics"
+
+	"github.com/cloudwego/kitex/pkg/kerrors"
+)
+
+// Done 调用结束时以调用结果调用，判断是否失败见 IsFailure
+type Done func(err error)
+
+// IsFailure 调用结果是否计为下游故障：业务异常说明下游正常处理，调用方取消不代表下游故障，均不计入
+func IsFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var abe ag_error.BizStatusErrorIface
+	if errors.As(err, &abe) {
+		return false
+	}
+	_, isBiz := kerrors.FromBizStatusError(err)
+	return !isBiz
+}
+
+// IsRejected 是否为熔断或隔离舱拒绝的调用
+func IsRejected(err error) bool {
+	var abe ag_error.BizStatusErrorIface
+	return errors.As(err, &abe) && (abe.BizCode() == ag_error.CodeCircuitOpen || abe.BizCode() == ag_error.CodeBulkheadFull)
+}
+
+// policy 下游服务的熔断器、隔离舱及重试策略，为nil时不启用
+type policy struct {
+	conf     PolicyProperties
+	breaker  *Breaker
+	inflight *atomic.Int64
+	retryer  *retryer
+}
+
+// Manager 按下游服务名管理故障隔离策略，配置支持运行时更新
+type Manager struct {
+	metrics *metrics
+	now     func() time.Time
+
+	mu       sync.Mutex
+	conf     *ResilienceProperties
+	policies sync.Map // name -> *policy
+}
+
+// NewManager 按配置创建，registry不为空时导出熔断状态、状态变化、拒绝数及重试数等指标
+func NewManager(conf ResilienceProperties, registry *ag_metrics.Registry) (*Manager, error) {
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+	m := &Manager{conf: &conf, now: time.Now}
+	if registry != nil {
+		m.metrics = newMetrics(registry)
+	}
+	return m, nil
+}
+
+// Update 更新配置，配置无效时返回错误并保留原配置；策略变更的下游服务重建熔断器及隔离舱
+func (m *Manager) Update(conf ResilienceProperties) error {
+	if err := conf.validate(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.conf = &conf
+	m.policies.Range(func(k, v any) bool {
+		name := k.(string)
+		if next := conf.policy(name); next != v.(*policy).conf {
+			m.policies.Store(name, m.newPolicy(name, next))
+			slog.Info("resilience policy updated", "name", name)
+		}
+		return true
+	})
+	return nil
+}
+
+func (m *Manager) policy(name string) *policy {
+	if v, ok := m.policies.Load(name); ok {
+		return v.(*policy)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.policies.Load(name); ok {
+		return v.(*policy)
+	}
+	p := m.newPolicy(name, m.conf.policy(name))
+	m.policies.Store(name, p)
+	return p
+}
+
+func (m *Manager) newPolicy(name string, conf PolicyProperties) *policy {
+	p := &policy{conf: conf, retryer: newRetryer(conf.Retry)}
+	if conf.CircuitBreaker.Enable {
+		p.breaker = NewBreaker(conf.CircuitBreaker, func(from, to State) {
+			slog.Warn("circuit breaker state changed", "name", name, "from", from.String(), "to", to.String())
+			m.metrics.transited(name, from, to)
+		})
+		p.breaker.now = m.now
+	}
+	m.metrics.state(name, StateClosed)
+	if conf.Bulkhead.MaxConcurrency > 0 {
+		p.inflight = new(atomic.Int64)
+	}
+	return p
+}
+
+// State 下游服务的熔断状态，未启用熔断时为closed
+func (m *Manager) State(name string) State {
+	if b := m.policy(name).breaker; b != nil {
+		return b.State()
+	}
+	return StateClosed
+}
+
+// Acquire 对下游服务name发起调用前获取许可，放行时调用结束后须调用一次done；
+// 隔离舱已满或熔断时返回业务码为 ag_error.CodeBulkheadFull、ag_error.CodeCircuitOpen 的业务异常
+func (m *Manager) Acquire(name string) (done Done, err error) {
+	p := m.policy(name)
+	if p.inflight != nil {
+		if p.inflight.Add(1) > int64(p.conf.Bulkhead.MaxConcurrency) {
+			p.inflight.Add(-1)
+			m.metrics.rejected(name, "bulkhead-full")
+			return nil, ag_error.NewBizStatusError(ag_error.CodeBulkheadFull, "bulkhead full",
+				map[string]string{"downstream": name})
+		}
+		m.metrics.inflight(name, 1)
+	}
+	release := func() {
+		if p.inflight != nil {
+			p.inflight.Add(-1)
+			m.metrics.inflight(name, -1)
+		}
+	}
+
+	if p.breaker == nil {
+		return func(error) { release() }, nil
+	}
+	gen, ok := p.breaker.allow()
+	if !ok {
+		release()
+		m.metrics.rejected(name, "circuit-open")
+		return nil, ag_error.NewBizStatusError(ag_error.CodeCircuitOpen, "circuit breaker open",
+			map[string]string{"downstream": name})
+	}
+	start := m.now()
+	return func(err error) {
+		p.breaker.record(gen, IsFailure(err), m.now().Sub(start))
+		release()
+	}, nil
+}
+
+// Call 在故障隔离下执行fn
+func (m *Manager) Call(name string, fn func() error) error {
+	done, err := m.Acquire(name)
+	if err != nil {
+		return err
+	}
+	err = fn()
+	done(err)
+	return err
+}
+
+// metrics 故障隔离指标，为nil时不采集
+type metrics struct {
+	states      *ag_metrics.GaugeVec
+	transitions *ag_metrics.CounterVec
+	rejections  *ag_metrics.CounterVec
+	concurrency *ag_metrics.GaugeVec
+	retries     *ag_metrics.CounterVec
+	noBudget    *ag_metrics.CounterVec
+}
+
+func newMetrics(registry *ag_metrics.Registry) *metrics {
+	return &metrics{
+		states: registry.Gauge("ag_resilience_circuit_state",
+			"下游服务熔断状态：0 closed，1 open，2 half-open", "name"),
+		transitions: registry.Counter("ag_resilience_circuit_transitions_total",
+			"熔断状态变化次数", "name", "from", "to"),
+		rejections: registry.Counter("ag_resilience_rejected_total",
+			"熔断或隔离舱拒绝的调用数", "name", "reason"),
+		concurrency: registry.Gauge("ag_resilience_bulkhead_inflight",
+			"隔离舱中进行中的调用数", "name"),
+		retries: registry.Counter("ag_resilience_retries_total",
+			"重试次数", "name"),
+		noBudget: registry.Counter("ag_resilience_retry_budget_exhausted_total",
+			"重试预算不足而放弃的重试数", "name"),
+	}
+}
+
+func (m *metrics) state(name string, s State) {
+	if m != nil {
+		m.states.With(name).Set(float64(s))
+	}
+}
+
+func (m *metrics) transited(name string, from, to State) {
+	if m != nil {
+		m.states.With(name).Set(float64(to))
+		m.transitions.With(name, from.String(), to.String()).Inc()
+	}
+}
+
+func (m *metrics) rejected(name, reason string) {
+	if m != nil {
+		m.rejections.With(name, reason).Inc()
+	}
+}
+
+func (m *metrics) inflight(name string, delta float64) {
+	if m != nil {
+		m.concurrency.With(name).Add(delta)
+	}
+}
+
+func (m *metrics) retried(name string) {
+	if m != nil {
+		m.retries.With(name).Inc()
+	}
+}
+
+func (m *metrics) retryRejected(name string) {
+	if m != nil {
+		m.noBudget.With(name).Inc()
+	}
+}
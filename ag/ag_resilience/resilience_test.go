@@ -0,0 +1,319 @@
+package ag_resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/kerrors"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/frochyzhang/ag-core/ag/ag_error"
+	"github.com/frochyzhang/ag-core/ag/ag_metrics"
+)
+
+var errTransport = errors.New("connection refused")
+
+// testManager 使用可控时钟
+func testManager(t *testing.T, conf ResilienceProperties, registry *ag_metrics.Registry) (*Manager, *time.Time) {
+	t.Helper()
+	m, err := NewManager(conf, registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1000, 0)
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func breakerConf() BreakerProperties {
+	return BreakerProperties{
+		Enable: true, Window: 10000, Buckets: 10, MinCalls: 4, ErrorRate: 0.5,
+		SlowCall: 100, SlowCallRate: 0.5, OpenDuration: 1000, HalfOpenCalls: 2,
+	}
+}
+
+func assertRejected(t *testing.T, err error, code int32) {
+	t.Helper()
+	var abe ag_error.BizStatusErrorIface
+	if !errors.As(err, &abe) || abe.BizCode() != code || !IsRejected(err) || IsFailure(err) {
+		t.Fatalf("err = %v, want rejected with %d", err, code)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	registry := ag_metrics.NewRegistry()
+	m, now := testManager(t, ResilienceProperties{
+		Services: map[string]PolicyProperties{"order": {CircuitBreaker: breakerConf()}},
+	}, registry)
+	fail := func() error { return errTransport }
+	ok := func() error { return nil }
+
+	// 业务异常不计为失败，未达到最小调用数时不熔断
+	biz := func() error { return ag_error.NewBizStatusError(1001, "denied") }
+	for _, fn := range []func() error{biz, biz, fail} {
+		m.Call("order", fn)
+	}
+	if s := m.State("order"); s != StateClosed {
+		t.Fatalf("state = %v", s)
+	}
+	m.Call("order", fail)
+	if s := m.State("order"); s != StateOpen {
+		t.Fatalf("state = %v, want open", s)
+	}
+	assertRejected(t, m.Call("order", ok), ag_error.CodeCircuitOpen)
+
+	// 半开放行有限的探测调用，失败时重新熔断
+	*now = now.Add(time.Second)
+	done, err := m.Acquire("order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Acquire("order")
+	_, err = m.Acquire("order")
+	assertRejected(t, err, ag_error.CodeCircuitOpen)
+	done(errTransport)
+	if s := m.State("order"); s != StateOpen {
+		t.Fatalf("state = %v, want reopened", s)
+	}
+
+	// 探测全部成功后恢复
+	*now = now.Add(time.Second)
+	for i := 0; i < 2; i++ {
+		if err := m.Call("order", ok); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s := m.State("order"); s != StateClosed {
+		t.Fatalf("state = %v, want closed", s)
+	}
+
+	if v := registry.Counter("ag_resilience_circuit_transitions_total", "熔断状态变化次数", "name", "from", "to").
+		With("order", "half-open", "open").Value(); v != 1 {
+		t.Fatalf("half-open -> open transitions = %v", v)
+	}
+	if v := registry.Counter("ag_resilience_rejected_total", "熔断或隔离舱拒绝的调用数", "name", "reason").
+		With("order", "circuit-open").Value(); v != 2 {
+		t.Fatalf("rejected = %v", v)
+	}
+}
+
+func TestSlowCallAndWindow(t *testing.T) {
+	m, now := testManager(t, ResilienceProperties{Default: PolicyProperties{CircuitBreaker: breakerConf()}}, nil)
+	slow := func() error {
+		*now = now.Add(200 * time.Millisecond)
+		return nil
+	}
+	for i := 0; i < 3; i++ {
+		m.Call("user", slow)
+	}
+	// 窗口滑过后旧的统计不再计入
+	*now = now.Add(10 * time.Second)
+	for i := 0; i < 2; i++ {
+		m.Call("user", func() error { return nil })
+	}
+	m.Call("user", slow)
+	if s := m.State("user"); s != StateClosed {
+		t.Fatalf("state = %v", s)
+	}
+	m.Call("user", slow)
+	if s := m.State("user"); s != StateOpen {
+		t.Fatalf("state = %v, want open by slow calls", s)
+	}
+}
+
+func TestBulkheadAndUpdate(t *testing.T) {
+	m, _ := testManager(t, ResilienceProperties{
+		Services: map[string]PolicyProperties{"pay": {Bulkhead: BulkheadProperties{MaxConcurrency: 1}}},
+	}, nil)
+	done, err := m.Acquire("pay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = m.Acquire("pay")
+	assertRejected(t, err, ag_error.CodeBulkheadFull)
+	if _, err := m.Acquire("other"); err != nil {
+		t.Fatalf("unconfigured downstream: %v", err)
+	}
+	done(nil)
+	if err := m.Call("pay", func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	bad := ResilienceProperties{Default: PolicyProperties{CircuitBreaker: BreakerProperties{Enable: true, ErrorRate: 2}}}
+	if err := m.Update(bad); err == nil {
+		t.Fatal("invalid update succeeded")
+	}
+	m.Acquire("pay")
+	_, err = m.Acquire("pay")
+	assertRejected(t, err, ag_error.CodeBulkheadFull)
+
+	// 配置变更后重建隔离舱
+	if err := m.Update(ResilienceProperties{}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := m.Acquire("pay"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIsFailure(t *testing.T) {
+	for err, want := range map[error]bool{
+		nil:              false,
+		context.Canceled: false,
+		fmt.Errorf("call: %w", ag_error.NewBizStatusError(1, "biz")): false,
+		context.DeadlineExceeded: true,
+		errTransport:             true,
+	} {
+		if got := IsFailure(err); got != want {
+			t.Errorf("IsFailure(%v) = %v", err, got)
+		}
+	}
+}
+
+type statusError int
+
+func (e statusError) Error() string   { return fmt.Sprintf("status %d", int(e)) }
+func (e statusError) Retryable() bool { return e >= 500 }
+
+func retryConf() RetryProperties {
+	return RetryProperties{
+		MaxAttempts: 3, Backoff: 1, MaxBackoff: 4, Multiplier: 2, RetryCodes: "1002",
+		IdempotentMethods: "Get*,/test.Echo/Echo", BudgetRatio: 0.5, BudgetMinPerSecond: 1,
+	}
+}
+
+func TestRetry(t *testing.T) {
+	registry := ag_metrics.NewRegistry()
+	m, err := NewManager(ResilienceProperties{Default: PolicyProperties{Retry: retryConf()}}, registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	attempts := func(method string, errs ...error) (int, error) {
+		n := 0
+		err := m.Invoke(ctx, "user", method, func(context.Context) error {
+			n++
+			if n <= len(errs) {
+				return errs[n-1]
+			}
+			return nil
+		})
+		return n, err
+	}
+
+	for name, c := range map[string]struct {
+		method string
+		errs   []error
+		want   int
+	}{
+		"transport recovered":  {"GetUser", []error{errTransport}, 2},
+		"max attempts":         {"GetUser", []error{errTransport, errTransport, errTransport, errTransport}, 3},
+		"not idempotent":       {"CreateUser", []error{errTransport}, 1},
+		"full method":          {"/test.Echo/Echo", []error{errTransport}, 2},
+		"retryable biz code":   {"GetUser", []error{ag_error.NewBizStatusError(1002, "busy")}, 2},
+		"biz code":             {"GetUser", []error{ag_error.NewBizStatusError(1001, "denied")}, 1},
+		"rejected":             {"GetUser", []error{ag_error.NewBizStatusError(ag_error.CodeCircuitOpen, "open")}, 1},
+		"retryable status":     {"GetUser", []error{statusError(503)}, 2},
+		"non-retryable status": {"GetUser", []error{statusError(404)}, 1},
+		"per-attempt timeout":  {"GetUser", []error{fmt.Errorf("rpc timeout: %w", context.DeadlineExceeded)}, 2},
+	} {
+		if n, _ := attempts(c.method, c.errs...); n != c.want {
+			t.Errorf("%s: attempts = %d, want %d", name, n, c.want)
+		}
+	}
+	retries := registry.Counter("ag_resilience_retries_total", "重试次数", "name").With("user").Value()
+	if retries != 7 {
+		t.Fatalf("retries = %v", retries)
+	}
+
+	// 剩余时间不足以退避时不重试
+	short, cancel := context.WithTimeout(ctx, time.Microsecond)
+	defer cancel()
+	n := 0
+	err = m.Invoke(short, "user", "GetUser", func(context.Context) error { n++; return errTransport })
+	if n != 1 || err != errTransport {
+		t.Fatalf("deadline: attempts = %d, err = %v", n, err)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	conf := retryConf()
+	conf.BudgetRatio, conf.BudgetMinPerSecond = 0.5, 0
+	m, now := testManager(t, ResilienceProperties{Default: PolicyProperties{Retry: conf}}, nil)
+	attempts := func() int {
+		n := 0
+		m.Invoke(context.Background(), "user", "GetUser", func(context.Context) error { n++; return errTransport })
+		return n
+	}
+
+	// 重试数不超过请求数的一半
+	var got []int
+	for i := 0; i < 4; i++ {
+		got = append(got, attempts())
+	}
+	if fmt.Sprint(got) != "[1 2 1 2]" {
+		t.Fatalf("attempts = %v", got)
+	}
+
+	// 窗口滑过后重新统计
+	*now = now.Add(budgetWindow * time.Second)
+	if n := attempts(); n != 1 {
+		t.Fatalf("attempts after window = %d", n)
+	}
+}
+
+// kitexResult 模拟kitex生成的应答类型
+type kitexResult struct {
+	Success *string
+}
+
+func TestKitexMiddlewareRetry(t *testing.T) {
+	m, err := NewManager(ResilienceProperties{Default: PolicyProperties{Retry: retryConf()}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(next endpoint.Endpoint) (*kitexResult, rpcinfo.Invocation, error) {
+		inv := rpcinfo.NewInvocation("user", "GetUser")
+		ri := rpcinfo.NewRPCInfo(nil, rpcinfo.NewEndpointInfo("user", "GetUser", nil, nil), inv, nil, nil)
+		resp := &kitexResult{}
+		err := KitexMiddleware(m, "user")(next)(rpcinfo.NewCtxWithRPCInfo(context.Background(), ri), nil, resp)
+		return resp, inv, err
+	}
+
+	// 重试成功时不残留前次尝试的应答及业务异常
+	n := 0
+	resp, inv, err := call(func(ctx context.Context, req, resp interface{}) error {
+		n++
+		r := resp.(*kitexResult)
+		if r.Success != nil {
+			t.Errorf("attempt %d reused response %q", n, *r.Success)
+		}
+		value := fmt.Sprintf("attempt-%d", n)
+		r.Success = &value
+		if n == 1 {
+			rpcinfo.GetRPCInfo(ctx).Invocation().(rpcinfo.InvocationSetter).SetBizStatusErr(kerrors.NewBizStatusError(1002, "busy"))
+		}
+		return nil
+	})
+	if err != nil || n != 2 || resp.Success == nil || *resp.Success != "attempt-2" || inv.BizStatusErr() != nil {
+		t.Fatalf("recovered: attempts = %d, resp = %v, biz = %v, err = %v", n, resp.Success, inv.BizStatusErr(), err)
+	}
+
+	// 最后一次尝试的业务异常由rpcinfo交给调用方
+	n = 0
+	resp, inv, err = call(func(ctx context.Context, req, resp interface{}) error {
+		n++
+		value := fmt.Sprintf("attempt-%d", n)
+		resp.(*kitexResult).Success = &value
+		rpcinfo.GetRPCInfo(ctx).Invocation().(rpcinfo.InvocationSetter).SetBizStatusErr(kerrors.NewBizStatusError(1002, "busy"))
+		return nil
+	})
+	if err != nil || n != 3 || *resp.Success != "attempt-3" || inv.BizStatusErr() == nil || inv.BizStatusErr().BizStatusCode() != 1002 {
+		t.Fatalf("exhausted: attempts = %d, resp = %v, biz = %v, err = %v", n, resp.Success, inv.BizStatusErr(), err)
+	}
+}
@@ -0,0 +1,230 @@
+package ag_resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frochyzhang/ag-core/ag/ag_error"
+
+	"github.com/cloudwego/kitex/pkg/kerrors"
+)
+
+// budgetWindow 重试预算的统计窗口，按秒分桶
+const budgetWindow = 10
+
+// RetryProperties 重试策略，时间单位为毫秒；仅重试idempotent-methods中的方法，
+// 传输错误(含单次调用超时)可重试，业务异常仅重试retry-codes中的业务码，
+// 熔断及隔离舱拒绝、调用方ctx取消或到期不重试
+type RetryProperties struct {
+	// MaxAttempts 含首次调用的最大尝试次数，1为不重试
+	MaxAttempts int `value:"${max-attempts:1}"`
+	// Backoff 首次重试前的退避时间，之后按multiplier指数增长，不超过max-backoff
+	Backoff    int     `value:"${backoff:50}"`
+	MaxBackoff int     `value:"${max-backoff:1000}"`
+	Multiplier float64 `value:"${multiplier:2}"`
+	// Jitter 退避时间的随机抖动比例，取值[0, 1]
+	Jitter float64 `value:"${jitter:0.2}"`
+	// RetryCodes 可重试的业务码，逗号分隔
+	RetryCodes string `value:"${retry-codes:}"`
+	// IdempotentMethods 幂等方法，逗号分隔，以*结尾时按前缀匹配，*为全部方法；
+	// kitex为方法名，netty为完整方法名(/package.Service/Method)，hertz为"HTTP方法 路径"，如 GET /users/{id}
+	IdempotentMethods string `value:"${idempotent-methods:}"`
+	// BudgetRatio 重试预算，最近10秒内的重试数不超过请求数的该比例加 budget-min-per-second*10，避免重试风暴
+	BudgetRatio        float64 `value:"${budget-ratio:0.2}"`
+	BudgetMinPerSecond int     `value:"${budget-min-per-second:10}"`
+}
+
+func (r RetryProperties) validate() error {
+	if r.MaxAttempts <= 1 {
+		return nil
+	}
+	switch {
+	case r.Backoff < 0 || r.MaxBackoff < r.Backoff:
+		return fmt.Errorf("retry backoff invalid: backoff=%d max-backoff=%d", r.Backoff, r.MaxBackoff)
+	case r.Multiplier < 1:
+		return fmt.Errorf("retry multiplier invalid: %v, must be >= 1", r.Multiplier)
+	case r.Jitter < 0 || r.Jitter > 1:
+		return fmt.Errorf("retry jitter invalid: %v, must be in [0, 1]", r.Jitter)
+	case r.BudgetRatio < 0 || r.BudgetMinPerSecond < 0:
+		return fmt.Errorf("retry budget invalid: budget-ratio=%v budget-min-per-second=%d", r.BudgetRatio, r.BudgetMinPerSecond)
+	}
+	if _, err := parseCodes(r.RetryCodes); err != nil {
+		return err
+	}
+	return nil
+}
+
+func parseCodes(s string) (map[int32]bool, error) {
+	codes := make(map[int32]bool)
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c == "" {
+			continue
+		}
+		code, err := strconv.ParseInt(c, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("retry-codes invalid: %s", c)
+		}
+		codes[int32(code)] = true
+	}
+	return codes, nil
+}
+
+// retryer 编译后的重试策略，为nil时不重试
+type retryer struct {
+	conf    RetryProperties
+	codes   map[int32]bool
+	methods []string
+	budget  *budget
+}
+
+func newRetryer(conf RetryProperties) *retryer {
+	if conf.MaxAttempts <= 1 {
+		return nil
+	}
+	codes, _ := parseCodes(conf.RetryCodes)
+	r := &retryer{conf: conf, codes: codes, budget: &budget{ratio: conf.BudgetRatio, min: conf.BudgetMinPerSecond * budgetWindow}}
+	for _, m := range strings.Split(conf.IdempotentMethods, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			r.methods = append(r.methods, m)
+		}
+	}
+	return r
+}
+
+func (r *retryer) idempotent(method string) bool {
+	for _, m := range r.methods {
+		if prefix, ok := strings.CutSuffix(m, "*"); ok && strings.HasPrefix(method, prefix) || m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// retryableError 可自行声明是否可重试的错误，如hertz客户端的HTTP状态错误
+type retryableError interface {
+	Retryable() bool
+}
+
+// retryable 错误是否可重试
+func (r *retryer) retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || IsRejected(err) {
+		return false
+	}
+	var abe ag_error.BizStatusErrorIface
+	if errors.As(err, &abe) {
+		return r.codes[abe.BizCode()]
+	}
+	if kbe, ok := kerrors.FromBizStatusError(err); ok {
+		return r.codes[kbe.BizStatusCode()]
+	}
+	var re retryableError
+	if errors.As(err, &re) {
+		return re.Retryable()
+	}
+	return true
+}
+
+// backoff 第attempt次重试前的退避时间
+func (r *retryer) backoff(attempt int) time.Duration {
+	d := float64(r.conf.Backoff) * math.Pow(r.conf.Multiplier, float64(attempt-1))
+	d = math.Min(d, float64(r.conf.MaxBackoff))
+	d *= 1 + r.conf.Jitter*(2*rand.Float64()-1)
+	return time.Duration(d * float64(time.Millisecond))
+}
+
+// budget 重试预算，按秒分桶统计最近budgetWindow秒的请求数及重试数
+type budget struct {
+	ratio float64
+	min   int
+
+	mu      sync.Mutex
+	buckets [budgetWindow]budgetBucket
+}
+
+type budgetBucket struct {
+	sec               int64
+	requests, retries int
+}
+
+func (b *budget) slot(now time.Time) *budgetBucket {
+	sec := now.Unix()
+	bk := &b.buckets[sec%budgetWindow]
+	if bk.sec != sec {
+		bk.sec, bk.requests, bk.retries = sec, 0, 0
+	}
+	return bk
+}
+
+func (b *budget) request(now time.Time) {
+	b.mu.Lock()
+	b.slot(now).requests++
+	b.mu.Unlock()
+}
+
+// withdraw 预算充足时记录一次重试
+func (b *budget) withdraw(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var requests, retries int
+	for _, bk := range b.buckets {
+		if now.Unix()-bk.sec < budgetWindow {
+			requests += bk.requests
+			retries += bk.retries
+		}
+	}
+	if float64(retries+1) > b.ratio*float64(requests)+float64(b.min) {
+		return false
+	}
+	b.slot(now).retries++
+	return true
+}
+
+// Invoke 按下游服务name的重试策略调用方法method，每次尝试均经过熔断及隔离舱；
+// 方法非幂等、错误不可重试、重试预算不足或剩余时间不足以退避时返回最近一次的错误
+func (m *Manager) Invoke(ctx context.Context, name, method string, call func(ctx context.Context) error) error {
+	return m.retry(ctx, name, method, func() error {
+		return m.Call(name, func() error { return call(ctx) })
+	})
+}
+
+// Retry 同 Invoke 但尝试不经过熔断及隔离舱，供每次尝试已由中间件做故障隔离的客户端使用，如hertz客户端
+func (m *Manager) Retry(ctx context.Context, name, method string, call func(ctx context.Context) error) error {
+	return m.retry(ctx, name, method, func() error { return call(ctx) })
+}
+
+func (m *Manager) retry(ctx context.Context, name, method string, attempt func() error) error {
+	r := m.policy(name).retryer
+	if r == nil || !r.idempotent(method) {
+		return attempt()
+	}
+	r.budget.request(m.now())
+	for i := 1; ; i++ {
+		err := attempt()
+		if err == nil || i >= r.conf.MaxAttempts || ctx.Err() != nil || !r.retryable(err) {
+			return err
+		}
+		delay := r.backoff(i)
+		if deadline, ok := ctx.Deadline(); ok && m.now().Add(delay).After(deadline) {
+			return err
+		}
+		if !r.budget.withdraw(m.now()) {
+			m.metrics.retryRejected(name)
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		m.metrics.retried(name)
+	}
+}
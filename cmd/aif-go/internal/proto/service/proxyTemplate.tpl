@@ -1,4 +1,4 @@
-{{$ifcName := .Name}}
+{{$ifcName := .Name}}{{$streamX := .StreamX}}
 package service
 
 import (
@@ -14,23 +14,38 @@ import (
 type {{$ifcName}}Proxy struct {
 	service *{{$ifcName}}Service // 原始服务实例
 	handlers map[string]mw.HandlerFunc
+	streamHandlers map[string]mw.StreamHandlerFunc
 }
 
 func New{{$ifcName}}Proxy(service *{{$ifcName}}Service, mws []mw.PrioritizedMiddleware) pb.{{$ifcName}}Server {
 	proxy := &{{$ifcName}}Proxy{
 		service:     service,
 		handlers:    make(map[string]mw.HandlerFunc),
+		streamHandlers: make(map[string]mw.StreamHandlerFunc),
 	}
     {{range .Methods}}
+	{{- if eq .Type 1}}
 	proxy.handlers["{{.Name}}"] = mw.RegisterHandler("{{.Name}}", mws, func(ctx context.Context, req interface{}) (interface{}, error) {
 		// 最终调用原始服务方法
 		return proxy.service.{{.Name}}(ctx, req.(*pb.{{.Request}}))
 	})
+	{{- else}}
+	proxy.streamHandlers["{{.Name}}"] = mw.RegisterStreamHandler("{{.Name}}", mws, func(ctx context.Context, call *mw.StreamCall) error {
+		{{- if $streamX}}
+		return proxy.service.{{.Name}}(ctx, {{if eq .Type 4}}call.Req.(*pb.{{.Request}}), {{end}}call.Stream.(pb.{{$ifcName}}_{{.Name}}Server))
+		{{- else}}
+		// 中间件处理后的ctx经stream.Context()传递给原始服务方法
+		stream := {{toLower $ifcName}}{{.Name}}Stream{call.Stream.(pb.{{$ifcName}}_{{.Name}}Server), ctx}
+		return proxy.service.{{.Name}}({{if eq .Type 4}}call.Req.(*pb.{{.Request}}), {{end}}stream)
+		{{- end}}
+	})
+	{{- end}}
     {{- end}}
 	return proxy
 }
 
 // ======== {{.Name}} 代理方法 ========{{range .Methods}}
+{{- if eq .Type 1}}
 func (p *{{$ifcName}}Proxy) {{.Name}}(ctx context.Context, in *pb.{{.Request}}) (*pb.{{.Reply}}, error) {
     start := time.Now()
     methodName := "{{.Name}}"
@@ -47,4 +62,38 @@ func (p *{{$ifcName}}Proxy) {{.Name}}(ctx context.Context, in *pb.{{.Request}})
 
     log.Printf("[%s] success in %v", methodName, time.Since(start))
     return res.(*pb.{{.Reply}}), nil
-}{{end}}
\ No newline at end of file
+}
+{{- else}}
+func (p *{{$ifcName}}Proxy) {{.Name}}({{if $streamX}}ctx context.Context, {{end}}{{if eq .Type 4}}in *pb.{{.Request}}, {{end}}conn pb.{{$ifcName}}_{{.Name}}Server) error {
+    start := time.Now()
+    methodName := "{{.Name}}"
+    {{- if not $streamX}}
+    ctx := conn.Context()
+    {{- end}}
+
+    // 获取处理链，流式方法的中间件在整个流的生命周期内执行一次
+	handler := p.streamHandlers[methodName]
+
+    // 执行调用链
+    err := handler(ctx, &mw.StreamCall{ {{- if eq .Type 4}}Req: in, {{end}}Stream: conn})
+    if err != nil {
+        log.Printf("[%s] stream failed in %v: %v", methodName, time.Since(start), err)
+        return err
+    }
+
+    log.Printf("[%s] stream success in %v", methodName, time.Since(start))
+    return nil
+}
+{{- if not $streamX}}
+
+type {{toLower $ifcName}}{{.Name}}Stream struct {
+	pb.{{$ifcName}}_{{.Name}}Server
+	ctx context.Context
+}
+
+func (s {{toLower $ifcName}}{{.Name}}Stream) Context() context.Context {
+	return s.ctx
+}
+{{- end}}
+{{- end}}
+{{- end}}
@@ -21,10 +21,14 @@ var CmdService = &cobra.Command{
 	Long:  "Generate the proto service implementations. Example: kratos proto service api/xxx.proto --target-dir=internal/service",
 	Run:   run,
 }
-var targetDir string
+var (
+	targetDir string
+	streamX   bool
+)
 
 func init() {
 	CmdService.Flags().StringVarP(&targetDir, "target-dir", "t", "internal/service", "generate target directory")
+	CmdService.Flags().BoolVar(&streamX, "streamx", false, "generate streaming methods with kitex StreamX API, same as protoc-gen-go-grpc streamx")
 }
 
 func run(_ *cobra.Command, args []string) {
@@ -46,6 +50,7 @@ func generateServiceProxy(services []*Service) {
 			Name:    service.Service,
 			PbPkg:   service.Package,
 			Methods: service.Methods,
+			StreamX: streamX,
 		}
 		if _, err := os.Stat(targetDir); os.IsNotExist(err) {
 			fmt.Printf("Target directory: %s does not exist\n", targetDir)
@@ -157,6 +162,7 @@ func generateFile(f string) []*Service {
 			cs := &Service{
 				Package: pkg,
 				Service: serviceName(s.Name),
+				StreamX: streamX,
 			}
 			for _, e := range s.Elements {
 				r, ok := e.(*proto.RPC)
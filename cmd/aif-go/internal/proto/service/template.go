@@ -43,9 +43,9 @@ func (s *{{ .Service }}Service) {{ .Name }}(ctx context.Context, in {{ if eq .Re
 }
 
 {{- else if eq .Type 2 }}
-func (s *{{ .Service }}Service) {{ .Name }}(conn pb.{{ .Service }}_{{ .Name }}Server) error {
+func (s *{{ .Service }}Service) {{ .Name }}({{ if $.StreamX }}ctx context.Context, {{ end }}conn pb.{{ .Service }}_{{ .Name }}Server) error {
 	for {
-		in, err := conn.Recv()
+		_, err := conn.Recv({{ if $.StreamX }}ctx{{ end }})
 		if err == io.EOF {
 			return nil
 		}
@@ -53,7 +53,7 @@ func (s *{{ .Service }}Service) {{ .Name }}(conn pb.{{ .Service }}_{{ .Name }}Se
 			return err
 		}
 		
-		err = conn.Send(&pb.{{ .Reply }}{})
+		err = conn.Send({{ if $.StreamX }}ctx, {{ end }}&pb.{{ .Reply }}{})
 		if err != nil {
 			return err
 		}
@@ -61,11 +61,11 @@ func (s *{{ .Service }}Service) {{ .Name }}(conn pb.{{ .Service }}_{{ .Name }}Se
 }
 
 {{- else if eq .Type 3 }}
-func (s *{{ .Service }}Service) {{ .Name }}(conn pb.{{ .Service }}_{{ .Name }}Server) error {
+func (s *{{ .Service }}Service) {{ .Name }}({{ if $.StreamX }}ctx context.Context, {{ end }}conn pb.{{ .Service }}_{{ .Name }}Server) error {
 	for {
-		in, err := conn.Recv()
+		_, err := conn.Recv({{ if $.StreamX }}ctx{{ end }})
 		if err == io.EOF {
-			return conn.SendAndClose(&pb.{{ .Reply }}{})
+			return conn.SendAndClose({{ if $.StreamX }}ctx, {{ end }}&pb.{{ .Reply }}{})
 		}
 		if err != nil {
 			return err
@@ -74,10 +74,10 @@ func (s *{{ .Service }}Service) {{ .Name }}(conn pb.{{ .Service }}_{{ .Name }}Se
 }
 
 {{- else if eq .Type 4 }}
-func (s *{{ .Service }}Service) {{ .Name }}(in {{ if eq .Request $s1 }}*emptypb.Empty
+func (s *{{ .Service }}Service) {{ .Name }}({{ if $.StreamX }}ctx context.Context, {{ end }}in {{ if eq .Request $s1 }}*emptypb.Empty
 {{ else }}*pb.{{ .Request }}{{ end }}, conn pb.{{ .Service }}_{{ .Name }}Server) error {
 	for {
-		err := conn.Send(&pb.{{ .Reply }}{})
+		err := conn.Send({{ if $.StreamX }}ctx, {{ end }}&pb.{{ .Reply }}{})
 		if err != nil {
 			return err
 		}
@@ -102,6 +102,8 @@ type Interface struct {
 	Name    string
 	PbPkg   string
 	Methods []*Method
+	// StreamX 流式方法使用kitex StreamX接口，与protoc-gen-go-grpc的streamx参数一致
+	StreamX bool
 }
 
 // Module is a fx server definition
@@ -124,6 +126,7 @@ type Service struct {
 
 	UseIO      bool
 	UseContext bool
+	StreamX    bool
 }
 
 // Method is a proto method.
@@ -148,7 +151,7 @@ func (s *Service) execute() ([]byte, error) {
 		if method.Type == twoWayStreamsType || method.Type == requestStreamsType {
 			s.UseIO = true
 		}
-		if method.Type == unaryType {
+		if method.Type == unaryType || s.StreamX {
 			s.UseContext = true
 		}
 	}
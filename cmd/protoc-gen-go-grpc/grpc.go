@@ -10,12 +10,14 @@ import (
 const (
 	contextPackage   = protogen.GoImportPath("context")
 	errorsPackage    = protogen.GoImportPath("errors")
+	fmtPackage       = protogen.GoImportPath("fmt")
 	protoPackage     = protogen.GoImportPath("google.golang.org/protobuf/proto")
 	clientPackage    = protogen.GoImportPath("github.com/cloudwego/kitex/client")
 	streamingPackage = protogen.GoImportPath("github.com/cloudwego/kitex/pkg/streaming")
 	fxPackage        = protogen.GoImportPath("go.uber.org/fx")
 	kitextPackage    = protogen.GoImportPath("github.com/cloudwego/kitex/pkg/serviceinfo")
 	agksPackage      = protogen.GoImportPath("github.com/frochyzhang/ag-core/ag/ag_kitex/server")
+	agkcPackage      = protogen.GoImportPath("github.com/frochyzhang/ag-core/ag/ag_kitex/client")
 )
 
 // generateFile generates a _grpc.pb.go file containing kratos errors definitions.
@@ -53,12 +55,14 @@ func generateFileContent(gen *protogen.Plugin, file *protogen.File, g *protogen.
 	g.P("// is compatible with the kratos package it is being compiled against.")
 	g.P("var _ = new(", contextPackage.Ident("Context"), ")")
 	g.P("var _ = ", errorsPackage.Ident("Join()"))
+	g.P("var _ = ", fmtPackage.Ident("Errorf"))
 	g.P("var _ = ", protoPackage.Ident("Error"))
 	g.P("var _ = ", clientPackage.Ident("Option{}"))
 	g.P("var _ = ", streamingPackage.Ident("Args{}"))
 	g.P("var _ = ", fxPackage.Ident("Self()"))
 	g.P("var _ = ", kitextPackage.Ident("ServiceInfo{}"))
 	g.P("var _ = ", agksPackage.Ident("Server{}"))
+	g.P("var _ = ", agkcPackage.Ident("KitexServiceSuites{}"))
 	g.P()
 
 	for _, service := range file.Services {
@@ -103,7 +107,9 @@ func genService(_ *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFi
 		NoFastAPI:      true,
 		Version:        release,
 		FrugalPretouch: false,
-		StreamX:        false,
+		StreamX:        *streamX,
+		// 与kitex client包同名，由protogen分配导入名
+		ServiceSuites: g.QualifiedGoIdent(agkcPackage.Ident("KitexServiceSuites")),
 	}
 	for _, method := range service.Methods {
 		name := method.Input.GoIdent.GoName
@@ -116,9 +122,9 @@ func genService(_ *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFi
 			Void:                   false,
 			IsResponseNeedRedirect: false,
 			GenArgResultStruct:     true,
-			IsStreaming:            false,
-			ClientStreaming:        false,
-			ServerStreaming:        false,
+			IsStreaming:            method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer(),
+			ClientStreaming:        method.Desc.IsStreamingClient(),
+			ServerStreaming:        method.Desc.IsStreamingServer(),
 			Args: []*Parameter{
 				{
 					Deps:    []PkgInfo{pkgInfo},
@@ -138,6 +144,7 @@ func genService(_ *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFi
 			ResStructName: fmt.Sprintf("%s%s%s", method.GoName, method.Output.GoIdent.GoName, "Result"),
 		}
 
+		si.HasStreaming = si.HasStreaming || mi.IsStreaming
 		si.AllMethods = append(si.AllMethods, mi)
 	}
 	g.P(pi.execute())
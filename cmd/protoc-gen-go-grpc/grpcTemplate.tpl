@@ -26,7 +26,69 @@ var {{LowerFirst .ServiceName}}Methods = map[string]serviceinfo.MethodInfo{
 	{{- end}}
 }
 
+{{- if .HasStreaming}}
+
+// {{.ServiceName}}StreamServer 流式方法的服务接口，非流式方法见 {{.ServiceName}}Server
+type {{.ServiceName}}StreamServer interface {
+{{- range .AllMethods}}
+{{- if or .ClientStreaming .ServerStreaming}}
+{{- $arg := index .Args 0}}
+{{- if .ClientStreaming}}
+	{{.Name}}({{if $.StreamX}}ctx context.Context, {{end}}stream {{.ServiceName}}_{{.RawName}}Server) error
+{{- else}}
+	{{.Name}}({{if $.StreamX}}ctx context.Context, {{end}}req {{$arg.Type}}, stream {{.ServiceName}}_{{.RawName}}Server) error
+{{- end}}
+{{- end}}
+{{- end}}
+}
+{{- end}}
+
+// {{.ServiceName}}GRPCClient gRPC客户端
+type {{.ServiceName}}GRPCClient interface {
+{{- range .AllMethods}}
+{{- if or .ClientStreaming .ServerStreaming}}
+	{{.Name}}(ctx context.Context{{if not .ClientStreaming}}{{range .Args}}, {{LowerFirst .Name}} {{.Type}}{{end}}{{end}}) ({{.ServiceName}}_{{.RawName}}Client, error)
+{{- else}}
+	{{.Name}}(ctx context.Context{{range .Args}}, {{.RawName}} {{.Type}}{{end}}) ({{.Resp.Type}}, error)
+{{- end}}
+{{- end}}
+}
+
+// New{{.ServiceName}}GRPCClient 创建gRPC客户端，opts通常为下游服务的client suite
+func New{{.ServiceName}}GRPCClient(destService string, opts ...client.Option) ({{.ServiceName}}GRPCClient, error) {
+	opts = append([]client.Option{client.WithDestService(destService)}, opts...)
+	c, err := client.NewClient(New{{.ServiceName}}ServiceInfo(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return new{{.ServiceName}}ServiceClient(c), nil
+}
+
+// New{{.ServiceName}}GRPCClientWithSuites 使用下游服务destService在 kitex.client.services.<destService> 配置的客户端套件创建gRPC客户端，opts追加在套件之后
+func New{{.ServiceName}}GRPCClientWithSuites(suites *{{.ServiceSuites}}, destService string, opts ...client.Option) ({{.ServiceName}}GRPCClient, error) {
+	suite, err := suites.Suite(destService)
+	if err != nil {
+		return nil, err
+	}
+	return New{{.ServiceName}}GRPCClient(destService, append(suite.Options(), opts...)...)
+}
+
+// Fx{{.ServiceName}}GRPCClientModule 提供访问下游服务destService的gRPC客户端，客户端套件由 FxKitexClientBaseModule 提供
+func Fx{{.ServiceName}}GRPCClientModule(destService string) fx.Option {
+	return fx.Module("fx_{{.ServiceName}}_GRPC_client_"+destService,
+		fx.Provide(func(suites *{{.ServiceSuites}}) ({{.ServiceName}}GRPCClient, error) {
+			return New{{.ServiceName}}GRPCClientWithSuites(suites, destService)
+		}),
+	)
+}
+
+// Register_{{.ServiceName}}_GRPCServer 注册gRPC服务{{if .HasStreaming}}，srv需同时实现 {{.ServiceName}}StreamServer{{end}}
 func Register_{{.ServiceName}}_GRPCServer(srv {{.ServiceType}}Server) server.Option {
+{{- if .HasStreaming}}
+	if _, ok := srv.({{.ServiceName}}StreamServer); !ok {
+		panic(fmt.Sprintf("%T does not implement {{.ServiceName}}StreamServer", srv))
+	}
+{{- end}}
 	return server.WithServiceRegistrar(&server.ServiceRegistrar{
 		ServiceInfo: New{{.ServiceName}}ServiceInfo(),
 		Handler: srv,
@@ -127,19 +189,42 @@ func {{LowerFirst .Name}}Handler(ctx context.Context, handler interface{}, arg,
 		return errors.New("invalid message type for service method handler")
 	}
 	{{- else}}{{/* streaming logic */}}
-		streamingArgs, ok := arg.(*streaming.Args)
-		if !ok {
-			return errors.New("invalid message type for service method handler")
-		}
-		st := streamingArgs.Stream
-		stream := &{{LowerFirst .ServiceName}}{{.RawName}}Server{st}
-		{{- if $serverSide}}
-		req := new({{NotPtr $arg.Type}})
-		if err := st.RecvMsg(req); err != nil {
-			return err
-		}
-		{{- end}}
-		return handler.({{.PkgRefName}}.{{.ServiceName}}).{{.Name}}({{if $serverSide}}req, {{end}}stream)
+	srv, ok := handler.({{.ServiceName}}StreamServer)
+	if !ok {
+		return errors.New("{{.ServiceName}}StreamServer is not implemented")
+	}
+	{{- if $.StreamX}}
+	st, err := streaming.GetServerStreamFromArg(arg)
+	if err != nil {
+		return err
+	}
+	{{- if $bidiSide}}
+	stream := streaming.NewBidiStreamingServer[{{NotPtr $arg.Type}}, {{NotPtr .Resp.Type}}](st)
+	{{- else if $clientSide}}
+	stream := streaming.NewClientStreamingServer[{{NotPtr $arg.Type}}, {{NotPtr .Resp.Type}}](st)
+	{{- else}}
+	stream := streaming.NewServerStreamingServer[{{NotPtr .Resp.Type}}](st)
+	req := new({{NotPtr $arg.Type}})
+	if err := stream.RecvMsg(ctx, req); err != nil {
+		return err
+	}
+	{{- end}}
+	return srv.{{.Name}}(ctx, {{if $serverSide}}req, {{end}}stream)
+	{{- else}}
+	streamingArgs, ok := arg.(*streaming.Args)
+	if !ok || streamingArgs.Stream == nil {
+		return errors.New("{{.ServiceName}}.{{.Name}} is a grpc streaming method, regenerate with streamx for other streaming transports")
+	}
+	st := streamingArgs.Stream
+	stream := &{{LowerFirst .ServiceName}}{{.RawName}}Server{st}
+	{{- if $serverSide}}
+	req := new({{NotPtr $arg.Type}})
+	if err := st.RecvMsg(req); err != nil {
+		return err
+	}
+	{{- end}}
+	return srv.{{.Name}}({{if $serverSide}}req, {{end}}stream)
+	{{- end}}
 	{{- end}} {{/* $unary end */}}
 	{{- else}} {{/* thrift logic */}}
 	{{- if $unary}} {{/* unary logic */}}
@@ -192,7 +277,51 @@ func {{LowerFirst .Name}}Handler(ctx context.Context, handler interface{}, arg,
 }
 
 {{- /* define streaming struct */}}
-{{- if $isStreaming}}
+{{- if and $isStreaming $.StreamX}}
+{{- if $bidiSide}}
+
+type {{.ServiceName}}_{{.RawName}}Server = streaming.BidiStreamingServer[{{NotPtr $arg.Type}}, {{NotPtr .Resp.Type}}]
+
+type {{.ServiceName}}_{{.RawName}}Client = streaming.BidiStreamingClient[{{NotPtr $arg.Type}}, {{NotPtr .Resp.Type}}]
+{{- else if $clientSide}}
+
+type {{.ServiceName}}_{{.RawName}}Server = streaming.ClientStreamingServer[{{NotPtr $arg.Type}}, {{NotPtr .Resp.Type}}]
+
+type {{.ServiceName}}_{{.RawName}}Client = streaming.ClientStreamingClient[{{NotPtr $arg.Type}}, {{NotPtr .Resp.Type}}]
+{{- else}}
+
+type {{.ServiceName}}_{{.RawName}}Server = streaming.ServerStreamingServer[{{NotPtr .Resp.Type}}]
+
+type {{.ServiceName}}_{{.RawName}}Client = streaming.ServerStreamingClient[{{NotPtr .Resp.Type}}]
+{{- end}}
+{{- else if $isStreaming}}
+
+type {{.ServiceName}}_{{.RawName}}Server interface {
+	streaming.Stream
+{{- if or $clientSide $bidiSide}}
+	Recv() ({{$arg.Type}}, error)
+{{- end}}
+{{- if or $serverSide $bidiSide}}
+	Send({{.Resp.Type}}) error
+{{- end}}
+{{- if $clientSide}}
+	SendAndClose({{.Resp.Type}}) error
+{{- end}}
+}
+
+type {{.ServiceName}}_{{.RawName}}Client interface {
+	streaming.Stream
+{{- if or $clientSide $bidiSide}}
+	Send({{$arg.Type}}) error
+{{- end}}
+{{- if or $serverSide $bidiSide}}
+	Recv() ({{.Resp.Type}}, error)
+{{- end}}
+{{- if $clientSide}}
+	CloseAndRecv() ({{.Resp.Type}}, error)
+{{- end}}
+}
+
 type {{LowerFirst .ServiceName}}{{.RawName}}Client struct {
 	streaming.Stream
 }
@@ -408,6 +537,27 @@ func (p *k{{.ServiceName}}Client) {{.Name}}(ctx context.Context{{if not .ClientS
 	if !ok {
 		return nil, fmt.Errorf("client not support streaming")
 	}
+	{{- if $.StreamX}}
+	{{- $arg := index .Args 0}}
+	st, err := streamClient.StreamX(ctx, "{{.RawName}}")
+	if err != nil {
+		return nil, err
+	}
+	{{- if and .ClientStreaming .ServerStreaming}}
+	stream := streaming.NewBidiStreamingClient[{{NotPtr $arg.Type}}, {{NotPtr .Resp.Type}}](st)
+	{{- else if .ClientStreaming}}
+	stream := streaming.NewClientStreamingClient[{{NotPtr $arg.Type}}, {{NotPtr .Resp.Type}}](st)
+	{{- else}}
+	stream := streaming.NewServerStreamingClient[{{NotPtr .Resp.Type}}](st)
+	if err := stream.SendMsg(ctx, {{LowerFirst $arg.Name}}); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(ctx); err != nil {
+		return nil, err
+	}
+	{{- end}}
+	return stream, nil
+	{{- else}}
 	res := new(streaming.Result)
 	err := streamClient.Stream(ctx, "{{.RawName}}", nil, res)
 	if err != nil {
@@ -424,6 +574,7 @@ func (p *k{{.ServiceName}}Client) {{.Name}}(ctx context.Context{{if not .ClientS
 	}
 	{{end -}}
 	return stream, nil
+	{{- end}}
 }
 {{- else}}
 func (p *k{{.ServiceName}}Client) {{.Name}}(ctx context.Context {{range .Args}}, {{.RawName}} {{.Type}}{{end}}) ({{if not .Void}}r {{.Resp.Type}}, {{end}}err error) {
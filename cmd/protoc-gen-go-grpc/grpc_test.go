@@ -0,0 +1,134 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func helloRequest() *pluginpb.CodeGeneratorRequest {
+	msg := func(name string) *descriptorpb.DescriptorProto {
+		return &descriptorpb.DescriptorProto{
+			Name: proto.String(name),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("name"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				JsonName: proto.String("name"),
+			}},
+		}
+	}
+	method := func(name string, client, server bool) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{
+			Name:            proto.String(name),
+			InputType:       proto.String(".hello.HelloReq"),
+			OutputType:      proto.String(".hello.HelloResp"),
+			ClientStreaming: proto.Bool(client),
+			ServerStreaming: proto.Bool(server),
+		}
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String("hello/hello.proto"),
+		Package:     proto.String("hello"),
+		Syntax:      proto.String("proto3"),
+		Options:     &descriptorpb.FileOptions{GoPackage: proto.String("example.com/hello;hello")},
+		MessageType: []*descriptorpb.DescriptorProto{msg("HelloReq"), msg("HelloResp")},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Greeter"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("SayHello", false, false),
+				method("Watch", false, true),
+				method("Upload", true, false),
+				method("Chat", true, true),
+			},
+		}},
+	}
+	return &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{file},
+	}
+}
+
+func generate(t *testing.T) string {
+	t.Helper()
+	gen, err := protogen.Options{}.New(helloRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	generateFile(gen, gen.Files[0], true, "")
+	resp := gen.Response()
+	if resp.Error != nil || len(resp.File) != 1 {
+		t.Fatalf("response = %v, %d files", resp.GetError(), len(resp.File))
+	}
+	content := resp.File[0].GetContent()
+	if _, err := parser.ParseFile(token.NewFileSet(), "hello_grpc.pb.go", content, 0); err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, content)
+	}
+	return content
+}
+
+func assertContains(t *testing.T, content string, wants ...string) {
+	t.Helper()
+	for _, want := range wants {
+		if !strings.Contains(content, want) {
+			t.Errorf("generated code missing %q\n%s", want, content)
+		}
+	}
+}
+
+func TestGenerateStreaming(t *testing.T) {
+	content := generate(t)
+	assertContains(t, content,
+		`serviceinfo.WithStreamingMode(serviceinfo.StreamingServer)`,
+		`serviceinfo.WithStreamingMode(serviceinfo.StreamingClient)`,
+		`serviceinfo.WithStreamingMode(serviceinfo.StreamingBidirectional)`,
+		`return newGreeterServiceInfo(true, true, true)`,
+		// 流式服务接口
+		`Watch(req *HelloReq, stream Greeter_WatchServer) error`,
+		`Upload(stream Greeter_UploadServer) error`,
+		`Chat(stream Greeter_ChatServer) error`,
+		`SendAndClose(*HelloResp) error`,
+		`CloseAndRecv() (*HelloResp, error)`,
+		`handler.(GreeterStreamServer)`,
+		`panic(fmt.Sprintf("%T does not implement GreeterStreamServer", srv))`,
+		// 客户端
+		`Watch(ctx context.Context, req *HelloReq) (Greeter_WatchClient, error)`,
+		`streamClient.Stream(ctx, "Chat", nil, res)`,
+		`fx.ResultTags(`+"`"+`group:"ag_kitex_server_registrars"`+"`"+`)`,
+	)
+}
+
+func TestGenerateStreamX(t *testing.T) {
+	*streamX = true
+	defer func() { *streamX = false }()
+	content := generate(t)
+	assertContains(t, content,
+		`Watch(ctx context.Context, req *HelloReq, stream Greeter_WatchServer) error`,
+		`type Greeter_ChatServer = streaming.BidiStreamingServer[HelloReq, HelloResp]`,
+		`type Greeter_UploadClient = streaming.ClientStreamingClient[HelloReq, HelloResp]`,
+		`streaming.GetServerStreamFromArg(arg)`,
+		`streamClient.StreamX(ctx, "Watch")`,
+		`stream.CloseSend(ctx)`,
+	)
+	if strings.Contains(content, "streaming.Result") {
+		t.Errorf("legacy streaming client generated with streamx\n%s", content)
+	}
+}
+
+func TestGenerateClientWithSuites(t *testing.T) {
+	content := generate(t)
+	// ag_kitex/client与kitex client包同名，使用protogen分配的导入名
+	assertContains(t, content,
+		`client1 "github.com/frochyzhang/ag-core/ag/ag_kitex/client"`,
+		`func NewGreeterGRPCClientWithSuites(suites *client1.KitexServiceSuites, destService string, opts ...client.Option) (GreeterGRPCClient, error)`,
+		`suite, err := suites.Suite(destService)`,
+		`func FxGreeterGRPCClientModule(destService string) fx.Option`,
+	)
+}
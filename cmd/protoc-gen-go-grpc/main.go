@@ -11,6 +11,7 @@ var (
 	showVersion     = flag.Bool("version", false, "print the version and exit")
 	omitempty       = flag.Bool("omitempty", true, "omit if google.api is empty")
 	omitemptyPrefix = flag.String("omitempty_prefix", "", "omit if google.api is empty")
+	streamX         = flag.Bool("streamx", false, "generate streaming methods with kitex StreamX API, which also supports ttheader streaming")
 )
 
 func main() {
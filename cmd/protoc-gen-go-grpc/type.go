@@ -51,6 +51,7 @@ type PackageInfo struct {
 	IDLName          string
 	ServerPkg        string
 	StreamX          bool
+	ServiceSuites    string // 下游服务客户端套件的类型名(含导入名)
 }
 
 // AddImport .
@@ -0,0 +1,7 @@
+module github.com/frochyzhang/ag-core/cmd/protoc-gen-go-netty
+
+go 1.22
+
+require google.golang.org/protobuf v1.36.6
+
+require github.com/google/go-cmp v0.6.0 // indirect
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	contextPackage     = protogen.GoImportPath("context")
+	rpcPackage         = protogen.GoImportPath("github.com/frochyzhang/ag-core/ag/ag_netty/rpc")
+	nettyServerPackage = protogen.GoImportPath("github.com/frochyzhang/ag-core/ag/ag_netty/server")
+	fxPackage          = protogen.GoImportPath("go.uber.org/fx")
+)
+
+// generateFile generates a _netty.pb.go file containing ag_netty rpc server dispatch and client.
+func generateFile(gen *protogen.Plugin, file *protogen.File) *protogen.GeneratedFile {
+	if len(file.Services) == 0 {
+		return nil
+	}
+	filename := file.GeneratedFilenamePrefix + "_netty.pb.go"
+	g := gen.NewGeneratedFile(filename, file.GoImportPath)
+	g.P("// Code generated by protoc-gen-go-netty. DO NOT EDIT.")
+	g.P("// versions:")
+	g.P(fmt.Sprintf("// - protoc-gen-go-netty %s", release))
+	g.P("// - protoc             ", protocVersion(gen))
+	if file.Proto.GetOptions().GetDeprecated() {
+		g.P("// ", file.Desc.Path(), " is a deprecated file.")
+	} else {
+		g.P("// source: ", file.Desc.Path())
+	}
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+	generateFileContent(gen, file, g)
+	return g
+}
+
+// generateFileContent generates the service definitions, excluding the package statement.
+func generateFileContent(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile) {
+	g.P("// This is a compile-time assertion to ensure that this generated file")
+	g.P("// is compatible with the ag_netty rpc package it is being compiled against.")
+	g.P("var _ = new(", contextPackage.Ident("Context"), ")")
+	g.P("var _ = ", rpcPackage.Ident("ServiceDesc{}"))
+	g.P("var _ = ", nettyServerPackage.Ident("Server{}"))
+	g.P("var _ = ", fxPackage.Ident("Self()"))
+	g.P()
+
+	for _, service := range file.Services {
+		genService(gen, file, g, service)
+	}
+}
+
+func genService(_ *protogen.Plugin, _ *protogen.File, g *protogen.GeneratedFile, service *protogen.Service) {
+	if service.Desc.Options().(*descriptorpb.ServiceOptions).GetDeprecated() {
+		g.P("//")
+		g.P(deprecationComment)
+	}
+	sd := &serviceDesc{
+		ServiceType:     service.GoName,
+		ServiceName:     string(service.Desc.FullName()),
+		ServerInterface: *serverInterface,
+	}
+	for _, method := range service.Methods {
+		if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+			_, _ = fmt.Fprintf(os.Stderr, "\u001B[31mWARN\u001B[m: %s.%s streaming is not supported by ag_netty rpc, skipped.\n",
+				service.Desc.FullName(), method.Desc.Name())
+			continue
+		}
+		comment := method.Comments.Leading.String() + method.Comments.Trailing.String()
+		if comment != "" {
+			comment = "// " + method.GoName + strings.TrimPrefix(strings.TrimSuffix(comment, "\n"), "//")
+		}
+		sd.Methods = append(sd.Methods, &methodDesc{
+			Name:       method.GoName,
+			FullMethod: fmt.Sprintf("/%s/%s", service.Desc.FullName(), method.Desc.Name()),
+			Request:    g.QualifiedGoIdent(method.Input.GoIdent),
+			Reply:      g.QualifiedGoIdent(method.Output.GoIdent),
+			Comment:    comment,
+		})
+	}
+	if len(sd.Methods) != 0 {
+		g.P(sd.execute())
+	}
+}
+
+func protocVersion(gen *protogen.Plugin) string {
+	v := gen.Request.GetCompilerVersion()
+	if v == nil {
+		return "(unknown)"
+	}
+	var suffix string
+	if s := v.GetSuffix(); s != "" {
+		suffix = "-" + s
+	}
+	return fmt.Sprintf("v%d.%d.%d%s", v.GetMajor(), v.GetMinor(), v.GetPatch(), suffix)
+}
+
+const deprecationComment = "// Deprecated: Do not use."
@@ -0,0 +1,81 @@
+{{$svrType := .ServiceType}}
+{{- range .Methods}}
+const {{$svrType}}_{{.Name}}_NettyMethod = "{{.FullMethod}}"
+{{- end}}
+
+{{if .ServerInterface}}
+type {{.ServiceType}}Server interface {
+{{- range .Methods}}
+	{{- if ne .Comment ""}}
+	{{.Comment}}
+	{{- end}}
+	{{.Name}}(context.Context, *{{.Request}}) (*{{.Reply}}, error)
+{{- end}}
+}
+{{end}}
+
+{{- range .Methods}}
+func _{{$svrType}}_{{.Name}}_Netty_Handler(srv any, ctx context.Context, dec func(any) error) (any, error) {
+	in := new({{.Request}})
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	return srv.({{$svrType}}Server).{{.Name}}(ctx, in)
+}
+{{end}}
+
+// {{$svrType}}_NettyServiceDesc is the ag_netty rpc service descriptor for {{.ServiceName}}.
+var {{$svrType}}_NettyServiceDesc = rpc.ServiceDesc{
+	ServiceName: "{{.ServiceName}}",
+	HandlerType: (*{{$svrType}}Server)(nil),
+	Methods: []rpc.MethodDesc{
+		{{- range .Methods}}
+		{
+			Name:    {{$svrType}}_{{.Name}}_NettyMethod,
+			Handler: _{{$svrType}}_{{.Name}}_Netty_Handler,
+		},
+		{{- end}}
+	},
+}
+
+func Register_{{$svrType}}_NettyServer(srv {{$svrType}}Server) server.Option {
+	return server.WithRPCService(&{{$svrType}}_NettyServiceDesc, srv)
+}
+
+type {{$svrType}}NettyClient interface {
+{{- range .Methods}}
+	{{.Name}}(ctx context.Context, req *{{.Request}}) (rsp *{{.Reply}}, err error)
+{{- end}}
+}
+
+type {{$svrType}}NettyClientImpl struct {
+	cc *rpc.Client
+}
+
+func New{{$svrType}}NettyClient(cc *rpc.Client) {{$svrType}}NettyClient {
+	return &{{$svrType}}NettyClientImpl{cc}
+}
+
+{{range .Methods}}
+func (c *{{$svrType}}NettyClientImpl) {{.Name}}(ctx context.Context, in *{{.Request}}) (*{{.Reply}}, error) {
+	out := new({{.Reply}})
+	if err := c.cc.Invoke(ctx, {{$svrType}}_{{.Name}}_NettyMethod, in, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+{{end}}
+var Fx{{$svrType}}NettyModule = fx.Module("fx_{{$svrType}}_Netty",
+	fx.Provide(
+		fx.Annotate(
+			Register_{{$svrType}}_NettyServer,
+			fx.ResultTags(`group:"ag_netty_server_options"`),
+		),
+	),
+)
+
+var Fx{{$svrType}}NettyClientModule = fx.Module("fx_{{$svrType}}_NettyClient",
+	fx.Provide(
+		New{{$svrType}}NettyClient,
+	),
+)
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func helloRequest() *pluginpb.CodeGeneratorRequest {
+	msg := func(name string) *descriptorpb.DescriptorProto {
+		return &descriptorpb.DescriptorProto{
+			Name: proto.String(name),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("name"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				JsonName: proto.String("name"),
+			}},
+		}
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String("hello/hello.proto"),
+		Package:     proto.String("hello"),
+		Syntax:      proto.String("proto3"),
+		Options:     &descriptorpb.FileOptions{GoPackage: proto.String("example.com/hello;hello")},
+		MessageType: []*descriptorpb.DescriptorProto{msg("HelloReq"), msg("HelloResp")},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Greeter"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("SayHello"), InputType: proto.String(".hello.HelloReq"), OutputType: proto.String(".hello.HelloResp")},
+				{Name: proto.String("Watch"), InputType: proto.String(".hello.HelloReq"), OutputType: proto.String(".hello.HelloResp"), ServerStreaming: proto.Bool(true)},
+			},
+		}},
+	}
+	return &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{file},
+	}
+}
+
+func generate(t *testing.T) string {
+	t.Helper()
+	gen, err := protogen.Options{}.New(helloRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	generateFile(gen, gen.Files[0])
+	resp := gen.Response()
+	if resp.Error != nil || len(resp.File) != 1 {
+		t.Fatalf("response = %v, %d files", resp.GetError(), len(resp.File))
+	}
+	if resp.File[0].GetName() != "example.com/hello/hello_netty.pb.go" {
+		t.Fatalf("file name = %s", resp.File[0].GetName())
+	}
+	content := resp.File[0].GetContent()
+	if _, err := parser.ParseFile(token.NewFileSet(), "hello_netty.pb.go", content, 0); err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, content)
+	}
+	return content
+}
+
+func TestGenerate(t *testing.T) {
+	content := generate(t)
+	for _, want := range []string{
+		`Greeter_SayHello_NettyMethod = "/hello.Greeter/SayHello"`,
+		`srv.(GreeterServer).SayHello(ctx, in)`,
+		`func Register_Greeter_NettyServer(srv GreeterServer) server.Option`,
+		`c.cc.Invoke(ctx, Greeter_SayHello_NettyMethod, in, out)`,
+		`fx.ResultTags(` + "`" + `group:"ag_netty_server_options"` + "`" + `)`,
+		`var FxGreeterNettyClientModule = fx.Module("fx_Greeter_NettyClient"`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("generated code missing %q\n%s", want, content)
+		}
+	}
+	// 流式方法不生成
+	if strings.Contains(content, "Watch") {
+		t.Errorf("streaming method generated\n%s", content)
+	}
+	// 服务接口默认由protoc-gen-go-http生成
+	if strings.Contains(content, "type GreeterServer interface") {
+		t.Errorf("server interface generated without server_interface flag")
+	}
+}
+
+func TestGenerateServerInterface(t *testing.T) {
+	*serverInterface = true
+	defer func() { *serverInterface = false }()
+	content := generate(t)
+	if !strings.Contains(content, "SayHello(context.Context, *HelloReq) (*HelloResp, error)") {
+		t.Errorf("server interface not generated\n%s", content)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"strings"
+	"text/template"
+)
+
+//go:embed nettyTemplate.tpl
+var nettyTemplate string
+
+type serviceDesc struct {
+	ServiceType string // Greeter
+	ServiceName string // helloworld.Greeter
+	// ServerInterface 是否生成服务接口，与protoc-gen-go-http同时使用时由其生成
+	ServerInterface bool
+	Methods         []*methodDesc
+}
+
+type methodDesc struct {
+	Name       string
+	FullMethod string // /helloworld.Greeter/SayHello
+	Request    string
+	Reply      string
+	Comment    string
+}
+
+func (s *serviceDesc) execute() string {
+	buf := new(bytes.Buffer)
+	tmpl, err := template.New("netty").Parse(strings.TrimSpace(nettyTemplate))
+	if err != nil {
+		panic(err)
+	}
+	if err := tmpl.Execute(buf, s); err != nil {
+		panic(err)
+	}
+	return strings.Trim(buf.String(), "\r\n")
+}
@@ -16,9 +16,20 @@ var FxAgConfModule = fx.Module("ag_conf",
 			ag_conf.NewConfigurationPropertiesBinder,
 			fx.As(new(ag_conf.IBinder)),
 		),
+		FxNewConfigWatcher,
 	),
 )
 
+// FxNewConfigWatcher 配置监听器，随应用启动订阅属性源变更
+func FxNewConfigWatcher(binder ag_conf.IBinder, lc fx.Lifecycle) *ag_conf.Watcher {
+	watcher := ag_conf.NewConfigWatcher(binder)
+	lc.Append(fx.Hook{
+		OnStart: watcher.Start,
+		OnStop:  watcher.Stop,
+	})
+	return watcher
+}
+
 var FxConfLocMode = fx.Module(
 	"fx_conf_local",
 	// LoadLocalConfig 构造使用了 embed.FS,目前需要应用main提前使用Supply等方式提供依赖
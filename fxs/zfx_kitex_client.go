@@ -1,7 +1,10 @@
 package fxs
 
 import (
+	"github.com/frochyzhang/ag-core/ag/ag_conf"
+	"github.com/frochyzhang/ag-core/ag/ag_ext/route"
 	agkc "github.com/frochyzhang/ag-core/ag/ag_kitex/client"
+	"github.com/frochyzhang/ag-core/ag/ag_resilience"
 
 	"github.com/cloudwego/kitex/client"
 	"github.com/nacos-group/nacos-sdk-go/clients/naming_client"
@@ -12,6 +15,7 @@ var FxKitexClientBaseModule = fx.Module(
 	"fx_kitex_Client_base",
 	fx.Provide(
 		FxBuilderKitexClientSuite,
+		FxNewKitexServiceSuites,
 	),
 )
 
@@ -30,17 +34,47 @@ type FxInKitexClientParams struct {
 	NamingClient naming_client.INamingClient `optional:"true"`
 }
 
-// func FxBuilderKitexClientSuite(params FxInKitexServerParams) (server.Suite, error) {
+type FxInKitexServiceSuitesParams struct {
+	fx.In
+	FxInKitexClientParams
+
+	Binder ag_conf.IBinder
+	// Watcher 存在时下游服务配置支持运行时刷新
+	Watcher *ag_conf.Watcher `optional:"true"`
+	// Router 引入 FxRouteModule 时按路由标签过滤实例
+	Router *route.Router `optional:"true"`
+	// Resilience 引入 FxResilienceModule 时启用熔断、隔离舱及重试
+	Resilience *ag_resilience.Manager `optional:"true"`
+}
+
+// FxBuilderKitexClientSuite 提供所有下游服务共用的客户端套件
+//
+// Deprecated: 使用 *agkc.KitexServiceSuites 按下游服务名取得套件，生成代码的 Fx{Service}GRPCClientModule 已按此装配
 func FxBuilderKitexClientSuite(params FxInKitexClientParams) (*agkc.KitexClientSuite, error) {
 	build := &agkc.KitexSuiteBuilder{
 		NamingClient: params.NamingClient,
 	}
 	// CustOptions:  params.CustOptions,
+	build.CustOptions = derefKitexClientOptions(params.CustOptions)
+
+	return build.BuildSuite()
+}
+
+// FxNewKitexServiceSuites 按 kitex.client.services.<name> 配置构建下游服务客户端套件
+func FxNewKitexServiceSuites(params FxInKitexServiceSuitesParams) (*agkc.KitexServiceSuites, error) {
+	build := &agkc.KitexSuiteBuilder{
+		CustOptions:  derefKitexClientOptions(params.CustOptions),
+		NamingClient: params.NamingClient,
+		Router:       params.Router,
+		Resilience:   params.Resilience,
+	}
+	return agkc.NewKitexServiceSuites(build, params.Binder, params.Watcher)
+}
+
+func derefKitexClientOptions(opts []*client.Option) []client.Option {
 	custOpt := make([]client.Option, 0)
-	for _, opt := range params.CustOptions {
+	for _, opt := range opts {
 		custOpt = append(custOpt, *opt)
 	}
-	build.CustOptions = custOpt
-
-	return build.BuildSuite()
+	return custOpt
 }
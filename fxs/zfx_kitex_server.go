@@ -30,6 +30,9 @@ var FxKitexServerBaseModule = fx.Module(
 		// agkitex server middleware 入口，可按顺序执行agkitex server 自定义middleware
 		FxBuildAgKitexServerMiddleware,
 
+		// agkitex server 流式方法逐消息middleware入口
+		FxBuildAgKitexServerStreamMiddleware,
+
 		FxBuildKitexServerRegistrar,
 
 		/* === 2. 将原生kitex server 包装为ag server, 并注入到APP服务列表中 === */
@@ -46,6 +49,10 @@ var FxKitexServerBaseModule = fx.Module(
 			agks.AgRegistKitexServerMiddlewareOption,
 			fx.ResultTags(`group:"kitex_server_options"`),
 		),
+		fx.Annotate(
+			agks.AgRegistKitexServerStreamMiddlewareOptions,
+			fx.ResultTags(`group:"kitex_server_options,flatten"`),
+		),
 	),
 )
 
@@ -88,6 +95,12 @@ type FxAgKitexServerMiddlewareInParams struct {
 	Middlewares []agks.IAgKitexServerMiddleware `group:"ag_kitex_server_middlewares",optional:"true"`
 }
 
+type FxAgKitexServerStreamMiddlewareInParams struct {
+	fx.In
+
+	Middlewares []agks.IAgKitexServerStreamMiddleware `group:"ag_kitex_server_stream_middlewares" optional:"true"`
+}
+
 func FxBuildKitexServerRegistrar(in FxInKitexServiceRegistrars) agks.KitexServerRegistrar {
 	return agks.KitexServerRegistrar{
 		Regs: in.Registrars,
@@ -101,6 +114,12 @@ func FxBuildAgKitexServerMiddleware(p FxAgKitexServerMiddlewareInParams) *agks.A
 	return akm
 }
 
+func FxBuildAgKitexServerStreamMiddleware(p FxAgKitexServerStreamMiddlewareInParams) *agks.AgKitexServerStreamMiddleware {
+	return &agks.AgKitexServerStreamMiddleware{
+		Middlewares: p.Middlewares,
+	}
+}
+
 // === kitex 服务端业务异常包装 middleware ===
 var FxKitexAgServerBizErrorMiddlewareOption = fx.Provide(
 	fx.Annotate(
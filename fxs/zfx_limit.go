@@ -0,0 +1,52 @@
+package fxs
+
+import (
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/frochyzhang/ag-core/ag/ag_conf"
+	"github.com/frochyzhang/ag-core/ag/ag_ext/limit"
+	hertz "github.com/frochyzhang/ag-core/ag/ag_hertz/server"
+	agks "github.com/frochyzhang/ag-core/ag/ag_kitex/server"
+	"go.uber.org/fx"
+)
+
+// FxLimitModule 提供服务端限流，引入后kitex服务端及hertz服务端按 limit.* 规则限流
+var FxLimitModule = fx.Module("fx_limit",
+	fx.Provide(
+		FxNewLimiter,
+		fx.Annotate(
+			FxNewKitexLimitMiddleware,
+			fx.ResultTags(`group:"ag_kitex_server_middlewares"`),
+		),
+		fx.Annotate(
+			FxNewHertzLimitMiddleware,
+			fx.ResultTags(`group:"hertz_middlewares"`),
+		),
+	),
+)
+
+type FxInLimiterParams struct {
+	fx.In
+
+	Binder ag_conf.IBinder
+	// Watcher 存在时限流规则支持运行时刷新
+	Watcher *ag_conf.Watcher `optional:"true"`
+}
+
+// FxNewLimiter 按 limit.* 配置创建限流器，配置刷新无效时保留原规则
+func FxNewLimiter(params FxInLimiterParams) (*limit.Limiter, error) {
+	return ag_conf.BindRefreshable(params.Binder, params.Watcher, limit.LimitPropertiesPrefix,
+		limit.NewLimiter, (*limit.Limiter).Update)
+}
+
+func FxNewKitexLimitMiddleware(limiter *limit.Limiter) agks.IAgKitexServerMiddleware {
+	return limit.NewKitexMiddleware(limiter)
+}
+
+// FxNewHertzLimitMiddleware 以 hertz.server.service-name 作为限流规则匹配的服务名
+func FxNewHertzLimitMiddleware(limiter *limit.Limiter, binder ag_conf.IBinder) (app.HandlerFunc, error) {
+	var hconf hertz.HertzServerProperties
+	if err := binder.Bind(&hconf, hertz.HertzServerPropertiesPrefix); err != nil {
+		return nil, err
+	}
+	return limit.NewHertzMiddleware(limiter, hconf.ServiceName), nil
+}
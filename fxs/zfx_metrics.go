@@ -0,0 +1,37 @@
+package fxs
+
+import (
+	"log/slog"
+
+	"github.com/frochyzhang/ag-core/ag/ag_conf"
+	"github.com/frochyzhang/ag-core/ag/ag_metrics"
+	"github.com/frochyzhang/ag-core/ag/ag_server"
+	"go.uber.org/fx"
+)
+
+// FxMetricsModule 提供框架指标注册表，metrics.enable为true时在独立端口导出指标
+// 引入本模块后netty等组件自动注册运行指标
+var FxMetricsModule = fx.Module("fx_metrics",
+	fx.Provide(
+		ag_metrics.Default,
+		FxNewMetricsServer,
+	),
+	fx.Provide(
+		fx.Annotate(
+			metricsServerWrapper,
+			fx.ResultTags(`group:"ag_servers"`),
+		),
+	),
+)
+
+func FxNewMetricsServer(binder ag_conf.IBinder, registry *ag_metrics.Registry, logger *slog.Logger) (*ag_metrics.Server, error) {
+	var conf ag_metrics.MetricsProperties
+	if err := binder.Bind(&conf, ag_metrics.MetricsPropertiesPrefix); err != nil {
+		return nil, err
+	}
+	return ag_metrics.NewServer(registry, conf, logger), nil
+}
+
+func metricsServerWrapper(s *ag_metrics.Server) ag_server.Server {
+	return s
+}
@@ -1,9 +1,14 @@
 package fxs
 
 import (
+	"fmt"
+
 	"github.com/frochyzhang/ag-core/ag/ag_conf"
+	"github.com/frochyzhang/ag-core/ag/ag_ext"
+	"github.com/frochyzhang/ag-core/ag/ag_metrics"
 	"github.com/frochyzhang/ag-core/ag/ag_netty"
 	"github.com/frochyzhang/ag-core/ag/ag_netty/client"
+	"github.com/nacos-group/nacos-sdk-go/clients/naming_client"
 	"go.uber.org/fx"
 	"log/slog"
 )
@@ -42,6 +47,10 @@ type FxNettyClientInParam struct {
 	Binder ag_conf.IBinder
 
 	CustomOptions []client.Option `group:"ag_netty_client_options" ,optional:"true"`
+
+	NamingClient naming_client.INamingClient `optional:"true"`
+	// MetricsRegistry 引入FxMetricsModule时采集运行指标
+	MetricsRegistry *ag_metrics.Registry `optional:"true"`
 }
 
 func FxNewNettyClientWithSuite(params FxNettyClientInParam) (*client.NettyOptionSuite, error) {
@@ -54,6 +63,28 @@ func FxNewNettyClientWithSuite(params FxNettyClientInParam) (*client.NettyOption
 
 	opts := params.CustomOptions
 	opts = append(opts, client.WithProps(clientProps))
+
+	if params.MetricsRegistry != nil {
+		name := clientProps.ServiceName
+		if name == "" {
+			name = "netty.client"
+		}
+		opts = append(opts, client.WithMetrics(ag_netty.NewMetrics(params.MetricsRegistry, name)))
+	}
+
+	// 配置服务名时经nacos解析实例，复用kitex的nacos解析器
+	if clientProps.ServiceName != "" {
+		if params.NamingClient == nil {
+			err := fmt.Errorf("ag_netty client service-name %s requires a nacos naming client", clientProps.ServiceName)
+			slog.Error("ag_netty client config error", "error", err)
+			return nil, err
+		}
+		opts = append(opts, client.WithResolver(ag_ext.NewNacosResolver(
+			params.NamingClient,
+			ag_ext.WithCluster(clientProps.Cluster),
+			ag_ext.WithGroup(clientProps.Group),
+		)))
+	}
 	return &client.NettyOptionSuite{
 		Opts: opts,
 	}, nil
@@ -68,5 +99,5 @@ func FxClientLoggerOption() client.Option {
 }
 
 func FxClientEchoOption() client.Option {
-	return client.AppendHandler(&client.EchoHandler{EchoHandler: &ag_netty.EchoHandler{}})
+	return client.WithEchoHandler()
 }
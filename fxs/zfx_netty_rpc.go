@@ -0,0 +1,63 @@
+package fxs
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/frochyzhang/ag-core/ag/ag_conf"
+	"github.com/frochyzhang/ag-core/ag/ag_netty/client"
+	"github.com/frochyzhang/ag-core/ag/ag_netty/rpc"
+	"github.com/frochyzhang/ag-core/ag/ag_resilience"
+	"go.uber.org/fx"
+)
+
+// FxNettyRPCClientModule 创建ag_netty RPC客户端，供protoc-gen-go-netty生成的客户端注入
+// 依赖 FxNettyClientBaseModule 提供的客户端配置
+var FxNettyRPCClientModule = fx.Module("fx_netty_rpc_client",
+	fx.Provide(
+		FxNewNettyRPCClient,
+	),
+)
+
+type FxInNettyRPCClientParams struct {
+	fx.In
+
+	Lc     fx.Lifecycle
+	Suite  *client.NettyOptionSuite
+	Logger *slog.Logger
+	Binder ag_conf.IBinder
+	// Resilience 引入 FxResilienceModule 时以 netty.client.service-name 为下游服务名启用熔断、隔离舱及重试
+	Resilience *ag_resilience.Manager `optional:"true"`
+}
+
+// FxNewNettyRPCClient 在客户端流水线末尾加入RPC处理器，随应用启动连接、停止关闭
+// 基础客户端的回显处理器会原样写出请求并以RPC应答完成Future，因此不加入RPC客户端
+func FxNewNettyRPCClient(params FxInNettyRPCClientParams) (*rpc.Client, error) {
+	lc := params.Lc
+	opts := append(params.Suite.WithoutEcho().Opts, client.AppendHandler(rpc.NewClientHandler()))
+	c := client.NewNettyClientWithSuite(&client.NettyOptionSuite{Opts: opts}, params.Logger)
+
+	var rpcOpts []rpc.Option
+	if params.Resilience != nil {
+		var props client.NettyClientProperties
+		if err := params.Binder.Bind(&props, client.NettyClientPropertiesPrefix); err != nil {
+			return nil, err
+		}
+		name := props.ServiceName
+		if name == "" {
+			name = "netty.client"
+		}
+		rpcOpts = append(rpcOpts, rpc.WithClientInterceptors(ag_resilience.NettyInterceptor(params.Resilience, name)))
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return c.Connect()
+		},
+		OnStop: func(ctx context.Context) error {
+			c.Close()
+			return nil
+		},
+	})
+	return rpc.NewClient(c, rpcOpts...), nil
+}
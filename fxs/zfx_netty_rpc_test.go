@@ -0,0 +1,99 @@
+package fxs
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/frochyzhang/ag-core/ag/ag_app"
+	"github.com/frochyzhang/ag-core/ag/ag_conf"
+	"github.com/frochyzhang/ag-core/ag/ag_netty/rpc"
+	"github.com/frochyzhang/ag-core/ag/ag_netty/server"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const methodUpper = "/test.Echo/Upper"
+
+type upperServer interface {
+	Upper(ctx context.Context, in *wrapperspb.StringValue) (*wrapperspb.StringValue, error)
+}
+
+type upperImpl struct{}
+
+func (upperImpl) Upper(ctx context.Context, in *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+	return wrapperspb.String("re:" + in.GetValue()), nil
+}
+
+var upperDesc = rpc.ServiceDesc{
+	ServiceName: "test.Echo",
+	HandlerType: (*upperServer)(nil),
+	Methods: []rpc.MethodDesc{{
+		Name: methodUpper,
+		Handler: func(srv any, ctx context.Context, dec func(any) error) (any, error) {
+			in := new(wrapperspb.StringValue)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			return srv.(upperServer).Upper(ctx, in)
+		},
+	}},
+}
+
+// freePort 获取本机空闲端口
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// TestNettyRPCModules 按应用的方式装配netty服务端、基础客户端及RPC客户端模块，完成RPC调用
+// 基础模块带有回显处理器，RPC服务端及客户端均不得加入该处理器
+func TestNettyRPCModules(t *testing.T) {
+	port := freePort(t)
+	env := ag_conf.NewStandardEnvironment()
+	env.GetPropertySources().AddFirst(ag_conf.NewPropertiesPropertySource("test", map[string]any{
+		"netty.server.host": "127.0.0.1",
+		"netty.server.port": strconv.Itoa(port),
+		"netty.client.addr": "127.0.0.1:" + strconv.Itoa(port),
+	}))
+
+	var cli *rpc.Client
+	app := fxtest.New(t,
+		fx.Supply(slog.Default()),
+		fx.Provide(
+			func() ag_conf.IConfigurableEnvironment { return env },
+			fx.Annotate(ag_conf.NewConfigurationPropertiesBinder, fx.As(new(ag_conf.IBinder))),
+			fx.Annotate(
+				func() server.Option { return server.WithRPCService(&upperDesc, upperImpl{}) },
+				fx.ResultTags(`group:"ag_netty_server_options"`),
+			),
+		),
+		FxAppMode,
+		FxNettyServerBaseModule,
+		FxNettyClientBaseModule,
+		FxNettyRPCClientModule,
+		fx.Invoke(func(*ag_app.App) {}),
+		fx.Populate(&cli),
+	)
+	app.RequireStart()
+	defer app.RequireStop()
+
+	for _, in := range []string{"a", "bc"} {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		out := new(wrapperspb.StringValue)
+		err := cli.Invoke(ctx, methodUpper, wrapperspb.String(in), out)
+		cancel()
+		if err != nil || out.GetValue() != "re:"+in {
+			t.Fatalf("Invoke(%q) = %q, %v", in, out.GetValue(), err)
+		}
+	}
+}
@@ -2,9 +2,11 @@ package fxs
 
 import (
 	"github.com/frochyzhang/ag-core/ag/ag_conf"
+	"github.com/frochyzhang/ag-core/ag/ag_metrics"
 	"github.com/frochyzhang/ag-core/ag/ag_netty"
 	"github.com/frochyzhang/ag-core/ag/ag_netty/server"
 	"github.com/frochyzhang/ag-core/ag/ag_server"
+	"github.com/nacos-group/nacos-sdk-go/clients/naming_client"
 	"go.uber.org/fx"
 )
 
@@ -13,9 +15,13 @@ var FxNettyServerBaseModule = fx.Module("fx_mini_netty_server_base",
 
 	fx.Provide(
 		FxNewNettyServerSuite,
+		server.NewNettyServerWithSuite,
+		// 服务端通道集合，供业务按ID或属性推送消息
+		FxNettyServerChannelGroup,
+	),
+	fx.Provide(
 		fx.Annotate(
-			server.NewNettyServerWithSuite,
-			fx.As(new(ag_server.Server)),
+			nettyServerWrapper,
 			fx.ResultTags(`group:"ag_servers"`),
 		),
 	),
@@ -36,23 +42,37 @@ var FxNettyServerBaseModule = fx.Module("fx_mini_netty_server_base",
 type FxNettyServerInParam struct {
 	fx.In
 
-	Binder      ag_conf.IBinder
-	CustOptions []server.Option `group:"ag_netty_server_options" ,optional:"true"`
+	Binder       ag_conf.IBinder
+	CustOptions  []server.Option             `group:"ag_netty_server_options" ,optional:"true"`
+	NamingClient naming_client.INamingClient `optional:"true"`
+	// MetricsRegistry 引入FxMetricsModule时采集运行指标
+	MetricsRegistry *ag_metrics.Registry `optional:"true"`
 }
 
 func FxNewNettyServerSuite(params FxNettyServerInParam) (*server.NettyOptionSuite, error) {
 	builder := &server.NettySuiteBuilder{
 		Binder:        params.Binder,
 		CustomOptions: params.CustOptions,
+		NamingClient:  params.NamingClient,
+
+		MetricsRegistry: params.MetricsRegistry,
 	}
 
 	return builder.BuildSuite()
 }
 
+func nettyServerWrapper(s *server.Server) ag_server.Server {
+	return s
+}
+
+func FxNettyServerChannelGroup(s *server.Server) *ag_netty.ChannelGroup {
+	return s.ChannelGroup()
+}
+
 func FxMnLoggerOption() server.Option {
 	return server.AppendHandler(ag_netty.NewLoggingHandler("fx_logger"))
 }
 
 func FxMnEchoOption() server.Option {
-	return server.AppendHandler(&ag_netty.EchoHandler{})
+	return server.WithEchoHandler()
 }
@@ -0,0 +1,32 @@
+package fxs
+
+import (
+	"github.com/frochyzhang/ag-core/ag/ag_conf"
+	"github.com/frochyzhang/ag-core/ag/ag_metrics"
+	"github.com/frochyzhang/ag-core/ag/ag_resilience"
+	"go.uber.org/fx"
+)
+
+// FxResilienceModule 提供下游服务的熔断、隔离舱及重试，引入后kitex下游服务客户端及netty RPC客户端自动启用，
+// hertz客户端通过 client.WithResilience 使用
+var FxResilienceModule = fx.Module("fx_resilience",
+	fx.Provide(FxNewResilienceManager),
+)
+
+type FxInResilienceParams struct {
+	fx.In
+
+	Binder ag_conf.IBinder
+	// Watcher 存在时故障隔离策略支持运行时刷新
+	Watcher *ag_conf.Watcher `optional:"true"`
+	// MetricsRegistry 引入FxMetricsModule时导出熔断状态等指标
+	MetricsRegistry *ag_metrics.Registry `optional:"true"`
+}
+
+// FxNewResilienceManager 按 resilience.* 配置创建，配置刷新无效时保留原策略
+func FxNewResilienceManager(params FxInResilienceParams) (*ag_resilience.Manager, error) {
+	return ag_conf.BindRefreshable(params.Binder, params.Watcher, ag_resilience.ResiliencePropertiesPrefix,
+		func(conf ag_resilience.ResilienceProperties) (*ag_resilience.Manager, error) {
+			return ag_resilience.NewManager(conf, params.MetricsRegistry)
+		}, (*ag_resilience.Manager).Update)
+}
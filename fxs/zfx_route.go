@@ -0,0 +1,27 @@
+package fxs
+
+import (
+	"github.com/frochyzhang/ag-core/ag/ag_conf"
+	"github.com/frochyzhang/ag-core/ag/ag_ext/route"
+	"go.uber.org/fx"
+)
+
+// FxRouteModule 提供灰度及标签路由，引入后kitex下游服务客户端按路由标签过滤实例，
+// hertz客户端通过 client.WithRouter 使用
+var FxRouteModule = fx.Module("fx_route",
+	fx.Provide(FxNewRouter),
+)
+
+type FxInRouterParams struct {
+	fx.In
+
+	Binder ag_conf.IBinder
+	// Watcher 存在时路由规则支持运行时刷新
+	Watcher *ag_conf.Watcher `optional:"true"`
+}
+
+// FxNewRouter 按 route.* 配置创建路由，配置刷新无效时保留原规则
+func FxNewRouter(params FxInRouterParams) (*route.Router, error) {
+	return ag_conf.BindRefreshable(params.Binder, params.Watcher, route.RoutePropertiesPrefix,
+		route.NewRouter, (*route.Router).Update)
+}
@@ -1,6 +1,7 @@
 package fxs
 
 import (
+	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/common/config"
 	"github.com/frochyzhang/ag-core/ag/ag_conf"
 	hertz "github.com/frochyzhang/ag-core/ag/ag_hertz/server"
@@ -35,6 +36,7 @@ type FxHertzServerInParam struct {
 
 	CustOptions   []config.Option             `group:"hertz_options" ,optional:"true"`
 	RouterOptions []hertz.Option              `group:"hertz_router_options" ,optional:"true"`
+	Middlewares   []app.HandlerFunc           `group:"hertz_middlewares" ,optional:"true"`
 	NamingClient  naming_client.INamingClient `optional:"true"`
 }
 
@@ -45,6 +47,7 @@ func FxBuilderHertzSuite(params FxHertzServerInParam) (*hertz.HertzOptionSuite,
 		NamingClient:  params.NamingClient,
 		CustOptions:   params.CustOptions,
 		RouterOptions: params.RouterOptions,
+		Middlewares:   params.Middlewares,
 	}
 
 	return build.BuildSuite()